@@ -2,11 +2,16 @@ package analyzer
 
 import (
 	commands "backend/commands"
+	stores "backend/stores"
+	"context"
 	"fmt"    
 	"strings" 
 )
 
-func Analyzer(input string) (string, error) {
+// Analyzer ejecuta una línea de comando. El contexto lleva el AuthStore de la petición (ver stores.WithAuth),
+// que login y logout modifican y el resto de comandos usa para saber quién tiene la sesión.
+// Cada comando queda registrado en el log de auditoría de la partición sobre la que trabajó.
+func Analyzer(ctx context.Context, input string) (string, error) {
 
 	trimmedInput := strings.TrimSpace(input)
 
@@ -16,7 +21,7 @@ func Analyzer(input string) (string, error) {
 		return "", nil
 	}
 	if strings.HasPrefix(trimmedInput, "#") {
-        fmt.Printf("Comentario ignorado: %s\n", trimmedInput)
+        logger.DebugContext(ctx, "Comentario ignorado", "line", trimmedInput)
 		return "", nil
 	}
 
@@ -31,10 +36,33 @@ func Analyzer(input string) (string, error) {
 	command := strings.ToLower(tokens[0]) // Convertir comando a minúsculas
 	arguments := tokens[1:]  
 
+	caller := callerOf(stores.AuthFromContext(ctx))
+	result, err := execute(ctx, command, arguments)
+
+	// Registrar el comando en el log de auditoría de su partición
+	auditCommand(ctx, caller, command, arguments, trimmedInput, err)
+	return result, err
+}
+
+// execute ejecuta el comando con los locks de estado, disco y partición que necesita y, ya liberados,
+// escribe en los discos los cambios que dejó en caché (ver flushDisks)
+func execute(ctx context.Context, command string, arguments []string) (string, error) {
+	held := acquireLocks(ctx, command, arguments)
+	result, err := runCommand(ctx, command, arguments)
+	held.release()
+
+	if flushErr := flushDisks(held.disks); flushErr != nil && err == nil {
+		return "", fmt.Errorf("error al escribir los cambios en disco: %w", flushErr)
+	}
+	return result, err
+}
+
+// runCommand ejecuta el comando ya separado de sus argumentos
+func runCommand(ctx context.Context, command string, arguments []string) (string, error) {
 	// Switch para manejar comandos conocidos
 	switch command {
 	case "mkdisk":
-		return commands.ParseMkdisk(arguments)
+		return commands.ParseMkdisk(ctx, arguments)
 	case "fdisk":
 		return commands.ParseFdisk(arguments)
 	case "mount":
@@ -42,34 +70,77 @@ func Analyzer(input string) (string, error) {
 	case "mkfs":
 		return commands.ParseMkfs(arguments)
 	case "rep":
-		return commands.ParseRep(arguments)
+		return commands.ParseRep(ctx, arguments)
 	case "mkdir":
-		return commands.ParseMkdir(arguments)
+		return commands.ParseMkdir(ctx, arguments)
 	case "rmdisk":
 		return commands.ParseRmdisk(arguments)
 	case "mounted":
 		return commands.ParseMounted(arguments)
 	case "cat":
-		return commands.ParseCat(arguments)
+		return commands.ParseCat(ctx, arguments)
 	case "login":
-		return commands.ParseLogin(arguments)
+		return commands.ParseLogin(ctx, arguments)
 	case "logout":
-		return commands.ParseLogout(arguments)
+		return commands.ParseLogout(ctx, arguments)
 	case "mkfile":
-		return commands.ParseMkfile(arguments)
+		return commands.ParseMkfile(ctx, arguments)
 	case "mkgrp":
-		return commands.ParseMkgrp(arguments)
+		return commands.ParseMkgrp(ctx, arguments)
 	case "rmgrp":
-		return commands.ParseRmgrp(arguments)
+		return commands.ParseRmgrp(ctx, arguments)
 	case "mkusr":
-		return commands.ParseMkusr(arguments)
+		return commands.ParseMkusr(ctx, arguments)
 	case "rmusr":
-		return commands.ParseRmusr(arguments)
+		return commands.ParseRmusr(ctx, arguments)
 	case "chgrp":
-		return commands.ParseChgrp(arguments)
+		return commands.ParseChgrp(ctx, arguments)
+	case "sync":
+		return commands.ParseSync(arguments, syncDisk)
+	case "defrag":
+		return commands.ParseDefrag(arguments)
+	case "resizefs":
+		return commands.ParseResizefs(arguments)
+	case "passwd":
+		return commands.ParsePasswd(ctx, arguments)
+	case "usermod":
+		return commands.ParseUsermod(ctx, arguments)
+	case "lockusr":
+		return commands.ParseLockusr(ctx, arguments, true)
+	case "unlockusr":
+		return commands.ParseLockusr(ctx, arguments, false)
+	case "su":
+		return commands.ParseSu(ctx, arguments)
+	case "exit":
+		return commands.ParseExit(ctx, arguments)
+	case "cd":
+		return commands.ParseCd(ctx, arguments)
+	case "pwd":
+		return commands.ParsePwd(ctx, arguments)
+	case "setquota":
+		return commands.ParseSetquota(ctx, arguments)
+	case "quota":
+		return commands.ParseQuota(ctx, arguments)
+	case "whoami":
+		return commands.ParseWhoami(ctx, arguments)
+	case "sessions":
+		return commands.ParseSessions(ctx, arguments)
+	case "sudo":
+		return commands.ParseSudo(ctx, arguments, runElevated)
+	case "debug":
+		return commands.ParseDebug(ctx, arguments)
 
 	default:
 
-		return "", fmt.Errorf("comando desconocido: %s", command)
+		return "", commands.NewError(commands.CodeUnknownCommand, "comando desconocido: %s", command)
+	}
+}
+
+// runElevated ejecuta el comando que recibe sudo. Solo se permiten comandos que trabajan sobre la partición
+// de la sesión: los locks que se tomaron para sudo son los de esa partición (ver acquireLocks).
+func runElevated(ctx context.Context, command string, arguments []string) (string, error) {
+	if spec, exists := commandLocks[command]; !exists || spec.target != targetSession || elevationCommands[command] {
+		return "", fmt.Errorf("el comando '%s' no se puede ejecutar con sudo", command)
 	}
+	return runCommand(ctx, command, arguments)
 }
\ No newline at end of file
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	stores "backend/stores"
+	utils "backend/utils"
+	"context"
+)
+
+// Quién ejecuta un comando, tomado antes de ejecutarlo (logout y su cambian el AuthStore)
+type auditCaller struct {
+	user      string
+	session   string
+	partition string
+}
+
+func callerOf(auth *stores.AuthStore) auditCaller {
+	return auditCaller{user: auth.Username, session: auth.SessionID(), partition: auth.GetPartitionID()}
+}
+
+// auditCommand registra un comando ya ejecutado en el log de auditoría de la partición sobre la que trabajó:
+// la del parámetro -id o, si no tiene, la de la sesión. Los comandos que no se pueden asociar a una partición
+// formateada no se registran. Un error al registrar no hace fallar el comando, solo se informa.
+func auditCommand(ctx context.Context, caller auditCaller, command string, arguments []string, input string, commandErr error) {
+	// su, exit y sudo registran sus propios eventos
+	if elevationCommands[command] {
+		return
+	}
+
+	auth := stores.AuthFromContext(ctx)
+	partitionID := caller.partition
+	if spec := commandLocks[command]; spec.target == targetID {
+		partitionID = argumentValue(arguments, "id")
+	}
+	if partitionID == "" {
+		return
+	}
+
+	entry := utils.AuditEntry{
+		User:    caller.user,
+		Session: caller.session,
+		Event:   "command",
+		Command: utils.RedactPasswords(input),
+		Result:  "ok",
+	}
+	// login: la sesión recién se crea; si falló, se registra el usuario que lo intentó
+	if entry.User == "" {
+		entry.User = auth.Username
+		entry.Session = auth.SessionID()
+	}
+	if entry.User == "" {
+		entry.User = argumentValue(arguments, "user")
+	}
+	if commandErr != nil {
+		entry.Result = utils.RedactPasswords(commandErr.Error())
+	}
+
+	// Con la partición en lectura solo se escribe sobre los bloques ya reservados del log. Crearlo o reasignarlo
+	// asigna inodos y bloques, así que en ese caso se vuelve a tomar la partición en escritura.
+	held := acquireAuditLocks(partitionID, lockRead)
+	if stores.MountedPartitions[partitionID] != "" && commands.AuditLogNeedsAllocation(partitionID) {
+		held.release()
+		held = acquireAuditLocks(partitionID, lockWrite)
+	}
+
+	if stores.MountedPartitions[partitionID] == "" {
+		held.release()
+		return
+	}
+	err := commands.RecordAudit(partitionID, entry)
+	held.release()
+	if err != nil {
+		logger.WarnContext(ctx, "No se pudo registrar el comando en el log de auditoría", "command", command, "partition", partitionID, "error", err)
+		return
+	}
+	if err := flushDisks(held.disks); err != nil {
+		logger.WarnContext(ctx, "Error al escribir el log de auditoría", "partition", partitionID, "error", err)
+	}
+}
+
+// ReadAuditLog devuelve los registros del log de auditoría de una partición que cumplen el filtro.
+// Solo root puede leerlo, con una sesión iniciada en esa misma partición.
+func ReadAuditLog(ctx context.Context, partitionID string, filter utils.AuditFilter) ([]utils.AuditEntry, error) {
+	if err := commands.CheckAuditAccess(ctx, partitionID); err != nil {
+		return nil, err
+	}
+
+	held := acquireAuditLocks(partitionID, lockRead)
+	defer held.release()
+
+	return commands.ReadAuditLog(partitionID, filter)
+}
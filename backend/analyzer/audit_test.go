@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	sandbox "backend/sandbox"
+	stores "backend/stores"
+	utils "backend/utils"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogRequiresRoot(t *testing.T) {
+	reportsDir := sandbox.Reports.Dir()
+	sandbox.Reports.Set(t.TempDir())
+	t.Cleanup(func() { sandbox.Reports.Set(reportsDir) })
+
+	rootCtx, id := newTestPartition(t, 1024)
+	mustRun(t, rootCtx, "mkgrp -name=usuarios", "mkusr -user=user1 -pass=abc1 -grp=usuarios")
+	userCtx := newTestSession()
+	mustRun(t, userCtx, "login -user=user1 -pass=abc1 -id="+id)
+	t.Cleanup(func() { Analyzer(userCtx, "logout") })
+
+	for _, line := range []string{
+		`cat -file1="/audit.log"`,
+		"rep -id=" + id + " -path=audit.html -name=audit",
+		"rep -id=" + id + " -path=audit.txt -name=file -path_file_ls=/audit.log",
+	} {
+		_, err := Analyzer(userCtx, line)
+		if code := commands.CodeOf(err); code != commands.CodePermissionDenied {
+			t.Errorf("%s como user1: código %q (%v), se esperaba %q", line, code, err, commands.CodePermissionDenied)
+		}
+	}
+	if _, err := ReadAuditLog(userCtx, id, utils.AuditFilter{}); commands.CodeOf(err) != commands.CodePermissionDenied {
+		t.Errorf("ReadAuditLog como user1: se esperaba permiso denegado, se obtuvo %v", err)
+	}
+
+	// root sí lo puede leer, y ahí quedaron los intentos de user1
+	if content := mustRun(t, rootCtx, `cat -file1="/audit.log"`); !strings.Contains(content, "mkusr") {
+		t.Errorf("el log de auditoría no registró mkusr:\n%s", content)
+	}
+	entries, err := ReadAuditLog(rootCtx, id, utils.AuditFilter{User: "user1"})
+	if err != nil {
+		t.Fatalf("ReadAuditLog como root: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Error("el log de auditoría no tiene los comandos de user1")
+	}
+}
+
+// El log se crea con la partición en escritura; después registrar un comando de solo lectura no asigna nada
+func TestAuditLogAllocatedOnce(t *testing.T) {
+	ctx, id := newTestPartition(t, 1024)
+	if commands.AuditLogNeedsAllocation(id) {
+		t.Fatal("el log de auditoría no quedó creado después de mkfs y login")
+	}
+
+	sb, _, _, err := stores.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatalf("GetMountedPartitionSuperblock: %v", err)
+	}
+	freeInodes, freeBlocks := sb.S_free_inodes_count, sb.S_free_blocks_count
+
+	mustRun(t, ctx, `cat -file1="/users.txt"`, `cat -file1="/users.txt"`)
+	sb, _, _, err = stores.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatalf("GetMountedPartitionSuperblock: %v", err)
+	}
+	if sb.S_free_inodes_count != freeInodes || sb.S_free_blocks_count != freeBlocks {
+		t.Errorf("registrar cat asignó inodos o bloques: libres %d/%d, antes %d/%d",
+			sb.S_free_inodes_count, sb.S_free_blocks_count, freeInodes, freeBlocks)
+	}
+}
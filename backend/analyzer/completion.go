@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Nombres de los comandos y de sus parámetros, para completarlos en la terminal (ver el paquete cli)
+
+// Parámetros de cada comando. Los que terminan en "=" llevan valor; los demás son opciones sin valor.
+var commandParams = map[string][]string{
+	"mkdisk":    {"-size=", "-unit=", "-fit=", "-path="},
+	"rmdisk":    {"-path="},
+	"fdisk":     {"-size=", "-unit=", "-fit=", "-path=", "-type=", "-name="},
+	"mount":     {"-path=", "-name="},
+	"mkfs":      {"-id=", "-type="},
+	"rep":       {"-id=", "-path=", "-name=", "-path_file_ls=", "-user=", "-from=", "-to="},
+	"login":     {"-user=", "-pass=", "-id="},
+	"cat":       {"-file1=", "-file2=", "-file3="},
+	"mkdir":     {"-path=", "-p"},
+	"mkfile":    {"-path=", "-size=", "-cont=", "-r"},
+	"mkgrp":     {"-name="},
+	"rmgrp":     {"-name="},
+	"mkusr":     {"-user=", "-pass=", "-grp=", "-expires="},
+	"rmusr":     {"-user="},
+	"chgrp":     {"-user=", "-grp="},
+	"passwd":    {"-user=", "-pass=", "-old="},
+	"usermod":   {"-user=", "-addgrp=", "-delgrp=", "-expires="},
+	"lockusr":   {"-user="},
+	"unlockusr": {"-user="},
+	"su":        {"-user=", "-pass="},
+	"cd":        {"-path="},
+	"setquota":  {"-user=", "-grp=", "-blocks=", "-inodes="},
+	"quota":     {"-user=", "-grp="},
+	"sessions":  {"-kill="},
+	"sudo":      {"-pass="},
+	"defrag":    {"-id="},
+	"resizefs":  {"-id=", "-size=", "-unit="},
+	"debug":     {"-level=", "-sub="},
+}
+
+// Commands devuelve, ordenados, los comandos que ejecuta Analyzer
+func Commands() []string {
+	names := make([]string, 0, len(commandLocks))
+	for name := range commandLocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CommandParams devuelve los parámetros que acepta un comando (vacío si no tiene o no existe)
+func CommandParams(command string) []string {
+	return commandParams[strings.ToLower(command)]
+}
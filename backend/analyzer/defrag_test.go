@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	structures "backend/structures"
+	"fmt"
+	"testing"
+)
+
+// fragmentation devuelve el porcentaje de fragmentación de la partición montada
+func fragmentation(t *testing.T, id string) float64 {
+	t.Helper()
+	sb, _, path, err := stores.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatalf("GetMountedPartitionSuperblock: %v", err)
+	}
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		t.Fatalf("OpenDevice: %v", err)
+	}
+	defer structures.CloseDevice(dev)
+
+	report, err := sb.AnalyzeFragmentation(dev)
+	if err != nil {
+		t.Fatalf("AnalyzeFragmentation: %v", err)
+	}
+	return report.Score()
+}
+
+func TestDefragCompactsAndKeepsContent(t *testing.T) {
+	ctx, id := newTestPartition(t, 1024)
+
+	// Cada 4 archivos la raíz necesita un bloque nuevo, que queda entre los bloques de los archivos
+	const files = 12
+	for i := 0; i < files; i++ {
+		mustRun(t, ctx, fmt.Sprintf("mkfile -path=/file%d.txt -size=%d", i, 100+i))
+	}
+	before := fragmentation(t, id)
+	if before == 0 {
+		t.Fatal("la partición no quedó fragmentada antes de desfragmentar")
+	}
+	contents := map[string]string{}
+	for i := 0; i < files; i++ {
+		path := fmt.Sprintf("/file%d.txt", i)
+		contents[path] = mustRun(t, ctx, `cat -file1="`+path+`"`)
+	}
+
+	mustRun(t, ctx, "defrag -id="+id)
+
+	if after := fragmentation(t, id); after != 0 {
+		t.Errorf("fragmentación después de defrag = %.2f%%, se esperaba 0 (antes %.2f%%)", after, before)
+	}
+	for path, want := range contents {
+		if got := mustRun(t, ctx, `cat -file1="`+path+`"`); got != want {
+			t.Errorf("contenido de %s cambió después de defrag:\n got  %q\n want %q", path, got, want)
+		}
+	}
+
+	// Los punteros de asignación quedaron al final de lo compactado: se puede seguir escribiendo
+	mustRun(t, ctx, "mkfile -path=/nuevo.txt -size=200", `cat -file1="/nuevo.txt"`)
+}
+
+func TestDefragRequiresFilesystem(t *testing.T) {
+	if _, err := Analyzer(newTestSession(), "defrag -id=999Z"); err == nil {
+		t.Error("defrag de una partición no montada: se esperaba un error")
+	}
+}
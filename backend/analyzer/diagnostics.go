@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	stores "backend/stores"
+	utils "backend/utils"
+	"time"
+)
+
+// Estado interno del servidor para diagnóstico (GET /debug/state) y la prueba de que los locks responden
+// (GET /readyz)
+
+// StateDump es una copia de la tabla de montajes y de las sesiones activas. Las sesiones no incluyen su token.
+type StateDump struct {
+	Mounts            []commands.MountInfo `json:"mounts"`
+	MountedPartitions map[string]string    `json:"mounted_partitions"` // stores.MountedPartitions: id -> disco
+	ListPartitions    []string             `json:"list_partitions"`    // stores.ListPatitions
+	ListMounted       []string             `json:"list_mounted"`       // stores.ListMounted
+	DiskLetters       map[string]string    `json:"disk_letters"`       // Letra del id de montaje de cada disco
+	Disks             []string             `json:"disks"`              // stores.KnownDisks
+	Sessions          []SessionState       `json:"sessions"`
+}
+
+// SessionState describe una sesión activa
+type SessionState struct {
+	ID           string     `json:"id"` // Identificador corto (ver stores.Session.ID)
+	User         string     `json:"user"`
+	Partition    string     `json:"partition"`
+	Cwd          string     `json:"cwd"`
+	Previous     []string   `json:"previous,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	IdleDeadline *time.Time `json:"idle_deadline,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// DumpState copia el estado de montajes y sesiones
+func DumpState() StateDump {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	defer held.release()
+
+	dump := StateDump{
+		Mounts:            commands.ListMounts(),
+		MountedPartitions: make(map[string]string, len(stores.MountedPartitions)),
+		ListPartitions:    append([]string{}, stores.ListPatitions...),
+		ListMounted:       append([]string{}, stores.ListMounted...),
+		DiskLetters:       utils.DiskLetters(),
+		Disks:             stores.KnownDisks(),
+		Sessions:          []SessionState{},
+	}
+	for id, path := range stores.MountedPartitions {
+		dump.MountedPartitions[id] = path
+	}
+	for _, session := range stores.Sessions.All() {
+		state := SessionState{
+			ID:         session.ID(),
+			User:       session.Username,
+			Partition:  session.PartitionID,
+			Cwd:        session.Cwd,
+			Previous:   session.Previous,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		}
+		if idle := session.IdleDeadline(); !idle.IsZero() {
+			state.IdleDeadline = &idle
+		}
+		if !session.ExpiresAt.IsZero() {
+			state.ExpiresAt = &session.ExpiresAt
+		}
+		dump.Sessions = append(dump.Sessions, state)
+	}
+	return dump
+}
+
+// StateResponsive indica si el lock de estado se puede tomar en lectura antes del timeout. Si no se puede, un
+// comando lo tiene en escritura desde hace demasiado (o quedó trabado) y el servidor no puede atender comandos.
+func StateResponsive(timeout time.Duration) bool {
+	acquired := make(chan struct{})
+	go func() {
+		stores.StateLock.RLock()
+		stores.StateLock.RUnlock()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
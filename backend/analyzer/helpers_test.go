@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Cantidad de particiones creadas por las pruebas: mount rechaza nombres de partición ya montados en cualquier disco
+var testPartitions int
+
+// newTestDisk crea un disco en memoria con una partición primaria por cada tamaño indicado (en K), las monta y
+// devuelve sus ids en el mismo orden
+func newTestDisk(t *testing.T, sizesK ...int) []string {
+	t.Helper()
+	ctx := newTestSession()
+	path := "mem://" + strings.ReplaceAll(t.Name(), "/", "_") + ".mia"
+	mustRun(t, ctx, "mkdisk -size=2 -unit=M -path="+path)
+
+	var ids []string
+	for _, sizeK := range sizesK {
+		testPartitions++
+		name := fmt.Sprintf("Part%d", testPartitions)
+		mustRun(t, ctx, fmt.Sprintf("fdisk -size=%d -unit=K -type=P -name=%s -path=%s", sizeK, name, path))
+		ids = append(ids, mountedID(t, mustRun(t, ctx, "mount -name="+name+" -path="+path)))
+	}
+	return ids
+}
+
+// newTestPartition crea un disco en memoria con una partición primaria del tamaño indicado (en K), la monta,
+// la formatea e inicia sesión como root. Devuelve el contexto con la sesión y el id de la partición montada.
+func newTestPartition(t *testing.T, sizeK int) (context.Context, string) {
+	t.Helper()
+	id := newTestDisk(t, sizeK)[0]
+	ctx := newTestSession()
+	mustRun(t, ctx, "mkfs -id="+id, "login -user=root -pass=123 -id="+id)
+	t.Cleanup(func() { Analyzer(ctx, "logout") })
+	return ctx, id
+}
+
+// newTestSession devuelve el contexto de una petición sin sesión iniciada
+func newTestSession() context.Context {
+	return stores.WithAuth(context.Background(), &stores.AuthStore{})
+}
+
+// mountedID obtiene el id de la partición de la salida de mount
+func mountedID(t *testing.T, output string) string {
+	t.Helper()
+	_, id, found := strings.Cut(output, "-> ID: ")
+	if !found || strings.TrimSpace(id) == "" {
+		t.Fatalf("mount no devolvió el id de la partición: %q", output)
+	}
+	return strings.TrimSpace(id)
+}
+
+// mustRun ejecuta los comandos en orden y detiene la prueba en el primero que falla. Devuelve la salida del último.
+func mustRun(t *testing.T, ctx context.Context, lines ...string) string {
+	t.Helper()
+	output := ""
+	for _, line := range lines {
+		var err error
+		output, err = Analyzer(ctx, line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+	return output
+}
@@ -0,0 +1,224 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	structures "backend/structures"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Modo en que un comando toma un lock
+type lockMode int
+
+const (
+	lockNone lockMode = iota
+	lockRead
+	lockWrite
+)
+
+// De dónde se obtiene el disco o partición que usa un comando
+type lockTarget int
+
+const (
+	targetNone    lockTarget = iota
+	targetPath               // El disco viene en el parámetro -path
+	targetID                 // La partición viene en el parámetro -id
+	targetSession            // La partición es la de la sesión activa
+)
+
+// Locks que necesita cada comando (ver el modelo de concurrencia en stores/locks.go)
+type commandLocking struct {
+	state     lockMode
+	disk      lockMode
+	partition lockMode
+	target    lockTarget
+}
+
+var commandLocks = map[string]commandLocking{
+	"mkdisk":    {state: lockRead, disk: lockWrite, target: targetPath},
+	"rmdisk":    {state: lockRead, disk: lockWrite, target: targetPath},
+	"fdisk":     {state: lockRead, disk: lockWrite, target: targetPath},
+	"mount":     {state: lockWrite, disk: lockWrite, target: targetPath},
+	"mounted":   {state: lockRead},
+	"mkfs":      {state: lockRead, disk: lockRead, partition: lockWrite, target: targetID},
+	"rep":       {state: lockRead, disk: lockRead, partition: lockRead, target: targetID},
+	"login":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetID},
+	"logout":    {state: lockRead},
+	"cat":       {state: lockRead, disk: lockRead, partition: lockRead, target: targetSession},
+	"mkdir":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"mkfile":    {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"mkgrp":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"rmgrp":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"mkusr":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"rmusr":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"chgrp":     {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"passwd":    {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"usermod":   {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"lockusr":   {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"unlockusr": {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"su":        {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"exit":      {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"cd":        {state: lockRead, disk: lockRead, partition: lockRead, target: targetSession},
+	"pwd":       {state: lockRead},
+	"setquota":  {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"quota":     {state: lockRead, disk: lockRead, partition: lockRead, target: targetSession},
+	"whoami":    {state: lockRead, disk: lockRead, partition: lockRead, target: targetSession},
+	"sessions":  {state: lockRead},
+	"sudo":      {state: lockRead, disk: lockRead, partition: lockWrite, target: targetSession},
+	"sync":      {state: lockRead},
+	"defrag":    {state: lockRead, disk: lockRead, partition: lockWrite, target: targetID},
+	"resizefs":  {state: lockRead, disk: lockRead, partition: lockWrite, target: targetID},
+	"debug":     {state: lockRead},
+}
+
+// Comandos que cambian la identidad de la sesión y por lo tanto no pueden ejecutarse con sudo
+var elevationCommands = map[string]bool{"su": true, "exit": true, "sudo": true}
+
+// lockingFor devuelve los locks de un comando. sudo toma los del comando que ejecuta, pero siempre
+// con la partición de la sesión en escritura porque registra la elevación en su log de auditoría.
+func lockingFor(command string, arguments []string) (commandLocking, bool) {
+	spec, exists := commandLocks[command]
+	if command != "sudo" || len(arguments) < 2 {
+		return spec, exists
+	}
+	if inner, innerExists := commandLocks[strings.ToLower(arguments[1])]; innerExists && inner.target == targetSession {
+		inner.partition = lockWrite
+		return inner, true
+	}
+	return spec, exists
+}
+
+// heldLocks guarda las funciones que liberan los locks tomados, en el orden en que se tomaron, y los discos
+// cuyos locks se tomaron: son los únicos en los que el comando puede haber dejado cambios en caché
+type heldLocks struct {
+	unlocks []func()
+	disks   []string
+}
+
+// take toma el lock en el modo indicado (no hace nada con lockNone)
+func (h *heldLocks) take(lock *sync.RWMutex, mode lockMode) {
+	switch mode {
+	case lockRead:
+		lock.RLock()
+		h.unlocks = append(h.unlocks, lock.RUnlock)
+	case lockWrite:
+		lock.Lock()
+		h.unlocks = append(h.unlocks, lock.Unlock)
+	}
+}
+
+// takeMutex toma un lock exclusivo
+func (h *heldLocks) takeMutex(lock *sync.Mutex) {
+	lock.Lock()
+	h.unlocks = append(h.unlocks, lock.Unlock)
+}
+
+// takeDisk toma el lock de un disco y lo anota entre los discos usados
+func (h *heldLocks) takeDisk(path string, mode lockMode) {
+	if mode == lockNone {
+		return
+	}
+	h.take(stores.DiskLock(path), mode)
+	h.disks = append(h.disks, stores.DiskKey(path))
+}
+
+// release libera los locks en orden inverso
+func (h heldLocks) release() {
+	for i := len(h.unlocks) - 1; i >= 0; i-- {
+		h.unlocks[i]()
+	}
+}
+
+// takePartition toma el lock del disco de una partición montada y luego el de la partición. Requiere StateLock tomado.
+func (h *heldLocks) takePartition(id string, disk, partition lockMode) {
+	if id == "" {
+		return
+	}
+	if diskPath := stores.MountedPartitions[id]; diskPath != "" {
+		h.takeDisk(diskPath, disk)
+	}
+	h.take(stores.PartitionLock(id), partition)
+}
+
+// acquireLocks toma, en orden estado -> disco -> partición, los locks que necesita el comando.
+// Los locks se liberan con release, en orden inverso.
+func acquireLocks(ctx context.Context, command string, arguments []string) heldLocks {
+	var held heldLocks
+	spec, exists := lockingFor(command, arguments)
+	if !exists {
+		return held
+	}
+
+	held.take(&stores.StateLock, spec.state)
+
+	switch spec.target {
+	case targetPath:
+		if path := argumentValue(arguments, "path"); path != "" {
+			held.takeDisk(path, spec.disk)
+		}
+	case targetID, targetSession:
+		// Con el lock de estado tomado, el id y su disco no pueden cambiar mientras se resuelven
+		id := argumentValue(arguments, "id")
+		if spec.target == targetSession {
+			id = stores.AuthFromContext(ctx).GetPartitionID()
+		}
+		held.takePartition(id, spec.disk, spec.partition)
+	}
+
+	return held
+}
+
+// acquireAuditLocks toma los locks necesarios para leer o escribir el log de auditoría de una partición. Para
+// escribir sobre el log ya creado basta la partición en lectura: el log tiene su propio mutex y así registrar un
+// comando de solo lectura no bloquea a los demás lectores. Crear o reasignar el log requiere la partición en escritura.
+func acquireAuditLocks(id string, partition lockMode) heldLocks {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	held.takePartition(id, lockRead, partition)
+	if id != "" {
+		held.takeMutex(stores.AuditLock(id))
+	}
+	return held
+}
+
+// flushDisks vuelca los cambios en caché de los discos que usó un comando, ya liberados sus locks. Cada disco se
+// vuelca con su lock en escritura, así ningún otro comando tiene cambios a medias en él. Si el disco no tiene
+// cambios pendientes no se toma el lock: los del comando ya los volcó otro.
+func flushDisks(paths []string) error {
+	var errs []error
+	for _, path := range paths {
+		if !structures.HasDirtyPages(path) {
+			continue
+		}
+		lock := stores.DiskLock(path)
+		lock.Lock()
+		err := structures.FlushDevices(path)
+		lock.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncDisk vuelca y sincroniza un disco para sync, con su lock en escritura (ver flushDisks)
+func syncDisk(path string) (bool, error) {
+	lock := stores.DiskLock(path)
+	lock.Lock()
+	defer lock.Unlock()
+	count, err := structures.SyncDevices(path)
+	return count > 0, err
+}
+
+// argumentValue obtiene el valor de un parámetro -name=valor (con o sin comillas) de la lista de argumentos
+func argumentValue(arguments []string, name string) string {
+	re := regexp.MustCompile(`(?i)(?:^|\s)-` + regexp.QuoteMeta(name) + `=("[^"]*"|\S+)`)
+	match := re.FindStringSubmatch(strings.Join(arguments, " "))
+	if match == nil {
+		return ""
+	}
+	return strings.Trim(match[1], "\"")
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	structures "backend/structures"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Estas pruebas tienen sentido sobre todo con go test -race: ejecutan en paralelo comandos que comparten
+// disco y partición y verifican que el sistema de archivos quede consistente.
+
+// runParallel ejecuta cada función en su propia goroutine, espera a que terminen y reporta los errores
+func runParallel(t *testing.T, workers ...func() error) {
+	t.Helper()
+	errs := make(chan error, len(workers))
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker func() error) {
+			defer wg.Done()
+			if err := worker(); err != nil {
+				errs <- err
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// runAll ejecuta los comandos en orden y devuelve el primer error
+func runAll(ctx context.Context, lines ...string) error {
+	for _, line := range lines {
+		if _, err := Analyzer(ctx, line); err != nil {
+			return fmt.Errorf("%s: %w", line, err)
+		}
+	}
+	return nil
+}
+
+func TestConcurrentFileCommandsAndSiblingPartitions(t *testing.T) {
+	ids := newTestDisk(t, 512, 512)
+	ctx := newTestSession()
+	otherDisk := "mem://" + t.Name() + "_2.mia"
+	mustRun(t, ctx,
+		"mkfs -id="+ids[0],
+		"mkfs -id="+ids[1],
+		"login -user=root -pass=123 -id="+ids[0],
+		"mkdisk -size=1 -unit=M -path="+otherDisk,
+		"fdisk -size=256 -unit=K -type=P -name=Hermana -path="+otherDisk,
+	)
+	t.Cleanup(func() { Analyzer(ctx, "logout") })
+
+	const workers, filesPerWorker = 4, 5
+	var tasks []func() error
+	for w := 0; w < workers; w++ {
+		tasks = append(tasks, func() error {
+			for i := 0; i < filesPerWorker; i++ {
+				if err := runAll(ctx, fmt.Sprintf("mkfile -path=/w%d_%d.txt -size=%d", w, i, 10*w+i+1)); err != nil {
+					return err
+				}
+				if _, err := Analyzer(ctx, `cat -file1="/users.txt"`); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	// En paralelo: la partición hermana se formatea (mismo disco, otra partición) y se monta otra de otro disco
+	tasks = append(tasks,
+		func() error {
+			for i := 0; i < 3; i++ {
+				if err := runAll(ctx, "mkfs -id="+ids[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func() error { return runAll(ctx, "mount -name=Hermana -path="+otherDisk) },
+	)
+	runParallel(t, tasks...)
+
+	// Todos los archivos quedaron con su contenido y la partición hermana sigue formateada
+	for w := 0; w < workers; w++ {
+		for i := 0; i < filesPerWorker; i++ {
+			path := fmt.Sprintf("/w%d_%d.txt", w, i)
+			content := mustRun(t, ctx, `cat -file1="`+path+`"`)
+			if want := strings.Repeat("0123456789", 10)[:10*w+i+1]; !strings.Contains(content, want) {
+				t.Errorf("contenido de %s = %q, se esperaba %q", path, content, want)
+			}
+		}
+	}
+	mustRun(t, ctx, "logout", "login -user=root -pass=123 -id="+ids[1], `cat -file1="/users.txt"`)
+}
+
+func TestConcurrentMkfsAndFileCommands(t *testing.T) {
+	ids := newTestDisk(t, 512)
+	ctx := newTestSession()
+	mustRun(t, ctx, "mkfs -id="+ids[0], "login -user=root -pass=123 -id="+ids[0])
+	t.Cleanup(func() { Analyzer(ctx, "logout") })
+
+	// mkfs toma la partición en escritura: cada mkfile ocurre entero antes o después del formateo, así que
+	// el archivo puede desaparecer pero el sistema de archivos nunca queda a medias
+	var tasks []func() error
+	for w := 0; w < 4; w++ {
+		tasks = append(tasks, func() error {
+			for i := 0; i < 5; i++ {
+				Analyzer(ctx, fmt.Sprintf("mkfile -path=/w%d_%d.txt -size=20", w, i))
+			}
+			return nil
+		})
+	}
+	tasks = append(tasks, func() error { return runAll(ctx, "mkfs -id="+ids[0], "mkfs -id="+ids[0]) })
+	runParallel(t, tasks...)
+
+	mustRun(t, ctx, "mkfile -path=/final.txt -size=5", `cat -file1="/final.txt"`, `cat -file1="/users.txt"`)
+}
+
+// Volcar la caché de un disco espera a que terminen los comandos que trabajan en él: con el lock del disco en
+// lectura podrían tener cambios a medias
+func TestFlushWaitsForDiskReaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	if err := os.WriteFile(path, make([]byte, structures.CachePageSize), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		t.Fatalf("OpenDevice: %v", err)
+	}
+	t.Cleanup(func() { structures.DiscardDevice(path) })
+	if _, err := dev.WriteAt([]byte("a medias"), 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	structures.CloseDevice(dev)
+
+	// Otro comando sigue trabajando en el disco
+	lock := stores.DiskLock(path)
+	lock.RLock()
+	flushed := make(chan error, 1)
+	go func() { flushed <- flushDisks([]string{stores.DiskKey(path)}) }()
+
+	select {
+	case <-flushed:
+		lock.RUnlock()
+		t.Fatal("se volcó la caché mientras otro comando tenía el disco en lectura")
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.RUnlock()
+	if err := <-flushed; err != nil {
+		t.Fatalf("flushDisks: %v", err)
+	}
+	if structures.HasDirtyPages(path) {
+		t.Error("quedaron cambios sin volcar")
+	}
+}
@@ -0,0 +1,5 @@
+package analyzer
+
+import logging "backend/logging"
+
+var logger = logging.Logger(logging.Analyzer)
@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	"testing"
+)
+
+// Una cuenta bloqueada por root o expirada responde igual con la contraseña correcta que con una incorrecta,
+// y los intentos contra ella no cuentan como fallidos
+func TestLoginLockedAccountHidesPassword(t *testing.T) {
+	rootCtx, id := newTestPartition(t, 1024)
+	mustRun(t, rootCtx, "mkgrp -name=usuarios",
+		"mkusr -user=user1 -pass=abc1 -grp=usuarios", "lockusr -user=user1",
+		"mkusr -user=user2 -pass=abc2 -grp=usuarios", "usermod -user=user2 -expires=2000-01-01")
+
+	for _, user := range []string{"user1", "user2"} {
+		var messages []string
+		for _, pass := range []string{"abc1", "abc2", "incorrecta", "otra", "mas", "intentos"} {
+			ctx := newTestSession()
+			_, err := Analyzer(ctx, "login -user="+user+" -pass="+pass+" -id="+id)
+			if code := commands.CodeOf(err); code != commands.CodeAccountLocked {
+				t.Fatalf("login de %s con -pass=%s: código %q (%v), se esperaba %q", user, pass, code, err, commands.CodeAccountLocked)
+			}
+			messages = append(messages, err.Error())
+		}
+		for _, message := range messages[1:] {
+			if message != messages[0] {
+				t.Errorf("login de %s: la respuesta cambia según la contraseña:\n%q\n%q", user, messages[0], message)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	metrics "backend/metrics"
+	stores "backend/stores"
+	structures "backend/structures"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Métricas de los comandos y de las particiones montadas (ver WriteMetrics)
+
+var (
+	commandsTotal = metrics.NewCounterVec("mia_commands_total",
+		"Comandos ejecutados por comando y resultado (ok o el código de error)", "command", "outcome")
+	commandDuration = metrics.NewHistogramVec("mia_command_duration_seconds",
+		"Duración de los comandos en segundos, incluida la espera de sus locks", metrics.DefaultBuckets, "command")
+)
+
+// observeResult registra el resultado de un comando en las métricas. Los comandos que no existen se cuentan
+// como "unknown" para no crear una serie por cada nombre mal escrito.
+func observeResult(result Result) {
+	command := "unknown"
+	if fields := strings.Fields(result.Command); len(fields) > 0 {
+		if name := strings.ToLower(fields[0]); isKnownCommand(name) {
+			command = name
+		}
+	}
+	outcome := StatusOK
+	if result.Status == StatusError {
+		outcome = string(result.Code)
+	}
+	commandsTotal.Inc(command, outcome)
+	commandDuration.Observe(result.DurationMs/1000, command)
+}
+
+// WriteMetrics escribe en formato de Prometheus las métricas de los comandos, la cantidad de particiones
+// montadas y, de cada partición formateada, los inodos y bloques libres y totales de su SuperBlock
+func WriteMetrics(w io.Writer) error {
+	if err := commandsTotal.Write(w); err != nil {
+		return err
+	}
+	if err := commandDuration.Write(w); err != nil {
+		return err
+	}
+
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	defer held.release()
+
+	ids := make([]string, 0, len(stores.MountedPartitions))
+	for id := range stores.MountedPartitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var freeInodes, freeBlocks, totalInodes, totalBlocks []metrics.Sample
+	for _, id := range ids {
+		superblock, ok := partitionUsage(id)
+		if !ok {
+			continue
+		}
+		labels := []metrics.Label{{Name: "id", Value: id}, {Name: "disk", Value: stores.MountedPartitions[id]}}
+		freeInodes = append(freeInodes, metrics.Sample{Labels: labels, Value: float64(superblock.S_free_inodes_count)})
+		freeBlocks = append(freeBlocks, metrics.Sample{Labels: labels, Value: float64(superblock.S_free_blocks_count)})
+		totalInodes = append(totalInodes, metrics.Sample{Labels: labels, Value: float64(superblock.S_inodes_count)})
+		totalBlocks = append(totalBlocks, metrics.Sample{Labels: labels, Value: float64(superblock.S_blocks_count)})
+	}
+
+	gauges := []struct {
+		name, help string
+		samples    []metrics.Sample
+	}{
+		{"mia_mounted_partitions", "Particiones montadas", []metrics.Sample{{Value: float64(len(ids))}}},
+		{"mia_partition_free_inodes", "Inodos libres de la partición según su SuperBlock", freeInodes},
+		{"mia_partition_free_blocks", "Bloques libres de la partición según su SuperBlock", freeBlocks},
+		{"mia_partition_inodes", "Inodos de la partición según su SuperBlock", totalInodes},
+		{"mia_partition_blocks", "Bloques de la partición según su SuperBlock", totalBlocks},
+	}
+	for _, gauge := range gauges {
+		if err := metrics.WriteGauge(w, gauge.name, gauge.help, gauge.samples...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionUsage lee el SuperBlock de una partición montada con sus locks en lectura. Devuelve false si la
+// partición no se puede leer o no tiene un sistema de archivos. Requiere StateLock tomado.
+func partitionUsage(id string) (*structures.SuperBlock, bool) {
+	var held heldLocks
+	held.takePartition(id, lockRead, lockRead)
+	defer held.release()
+
+	superblock, _, _, err := stores.GetMountedPartitionSuperblock(id)
+	if err != nil || superblock.S_magic != 0xEF53 {
+		return nil, false
+	}
+	return superblock, true
+}
+
+// isKnownCommand indica si el comando es uno de los que ejecuta runCommand
+func isKnownCommand(name string) bool {
+	_, known := commandLocks[name]
+	return known
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	"strings"
+	"testing"
+)
+
+// quotaBlocks devuelve el texto "bloques N/M" del usuario en la salida de quota
+func quotaBlocks(t *testing.T, output string) string {
+	t.Helper()
+	_, usage, found := strings.Cut(output, "): bloques ")
+	if !found {
+		t.Fatalf("quota no devolvió el uso del usuario: %q", output)
+	}
+	blocks, _, _ := strings.Cut(usage, ",")
+	return blocks
+}
+
+// El uso cacheado se actualiza con cada archivo, y la cuota cuenta los bloques de punteros y el bloque carpeta
+// que necesita el padre cuando se llena
+func TestQuotaCountsPointerAndParentBlocks(t *testing.T) {
+	rootCtx, id := newTestPartition(t, 1024)
+	mustRun(t, rootCtx, "mkgrp -name=usuarios", "mkusr -user=user1 -pass=abc1 -grp=usuarios")
+	userCtx := newTestSession()
+	mustRun(t, userCtx, "login -user=user1 -pass=abc1 -id="+id)
+	t.Cleanup(func() { Analyzer(userCtx, "logout") })
+
+	// La carpeta ocupa un bloque; el archivo de 13 bloques de datos necesita además el bloque de punteros simple
+	steps := []struct{ line, blocks string }{
+		{"mkdir -path=/u1", "1/-"},
+		{"mkfile -path=/u1/a.txt -size=832", "15/-"},
+		{"mkfile -path=/u1/b.txt -size=0", "15/-"},
+	}
+	for _, step := range steps {
+		mustRun(t, userCtx, step.line)
+		if blocks := quotaBlocks(t, mustRun(t, userCtx, "quota")); blocks != step.blocks {
+			t.Errorf("después de %s: bloques %s, se esperaba %s", step.line, blocks, step.blocks)
+		}
+	}
+
+	// El bloque carpeta de /u1 ya no tiene lugar: un archivo vacío más necesita un bloque
+	mustRun(t, rootCtx, "setquota -user=user1 -blocks=15")
+	_, err := Analyzer(userCtx, "mkfile -path=/u1/c.txt -size=0")
+	if code := commands.CodeOf(err); code != commands.CodeQuotaExceeded {
+		t.Fatalf("mkfile con la carpeta llena: código %q (%v), se esperaba %q", code, err, commands.CodeQuotaExceeded)
+	}
+	mustRun(t, rootCtx, "setquota -user=user1 -blocks=16")
+	mustRun(t, userCtx, "mkfile -path=/u1/c.txt -size=0")
+	if blocks := quotaBlocks(t, mustRun(t, userCtx, "quota")); blocks != "16/16" {
+		t.Errorf("después de c.txt: bloques %s, se esperaba 16/16", blocks)
+	}
+}
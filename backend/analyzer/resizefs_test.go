@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	"fmt"
+	"testing"
+)
+
+// inodesCount devuelve la cantidad de inodos del sistema de archivos de la partición montada
+func inodesCount(t *testing.T, id string) int32 {
+	t.Helper()
+	sb, _, _, err := stores.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatalf("GetMountedPartitionSuperblock: %v", err)
+	}
+	return sb.S_inodes_count
+}
+
+func TestResizefsShrinkAndGrow(t *testing.T) {
+	ctx, id := newTestPartition(t, 1024)
+	const files = 5
+	contents := map[string]string{}
+	for i := 0; i < files; i++ {
+		path := fmt.Sprintf("/file%d.txt", i)
+		contents[path] = mustRun(t, ctx, fmt.Sprintf("mkfile -path=%s -size=%d", path, 200+i), `cat -file1="`+path+`"`)
+	}
+	checkContents := func(step string) {
+		t.Helper()
+		for path, want := range contents {
+			if got := mustRun(t, ctx, `cat -file1="`+path+`"`); got != want {
+				t.Errorf("contenido de %s cambió después de %s:\n got  %q\n want %q", path, step, got, want)
+			}
+		}
+	}
+	original := inodesCount(t, id)
+
+	// Reducir a un cuarto de la partición
+	mustRun(t, ctx, "resizefs -id="+id+" -size=256 -unit=K")
+	shrunk := inodesCount(t, id)
+	if shrunk >= original {
+		t.Fatalf("inodos después de reducir = %d, se esperaban menos que %d", shrunk, original)
+	}
+	checkContents("reducir")
+
+	// Sin -size vuelve a ocupar toda la partición
+	mustRun(t, ctx, "resizefs -id="+id)
+	if grown := inodesCount(t, id); grown != original {
+		t.Errorf("inodos después de agrandar = %d, se esperaban %d", grown, original)
+	}
+	checkContents("agrandar")
+	mustRun(t, ctx, "mkfile -path=/nuevo.txt -size=300", `cat -file1="/nuevo.txt"`)
+}
+
+func TestResizefsRejectsInvalidSizes(t *testing.T) {
+	ctx, id := newTestPartition(t, 512)
+	mustRun(t, ctx, "mkfile -path=/a.txt -size=10", "mkfile -path=/b.txt -size=10", "mkfile -path=/c.txt -size=10")
+
+	for _, line := range []string{
+		"resizefs -id=" + id + " -size=2 -unit=M", // Mayor que la partición
+		"resizefs -id=" + id + " -size=1 -unit=K", // No alcanza para lo que está en uso
+		"resizefs -id=" + id,                      // Ya tiene ese tamaño
+	} {
+		if _, err := Analyzer(ctx, line); err == nil {
+			t.Errorf("%s: se esperaba un error", line)
+		}
+	}
+}
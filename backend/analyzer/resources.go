@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	stores "backend/stores"
+	"context"
+)
+
+// Consultas de la API REST. Toman los mismos locks que el comando equivalente (ver commandLocks) y no
+// pasan por el log de auditoría porque no modifican nada.
+
+// ListDisks describe todos los discos conocidos (ver stores.KnownDisks)
+func ListDisks() ([]commands.DiskInfo, error) {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	defer held.release()
+
+	disks := []commands.DiskInfo{}
+	for _, path := range stores.KnownDisks() {
+		disk, err := describeDiskLocked(path)
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, *disk)
+	}
+	return disks, nil
+}
+
+// DescribeDisk describe un disco y sus particiones
+func DescribeDisk(path string) (*commands.DiskInfo, error) {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	defer held.release()
+
+	return describeDiskLocked(path)
+}
+
+// describeDiskLocked toma el lock del disco. Requiere StateLock tomado.
+func describeDiskLocked(path string) (*commands.DiskInfo, error) {
+	var held heldLocks
+	held.take(stores.DiskLock(path), lockRead)
+	defer held.release()
+
+	return commands.DescribeDisk(path)
+}
+
+// ListMounts devuelve las particiones montadas (como mounted)
+func ListMounts() []commands.MountInfo {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	defer held.release()
+
+	return commands.ListMounts()
+}
+
+// DescribePath describe un archivo o carpeta de la partición de la sesión (como cat)
+func DescribePath(ctx context.Context, partitionID, path string, depth int) (*commands.FileInfo, error) {
+	release := acquirePartitionReadLocks(partitionID)
+	defer release()
+
+	return commands.DescribePath(ctx, partitionID, path, depth)
+}
+
+// ReadFile devuelve el contenido de un archivo de la partición de la sesión (como cat)
+func ReadFile(ctx context.Context, partitionID, path string) (string, error) {
+	release := acquirePartitionReadLocks(partitionID)
+	defer release()
+
+	return commands.ReadFile(ctx, partitionID, path)
+}
+
+// ListUsers devuelve los usuarios y grupos de la partición de la sesión
+func ListUsers(ctx context.Context, partitionID string) ([]commands.UserInfo, []commands.GroupInfo, error) {
+	release := acquirePartitionReadLocks(partitionID)
+	defer release()
+
+	return commands.ListUsers(ctx, partitionID)
+}
+
+// acquirePartitionReadLocks toma en lectura el estado, el disco y la partición indicada
+func acquirePartitionReadLocks(id string) func() {
+	var held heldLocks
+	held.take(&stores.StateLock, lockRead)
+	held.takePartition(id, lockRead, lockRead)
+	return held.release
+}
@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	commands "backend/commands"
+	utils "backend/utils"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result es el resultado de una línea de un script de comandos
+type Result struct {
+	Line       int                `json:"line"`                // Número de línea en el script (desde 1)
+	Command    string             `json:"command"`             // Línea original, sin espacios al inicio y al final
+	Status     string             `json:"status"`              // "ok" o "error"
+	Code       commands.ErrorCode `json:"code,omitempty"`      // Código del error (ver commands.CodeOf)
+	Message    string             `json:"message"`             // Salida del comando o mensaje de error
+	DurationMs float64            `json:"duration_ms"`         // Tiempo de ejecución en milisegundos
+	Artifacts  []string           `json:"artifacts,omitempty"` // Archivos generados (por ejemplo, los de rep)
+}
+
+// Estados de un Result
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// RunScript ejecuta un script línea por línea con Analyzer y devuelve un resultado por comando.
+// Las líneas vacías y los comentarios no generan resultado, pero cuentan para el número de línea.
+func RunScript(ctx context.Context, script string) []Result {
+	results, _ := RunScriptObserved(ctx, script, ScriptObserver{})
+	return results
+}
+
+// ScriptObserver recibe el avance de un script. Las funciones nil se ignoran.
+type ScriptObserver struct {
+	OnStart  func(line int, command string) // Antes de ejecutar cada comando
+	OnResult func(result Result)            // Al terminar cada comando
+}
+
+// RunScriptObserved ejecuta el script como RunScript avisando al observador el inicio y el resultado de cada
+// comando. Si el contexto se cancela, se detiene antes del siguiente comando (el que está en curso termina)
+// y devuelve los resultados hasta ese momento junto con el error del contexto.
+func RunScriptObserved(ctx context.Context, script string, observer ScriptObserver) ([]Result, error) {
+	results := []Result{}
+	for index, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		if observer.OnStart != nil {
+			observer.OnStart(index+1, trimmed)
+		}
+		result := runLine(ctx, index+1, trimmed)
+		if observer.OnResult != nil {
+			observer.OnResult(result)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runLine ejecuta un comando del script y arma su resultado
+func runLine(ctx context.Context, lineNumber int, command string) Result {
+	commandCtx, artifacts := commands.WithArtifacts(ctx)
+	start := time.Now()
+	output, err := Analyzer(commandCtx, command)
+
+	result := Result{
+		Line:       lineNumber,
+		Command:    command,
+		Status:     StatusOK,
+		Message:    output,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Artifacts:  *artifacts,
+	}
+	if err != nil {
+		result.Status = StatusError
+		result.Code = commands.CodeOf(err)
+		result.Message = err.Error()
+	}
+	logResult(ctx, result)
+	observeResult(result)
+	return result
+}
+
+// logResult registra el resultado de un comando: Info si terminó bien y Warn si falló. La línea va sin
+// contraseñas y con el request_id del contexto, si lo tiene.
+func logResult(ctx context.Context, result Result) {
+	attrs := []any{"line", result.Line, "command", utils.RedactPasswords(result.Command), "duration_ms", result.DurationMs}
+	if result.Status == StatusError {
+		logger.WarnContext(ctx, "Comando fallido", append(attrs, "code", result.Code, "error", utils.RedactPasswords(result.Message))...)
+		return
+	}
+	logger.InfoContext(ctx, "Comando ejecutado", attrs...)
+}
+
+// RequestError es el resultado de una petición que falló antes de ejecutar su script (por ejemplo, con una
+// sesión vencida). No corresponde a ninguna línea, así que Line queda en 0.
+func RequestError(err error) Result {
+	return Result{Status: StatusError, Code: commands.CodeOf(err), Message: err.Error()}
+}
+
+// FormatOutput arma la salida de texto de un script como la devolvía el servidor antes de los resultados
+// estructurados: una línea por comando, con "Error: " delante de los que fallaron
+func FormatOutput(results []Result) string {
+	output := ""
+	for _, result := range results {
+		if result.Status == StatusError {
+			output += fmt.Sprintf("Error: %s\n", result.Message)
+		} else {
+			output += fmt.Sprintf("%s\n", result.Message)
+		}
+	}
+	if output == "" {
+		output = "No se ejecutó ningún comando"
+	}
+	return output
+}
+
+// Comandos que pueden tardar: mkdisk llena de ceros el disco completo y rep ejecuta Graphviz.
+// Se pueden ejecutar como trabajos en segundo plano (ver el paquete jobs).
+var longRunningCommands = map[string]bool{"mkdisk": true, "rep": true}
+
+// IsLongRunning indica si la línea es un único comando marcado como largo
+func IsLongRunning(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 0 && !strings.Contains(strings.TrimSpace(line), "\n") && longRunningCommands[strings.ToLower(fields[0])]
+}
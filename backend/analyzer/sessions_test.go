@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	stores "backend/stores"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestClock reemplaza el reloj de las sesiones por uno fijo que solo avanza con la función devuelta, y
+// los límites de las sesiones por los indicados
+func useTestClock(t *testing.T, timeouts stores.SessionTimeoutPolicy) (advance func(time.Duration)) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	previous := stores.SessionTimeouts
+	stores.Clock, stores.SessionTimeouts = func() time.Time { return now }, timeouts
+	t.Cleanup(func() { stores.Clock, stores.SessionTimeouts = time.Now, previous })
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestSessionsAndWhoamiFollowTheClock(t *testing.T) {
+	advance := useTestClock(t, stores.SessionTimeoutPolicy{Idle: 10 * time.Minute, Absolute: time.Hour})
+	rootCtx, id := newTestPartition(t, 1024)
+	mustRun(t, rootCtx, "mkgrp -name=usuarios", "mkusr -user=user1 -pass=abc1 -grp=usuarios")
+	userCtx := newTestSession()
+	mustRun(t, userCtx, "login -user=user1 -pass=abc1 -id="+id)
+	t.Cleanup(func() { Analyzer(userCtx, "logout") })
+
+	if output := mustRun(t, userCtx, "whoami"); !strings.Contains(output, "Inicio de sesión: 2025-03-01 10:00:00") {
+		t.Errorf("whoami no muestra la hora del login según el reloj:\n%s", output)
+	}
+
+	// root sigue usando su sesión; la de user1 pasa más de 10 minutos sin uso
+	root, user := stores.AuthFromContext(rootCtx), stores.AuthFromContext(userCtx)
+	advance(6 * time.Minute)
+	if err := stores.Sessions.Touch(root.Token); err != nil {
+		t.Fatalf("Touch de la sesión de root: %v", err)
+	}
+	advance(5 * time.Minute)
+
+	output := mustRun(t, rootCtx, "sessions")
+	if !strings.Contains(output, "1 sesiones activas") || !strings.Contains(output, "* "+root.SessionID()) {
+		t.Errorf("sessions debería listar solo la sesión de root:\n%s", output)
+	}
+	if strings.Contains(output, user.SessionID()) {
+		t.Errorf("sessions lista la sesión vencida de user1:\n%s", output)
+	}
+	// La sesión de root vence por inactividad 10 minutos después de su último uso (10:06)
+	if !strings.Contains(output, "expira: 2025-03-01 10:16:00") {
+		t.Errorf("sessions no muestra el vencimiento por inactividad de root:\n%s", output)
+	}
+	if _, err := stores.ResumeSession(user.Token); err == nil {
+		t.Error("la sesión de user1 sigue sirviendo después del límite de inactividad")
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	stores "backend/stores"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// API REST sobre los mismos comandos que ejecuta POST /. Las consultas usan las funciones con datos de
+// analyzer/resources.go y las modificaciones arman la línea del comando y la ejecutan con analyzer.RunScript,
+// así que pasan por las mismas validaciones, locks y log de auditoría que un script.
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// ErrorResponse es el cuerpo de todas las respuestas de error de la API
+type ErrorResponse struct {
+	Error  string             `json:"error"`
+	Code   commands.ErrorCode `json:"code"`
+	Result *analyzer.Result   `json:"result,omitempty"` // Resultado del comando, si el error vino de uno
+}
+
+// Register agrega las rutas de la API a la aplicación
+func Register(app *fiber.App) {
+	app.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(openAPIDocument)
+	})
+
+	app.Get("/healthz", getHealth)
+	app.Get("/readyz", getReady)
+	app.Get("/metrics", getMetrics)
+	app.Get("/debug/state", getDebugState)
+
+	app.Get("/disks", listDisks)
+	app.Post("/disks", createDisk)
+	app.Get("/disks/:path", getDisk)
+	app.Delete("/disks/:path", deleteDisk)
+	app.Get("/disks/:path/partitions", listPartitions)
+	app.Post("/disks/:path/partitions", createPartition)
+
+	app.Get("/mounts", listMounts)
+	app.Post("/mounts", createMount)
+
+	app.Get("/fs/:id/tree", getTree)
+	app.Get("/fs/:id/files", getFile)
+	app.Post("/fs/:id/files", createFile)
+
+	app.Get("/users", listUsers)
+	app.Post("/users", createUser)
+	app.Delete("/users/:name", deleteUser)
+
+	app.Post("/jobs", createJob)
+	app.Get("/jobs", listJobs)
+	app.Get("/jobs/:id", getJob)
+
+	app.Post("/stream", streamScript)
+	app.Get("/stream", streamScript)
+	app.Delete("/stream/:id", cancelStream)
+
+	app.Get("/reports/files/*", getReportFile)
+	app.Get("/reports/:id/:name", getReport)
+}
+
+// RequestToken obtiene el token de sesión del header Authorization: Bearer o, si no viene, del valor indicado
+func RequestToken(c *fiber.Ctx, fallback string) string {
+	if header := c.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	return fallback
+}
+
+// sessionContext recupera la sesión de la petición (token en Authorization o en ?token=) y la pone en un contexto
+func sessionContext(c *fiber.Ctx) (context.Context, error) {
+	auth, err := stores.ResumeSession(RequestToken(c, c.Query("token")))
+	if err != nil {
+		return nil, err
+	}
+	return stores.WithAuth(RequestContext(c), auth), nil
+}
+
+// requireSession verifica que la petición tenga una sesión iniciada y devuelve su contexto (para las rutas que
+// leen o modifican archivos del servidor, como los discos)
+func requireSession(c *fiber.Ctx) (context.Context, error) {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return nil, err
+	}
+	if !stores.AuthFromContext(ctx).IsAuthenticated() {
+		return nil, commands.NewError(commands.CodeUnauthenticated, "esta operación requiere inicio de sesión")
+	}
+	return ctx, nil
+}
+
+// requirePartition verifica que haya sesión y que sea de la partición indicada (para los comandos que trabajan
+// sobre la partición de la sesión y no reciben -id)
+func requirePartition(ctx context.Context, partitionID string) error {
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return commands.NewError(commands.CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
+	}
+	if auth.GetPartitionID() != partitionID {
+		return commands.NewError(commands.CodePermissionDenied, "permiso denegado: la sesión es de la partición '%s', no de '%s'", auth.GetPartitionID(), partitionID)
+	}
+	return nil
+}
+
+// run ejecuta una línea de comando con la sesión del contexto. Si el comando falla devuelve su resultado
+// dentro de un *commandFailure, que fail convierte en la respuesta de error.
+func run(ctx context.Context, line string) (analyzer.Result, error) {
+	results := analyzer.RunScript(ctx, line)
+	if len(results) != 1 {
+		return analyzer.Result{}, fmt.Errorf("se esperaba un resultado para '%s' y se obtuvieron %d", line, len(results))
+	}
+	if results[0].Status == analyzer.StatusError {
+		return results[0], &commandFailure{result: results[0]}
+	}
+	return results[0], nil
+}
+
+// commandFailure es el error de un comando ejecutado por run
+type commandFailure struct {
+	result analyzer.Result
+}
+
+func (f *commandFailure) Error() string { return f.result.Message }
+
+// commandLine arma "comando -clave=valor ..." con los parámetros no vacíos, en el orden indicado. Los valores
+// no pueden tener espacios ni comillas porque los comandos se separan por espacios.
+func commandLine(command string, params ...[2]string) (string, error) {
+	line := command
+	for _, param := range params {
+		name, value := param[0], param[1]
+		if value == "" {
+			continue
+		}
+		if strings.ContainsAny(value, " \t\r\n\"") {
+			return "", commands.NewError(commands.CodeInvalidArgument, "el valor de '%s' no puede contener espacios ni comillas: %q", name, value)
+		}
+		line += fmt.Sprintf(" -%s=%s", name, value)
+	}
+	return line, nil
+}
+
+// pathParam devuelve un parámetro de ruta decodificado (los paths de disco llegan como %2Ftmp%2Fdisco.mia)
+func pathParam(c *fiber.Ctx, name string) (string, error) {
+	value, err := url.PathUnescape(c.Params(name))
+	if err != nil {
+		return "", commands.NewError(commands.CodeInvalidArgument, "parámetro de ruta '%s' inválido: %w", name, err)
+	}
+	return value, nil
+}
+
+// fail responde con el error y el código HTTP que le corresponde a su código de error
+func fail(c *fiber.Ctx, err error) error {
+	response := ErrorResponse{Error: err.Error(), Code: commands.CodeOf(err)}
+	var failure *commandFailure
+	if errors.As(err, &failure) {
+		response.Code = failure.result.Code
+		response.Result = &failure.result
+	}
+	return c.Status(httpStatus(response.Code)).JSON(response)
+}
+
+// httpStatus traduce un código de error de los comandos a un código HTTP
+func httpStatus(code commands.ErrorCode) int {
+	switch code {
+	case commands.CodeInvalidArgument, commands.CodeUnknownCommand:
+		return fiber.StatusBadRequest
+	case commands.CodeUnauthenticated, commands.CodeSessionExpired:
+		return fiber.StatusUnauthorized
+	case commands.CodePermissionDenied, commands.CodeAccountLocked:
+		return fiber.StatusForbidden
+	case commands.CodeNotFound:
+		return fiber.StatusNotFound
+	case commands.CodeAlreadyExists, commands.CodeDiskModified:
+		return fiber.StatusConflict
+	case commands.CodeQuotaExceeded:
+		return fiber.StatusInsufficientStorage
+	default:
+		return fiber.StatusUnprocessableEntity
+	}
+}
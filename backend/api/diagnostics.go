@@ -0,0 +1,92 @@
+package api
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	metrics "backend/metrics"
+	reports "backend/reports"
+	sandbox "backend/sandbox"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Endpoints de salud, métricas y diagnóstico
+
+// DebugStateEnabled habilita GET /debug/state. Está apagado por defecto porque expone la tabla de montajes y
+// quién tiene sesión en cada partición.
+var DebugStateEnabled = false
+
+// Cuánto puede esperar /readyz el lock de estado antes de considerar que el servidor no responde
+const readyLockTimeout = 2 * time.Second
+
+// ReadyResponse es el cuerpo de GET /readyz
+type ReadyResponse struct {
+	Status string            `json:"status"` // "ready" o "not_ready"
+	Checks map[string]string `json:"checks"` // "ok" o el motivo del fallo, por verificación
+}
+
+// getHealth responde 200 mientras el proceso atiende peticiones
+func getHealth(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// getReady verifica que el servidor pueda ejecutar comandos y generar reportes; responde 503 si algo falla
+func getReady(c *fiber.Ctx) error {
+	response := ReadyResponse{Status: "ready", Checks: map[string]string{}}
+	check := func(name string, err error) {
+		if err != nil {
+			response.Checks[name] = err.Error()
+			response.Status = "not_ready"
+			return
+		}
+		response.Checks[name] = "ok"
+	}
+
+	if analyzer.StateResponsive(readyLockTimeout) {
+		check("state_lock", nil)
+	} else {
+		check("state_lock", fmt.Errorf("el lock de estado no se liberó en %s", readyLockTimeout))
+	}
+	_, err := exec.LookPath(reports.GraphvizBinary)
+	check("graphviz", err)
+	check("reports_root", writableDir(sandbox.Reports.Dir()))
+
+	status := fiber.StatusOK
+	if response.Status != "ready" {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(response)
+}
+
+// writableDir verifica que se pueda crear un archivo en la carpeta
+func writableDir(dir string) error {
+	file, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// getMetrics devuelve las métricas en formato de Prometheus
+func getMetrics(c *fiber.Ctx) error {
+	var buffer bytes.Buffer
+	if err := analyzer.WriteMetrics(&buffer); err != nil {
+		return fail(c, err)
+	}
+	c.Set(fiber.HeaderContentType, metrics.ContentType)
+	return c.Send(buffer.Bytes())
+}
+
+// getDebugState devuelve la tabla de montajes y las sesiones activas, si DebugStateEnabled lo permite
+func getDebugState(c *fiber.Ctx) error {
+	if !DebugStateEnabled {
+		return fail(c, commands.NewError(commands.CodeNotFound, "/debug/state está deshabilitado"))
+	}
+	return c.JSON(analyzer.DumpState())
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	jobs "backend/jobs"
+	stores "backend/stores"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// createJob encola un comando largo (mkdisk, rep) y responde de inmediato con el id del trabajo
+func createJob(c *fiber.Ctx) error {
+	var req ScriptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+	auth, err := stores.ResumeSession(RequestToken(c, req.Token))
+	if err != nil {
+		return fail(c, err)
+	}
+	return submitJob(c, stores.WithAuth(RequestContext(c), auth), strings.TrimSpace(req.Command))
+}
+
+// submitJob encola la línea de comando y responde 202 con el estado del trabajo
+func submitJob(c *fiber.Ctx, ctx context.Context, line string) error {
+	if !analyzer.IsLongRunning(line) {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "solo un comando largo (mkdisk o rep) se puede ejecutar como trabajo; use POST / para los demás"))
+	}
+	info, err := jobs.Submit(ctx, line)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{Error: err.Error(), Code: commands.CodeFailed})
+	}
+	if err != nil {
+		return fail(c, err)
+	}
+	c.Location("/jobs/" + info.ID)
+	return c.Status(fiber.StatusAccepted).JSON(info)
+}
+
+// getJob devuelve el estado de un trabajo de la sesión
+func getJob(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	info, err := jobs.Get(ctx, c.Params("id"))
+	if err != nil {
+		return fail(c, commands.NewError(commands.CodeNotFound, "%w", err))
+	}
+	return c.JSON(info)
+}
+
+// listJobs devuelve los trabajos de la sesión
+func listJobs(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(jobs.List(ctx))
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	logging "backend/logging"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+var logger = logging.Logger(logging.HTTP)
+
+// RequestID asigna a cada petición un id (el del header X-Request-ID si el cliente lo envía) que se devuelve en
+// la respuesta y acompaña a las líneas de log de la petición
+func RequestID() fiber.Handler {
+	return requestid.New()
+}
+
+// RequestContext devuelve un contexto con el id de la petición, para que los comandos que se ejecutan con él
+// lo agreguen a su log
+func RequestContext(c *fiber.Ctx) context.Context {
+	id, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+	return logging.WithRequestID(context.Background(), id)
+}
+
+// Rutas que consultan los monitores cada pocos segundos; se registran en Debug para no llenar el log
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// AccessLog registra cada petición al terminar su handler. En los streams (cuerpo que se escribe después, como
+// /stream) la duración no incluye el envío del cuerpo.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			// El error todavía no pasó por el ErrorHandler de fiber; calcular el código que va a responder
+			status = fiber.StatusInternalServerError
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			}
+		}
+		level := slog.LevelInfo
+		if probePaths[c.Path()] {
+			level = slog.LevelDebug
+		}
+		logger.Log(RequestContext(c), level, "Petición atendida",
+			"method", c.Method(), "path", c.Path(), "status", status, "duration", time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,470 @@
+package api
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	reports "backend/reports"
+	sandbox "backend/sandbox"
+	stores "backend/stores"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cuerpos de las peticiones que crean recursos. Los tamaños y unidades son los de mkdisk y fdisk.
+
+type diskRequest struct {
+	Path string `json:"path"`
+	Size int    `json:"size"`
+	Unit string `json:"unit,omitempty"`
+	Fit  string `json:"fit,omitempty"`
+}
+
+type partitionRequest struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	Unit string `json:"unit,omitempty"`
+	Type string `json:"type,omitempty"`
+	Fit  string `json:"fit,omitempty"`
+}
+
+type mountRequest struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+type userRequest struct {
+	User    string `json:"user"`
+	Pass    string `json:"pass"`
+	Group   string `json:"grp"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// Respuesta de GET /users
+type usersResponse struct {
+	Users  []commands.UserInfo  `json:"users"`
+	Groups []commands.GroupInfo `json:"groups"`
+}
+
+// Respuesta de GET /fs/{id}/files
+type fileResponse struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Reportes que rep genera como texto sin importar la extensión; el resto pasa por Graphviz
+var textReports = map[string]bool{"file": true, "bm_inode": true, "bm_block": true}
+
+func listDisks(c *fiber.Ctx) error {
+	if _, err := requireSession(c); err != nil {
+		return fail(c, err)
+	}
+	disks, err := analyzer.ListDisks()
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(disks)
+}
+
+func getDisk(c *fiber.Ctx) error {
+	if _, err := requireSession(c); err != nil {
+		return fail(c, err)
+	}
+	path, err := pathParam(c, "path")
+	if err != nil {
+		return fail(c, err)
+	}
+	disk, err := analyzer.DescribeDisk(path)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(disk)
+}
+
+// createDisk crea un disco con mkdisk (?async=true lo encola como trabajo y responde con su id)
+func createDisk(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	var req diskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+	line, err := commandLine("mkdisk", [2]string{"size", sizeValue(req.Size)}, [2]string{"unit", req.Unit}, [2]string{"fit", req.Fit}, [2]string{"path", req.Path})
+	if err != nil {
+		return fail(c, err)
+	}
+	if c.QueryBool("async") {
+		return submitJob(c, ctx, line)
+	}
+	if _, err := run(ctx, line); err != nil {
+		return fail(c, err)
+	}
+	disk, err := analyzer.DescribeDisk(req.Path)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(disk)
+}
+
+func deleteDisk(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	path, err := pathParam(c, "path")
+	if err != nil {
+		return fail(c, err)
+	}
+	line, err := commandLine("rmdisk", [2]string{"path", path})
+	if err != nil {
+		return fail(c, err)
+	}
+	result, err := run(ctx, line)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(result)
+}
+
+func listPartitions(c *fiber.Ctx) error {
+	if _, err := requireSession(c); err != nil {
+		return fail(c, err)
+	}
+	path, err := pathParam(c, "path")
+	if err != nil {
+		return fail(c, err)
+	}
+	disk, err := analyzer.DescribeDisk(path)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(disk.Partitions)
+}
+
+func createPartition(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	path, err := pathParam(c, "path")
+	if err != nil {
+		return fail(c, err)
+	}
+	var req partitionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+	line, err := commandLine("fdisk", [2]string{"size", sizeValue(req.Size)}, [2]string{"unit", req.Unit}, [2]string{"type", req.Type},
+		[2]string{"fit", req.Fit}, [2]string{"path", path}, [2]string{"name", req.Name})
+	if err != nil {
+		return fail(c, err)
+	}
+	if _, err := run(ctx, line); err != nil {
+		return fail(c, err)
+	}
+	disk, err := analyzer.DescribeDisk(path)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(disk.Partitions)
+}
+
+func listMounts(c *fiber.Ctx) error {
+	return c.JSON(analyzer.ListMounts())
+}
+
+func createMount(c *fiber.Ctx) error {
+	ctx, err := requireSession(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	var req mountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+	line, err := commandLine("mount", [2]string{"path", req.Path}, [2]string{"name", req.Name})
+	if err != nil {
+		return fail(c, err)
+	}
+	result, err := run(ctx, line)
+	if err != nil {
+		return fail(c, err)
+	}
+	for _, mount := range analyzer.ListMounts() {
+		if mount.DiskPath == req.Path && mount.Partition == req.Name {
+			return c.Status(fiber.StatusCreated).JSON(mount)
+		}
+	}
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+func getTree(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	depth := -1
+	if raw := c.Query("depth"); raw != "" {
+		if depth, err = strconv.Atoi(raw); err != nil {
+			return fail(c, commands.NewError(commands.CodeInvalidArgument, "valor de depth inválido: %s", raw))
+		}
+	}
+	info, err := analyzer.DescribePath(ctx, c.Params("id"), c.Query("path", "/"), depth)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(info)
+}
+
+func getFile(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	path := c.Query("path")
+	if path == "" {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "falta el parámetro path"))
+	}
+	content, err := analyzer.ReadFile(ctx, c.Params("id"), path)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(fileResponse{Path: path, Content: content})
+}
+
+// createFile crea un archivo con mkfile; el contenido es el cuerpo de la petición (?r=true crea las carpetas padre)
+func createFile(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	if err := requirePartition(ctx, c.Params("id")); err != nil {
+		return fail(c, err)
+	}
+	path := c.Query("path")
+	if path == "" {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "falta el parámetro path"))
+	}
+
+	// mkfile lee el contenido de un archivo del sistema anfitrión (-cont)
+	contentPath := ""
+	if body := c.Body(); len(body) > 0 {
+		contentFile, err := importTempFile()
+		if err != nil {
+			return fail(c, err)
+		}
+		defer os.Remove(contentFile.Name())
+		_, err = contentFile.Write(body)
+		if closeErr := contentFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fail(c, err)
+		}
+		contentPath = contentFile.Name()
+	}
+
+	line, err := commandLine("mkfile", [2]string{"path", path}, [2]string{"cont", contentPath})
+	if err != nil {
+		return fail(c, err)
+	}
+	if c.QueryBool("r") {
+		line += " -r"
+	}
+	if _, err := run(ctx, line); err != nil {
+		return fail(c, err)
+	}
+	info, err := analyzer.DescribePath(ctx, c.Params("id"), path, 0)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(info)
+}
+
+// listUsers devuelve los usuarios y grupos de la partición de la sesión
+func listUsers(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	id := c.Query("id")
+	if id == "" {
+		id = stores.AuthFromContext(ctx).GetPartitionID()
+	}
+	users, groups, err := analyzer.ListUsers(ctx, id)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(usersResponse{Users: users, Groups: groups})
+}
+
+func createUser(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	var req userRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+	line, err := commandLine("mkusr", [2]string{"user", req.User}, [2]string{"pass", req.Pass}, [2]string{"grp", req.Group}, [2]string{"expires", req.Expires})
+	if err != nil {
+		return fail(c, err)
+	}
+	result, err := run(ctx, line)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// deleteUser elimina un usuario con rmusr (?purge=true también borra su directorio personal)
+func deleteUser(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return fail(c, err)
+	}
+	line, err := commandLine("rmusr", [2]string{"user", name})
+	if err != nil {
+		return fail(c, err)
+	}
+	if c.QueryBool("purge") {
+		line += " -purge"
+	}
+	result, err := run(ctx, line)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(result)
+}
+
+// getReport genera el reporte con rep en una carpeta temporal y devuelve el archivo. El formato sale de la extensión
+// del nombre (mbr.svg, tree.pdf, sb.dot...); sin extensión es PNG, o texto para los reportes de texto.
+// ?path_file_ls=, ?user=, ?from= y ?to= son los parámetros de rep con el mismo nombre. Requiere una sesión de la
+// partición del reporte.
+func getReport(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	if err := requirePartition(ctx, c.Params("id")); err != nil {
+		return fail(c, err)
+	}
+	fileName := c.Params("name")
+	extension := filepath.Ext(fileName)
+	name := strings.TrimSuffix(fileName, extension)
+	switch {
+	case extension == "" && textReports[name]:
+		extension = ".txt"
+	case extension == "":
+		extension = ".png"
+	case !reports.IsKnownFormat(fileName):
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "formato de reporte no soportado: %s", extension))
+	case textReports[name] && strings.ToLower(extension) != ".txt":
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "el reporte '%s' solo se genera como texto (.txt)", name))
+	}
+
+	dir, err := reportTempDir()
+	if err != nil {
+		return fail(c, err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, name+extension)
+
+	line, err := commandLine("rep", [2]string{"id", c.Params("id")}, [2]string{"path", output}, [2]string{"name", name},
+		[2]string{"path_file_ls", c.Query("path_file_ls")}, [2]string{"user", c.Query("user")},
+		[2]string{"from", c.Query("from")}, [2]string{"to", c.Query("to")})
+	if err != nil {
+		return fail(c, err)
+	}
+	if _, err := run(ctx, line); err != nil {
+		return fail(c, err)
+	}
+	return sendReportFile(c, output)
+}
+
+// getReportFile devuelve un reporte ya generado con rep dentro de la carpeta de reportes (ver sandbox.Reports).
+// Solo entrega los reportes que rep generó desde que inició el servidor, y solo a sesiones de la partición de la
+// que se generaron.
+func getReportFile(c *fiber.Ctx) error {
+	ctx, err := sessionContext(c)
+	if err != nil {
+		return fail(c, err)
+	}
+	if sandbox.Reports.Dir() == "" {
+		return fail(c, commands.NewError(commands.CodeNotFound, "no hay una carpeta de reportes configurada"))
+	}
+	relative, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "path de reporte inválido: %w", err))
+	}
+	path, err := sandbox.Reports.Resolve(relative)
+	if err != nil {
+		return fail(c, err)
+	}
+	partitionID, exists := stores.ReportPartition(path)
+	if !exists {
+		return fail(c, commands.NewError(commands.CodeNotFound, "no existe el reporte '%s'", relative))
+	}
+	if err := requirePartition(ctx, partitionID); err != nil {
+		return fail(c, err)
+	}
+	return sendReportFile(c, path)
+}
+
+// sendReportFile responde con el archivo y el tipo de contenido de su extensión
+func sendReportFile(c *fiber.Ctx, path string) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fail(c, commands.NewError(commands.CodeNotFound, "no existe el reporte '%s'", path))
+	}
+	if err != nil {
+		return fail(c, err)
+	}
+	c.Set(fiber.HeaderContentType, reports.FormatFor(path).ContentType)
+	return c.Send(content)
+}
+
+// reportTempDir crea la carpeta temporal donde se genera un reporte pedido por HTTP. Si hay carpeta de reportes
+// se crea dentro de ella, porque rep no puede escribir en otro lugar.
+func reportTempDir() (string, error) {
+	root := sandbox.Reports.Dir()
+	if root == "" {
+		return os.MkdirTemp("", "rep-*")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("error al crear la carpeta de reportes: %w", err)
+	}
+	return os.MkdirTemp(root, ".rep-*")
+}
+
+// importTempFile crea el archivo temporal con el contenido que importa mkfile por HTTP. Se crea dentro de la
+// carpeta de importación, porque mkfile -cont no puede leer de otro lugar.
+func importTempFile() (*os.File, error) {
+	root := sandbox.Imports.Dir()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("error al crear la carpeta de importación: %w", err)
+	}
+	return os.CreateTemp(root, ".mkfile-*.txt")
+}
+
+// sizeValue convierte el tamaño pedido en el valor de -size (vacío si no se indicó, para que el comando lo pida)
+func sizeValue(size int) string {
+	if size == 0 {
+		return ""
+	}
+	return strconv.Itoa(size)
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	logging "backend/logging"
+	stores "backend/stores"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Ejecución de scripts con Server-Sent Events: un evento por comando al empezar y al terminar, más las líneas
+// de log que registran sus comandos (solo las de este script, ver logging.WithSink). La ejecución se puede
+// cancelar entre comandos.
+
+// ScriptRequest es el cuerpo de POST / y POST /stream
+type ScriptRequest struct {
+	Command string `json:"command"`
+	Token   string `json:"token,omitempty"` // Token de sesión (también puede ir en el header Authorization: Bearer)
+}
+
+// Líneas de log pendientes por stream; si el cliente se atrasa más, las líneas nuevas se descartan
+const streamLogBuffer = 256
+
+// Datos de los eventos del stream
+type runEvent struct {
+	ID string `json:"id"` // Para cancelar con DELETE /stream/{id}
+}
+
+type startEvent struct {
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+}
+
+type logEvent struct {
+	Line int    `json:"line"` // Comando que estaba en curso cuando se escribió la línea
+	Text string `json:"text"`
+}
+
+type doneEvent struct {
+	Status   string `json:"status"` // "completed" o "cancelled"
+	Commands int    `json:"commands"`
+	Token    string `json:"token,omitempty"`
+}
+
+type streamEvent struct {
+	name    string
+	data    any
+	written chan struct{} // Si no es nil, se cierra cuando el evento ya se escribió
+}
+
+// Scripts en ejecución por stream, para poder cancelarlos
+var (
+	streamRunsMu sync.Mutex
+	streamRuns   = map[string]context.CancelFunc{}
+)
+
+// ScriptSession recupera la sesión para ejecutar un script. Con una sesión vencida o terminada solo se acepta
+// un script que empiece con login, que reemplaza el token viejo.
+func ScriptSession(c *fiber.Ctx, token, script string) (*stores.AuthStore, error) {
+	auth, err := stores.ResumeSession(RequestToken(c, token))
+	if err != nil && startsWithLogin(script) {
+		return &stores.AuthStore{}, nil
+	}
+	return auth, err
+}
+
+// startsWithLogin indica si el primer comando del script (sin contar líneas vacías ni comentarios) es login
+func startsWithLogin(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		return strings.EqualFold(fields[0], "login")
+	}
+	return false
+}
+
+// streamScript ejecuta el script de la petición enviando el avance como Server-Sent Events. Acepta POST con el
+// mismo cuerpo que POST / o GET con ?command= y ?token= (para EventSource).
+func streamScript(c *fiber.Ctx) error {
+	var req ScriptRequest
+	if c.Method() == fiber.MethodGet {
+		req.Command, req.Token = c.Query("command"), c.Query("token")
+	} else if err := c.BodyParser(&req); err != nil {
+		return fail(c, commands.NewError(commands.CodeInvalidArgument, "petición inválida: %w", err))
+	}
+
+	auth, err := ScriptSession(c, req.Token, req.Command)
+	if err != nil {
+		return fail(c, err)
+	}
+	// Las líneas de log de los comandos del script se reciben por logs (el canal no se cierra: el sink puede
+	// llamarse mientras termina el último comando)
+	logs := make(chan string, streamLogBuffer)
+	sink := func(line string) {
+		select {
+		case logs <- line:
+		default:
+		}
+	}
+	ctx, cancel := context.WithCancel(logging.WithSink(stores.WithAuth(RequestContext(c), auth), sink))
+	id, err := newRunID()
+	if err != nil {
+		cancel()
+		return fail(c, err)
+	}
+	streamRunsMu.Lock()
+	streamRuns[id] = cancel
+	streamRunsMu.Unlock()
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			streamRunsMu.Lock()
+			delete(streamRuns, id)
+			streamRunsMu.Unlock()
+			cancel()
+		}()
+
+		events := make(chan streamEvent, 16)
+
+		go func() {
+			defer close(events)
+			observer := analyzer.ScriptObserver{
+				// Esperar a que se escriba el inicio para que las líneas del comando queden después
+				OnStart: func(line int, command string) {
+					written := make(chan struct{})
+					events <- streamEvent{"start", startEvent{Line: line, Command: command}, written}
+					<-written
+				},
+				OnResult: func(result analyzer.Result) {
+					events <- streamEvent{"result", result, nil}
+				},
+			}
+			results, err := analyzer.RunScriptObserved(ctx, req.Command, observer)
+			done := doneEvent{Status: "completed", Commands: len(results), Token: auth.Token}
+			if err != nil {
+				done.Status = "cancelled"
+			}
+			events <- streamEvent{"done", done, nil}
+		}()
+
+		// Si el cliente se desconecta se cancela el script, pero se siguen leyendo los eventos hasta que termine
+		// el comando en curso
+		var writeErr error
+		write := func(event streamEvent) {
+			if writeErr == nil {
+				if writeErr = writeEvent(w, event); writeErr != nil {
+					cancel()
+				}
+			}
+			if event.written != nil {
+				close(event.written)
+			}
+		}
+		currentLine := 0 // Comando en curso, para las líneas de salida
+		writeLog := func(text string) {
+			write(streamEvent{"log", logEvent{Line: currentLine, Text: text}, nil})
+		}
+
+		write(streamEvent{"run", runEvent{ID: id}, nil})
+		for {
+			select {
+			case line := <-logs:
+				writeLog(line)
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				// Escribir primero las líneas que ya llegaron, que son del comando anterior al evento
+				for pending := true; pending; {
+					select {
+					case line := <-logs:
+						writeLog(line)
+					default:
+						pending = false
+					}
+				}
+				if start, isStart := event.data.(startEvent); isStart {
+					currentLine = start.Line
+				}
+				write(event)
+			}
+		}
+	})
+	return nil
+}
+
+// cancelStream cancela un script en ejecución por stream; el comando en curso termina y no se ejecutan más
+func cancelStream(c *fiber.Ctx) error {
+	streamRunsMu.Lock()
+	cancel, exists := streamRuns[c.Params("id")]
+	streamRunsMu.Unlock()
+	if !exists {
+		return fail(c, commands.NewError(commands.CodeNotFound, "no hay ningún script en ejecución con id '%s'", c.Params("id")))
+	}
+	cancel()
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// writeEvent escribe un evento en formato Server-Sent Events y lo envía al cliente
+func writeEvent(w *bufio.Writer, event streamEvent) error {
+	data, err := json.Marshal(event.data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// newRunID genera el id con el que se cancela un script
+func newRunID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error al generar el id del script: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
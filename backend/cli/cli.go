@@ -0,0 +1,189 @@
+package cli
+
+import (
+	analyzer "backend/analyzer"
+	stores "backend/stores"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Ejecución de comandos sin el servidor HTTP: una terminal interactiva (backend repl) y la ejecución de scripts
+// por lotes (backend run script.mias). Los comandos pasan por analyzer igual que los de la API. La sesión de
+// login se conserva entre comandos, como en un script enviado en una sola petición.
+
+// Prompts de la terminal: el de cada comando y el de las líneas que continúan un comando
+const (
+	prompt             = "mia> "
+	continuationPrompt = "...> "
+)
+
+// Palabra que cierra la terminal (además de Ctrl+D)
+const quitCommand = "quit"
+
+// lineReader lee una línea de la terminal mostrando el prompt
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// REPL ejecuta uno por uno los comandos que se escriben en la terminal hasta quit o Ctrl+D. Si in es una
+// terminal, las líneas se editan con historial (flechas) y completado (Tab); si no, se leen tal como llegan.
+// Una línea que termina en \ o que deja comillas abiertas continúa en la siguiente.
+func REPL(ctx context.Context, in *os.File, out io.Writer) error {
+	var reader lineReader
+	if isTerminal(int(in.Fd())) {
+		editor := newLineEditor(in, out, loadHistory(HistoryFile()))
+		reader = editor
+		fmt.Fprintf(out, "Terminal de comandos. Tab completa comandos y parámetros; %s o Ctrl+D para salir.\n", quitCommand)
+	} else {
+		reader = &plainReader{scanner: bufio.NewScanner(in)}
+	}
+
+	// Los comandos no se pueden detener a la mitad sin dejar el disco inconsistente: mientras uno se ejecuta,
+	// Ctrl+C no cierra la terminal (al escribir, Ctrl+C solo descarta la línea)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+		}
+	}()
+
+	session := &replSession{}
+	for {
+		command, err := readCommand(reader)
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if command == "" {
+			continue
+		}
+		if editor, ok := reader.(*lineEditor); ok {
+			editor.history.add(command)
+		}
+		if strings.EqualFold(command, quitCommand) {
+			return nil
+		}
+		for _, result := range session.run(ctx, command) {
+			printResult(out, out, "", result)
+		}
+	}
+}
+
+// readCommand lee un comando, que puede ocupar varias líneas. Las líneas se unen con un espacio.
+func readCommand(reader lineReader) (string, error) {
+	line, err := reader.readLine(prompt)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for {
+		trimmed := strings.TrimSpace(line)
+		continues := strings.HasSuffix(trimmed, "\\")
+		parts = append(parts, strings.TrimSpace(strings.TrimSuffix(trimmed, "\\")))
+		command := strings.TrimSpace(strings.Join(parts, " "))
+		if !continues && strings.Count(command, "\"")%2 == 0 {
+			return command, nil
+		}
+		if line, err = reader.readLine(continuationPrompt); err != nil {
+			return "", err
+		}
+	}
+}
+
+// replSession guarda el token de la sesión de login entre los comandos de la terminal
+type replSession struct {
+	token string
+}
+
+// run ejecuta un comando con la sesión actual. Si la sesión venció, lo avisa y ejecuta el comando sin sesión.
+func (s *replSession) run(ctx context.Context, command string) []analyzer.Result {
+	auth, err := stores.ResumeSession(s.token)
+	if err != nil {
+		logger.Warn("La sesión terminó", "error", err)
+		auth = &stores.AuthStore{}
+	}
+	results := analyzer.RunScript(stores.WithAuth(ctx, auth), command)
+	s.token = auth.Token
+	return results
+}
+
+// Run ejecuta los scripts en orden con una misma sesión. Se detiene en el primer comando que falla, salvo con
+// keepGoing, que ejecuta todos. Devuelve el código de salida: 0 si todos los comandos terminaron bien, 1 si
+// alguno falló o la ejecución se interrumpió y 2 si no se pudo leer un script.
+func Run(ctx context.Context, paths []string, keepGoing bool, stdout, stderr io.Writer) int {
+	ctx, cancel := context.WithCancel(stores.WithAuth(ctx, &stores.AuthStore{}))
+	defer cancel()
+
+	failed := false
+	for _, path := range paths {
+		script, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: no se pudo leer el script: %v\n", err)
+			return 2
+		}
+		_, err = analyzer.RunScriptObserved(ctx, string(script), analyzer.ScriptObserver{
+			OnResult: func(result analyzer.Result) {
+				printResult(stdout, stderr, path, result)
+				if result.Status == analyzer.StatusError {
+					failed = true
+					if !keepGoing {
+						cancel()
+					}
+				}
+			},
+		})
+		if err != nil {
+			if !failed {
+				fmt.Fprintln(stderr, "Error: ejecución interrumpida")
+			}
+			return 1
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// printResult escribe la salida de un comando en stdout o, si falló, el error con su código en stderr. En un
+// script el error indica el archivo y la línea.
+func printResult(stdout, stderr io.Writer, path string, result analyzer.Result) {
+	if result.Status != analyzer.StatusError {
+		if result.Message != "" {
+			fmt.Fprintln(stdout, result.Message)
+		}
+		return
+	}
+	location := ""
+	if path != "" {
+		location = fmt.Sprintf("%s:%d: ", path, result.Line)
+	}
+	fmt.Fprintf(stderr, "%sError [%s]: %s\n", location, result.Code, result.Message)
+}
+
+// plainReader lee líneas sin editor, cuando la entrada no es una terminal (por ejemplo, un pipe)
+type plainReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *plainReader) readLine(string) (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
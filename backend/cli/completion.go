@@ -0,0 +1,66 @@
+package cli
+
+import (
+	analyzer "backend/analyzer"
+	"strings"
+)
+
+// completeLine busca los candidatos para completar la palabra del cursor: el nombre del comando si es la
+// primera palabra (o la que sigue a sudo -pass=...) y los nombres de sus parámetros si la palabra empieza con
+// "-". Devuelve dónde empieza la palabra y los candidatos que empiezan con ella.
+func completeLine(line []rune, cursor int) (int, []string) {
+	start := cursor
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	word := strings.ToLower(string(line[start:cursor]))
+	previous := strings.Fields(string(line[:start]))
+
+	command := ""
+	if len(previous) > 0 {
+		command = strings.ToLower(previous[0])
+	}
+	// sudo -pass=<contraseña> <comando> ...: después de -pass= se completa el comando elevado y sus parámetros
+	if command == "sudo" && len(previous) >= 2 {
+		if len(previous) == 2 && !strings.HasPrefix(word, "-") {
+			return start, matching(analyzer.Commands(), word)
+		}
+		if len(previous) >= 3 {
+			command = strings.ToLower(previous[2])
+		}
+	}
+
+	switch {
+	case len(previous) == 0:
+		return start, matching(analyzer.Commands(), word)
+	case strings.HasPrefix(word, "-"):
+		return start, matching(unusedParams(command, previous), word)
+	}
+	return start, nil
+}
+
+// unusedParams devuelve los parámetros del comando que todavía no aparecen en la línea
+func unusedParams(command string, previous []string) []string {
+	used := map[string]bool{}
+	for _, field := range previous {
+		name, _, _ := strings.Cut(strings.ToLower(field), "=")
+		used[name] = true
+	}
+	var params []string
+	for _, param := range analyzer.CommandParams(command) {
+		if !used[strings.TrimSuffix(param, "=")] {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func matching(words []string, prefix string) []string {
+	var candidates []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			candidates = append(candidates, word)
+		}
+	}
+	return candidates
+}
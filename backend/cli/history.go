@@ -0,0 +1,80 @@
+package cli
+
+import (
+	utils "backend/utils"
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Líneas que guarda el historial
+const historyLimit = 1000
+
+// history son las líneas ejecutadas en la terminal, de la más antigua a la más reciente. Si tiene archivo,
+// se carga al iniciar y cada línea nueva se agrega al final; las líneas con contraseñas no se guardan en el
+// archivo.
+type history struct {
+	lines []string
+	file  string
+}
+
+// HistoryFile es el archivo del historial de la terminal (por defecto ~/.mia_history; vacío: sin archivo)
+func HistoryFile() string {
+	if file, exists := os.LookupEnv("MIA_HISTORY"); exists {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mia_history")
+}
+
+// loadHistory carga el historial del archivo, si existe
+func loadHistory(file string) *history {
+	h := &history{file: file}
+	if file == "" {
+		return h
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if len(h.lines) > historyLimit {
+		h.lines = h.lines[len(h.lines)-historyLimit:]
+	}
+	return h
+}
+
+// add agrega una línea, salvo que sea igual a la anterior
+func (h *history) add(line string) {
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > historyLimit {
+		h.lines = h.lines[len(h.lines)-historyLimit:]
+	}
+	if h.file == "" || utils.RedactPasswords(line) != line {
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		logger.Warn("No se pudo guardar el historial", "file", h.file, "error", err)
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+func (h *history) len() int { return len(h.lines) }
+
+func (h *history) at(index int) string { return h.lines[index] }
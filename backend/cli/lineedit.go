@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Editor de línea para la terminal: mover el cursor, borrar, recorrer el historial con las flechas y completar
+// con Tab. Trabaja con la terminal en modo crudo y asume que cada carácter ocupa una columna.
+
+// errInterrupted se devuelve cuando el usuario cancela la línea con Ctrl+C
+var errInterrupted = errors.New("línea cancelada")
+
+// Teclas de control
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// lineEditor lee líneas de una terminal en modo crudo
+type lineEditor struct {
+	fd       int
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete func(line []rune, cursor int) (start int, candidates []string)
+
+	prompt  string
+	buffer  []rune
+	cursor  int
+	browse  int    // Posición en el historial mientras se recorre con las flechas
+	pending string // Línea que se estaba escribiendo antes de recorrer el historial
+}
+
+func newLineEditor(in *os.File, out io.Writer, history *history) *lineEditor {
+	return &lineEditor{fd: int(in.Fd()), in: bufio.NewReader(in), out: out, history: history, complete: completeLine}
+}
+
+// readLine muestra el prompt y devuelve la línea al presionar Enter. Devuelve io.EOF con Ctrl+D en una línea
+// vacía y errInterrupted con Ctrl+C. La terminal queda en modo crudo solo mientras se lee la línea, para que
+// la salida de los comandos se escriba normalmente.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := rawMode(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.prompt, e.buffer, e.cursor = prompt, nil, 0
+	e.browse, e.pending = e.history.len(), ""
+	e.refresh()
+
+	for {
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buffer), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(e.buffer) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.cursor)
+		case keyBackspace, '\b':
+			if e.cursor > 0 {
+				e.cursor--
+				e.deleteAt(e.cursor)
+			}
+		case keyCtrlA:
+			e.cursor = 0
+		case keyCtrlE:
+			e.cursor = len(e.buffer)
+		case keyCtrlB:
+			e.moveCursor(-1)
+		case keyCtrlF:
+			e.moveCursor(1)
+		case keyCtrlK:
+			e.buffer = e.buffer[:e.cursor]
+		case keyCtrlU:
+			e.buffer = append([]rune{}, e.buffer[e.cursor:]...)
+			e.cursor = 0
+		case keyCtrlW:
+			e.deleteWordBefore()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			e.historyStep(-1)
+		case keyCtrlN:
+			e.historyStep(1)
+		case keyTab:
+			e.completeWord()
+		case keyEscape:
+			e.readEscape()
+		default:
+			if unicode.IsPrint(key) {
+				e.insert(key)
+			}
+		}
+		e.refresh()
+	}
+}
+
+// readEscape interpreta las secuencias de las flechas, Inicio, Fin y Suprimir
+func (e *lineEditor) readEscape() {
+	prefix, _, err := e.in.ReadRune()
+	if err != nil || (prefix != '[' && prefix != 'O') {
+		return
+	}
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+	switch code {
+	case 'A':
+		e.historyStep(-1)
+	case 'B':
+		e.historyStep(1)
+	case 'C':
+		e.moveCursor(1)
+	case 'D':
+		e.moveCursor(-1)
+	case 'H':
+		e.cursor = 0
+	case 'F':
+		e.cursor = len(e.buffer)
+	default:
+		// Secuencias con número, como ESC [ 3 ~ (Suprimir), ESC [ 1 ~ (Inicio) o ESC [ 4 ~ (Fin)
+		if code < '0' || code > '9' {
+			return
+		}
+		number := string(code)
+		for {
+			next, _, err := e.in.ReadRune()
+			if err != nil || next == '~' {
+				break
+			}
+			number += string(next)
+		}
+		switch number {
+		case "3":
+			e.deleteAt(e.cursor)
+		case "1", "7":
+			e.cursor = 0
+		case "4", "8":
+			e.cursor = len(e.buffer)
+		}
+	}
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.buffer = append(e.buffer[:e.cursor], append([]rune{r}, e.buffer[e.cursor:]...)...)
+	e.cursor++
+}
+
+func (e *lineEditor) insertText(text string) {
+	for _, r := range text {
+		e.insert(r)
+	}
+}
+
+func (e *lineEditor) deleteAt(position int) {
+	if position < len(e.buffer) {
+		e.buffer = append(e.buffer[:position], e.buffer[position+1:]...)
+	}
+}
+
+func (e *lineEditor) deleteWordBefore() {
+	start := e.cursor
+	for start > 0 && e.buffer[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.buffer[start-1] != ' ' {
+		start--
+	}
+	e.buffer = append(e.buffer[:start], e.buffer[e.cursor:]...)
+	e.cursor = start
+}
+
+func (e *lineEditor) moveCursor(delta int) {
+	e.cursor = min(max(e.cursor+delta, 0), len(e.buffer))
+}
+
+// historyStep reemplaza la línea por la anterior (-1) o la siguiente (1) del historial. Al pasar la última
+// vuelve la línea que se estaba escribiendo.
+func (e *lineEditor) historyStep(delta int) {
+	target := e.browse + delta
+	if target < 0 || target > e.history.len() {
+		return
+	}
+	if e.browse == e.history.len() {
+		e.pending = string(e.buffer)
+	}
+	e.browse = target
+	if target == e.history.len() {
+		e.buffer = []rune(e.pending)
+	} else {
+		e.buffer = []rune(e.history.at(target))
+	}
+	e.cursor = len(e.buffer)
+}
+
+// completeWord completa la palabra del cursor. Con un solo candidato lo inserta completo; con varios inserta
+// lo que tienen en común y, si no hay nada en común que agregar, los muestra debajo de la línea.
+func (e *lineEditor) completeWord() {
+	start, candidates := e.complete(e.buffer, e.cursor)
+	if len(candidates) == 0 {
+		return
+	}
+	typed := string(e.buffer[start:e.cursor])
+	if len(candidates) == 1 {
+		e.insertText(candidates[0][len(typed):])
+		if !strings.HasSuffix(candidates[0], "=") {
+			e.insert(' ')
+		}
+		return
+	}
+	if common := commonPrefix(candidates); len(common) > len(typed) {
+		e.insertText(common[len(typed):])
+		return
+	}
+	fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+// refresh vuelve a dibujar el prompt y la línea, con el cursor en su lugar
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buffer))
+	if back := len(e.buffer) - e.cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,5 @@
+package cli
+
+import logging "backend/logging"
+
+var logger = logging.Logger(logging.CLI)
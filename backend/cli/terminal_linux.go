@@ -0,0 +1,33 @@
+//go:build linux
+
+package cli
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// rawMode pone la terminal en modo crudo (sin eco ni edición de línea) para que el editor lea cada tecla.
+// Devuelve la función que restaura el modo anterior, o un error si fd no es una terminal.
+func rawMode(fd int) (func(), error) {
+	previous, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	raw := *previous
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+	return func() { unix.IoctlSetTermios(fd, unix.TCSETS, previous) }, nil
+}
+
+// isTerminal indica si fd es una terminal
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	return err == nil
+}
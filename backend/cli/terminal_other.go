@@ -0,0 +1,15 @@
+//go:build !linux
+
+package cli
+
+import "errors"
+
+// En otros sistemas no hay editor de línea: la terminal se lee línea por línea, sin historial ni completado
+
+func rawMode(fd int) (func(), error) {
+	return nil, errors.New("modo crudo no disponible en este sistema")
+}
+
+func isTerminal(fd int) bool {
+	return false
+}
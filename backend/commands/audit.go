@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// Bloques que se reservan para el log de auditoría: 12 directos + 16 del indirecto simple + 256 del doble,
+// el máximo que admite allocateDataBlocks. Se asignan una sola vez al crear el log y luego se escribe sobre ellos,
+// porque la asignación secuencial de bloques no reutiliza los que se liberan.
+const auditLogBlocks = 12 + 16 + 16*16
+
+// recordAudit agrega un registro al log de auditoría de la partición de la sesión.
+// Completa el usuario y la sesión a partir del AuthStore si no vienen en el registro.
+func recordAudit(auth *stores.AuthStore, entry utils.AuditEntry) error {
+	if entry.User == "" {
+		entry.User = auth.Username
+	}
+	if entry.Session == "" {
+		entry.Session = auth.SessionID()
+	}
+	return RecordAudit(auth.GetPartitionID(), entry)
+}
+
+// RecordAudit agrega un registro al log de auditoría (/audit.log) de una partición montada.
+// El log solo crece agregando al final; cuando se llena se descartan los registros más antiguos
+// hasta liberar la mitad de su espacio. Requiere el lock de la partición en lectura y el del log de auditoría
+// (ver stores.AuditLock), o el de la partición en escritura. Si hay que crear o reasignar el log
+// (ver AuditLogNeedsAllocation) se asignan inodos y bloques, y entonces requiere la partición en escritura.
+func RecordAudit(partitionID string, entry utils.AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := utils.FormatAuditEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	// Obtener Partición y Superbloque (se leen de nuevo por si el comando auditado los modificó)
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+	if partitionSuperblock.S_magic != 0xEF53 {
+		return fmt.Errorf("la partición '%s' no tiene un sistema de archivos EXT2 válido", partitionID)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	auditInodeIndex, auditInode, capacity, allocated, err := findOrCreateAuditLog(partitionSuperblock, dev)
+	if err != nil {
+		return err
+	}
+	if int64(len(line)) > capacity {
+		return fmt.Errorf("el registro de auditoría (%d bytes) no cabe en %s (%d bytes)", len(line), utils.AuditLogPath, capacity)
+	}
+
+	if int64(auditInode.I_size)+int64(len(line)) <= capacity {
+		// Agregar al final
+		if err := partitionSuperblock.WriteFileAt(dev, auditInode, []byte(line), int64(auditInode.I_size)); err != nil {
+			return fmt.Errorf("error escribiendo en %s: %w", utils.AuditLogPath, err)
+		}
+		auditInode.I_size += int32(len(line))
+	} else {
+		// Log lleno: conservar solo los registros más recientes
+		oldContent, err := structures.ReadFileContent(partitionSuperblock, dev, auditInode)
+		if err != nil {
+			return fmt.Errorf("error leyendo el contenido de %s: %w", utils.AuditLogPath, err)
+		}
+		newContent := trimAuditLog(oldContent, capacity/2-int64(len(line))) + line
+		logger.Debug("Log de auditoría lleno: se descartan los registros antiguos", "path", utils.AuditLogPath, "bytes", len(oldContent)+len(line)-len(newContent))
+		if err := partitionSuperblock.WriteFileAt(dev, auditInode, []byte(newContent), 0); err != nil {
+			return fmt.Errorf("error escribiendo en %s: %w", utils.AuditLogPath, err)
+		}
+		auditInode.I_size = int32(len(newContent))
+	}
+
+	// Actualizar Inodo del log
+	auditInode.I_mtime = float32(time.Now().Unix())
+	auditInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(auditInodeIndex)*int64(partitionSuperblock.S_inode_size)
+	if err := auditInode.Serialize(dev, auditInodeOffset); err != nil {
+		return fmt.Errorf("error serializando inodo de %s: %w", utils.AuditLogPath, err)
+	}
+
+	// Serializar Superbloque (solo cambia si el log se acaba de crear o reasignar)
+	if allocated {
+		if err := partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start)); err != nil {
+			return fmt.Errorf("error al serializar el superbloque: %w", err)
+		}
+	}
+	return nil
+}
+
+// AuditLogNeedsAllocation indica si RecordAudit tendría que crear o reasignar el log de auditoría de la partición,
+// es decir, asignar inodos y bloques. En ese caso hay que registrar con la partición en escritura. Ante un error
+// devuelve false: RecordAudit lo informa sin llegar a asignar nada. Requiere el lock de la partición al menos en lectura.
+func AuditLogNeedsAllocation(partitionID string) bool {
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil || partitionSuperblock.S_magic != 0xEF53 {
+		return false
+	}
+
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return false
+	}
+	defer structures.CloseDevice(dev)
+
+	_, inode, err := structures.FindInodeByPath(partitionSuperblock, dev, utils.AuditLogPath)
+	if err != nil {
+		return true
+	}
+	if inode.I_type[0] != '1' {
+		return false
+	}
+	blocks, err := partitionSuperblock.FileDataBlocks(dev, inode)
+	if err != nil {
+		return false
+	}
+	return int32(len(blocks)) < auditLogTargetBlocks(partitionSuperblock)
+}
+
+// CheckAuditAccess verifica que la sesión sea de root en la partición indicada. Solo root puede leer el log de
+// auditoría, ya sea con el comando audit, con rep -name=audit o leyendo /audit.log como archivo.
+func CheckAuditAccess(ctx context.Context, partitionID string) error {
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "leer el log de auditoría requiere inicio de sesión")
+	}
+	currentUser, sessionPartition := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return NewError(CodePermissionDenied, "permiso denegado: solo 'root' puede leer el log de auditoría (usuario actual: %s)", currentUser)
+	}
+	if sessionPartition != partitionID {
+		return NewError(CodePermissionDenied, "permiso denegado: la sesión es de la partición '%s', no de '%s'", sessionPartition, partitionID)
+	}
+	return nil
+}
+
+// ReadAuditLog devuelve los registros del log de auditoría de una partición montada que cumplen el filtro.
+// Si el log todavía no existe devuelve una lista vacía. Requiere el lock de la partición al menos en lectura.
+func ReadAuditLog(partitionID string, filter utils.AuditFilter) ([]utils.AuditEntry, error) {
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+	if partitionSuperblock.S_magic != 0xEF53 {
+		return nil, fmt.Errorf("la partición '%s' no tiene un sistema de archivos EXT2 válido", partitionID)
+	}
+
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	_, auditInode, err := structures.FindInodeByPath(partitionSuperblock, dev, utils.AuditLogPath)
+	if err != nil {
+		return []utils.AuditEntry{}, nil
+	}
+	content, err := structures.ReadFileContent(partitionSuperblock, dev, auditInode)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el contenido de %s: %w", utils.AuditLogPath, err)
+	}
+
+	entries := []utils.AuditEntry{}
+	for _, entry := range utils.ParseAuditLog(content) {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// trimAuditLog descarta registros completos del principio del log hasta que ocupe como mucho limit bytes
+func trimAuditLog(content string, limit int64) string {
+	for int64(len(content)) > limit {
+		newline := strings.IndexByte(content, '\n')
+		if newline == -1 {
+			return ""
+		}
+		content = content[newline+1:]
+	}
+	return content
+}
+
+// auditLogTargetBlocks devuelve cuántos bloques debe tener reservados el log de auditoría
+func auditLogTargetBlocks(sb *structures.SuperBlock) int32 {
+	return min(int32(auditLogBlocks), sb.S_free_blocks_count/8)
+}
+
+// findOrCreateAuditLog devuelve el inodo del log de auditoría, el espacio que tiene reservado y si tuvo que
+// asignar inodos o bloques. Si el log no existe lo crea en la raíz; si existe pero no tiene el espacio reservado
+// (logs creados antes de escribirse en el lugar), lo reasigna conservando su contenido.
+func findOrCreateAuditLog(sb *structures.SuperBlock, dev structures.BlockDevice) (int32, *structures.Inode, int64, bool, error) {
+	targetBlocks := auditLogTargetBlocks(sb)
+
+	inodeIndex, inode, err := structures.FindInodeByPath(sb, dev, utils.AuditLogPath)
+	if err == nil {
+		if inode.I_type[0] != '1' {
+			return -1, nil, 0, false, fmt.Errorf("error crítico: %s no es un archivo", utils.AuditLogPath)
+		}
+		blocks, err := sb.FileDataBlocks(dev, inode)
+		if err != nil {
+			return -1, nil, 0, false, fmt.Errorf("error leyendo los bloques de %s: %w", utils.AuditLogPath, err)
+		}
+		if int32(len(blocks)) >= targetBlocks {
+			return inodeIndex, inode, int64(len(blocks)) * int64(sb.S_block_size), false, nil
+		}
+
+		// Reasignar con el espacio completo
+		content, err := structures.ReadFileContent(sb, dev, inode)
+		if err != nil {
+			return -1, nil, 0, false, fmt.Errorf("error leyendo el contenido de %s: %w", utils.AuditLogPath, err)
+		}
+		if err := structures.FreeInodeBlocks(inode, sb, dev); err != nil {
+			return -1, nil, 0, false, fmt.Errorf("error liberando bloques antiguos de %s: %w", utils.AuditLogPath, err)
+		}
+		capacity, err := reserveAuditLogBlocks(sb, dev, inode, targetBlocks)
+		if err != nil {
+			return -1, nil, 0, false, err
+		}
+		content = trimAuditLog(content, capacity)
+		if err := sb.WriteFileAt(dev, inode, []byte(content), 0); err != nil {
+			return -1, nil, 0, false, fmt.Errorf("error escribiendo en %s: %w", utils.AuditLogPath, err)
+		}
+		inode.I_size = int32(len(content))
+		return inodeIndex, inode, capacity, true, nil
+	}
+
+	// Asignar Inodo
+	if sb.S_free_inodes_count <= 0 {
+		return -1, nil, 0, false, errors.New("no hay inodos libres para crear el log de auditoría")
+	}
+	logger.Debug("Creando log de auditoría", "path", utils.AuditLogPath)
+	inodeIndex = (sb.S_first_ino - sb.S_inode_start) / sb.S_inode_size
+	if err := sb.UpdateBitmapInode(dev, inodeIndex); err != nil {
+		return -1, nil, 0, false, fmt.Errorf("error actualizando bitmap para inodo %d: %w", inodeIndex, err)
+	}
+	sb.S_free_inodes_count--
+	sb.S_first_ino += sb.S_inode_size
+
+	// Archivo vacío de root, legible solo por root y su grupo
+	currentTime := float32(time.Now().Unix())
+	inode = &structures.Inode{
+		I_uid: 1, I_gid: 1, I_size: 0,
+		I_atime: currentTime, I_ctime: currentTime, I_mtime: currentTime,
+		I_type: [1]byte{'1'}, I_perm: [3]byte{'6', '4', '0'},
+	}
+	capacity, err := reserveAuditLogBlocks(sb, dev, inode, targetBlocks)
+	if err != nil {
+		return -1, nil, 0, false, err
+	}
+	inodeOffset := int64(sb.S_inode_start) + int64(inodeIndex)*int64(sb.S_inode_size)
+	if err := inode.Serialize(dev, inodeOffset); err != nil {
+		return -1, nil, 0, false, fmt.Errorf("error serializando inodo de %s: %w", utils.AuditLogPath, err)
+	}
+
+	if err := addEntryToParent(0, utils.AuditLogPath[1:], inodeIndex, sb, dev); err != nil {
+		return -1, nil, 0, false, fmt.Errorf("error añadiendo %s a la raíz: %w", utils.AuditLogPath, err)
+	}
+	return inodeIndex, inode, capacity, true, nil
+}
+
+// reserveAuditLogBlocks asigna al inodo los bloques del log (en cero) y lo deja vacío. Devuelve la capacidad en bytes.
+func reserveAuditLogBlocks(sb *structures.SuperBlock, dev structures.BlockDevice, inode *structures.Inode, blocks int32) (int64, error) {
+	if blocks < 1 {
+		return 0, errors.New("no hay bloques libres suficientes para el log de auditoría")
+	}
+	capacity := blocks * sb.S_block_size
+	allocated, err := allocateDataBlocks(make([]byte, capacity), capacity, sb, dev)
+	if err != nil {
+		return 0, fmt.Errorf("error reservando bloques para %s: %w", utils.AuditLogPath, err)
+	}
+	inode.I_block = allocated
+	inode.I_size = 0
+	return int64(capacity), nil
+}
@@ -3,21 +3,22 @@ package commands
 import (
 	stores "backend/stores"
 	structures "backend/structures"
-	"errors"
+	utils "backend/utils"
+	"context"
 	"fmt"
 	"regexp" // Paquete para trabajar con expresiones regulares, útil para encontrar y manipular patrones en cadenas
 	"strings"
 )
 
-func ParseCat(tokens []string) (string, error) {
+func ParseCat(ctx context.Context, tokens []string) (string, error) {
 	// Verificar que se proporcionó un parámetro
 	if len(tokens) == 0 {
-		return "", fmt.Errorf("faltan parámetros requeridos")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos")
 	}
 
 	// Unir tokens en una sola cadena y luego dividir por espacios, respetando las comillas
 	args := strings.Join(tokens, " ")
-	fmt.Printf("Argumentos completos: %s\n", args)
+	logger.DebugContext(ctx, "Argumentos de cat", "args", args)
 
 
 	//Expresión regular para encontrar todos los path
@@ -25,7 +26,7 @@ func ParseCat(tokens []string) (string, error) {
 
 	// Buscar todas las coincidencias
 	matches := re.FindAllStringSubmatch(args, -1)
-	fmt.Printf("Coincidencias encontradas: %v\n", matches)
+	logger.DebugContext(ctx, "Coincidencias encontradas", "matches", matches)
 
 
 	// Extraer los paths en una lista
@@ -33,10 +34,7 @@ func ParseCat(tokens []string) (string, error) {
 	for _, match := range matches {
 		if len(match) > 1 {
 			path := match[1]
-            // Asegurarse de que el path comience con /
-            if !strings.HasPrefix(path, "/") {
-                path = "/" + path
-            }
+            // Los paths relativos se resuelven en commandCat, desde el directorio de trabajo de la sesión
             paths = append(paths, path)
 		}
 	}
@@ -46,24 +44,19 @@ func ParseCat(tokens []string) (string, error) {
         for _, token := range tokens {
             // Eliminar comillas si están presentes
             path := strings.Trim(token, "\"'")
-            // Asegurarse de que el path comience con /
-            if !strings.HasPrefix(path, "/") {
-                path = "/" + path
-            }
             paths = append(paths, path)
         }
     }
 
 
-	fmt.Println("---------------------------------")
-	fmt.Println(paths)
+	logger.DebugContext(ctx, "Paths a mostrar", "paths", paths)
 
     // Si aún no hay paths, reportar error
     if len(paths) == 0 {
         return "", fmt.Errorf("no se encontraron paths válidos en los argumentos")
     }
 
-	texto, err := commandCat(paths)
+	texto, err := commandCat(ctx, paths)
 
 	if err != nil {
 		return "", err
@@ -75,16 +68,18 @@ func ParseCat(tokens []string) (string, error) {
 
 
 
-func commandCat(paths []string) (string, error) {
+func commandCat(ctx context.Context, paths []string) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+
     salida := ""
 
 
 	var partitionID string
 
-	if stores.Auth.IsAuthenticated() {
-		partitionID = stores.Auth.GetPartitionID()
+	if auth.IsAuthenticated() {
+		partitionID = auth.GetPartitionID()
 	} else {
-		return "",errors.New("no se ha iniciado sesión en ninguna partición")
+		return "",NewError(CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
 	}
 
 
@@ -94,15 +89,25 @@ func commandCat(paths []string) (string, error) {
         return "", err
     }
 
+    // Abrir el dispositivo del disco
+    dev, err := structures.OpenDevice(mountedDiskPath)
+    if err != nil {
+        return "", fmt.Errorf("error al abrir el disco '%s': %w", mountedDiskPath, err)
+    }
+    defer structures.CloseDevice(dev)
+
     for _, path := range paths {
-		fmt.Println("Buscando path",path)
+		logger.DebugContext(ctx, "Buscando path", "path", path)
 
 
-		if !strings.HasPrefix(path, "/") {
-            path = "/" + path
-        }
+		path = utils.ResolvePath(auth.Cwd, path)
+		if path == utils.AuditLogPath {
+			if err := CheckAuditAccess(ctx, partitionID); err != nil {
+				return "", err
+			}
+		}
 
-        _,inode, err := structures.FindInodeByPath(mountedSb, mountedDiskPath, path)
+        _,inode, err := structures.FindInodeByPath(mountedSb, dev, path)
         if err != nil {
             return "", fmt.Errorf("error al buscar inodo: %v", err)
         }
@@ -117,7 +122,7 @@ func commandCat(paths []string) (string, error) {
             return "", fmt.Errorf("'%s' no es un archivo", path)
         }
 
-        content, err := structures.ReadFileContent(mountedSb, mountedDiskPath, inode)
+        content, err := structures.ReadFileContent(mountedSb, dev, inode)
         if err != nil {
             return "", fmt.Errorf("error al leer contenido: %v", err)
         }
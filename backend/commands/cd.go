@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type CD struct {
+	path string // Directorio destino, absoluto o relativo al actual (vacío: el directorio personal)
+}
+
+func ParseCd(ctx context.Context, tokens []string) (string, error) {
+	cmd := &CD{}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-path=("[^"]+"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(tokens) > 0 && len(matches) == 0 {
+		return "", errors.New("formato incorrecto. Uso: cd [-path=<directorio>]")
+	}
+	for _, match := range matches {
+		value := match[1]
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = strings.Trim(value, "\"")
+		}
+		if cmd.path != "" {
+			return "", errors.New("parámetro '-path' especificado más de una vez")
+		}
+		cmd.path = value
+	}
+
+	cwd, err := commandCd(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CD: Directorio actual: %s", cwd), nil
+}
+
+// commandCd cambia el directorio de trabajo de la sesión. Sin -path vuelve al directorio personal
+// del usuario, o a la raíz si no tiene uno. Devuelve el nuevo directorio.
+func commandCd(ctx context.Context, cd *CD) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
+	}
+
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(auth.GetPartitionID())
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return "", fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	target := utils.ResolvePath(auth.Cwd, cd.path)
+	if cd.path == "" {
+		target = "/"
+		if _, homeInode, err := structures.FindInodeByPath(partitionSuperblock, dev, utils.HomeDir(auth.Username)); err == nil && homeInode.I_type[0] == '0' {
+			target = utils.HomeDir(auth.Username)
+		}
+	}
+
+	_, inode, err := structures.FindInodeByPath(partitionSuperblock, dev, target)
+	if err != nil {
+		return "", NewError(CodeNotFound, "error: el directorio '%s' no existe", target)
+	}
+	if inode.I_type[0] != '0' {
+		return "", fmt.Errorf("error: '%s' no es un directorio", target)
+	}
+
+	logger.DebugContext(ctx, "Cambiando directorio de trabajo", "from", auth.Cwd, "to", target)
+	if err := auth.ChangeDir(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
@@ -1,13 +1,16 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 	stores "backend/stores"
 	structures "backend/structures"
+	utils "backend/utils"
 )
 
 type CHGRP struct {
@@ -15,7 +18,7 @@ type CHGRP struct {
 	grp  string 
 }
 
-func ParseChgrp(tokens []string) (string, error) {
+func ParseChgrp(ctx context.Context, tokens []string) (string, error) {
 	cmd := &CHGRP{}
 	expectedArgs := map[string]bool{"-user": false, "-grp": false}
 
@@ -48,14 +51,14 @@ func ParseChgrp(tokens []string) (string, error) {
 			cmd.grp = value
 			expectedArgs["-grp"] = true
 		default:
-			return "", fmt.Errorf("parámetro desconocido detectado: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido detectado: %s", key)
 		}
 	}
 	if !expectedArgs["-user"] || !expectedArgs["-grp"] {
-		return "", errors.New("faltan parámetros obligatorios: se requieren -user y -grp")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros obligatorios: se requieren -user y -grp")
 	}
 
-	err := commandChgrp(cmd)
+	err := commandChgrp(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -63,14 +66,16 @@ func ParseChgrp(tokens []string) (string, error) {
 	return fmt.Sprintf("CHGRP: Grupo del usuario '%s' cambiado a '%s'.", cmd.user, cmd.grp), nil
 }
 
-func commandChgrp(chgrp *CHGRP) error {
+func commandChgrp(ctx context.Context, chgrp *CHGRP) error {
+	auth := stores.AuthFromContext(ctx)
+
 	// Verificar Permisos 
-	if !stores.Auth.IsAuthenticated() {
-		return errors.New("comando chgrp requiere inicio de sesión")
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando chgrp requiere inicio de sesión")
 	}
-	currentUser, _, partitionID := stores.Auth.GetCurrentUser()
+	currentUser, partitionID := auth.GetCurrentUser()
 	if currentUser != "root" {
-		return fmt.Errorf("permiso denegado: solo 'root' puede ejecutar chgrp (actual: %s)", currentUser)
+		return NewError(CodePermissionDenied, "permiso denegado: solo 'root' puede ejecutar chgrp (actual: %s)", currentUser)
 	}
 
 	// Obtener Partición y Superbloque
@@ -78,13 +83,20 @@ func commandChgrp(chgrp *CHGRP) error {
 	if err != nil {
 		return fmt.Errorf("error al obtener partición montada '%s': %w", partitionID, err)
 	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
 		return errors.New("tamaño inválido de inodo/bloque en superbloque")
 	}
 
 	//Encontrar y Leer /users.txt
-	fmt.Println("Buscando inodo para /users.txt...")
-	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando inodo para /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
 		return fmt.Errorf("error crítico: no se pudo encontrar /users.txt: %w", errFind)
 	}
@@ -92,8 +104,8 @@ func commandChgrp(chgrp *CHGRP) error {
 		return errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	fmt.Println("Leyendo contenido actual de /users.txt...")
-	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	logger.DebugContext(ctx, "Leyendo contenido actual de /users.txt...")
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	if errRead != nil && oldContent == "" {
 		return fmt.Errorf("error leyendo /users.txt: %w", errRead)
 	}
@@ -102,7 +114,7 @@ func commandChgrp(chgrp *CHGRP) error {
 	}
 
 	// Parsear Contenido y Validaciones
-	fmt.Printf("Validando usuario '%s' y nuevo grupo '%s'...\n", chgrp.user, chgrp.grp)
+	logger.DebugContext(ctx, "Validando usuario y nuevo grupo", "user", chgrp.user, "group", chgrp.grp)
 	lines := strings.Split(oldContent, "\n")
 	newLines := make([]string, 0, len(lines)) // Slice para reconstruir el archivo
 	userFound := false
@@ -125,9 +137,9 @@ func commandChgrp(chgrp *CHGRP) error {
 		}
 	}
 	if !groupFound {
-		return fmt.Errorf("error: el nuevo grupo '%s' no existe", chgrp.grp)
+		return NewError(CodeNotFound, "error: el nuevo grupo '%s' no existe", chgrp.grp)
 	}
-	fmt.Printf("Grupo '%s' encontrado y válido.\n", chgrp.grp)
+	logger.DebugContext(ctx, "Grupo encontrado y válido", "group", chgrp.grp)
 
 	// Encontrar usuario y reconstruir archivo
 	for _, line := range lines {
@@ -142,13 +154,17 @@ func commandChgrp(chgrp *CHGRP) error {
 		}
 
 		// Verificar si es la línea del usuario a modificar
-		if len(fields) == 5 && fields[1] == "U" && strings.EqualFold(fields[3], chgrp.user) {
+		if utils.IsUserLine(fields) && strings.EqualFold(fields[3], chgrp.user) {
 			userFound = true
+			record := utils.ParseUserRecord(fields)
+			// Si el nuevo grupo principal era uno de sus grupos secundarios, deja de estar en esa lista
+			record.Supplementary = slices.DeleteFunc(record.Supplementary, func(g string) bool { return strings.EqualFold(g, chgrp.grp) })
 			// Modificar la línea cambiando el nombre del grupo
-			modifiedLine := fmt.Sprintf("%s,U,%s,%s,%s", fields[0], chgrp.grp, fields[3], fields[4])
+			record.Group = chgrp.grp
+			modifiedLine := record.Line()
 			newLines = append(newLines, modifiedLine)
 			userLineModified = true
-			fmt.Printf("Línea del usuario '%s' modificada a: %s\n", chgrp.user, modifiedLine)
+			logger.DebugContext(ctx, "Línea del usuario modificada", "user", chgrp.user, "line", modifiedLine)
 		} else {
 			// Conservar la línea original
 			newLines = append(newLines, line)
@@ -157,7 +173,7 @@ func commandChgrp(chgrp *CHGRP) error {
 
 	// Valida si se encontró al usuario
 	if !userFound {
-		return fmt.Errorf("error: el usuario '%s' no fue encontrado", chgrp.user)
+		return NewError(CodeNotFound, "error: el usuario '%s' no fue encontrado", chgrp.user)
 	}
 	if !userLineModified {
 		return errors.New("error interno: se encontró el usuario pero no se modificó la línea")
@@ -169,41 +185,41 @@ func commandChgrp(chgrp *CHGRP) error {
 		newContent += "\n"
 	}
 	newSize := int32(len(newContent))
-	fmt.Printf("Nuevo contenido de users.txt preparado (%d bytes).\n", newSize)
+	logger.DebugContext(ctx, "Nuevo contenido de users.txt preparado", "bytes", newSize)
 
 	// Libera Bloques Antiguos de users.txt
-	fmt.Println("Liberando bloques antiguos de /users.txt...")
-	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Liberando bloques antiguos de /users.txt...")
+	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, dev)
 	if errFree != nil {
-		fmt.Printf("ADVERTENCIA: Error al liberar bloques: %v\n", errFree)
+		logger.WarnContext(ctx, "Error al liberar bloques", "error", errFree)
 	} else {
-		fmt.Println("Bloques antiguos liberados.")
+		logger.DebugContext(ctx, "Bloques antiguos liberados.")
 	}
 
 	// Asignar Nuevos Bloques para el nuevo contenido
-	fmt.Printf("Asignando bloques para nuevo tamaño (%d bytes)...\n", newSize)
+	logger.DebugContext(ctx, "Asignando bloques para el nuevo tamaño", "bytes", newSize)
 	var newAllocatedBlockIndices [15]int32
-	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, partitionPath)
+	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
 	}
 
 	// Actualizar Inodo de users.txt
-	fmt.Println("Actualizando inodo /users.txt...")
+	logger.DebugContext(ctx, "Actualizando inodo /users.txt...")
 	usersInode.I_size = newSize
 	usersInode.I_mtime = float32(time.Now().Unix())
 	usersInode.I_atime = usersInode.I_mtime
 	usersInode.I_block = newAllocatedBlockIndices
 
 	usersInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(usersInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = usersInode.Serialize(partitionPath, usersInodeOffset)
+	err = usersInode.Serialize(dev, usersInodeOffset)
 	if err != nil {
 		return fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("Serializando SuperBlock después de CHGRP...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de CHGRP...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque después de chgrp: %w", err)
 	}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	logging "backend/logging"
+	stores "backend/stores"
+)
+
+type DEBUG struct {
+	level     string // Nivel a aplicar: debug, info, warn o error (vacío para solo mostrar los niveles)
+	subsystem string // Subsistema al que se aplica, o "all" para todos
+}
+
+// ParseDebug muestra los niveles de log de cada subsistema o, con -level=<nivel> [-sub=<subsistema>|all],
+// los cambia mientras el servidor está en ejecución
+func ParseDebug(ctx context.Context, tokens []string) (string, error) {
+	cmd := &DEBUG{subsystem: "all"}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(level|sub)=([a-zA-Z]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(matches) != len(tokens) {
+		for _, token := range tokens {
+			if !re.MatchString(token) {
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, match := range matches {
+		key, value := strings.ToLower(match[1]), strings.ToLower(match[2])
+		if seen[key] {
+			return "", NewError(CodeInvalidArgument, "parámetro '-%s' especificado más de una vez", key)
+		}
+		seen[key] = true
+		switch key {
+		case "level":
+			cmd.level = value
+		case "sub":
+			cmd.subsystem = value
+		}
+	}
+	if seen["sub"] && cmd.level == "" {
+		return "", NewError(CodeInvalidArgument, "el parámetro '-sub' requiere '-level'")
+	}
+
+	return commandDebug(ctx, cmd)
+}
+
+func commandDebug(ctx context.Context, debug *DEBUG) (string, error) {
+	if debug.level == "" {
+		return fmt.Sprintf("DEBUG: Niveles de log: %s", logging.FormatLevels()), nil
+	}
+
+	// Cambiar los niveles afecta a todo el servidor, así que solo lo puede hacer root
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "cambiar los niveles de log requiere inicio de sesión")
+	}
+	currentUser, _ := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return "", NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede cambiar los niveles de log (usuario actual: %s)", currentUser)
+	}
+
+	level, err := logging.ParseLevel(debug.level)
+	if err != nil {
+		return "", NewError(CodeInvalidArgument, "%w", err)
+	}
+	if err := logging.SetLevel(debug.subsystem, level); err != nil {
+		return "", NewError(CodeInvalidArgument, "%w", err)
+	}
+	logger.InfoContext(ctx, "Niveles de log cambiados", "user", currentUser, "levels", logging.FormatLevels())
+	return fmt.Sprintf("DEBUG: Niveles de log: %s", logging.FormatLevels()), nil
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	stores "backend/stores"
+	structures "backend/structures"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DEFRAG estructura que representa el comando defrag con sus parámetros
+type DEFRAG struct {
+	id string // ID de la partición montada
+}
+
+// ParseDefrag parsea el comando defrag y lo ejecuta
+func ParseDefrag(tokens []string) (string, error) {
+	cmd := &DEFRAG{}
+
+	// Unir tokens en una sola cadena
+	args := strings.Join(tokens, " ")
+	// Expresión regular para encontrar los parámetros del comando defrag
+	re := regexp.MustCompile(`-id=[^\s]+`)
+	matches := re.FindAllString(args, -1)
+
+	for _, match := range matches {
+		kv := strings.SplitN(match, "=", 2)
+		key, value := strings.ToLower(kv[0]), strings.Trim(kv[1], "\"")
+
+		switch key {
+		case "-id":
+			if value == "" {
+				return "", errors.New("el id no puede estar vacío")
+			}
+			cmd.id = value
+		default:
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -id")
+	}
+
+	result, err := commandDefrag(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("DEFRAG: Partición desfragmentada exitosamente\n"+
+		"-> ID: %s\n"+
+		"-> Inodos reubicados: %d\n"+
+		"-> Bloques reubicados: %d\n"+
+		"-> Bloques huérfanos liberados: %d\n"+
+		"-> Fragmentación antes: %.2f%%\n"+
+		"-> Fragmentación después: %.2f%%",
+		cmd.id, result.MovedInodes, result.MovedBlocks, result.FreedOrphans,
+		result.Before.Score(), result.After.Score()), nil
+}
+
+func commandDefrag(defrag *DEFRAG) (*structures.DefragResult, error) {
+	// Obtener el superbloque de la partición montada
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(defrag.id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la partición montada '%s': %w", defrag.id, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	if partitionSuperblock.S_magic != 0xEF53 {
+		return nil, errors.New("la partición no tiene un sistema de archivos EXT2 (ejecute mkfs primero)")
+	}
+
+	result, err := partitionSuperblock.Defragment(dev)
+	if err != nil {
+		return nil, fmt.Errorf("error al desfragmentar: %w", err)
+	}
+
+	// Guardar el superbloque con los nuevos punteros de asignación
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el superbloque: %w", err)
+	}
+
+	logger.Debug("Desfragmentación terminada", "moved_inodes", result.MovedInodes, "moved_blocks", result.MovedBlocks)
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sandbox "backend/sandbox"
+	stores "backend/stores"
+	structures "backend/structures"
+)
+
+// ErrorCode es el código de error de un comando que pueden interpretar los clientes (ver CodeOf)
+type ErrorCode string
+
+const (
+	CodeInvalidArgument  ErrorCode = "INVALID_ARGUMENT"  // Parámetros faltantes, desconocidos o con valores inválidos
+	CodeUnknownCommand   ErrorCode = "UNKNOWN_COMMAND"   // El comando no existe
+	CodeUnauthenticated  ErrorCode = "UNAUTHENTICATED"   // El comando necesita una sesión y no hay ninguna
+	CodeSessionExpired   ErrorCode = "SESSION_EXPIRED"   // La sesión venció, fue terminada o no existe
+	CodePermissionDenied ErrorCode = "PERMISSION_DENIED" // El usuario no puede ejecutar el comando
+	CodeAccountLocked    ErrorCode = "ACCOUNT_LOCKED"    // La cuenta está bloqueada o expiró
+	CodeNotFound         ErrorCode = "NOT_FOUND"         // No existe el usuario, grupo, archivo, disco o partición
+	CodeAlreadyExists    ErrorCode = "ALREADY_EXISTS"    // Ya existe lo que se quiere crear
+	CodeQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"    // Se superaría la cuota del usuario o de su grupo
+	CodeDiskModified     ErrorCode = "DISK_MODIFIED"     // El disco se modificó fuera del servidor
+	CodeFailed           ErrorCode = "COMMAND_FAILED"    // Cualquier otro error
+)
+
+// CommandError es un error de comando con su código
+type CommandError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// NewError crea un CommandError con el código indicado; el mensaje se arma igual que con fmt.Errorf (admite %w)
+func NewError(code ErrorCode, format string, args ...any) error {
+	return &CommandError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Errores que se definieron antes que los códigos, con el código que les corresponde
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrQuotaExceeded, CodeQuotaExceeded},
+	{ErrAccountLocked, CodeAccountLocked},
+	{ErrAccountExpired, CodeAccountLocked},
+	{ErrTooManyFailedLogins, CodeAccountLocked},
+	{stores.ErrInvalidSession, CodeSessionExpired},
+	{stores.ErrSessionExpired, CodeSessionExpired},
+	{stores.ErrSessionTerminated, CodeSessionExpired},
+	{structures.ErrExternalModification, CodeDiskModified},
+	{sandbox.ErrPathDenied, CodePermissionDenied},
+}
+
+// CodeOf devuelve el código de un error de comando: el del CommandError más externo de la cadena,
+// el de un error conocido o CodeFailed. Para nil devuelve "".
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.Code
+	}
+	for _, sentinel := range sentinelCodes {
+		if errors.Is(err, sentinel.err) {
+			return sentinel.code
+		}
+	}
+	return CodeFailed
+}
+
+// Clave para guardar en el contexto la lista de archivos generados por los comandos
+type artifactsContextKey struct{}
+
+// WithArtifacts devuelve un contexto en el que los comandos anotan los archivos que generan (por ejemplo rep),
+// y la lista donde quedan anotados
+func WithArtifacts(ctx context.Context) (context.Context, *[]string) {
+	artifacts := &[]string{}
+	return context.WithValue(ctx, artifactsContextKey{}, artifacts), artifacts
+}
+
+// addArtifact anota un archivo generado, si el contexto tiene dónde (ver WithArtifacts)
+func addArtifact(ctx context.Context, path string) {
+	if artifacts, ok := ctx.Value(artifactsContextKey{}).(*[]string); ok {
+		*artifacts = append(*artifacts, path)
+	}
+}
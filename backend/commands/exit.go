@@ -0,0 +1,32 @@
+package commands
+
+import (
+	stores "backend/stores"
+	utils "backend/utils"
+	"context"
+	"errors"
+	"fmt"
+)
+
+func ParseExit(ctx context.Context, tokens []string) (string, error) {
+	if len(tokens) != 0 {
+		return "", errors.New("el comando exit no acepta parámetros")
+	}
+	// Verifica si hay una sesión activa
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "no hay ninguna sesión activa")
+	}
+
+	// Vuelve al usuario anterior al último su
+	previousUser := auth.Username
+	restoredUser, err := auth.ExitUser()
+	if err != nil {
+		return "", err
+	}
+	entry := utils.AuditEntry{User: previousUser, Event: "exit", Target: restoredUser, Result: "ok"}
+	if err := recordAudit(auth, entry); err != nil {
+		return "", fmt.Errorf("error al registrar exit en el log de auditoría: %w", err)
+	}
+	return fmt.Sprintf("EXIT: Volviste al usuario '%s'.", restoredUser), nil
+}
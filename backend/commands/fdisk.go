@@ -1,12 +1,12 @@
 package commands
 
 import (
+	stores "backend/stores"
 	structures "backend/structures"
 	utils "backend/utils"
 	"encoding/binary"
 	"errors" // Paquete para manejar errores y crear nuevos errores con mensajes personalizados
 	"fmt"    // Paquete para formatear cadenas y realizar operaciones de entrada/salida
-	"os"
 	"regexp"  // Paquete para trabajar con expresiones regulares, útil para encontrar y manipular patrones en cadenas
 	"strconv" // Paquete para convertir cadenas a otros tipos de datos, como enteros
 	"strings" // Paquete para manipular cadenas, como unir, dividir, y modificar contenido de cadenas
@@ -44,7 +44,7 @@ func ParseFdisk(tokens []string) (string, error) {
 		// Divide cada parte en clave y valor usando "=" como delimitador
 		kv := strings.SplitN(match, "=", 2)
 		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+			return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 		}
 		key, value := strings.ToLower(kv[0]), kv[1]
 
@@ -96,20 +96,25 @@ func ParseFdisk(tokens []string) (string, error) {
 			cmd.name = value
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que los parámetros -size, -path y -name hayan sido proporcionados
 	if cmd.size == 0 {
-		return "", errors.New("faltan parámetros requeridos: -size")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -size")
 	}
 	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -path")
 	}
 	if cmd.name == "" {
-		return "", errors.New("faltan parámetros requeridos: -name")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -name")
 	}
+	resolvedPath, err := stores.ResolveDiskPath(cmd.path)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = resolvedPath
 
 	// Si no se proporcionó la unidad, se establece por defecto a "M"
 	if cmd.unit == "" {
@@ -127,9 +132,8 @@ func ParseFdisk(tokens []string) (string, error) {
 	}
 
 	// Crear la partición con los parámetros proporcionados
-	err := commandFdisk(cmd)
+	err = commandFdisk(cmd)
 	if err != nil {
-		fmt.Println("Error:", err)
 		return "", err
 	}
 
@@ -147,114 +151,117 @@ func commandFdisk(fdisk *FDISK) error {
 	// Convertir el tamaño a bytes
 	sizeBytes, err := utils.ConvertToBytes(fdisk.size, fdisk.unit)
 	if err != nil {
-		fmt.Println("Error convirtiendo tamaño:", err)
+		logger.Debug("Error convirtiendo tamaño", "error", err)
 		return err
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(fdisk.path)
+	if err != nil {
+		logger.Debug("Error abriendo el disco", "error", err)
+		return fmt.Errorf("error abriendo el disco: %w", err)
+	}
+	defer structures.CloseDevice(dev)
+
 	switch fdisk.typ {
 	case "P":
 		// Crear partición primaria
-		err = createPrimaryPartition(fdisk, sizeBytes)
+		err = createPrimaryPartition(fdisk, dev, sizeBytes)
 		if err != nil {
-			fmt.Println("Error creando partición primaria:", err)
+			logger.Debug("Error creando partición primaria", "error", err)
 			return err
 		}
 	case "E":
 		// Crear partición extendida
-		err = createExtendedPartition(fdisk, sizeBytes)
+		err = createExtendedPartition(fdisk, dev, sizeBytes)
 		if err != nil {
-			fmt.Println("Error creando partición primaria:", err)
+			logger.Debug("Error creando partición primaria", "error", err)
 			return err
 		}
 	case "L":
 		// Crear partición lógica
-		err = createLogicalPartition(fdisk, sizeBytes)
+		err = createLogicalPartition(fdisk, dev, sizeBytes)
 		if err != nil {
-			fmt.Println("Error creando partición primaria:", err)
+			logger.Debug("Error creando partición primaria", "error", err)
 			return err
 		}
 	}
 	if err != nil {
-		fmt.Println("Error creando partición:", err)
+		logger.Debug("Error creando partición", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func createPrimaryPartition(fdisk *FDISK, sizeBytes int) error {
+func createPrimaryPartition(fdisk *FDISK, dev structures.BlockDevice, sizeBytes int) error {
 	// Crear una instancia de MBR
 	var mbr structures.MBR
 
-	// Deserializar la estructura MBR desde un archivo binario
-	err := mbr.Deserialize(fdisk.path)
+	// Deserializar la estructura MBR desde el dispositivo
+	err := mbr.Deserialize(dev)
 	if err != nil {
-		fmt.Println("Error deserializando el MBR:", err)
+		logger.Debug("Error deserializando el MBR", "error", err)
 		return fmt.Errorf("error deserializando el MBR: %w", err)
 	}
 
 	/* SOLO PARA VERIFICACIÓN */
 	// Imprimir MBR
-	fmt.Println("\nMBR original:")
-	mbr.PrintMBR()
+	logger.Debug("MBR original", "mbr", &mbr)
 
 	// Obtener la primera partición disponible
 	availablePartition, startPartition, indexPartition := mbr.GetFirstAvailablePartition()
 	if availablePartition == nil {
-		fmt.Println("No hay particiones disponibles.")
+		logger.Debug("No hay particiones disponibles.")
 		return errors.New("no hay espacio disponible para la partición")
 	}
 
 	for _, partitionName := range mbr.GetPartitionNames() {
 		if partitionName == fdisk.name {
-			fmt.Println("Ya existe una partición con el nombre especificado.")
-			return errors.New("ya existe una partición con el nombre especificado")
+			logger.Debug("Ya existe una partición con el nombre especificado.")
+			return NewError(CodeAlreadyExists, "ya existe una partición con el nombre especificado")
 		}
 	}
 
 	/* SOLO PARA VERIFICACIÓN */
-	// Print para verificar que la partición esté disponible
-	fmt.Println("\nPartición disponible:")
-	availablePartition.PrintPartition()
+	// Registrar para verificar que la partición esté disponible
+	logger.Debug("Partición disponible", "partition", availablePartition)
 
 	// Crear la partición con los parámetros proporcionados
 	availablePartition.CreatePartition(startPartition, sizeBytes, fdisk.typ, fdisk.fit, fdisk.name)
 
-	// Print para verificar que la partición se haya creado correctamente
-	fmt.Println("\nPartición creada (modificada):")
-	availablePartition.PrintPartition()
+	// Registrar para verificar que la partición se haya creado correctamente
+	logger.Debug("Partición creada (modificada)", "partition", availablePartition)
 
 	// Colocar la partición en el MBR
 	mbr.Mbr_partitions[indexPartition] = *availablePartition
 
 	// Imprimir las particiones del MBR
-	fmt.Println("\nParticiones del MBR:")
-	mbr.PrintPartitions()
+	logger.Debug("MBR actualizado", "mbr", &mbr)
 
-	// Serializar el MBR en el archivo binario
-	err = mbr.Serialize(fdisk.path)
+	// Serializar el MBR en el dispositivo
+	err = mbr.Serialize(dev)
 	if err != nil {
-		fmt.Println("Error:", err)
 		return fmt.Errorf("error serializando el MBR: %w", err)
 	}
 	return nil
 }
 
 // Función para crear una partición extendida
-func createExtendedPartition(fdisk *FDISK, sizeBytes int) error {
+func createExtendedPartition(fdisk *FDISK, dev structures.BlockDevice, sizeBytes int) error {
 	var mbr structures.MBR
 
 	// Deserializar el MBR del disco
-	err := mbr.Deserialize(fdisk.path)
+	err := mbr.Deserialize(dev)
 	if err != nil {
-		fmt.Println("Error deserializando el MBR:", err)
+		logger.Debug("Error deserializando el MBR", "error", err)
 		return fmt.Errorf("error deserializando el MBR: %w", err)
 	}
 
 	// Verificar si ya existe una partición extendida
 	for _, partition := range mbr.Mbr_partitions {
 		if partition.Part_type[0] == 'E' {
-			return errors.New("ya existe una partición extendida en el disco")
+			return NewError(CodeAlreadyExists, "ya existe una partición extendida en el disco")
 		}
 	}
 
@@ -266,8 +273,8 @@ func createExtendedPartition(fdisk *FDISK, sizeBytes int) error {
 
 	for _, partitionName := range mbr.GetPartitionNames() {
 		if partitionName == fdisk.name {
-			fmt.Println("Ya existe una partición con el nombre especificado.")
-			return errors.New("ya existe una partición con el nombre especificado")
+			logger.Debug("Ya existe una partición con el nombre especificado.")
+			return NewError(CodeAlreadyExists, "ya existe una partición con el nombre especificado")
 		}
 	}
 
@@ -278,24 +285,24 @@ func createExtendedPartition(fdisk *FDISK, sizeBytes int) error {
 	mbr.Mbr_partitions[indexPartition] = *availablePartition
 
 	// Serializar el MBR modificado
-	err = mbr.Serialize(fdisk.path)
+	err = mbr.Serialize(dev)
 	if err != nil {
-		fmt.Println("Error serializando MBR:", err)
+		logger.Debug("Error serializando MBR", "error", err)
 		return fmt.Errorf("error serializando el MBR: %w", err)
 	}
 
-	fmt.Println("Partición extendida creada correctamente.")
+	logger.Debug("Partición extendida creada correctamente.")
 	return nil
 }
 
 // Función para crear una partición lógica
-func createLogicalPartition(fdisk *FDISK, sizeBytes int) error {
+func createLogicalPartition(fdisk *FDISK, dev structures.BlockDevice, sizeBytes int) error {
 	var mbr structures.MBR
 
 	// Deserializar el MBR
-	err := mbr.Deserialize(fdisk.path)
+	err := mbr.Deserialize(dev)
 	if err != nil {
-		fmt.Println("Error deserializando MBR:", err)
+		logger.Debug("Error deserializando MBR", "error", err)
 		return fmt.Errorf("error deserializando el MBR: %w", err)
 	}
 
@@ -312,13 +319,6 @@ func createLogicalPartition(fdisk *FDISK, sizeBytes int) error {
 		return errors.New("no se encontró una partición extendida en el disco")
 	}
 
-	// Abrir el archivo del disco
-	file, err := os.OpenFile(fdisk.path, os.O_RDWR, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	ebrSize := int32(binary.Size(structures.EBR{}))
 
 	// Buscar el último EBR dentro de la partición extendida
@@ -329,11 +329,8 @@ func createLogicalPartition(fdisk *FDISK, sizeBytes int) error {
 
 	//Comienzo con el ciclo para irme moviendo :)
 	for {
-		// Moverse al offset actual
-		file.Seek(int64(currentEBRPosition), 0)
-
 		// Leer el EBR en la posición actual
-		err := binary.Read(file, binary.LittleEndian, &lastEBR)
+		err := lastEBR.Deserialize(dev, int64(currentEBRPosition))
 		if err != nil {
 			break
 		}
@@ -386,25 +383,23 @@ func createLogicalPartition(fdisk *FDISK, sizeBytes int) error {
 	// Copiar el nombre de la partición al EBR
 	copy(newEBR.Part_name[:], fdisk.name)
 
-	// Escribir el nuevo EBR en el archivo del disco
-	file.Seek(int64(newEBRPosition), 0)
-	err = binary.Write(file, binary.LittleEndian, &newEBR)
+	// Escribir el nuevo EBR en el disco
+	err = newEBR.Serialize(dev, int64(newEBRPosition))
 	if err != nil {
-		fmt.Println("Error escribiendo EBR:", err)
+		logger.Debug("Error escribiendo EBR", "error", err)
 		return err
 	}
 
 	// Actualizar el EBR anterior si existe
 	if lastEBRPosition != -1 {
 		lastEBR.Part_next = newEBRPosition
-		file.Seek(int64(lastEBRPosition), 0)
-		err = binary.Write(file, binary.LittleEndian, &lastEBR)
+		err = lastEBR.Serialize(dev, int64(lastEBRPosition))
 		if err != nil {
-			fmt.Println("Error actualizando EBR anterior:", err)
+			logger.Debug("Error actualizando EBR anterior", "error", err)
 			return err
 		}
 	}
 
-	fmt.Println("Partición lógica creada correctamente.")
+	logger.Debug("Partición lógica creada correctamente.")
 	return nil
 }
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// Permisos del directorio personal: solo el dueño puede entrar
+var homePerm = [3]byte{'7', '0', '0'}
+
+// createHome crea el directorio personal del usuario (y /home si no existe) con dueño uid/gid y permisos 700,
+// y copia dentro el contenido de /etc/skel si esa carpeta existe. No serializa el superbloque.
+func createHome(sb *structures.SuperBlock, dev structures.BlockDevice, username string, uid, gid int32) error {
+	homePath := utils.HomeDir(username)
+
+	// Asegurar /home
+	if _, rootInode, err := structures.FindInodeByPath(sb, dev, utils.HomeRoot); err != nil {
+		logger.Debug("Creando carpeta de directorios personales", "path", utils.HomeRoot)
+		parentDirs, destDir := utils.GetParentDirectories(utils.HomeRoot)
+		if err := sb.CreateFolder(dev, parentDirs, destDir); err != nil {
+			return fmt.Errorf("error al crear %s: %w", utils.HomeRoot, err)
+		}
+	} else if rootInode.I_type[0] != '0' {
+		return fmt.Errorf("error: '%s' existe pero no es un directorio", utils.HomeRoot)
+	}
+
+	if _, _, err := structures.FindInodeByPath(sb, dev, homePath); err == nil {
+		return NewError(CodeAlreadyExists, "error: el directorio personal '%s' ya existe", homePath)
+	}
+
+	logger.Debug("Creando directorio personal", "path", homePath)
+	homeIndex, err := createOwnedFolder(sb, dev, homePath, uid, gid, homePerm)
+	if err != nil {
+		return err
+	}
+
+	// Copiar la plantilla, si existe
+	skelIndex, skelInode, err := structures.FindInodeByPath(sb, dev, utils.SkelDir)
+	if err != nil || skelInode.I_type[0] != '0' {
+		logger.Debug("No existe la plantilla; el directorio personal queda vacío", "skel", utils.SkelDir)
+		return nil
+	}
+	logger.Debug("Copiando plantilla en el directorio personal", "skel", utils.SkelDir, "path", homePath)
+	return copyTree(sb, dev, skelIndex, homeIndex, homePath, uid, gid)
+}
+
+// copyTree copia el contenido de la carpeta srcIndex dentro de la carpeta dstPath (inodo dstIndex),
+// con dueño uid/gid y conservando los permisos de cada elemento
+func copyTree(sb *structures.SuperBlock, dev structures.BlockDevice, srcIndex, dstIndex int32, dstPath string, uid, gid int32) error {
+	srcInode := &structures.Inode{}
+	if err := srcInode.Deserialize(dev, int64(sb.S_inode_start)+int64(srcIndex)*int64(sb.S_inode_size)); err != nil {
+		return fmt.Errorf("error deserializando inodo %d: %w", srcIndex, err)
+	}
+	entries, err := sb.DirectoryEntries(dev, srcInode)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Inode == dstIndex {
+			continue // La copia no se copia a sí misma
+		}
+		childInode := &structures.Inode{}
+		if err := childInode.Deserialize(dev, int64(sb.S_inode_start)+int64(entry.Inode)*int64(sb.S_inode_size)); err != nil {
+			return fmt.Errorf("error deserializando inodo %d ('%s'): %w", entry.Inode, entry.Name, err)
+		}
+		childPath := dstPath + "/" + entry.Name
+
+		if childInode.I_type[0] == '0' {
+			childIndex, err := createOwnedFolder(sb, dev, childPath, uid, gid, childInode.I_perm)
+			if err != nil {
+				return err
+			}
+			if err := copyTree(sb, dev, entry.Inode, childIndex, childPath, uid, gid); err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, err := structures.ReadFileContent(sb, dev, childInode)
+		if err != nil {
+			return fmt.Errorf("error leyendo '%s': %w", entry.Name, err)
+		}
+		if err := createOwnedFile(sb, dev, dstIndex, entry.Name, []byte(content), uid, gid, childInode.I_perm); err != nil {
+			return fmt.Errorf("error copiando '%s': %w", childPath, err)
+		}
+	}
+	return nil
+}
+
+// createOwnedFolder crea la carpeta del path (su padre debe existir) y le asigna dueño y permisos
+func createOwnedFolder(sb *structures.SuperBlock, dev structures.BlockDevice, path string, uid, gid int32, perm [3]byte) (int32, error) {
+	parentDirs, destDir := utils.GetParentDirectories(path)
+	if err := sb.CreateFolder(dev, parentDirs, destDir); err != nil {
+		return -1, fmt.Errorf("error al crear '%s': %w", path, err)
+	}
+	folderIndex, folderInode, err := structures.FindInodeByPath(sb, dev, path)
+	if err != nil {
+		return -1, fmt.Errorf("error al buscar '%s' recién creado: %w", path, err)
+	}
+	folderInode.I_uid = uid
+	folderInode.I_gid = gid
+	folderInode.I_perm = perm
+	if err := folderInode.Serialize(dev, int64(sb.S_inode_start)+int64(folderIndex)*int64(sb.S_inode_size)); err != nil {
+		return -1, fmt.Errorf("error serializando inodo de '%s': %w", path, err)
+	}
+	return folderIndex, nil
+}
+
+// createOwnedFile crea un archivo con el contenido indicado dentro de la carpeta parentIndex
+func createOwnedFile(sb *structures.SuperBlock, dev structures.BlockDevice, parentIndex int32, name string, content []byte, uid, gid int32, perm [3]byte) error {
+	if sb.S_free_inodes_count <= 0 {
+		return errors.New("no hay inodos libres")
+	}
+	allocatedBlockIndices, err := allocateDataBlocks(content, int32(len(content)), sb, dev)
+	if err != nil {
+		return fmt.Errorf("falló la asignación de bloques: %w", err)
+	}
+
+	newInodeIndex := (sb.S_first_ino - sb.S_inode_start) / sb.S_inode_size
+	if err := sb.UpdateBitmapInode(dev, newInodeIndex); err != nil {
+		return fmt.Errorf("error actualizando bitmap para inodo %d: %w", newInodeIndex, err)
+	}
+	sb.S_free_inodes_count--
+	sb.S_first_ino += sb.S_inode_size
+
+	currentTime := float32(time.Now().Unix())
+	newInode := &structures.Inode{
+		I_uid: uid, I_gid: gid, I_size: int32(len(content)),
+		I_atime: currentTime, I_ctime: currentTime, I_mtime: currentTime,
+		I_block: allocatedBlockIndices,
+		I_type:  [1]byte{'1'}, I_perm: perm,
+	}
+	if err := newInode.Serialize(dev, int64(sb.S_inode_start)+int64(newInodeIndex)*int64(sb.S_inode_size)); err != nil {
+		return fmt.Errorf("error serializando nuevo inodo %d: %w", newInodeIndex, err)
+	}
+	return addEntryToParent(parentIndex, name, newInodeIndex, sb, dev)
+}
+
+// purgeHome elimina el directorio personal del usuario con todo su contenido, liberando inodos y bloques.
+// Si no existe no hace nada; si existe pero no es del usuario, no lo toca y devuelve un error.
+// Devuelve cuántos inodos y bloques liberó. No serializa el superbloque.
+func purgeHome(sb *structures.SuperBlock, dev structures.BlockDevice, username string, uid int32) (bool, int32, int32, error) {
+	homePath := utils.HomeDir(username)
+	homeIndex, homeInode, err := structures.FindInodeByPath(sb, dev, homePath)
+	if err != nil {
+		logger.Debug("El usuario no tiene directorio personal", "user", username, "path", homePath)
+		return false, 0, 0, nil
+	}
+	if homeInode.I_type[0] != '0' || homeInode.I_uid != uid {
+		return false, 0, 0, fmt.Errorf("error: '%s' no es un directorio del usuario '%s', no se elimina", homePath, username)
+	}
+	homeRootIndex, _, err := structures.FindInodeByPath(sb, dev, utils.HomeRoot)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("error al buscar %s: %w", utils.HomeRoot, err)
+	}
+
+	logger.Debug("Eliminando directorio personal", "path", homePath)
+	if err := sb.RemoveEntry(dev, homeRootIndex, username); err != nil {
+		return false, 0, 0, err
+	}
+	freedInodes, freedBlocks, err := sb.RemoveTree(dev, homeIndex)
+	if err != nil {
+		return true, freedInodes, freedBlocks, fmt.Errorf("error liberando '%s': %w", homePath, err)
+	}
+	return true, freedInodes, freedBlocks, nil
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type LOCKUSR struct {
+	user string // Usuario a bloquear o desbloquear
+	lock bool   // true para lockusr, false para unlockusr
+}
+
+// ParseLockusr interpreta lockusr (lock en true) y unlockusr (lock en false); ambos reciben solo -user
+func ParseLockusr(ctx context.Context, tokens []string, lock bool) (string, error) {
+	cmd := &LOCKUSR{lock: lock}
+	commandName := "unlockusr"
+	if lock {
+		commandName = "lockusr"
+	}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user)=("[^"]+"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+
+	for _, match := range matches {
+		value := match[2]
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = strings.Trim(value, "\"")
+		}
+		if len(value) > 10 {
+			return "", fmt.Errorf("el valor para '-user' ('%s') excede los 10 caracteres", value)
+		}
+		if cmd.user != "" {
+			return "", errors.New("parámetro '-user' especificado más de una vez")
+		}
+		cmd.user = value
+	}
+	if cmd.user == "" {
+		return "", NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -user")
+	}
+
+	closed, err := commandLockusr(ctx, cmd, commandName)
+	if err != nil {
+		return "", err
+	}
+
+	if !lock {
+		return fmt.Sprintf("UNLOCKUSR: Usuario '%s' desbloqueado.", cmd.user), nil
+	}
+	return fmt.Sprintf("LOCKUSR: Usuario '%s' bloqueado (%d sesiones cerradas).", cmd.user, closed), nil
+}
+
+// commandLockusr marca o desmarca la cuenta como bloqueada en /users.txt. Al bloquear cierra las sesiones
+// abiertas del usuario; al desbloquear también borra el bloqueo temporal por intentos fallidos.
+// Devuelve cuántas sesiones cerró.
+func commandLockusr(ctx context.Context, lockusr *LOCKUSR, commandName string) (int, error) {
+	auth := stores.AuthFromContext(ctx)
+
+	//Verificar Permisos
+	if !auth.IsAuthenticated() {
+		return 0, NewError(CodeUnauthenticated, "comando %s requiere inicio de sesión", commandName)
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return 0, NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar %s (usuario actual: %s)", commandName, currentUser)
+	}
+	if lockusr.lock && strings.EqualFold(lockusr.user, "root") {
+		return 0, errors.New("error: no se puede bloquear al usuario 'root'")
+	}
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return 0, fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	usersInodeIndex, usersInode, oldContent, err := readUsersFile(partitionSuperblock, dev)
+	if err != nil {
+		return 0, err
+	}
+
+	// Modificar la línea del usuario
+	lines := strings.Split(oldContent, "\n")
+	newLines := make([]string, 0, len(lines))
+	foundUser := false
+	changed := false
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+		fields := strings.Split(trimmedLine, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if utils.IsUserLine(fields) && strings.EqualFold(fields[3], lockusr.user) {
+			foundUser = true
+			record := utils.ParseUserRecord(fields)
+			lockusr.user = record.Name // Usar el nombre tal como está en /users.txt
+			if record.Locked != lockusr.lock {
+				record.Locked = lockusr.lock
+				trimmedLine = record.Line()
+				changed = true
+				logger.DebugContext(ctx, "Línea del usuario modificada", "user", record.Name, "line", trimmedLine)
+			}
+		}
+		newLines = append(newLines, trimmedLine)
+	}
+	if !foundUser {
+		return 0, NewError(CodeNotFound, "error: el usuario '%s' no existe", lockusr.user)
+	}
+
+	if !lockusr.lock {
+		// Desbloquear también quita el bloqueo temporal, aunque la cuenta no estuviera marcada
+		stores.ResetLoginFailures(partitionID, lockusr.user)
+	}
+	if changed {
+		newContent, _, err := utils.MigrateUsersContent(strings.Join(newLines, "\n"))
+		if err != nil {
+			return 0, fmt.Errorf("error migrando /users.txt: %w", err)
+		}
+		logger.DebugContext(ctx, "Actualizando estado del usuario en /users.txt", "user", lockusr.user)
+		if err := writeUsersFile(partitionSuperblock, mountedPartition, dev, usersInodeIndex, usersInode, newContent); err != nil {
+			return 0, err
+		}
+	} else if lockusr.lock {
+		logger.DebugContext(ctx, "El usuario ya estaba bloqueado", "user", lockusr.user)
+	}
+
+	if !lockusr.lock {
+		return 0, nil
+	}
+	closed := stores.Sessions.DeleteUser(partitionID, lockusr.user)
+	logger.DebugContext(ctx, "Sesiones cerradas del usuario", "user", lockusr.user, "sessions", closed)
+	return closed, nil
+}
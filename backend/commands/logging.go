@@ -0,0 +1,5 @@
+package commands
+
+import logging "backend/logging"
+
+var logger = logging.Logger(logging.Commands)
@@ -1,13 +1,23 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	stores "backend/stores"
 	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// Errores de login para cuentas que no pueden iniciar sesión aunque existan
+var (
+	ErrAccountLocked       = errors.New("cuenta bloqueada")
+	ErrAccountExpired      = errors.New("cuenta expirada")
+	ErrTooManyFailedLogins = errors.New("demasiados intentos fallidos")
 )
 
 type LOGIN struct {
@@ -16,7 +26,7 @@ type LOGIN struct {
 	id   string
 }
 
-func ParseLogin(tokens []string) (string, error) {
+func ParseLogin(ctx context.Context, tokens []string) (string, error) {
 	cmd := &LOGIN{}
 	foundParams := map[string]bool{"-user": false, "-pass": false, "-id": false} // Para rastrear requeridos
 
@@ -79,31 +89,33 @@ func ParseLogin(tokens []string) (string, error) {
 		missing = append(missing, "-id")
 	}
 	if len(missing) > 0 {
-		return "", fmt.Errorf("faltan parámetros requeridos: %s", strings.Join(missing, ", "))
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: %s", strings.Join(missing, ", "))
 	}
 
 	// Llamar a la lógica principal
-	err := commandLogin(cmd)
+	err := commandLogin(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("LOGIN: Sesión iniciada para usuario '%s' en partición '%s'.", cmd.user, cmd.id), nil
+	return fmt.Sprintf("LOGIN: Sesión iniciada para usuario '%s' en partición '%s'. Directorio actual: %s", cmd.user, cmd.id, stores.AuthFromContext(ctx).Cwd), nil
 }
 
-func commandLogin(login *LOGIN) error {
+func commandLogin(ctx context.Context, login *LOGIN) error {
+	auth := stores.AuthFromContext(ctx)
+
 	// Verificar si ya hay una sesión activa
-	if stores.Auth.IsAuthenticated() {
-		_, _, currentPartition := stores.Auth.GetCurrentUser()
+	if auth.IsAuthenticated() {
+		_, currentPartition := auth.GetCurrentUser()
 		if currentPartition == login.id {
-			return fmt.Errorf("ya hay una sesión activa en la partición '%s' para el usuario '%s'", login.id, stores.Auth.Username)
+			return fmt.Errorf("ya hay una sesión activa en la partición '%s' para el usuario '%s'", login.id, auth.Username)
 		} else {
 			return fmt.Errorf("ya hay una sesión activa en otra partición ('%s'). Debes hacer 'logout' primero", currentPartition)
 		}
 	}
 
 	// Obtener la partición montada y el superbloque
-	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(login.id)
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(login.id)
 	if err != nil {
 		_, exists := stores.MountedPartitions[login.id]
 		if !exists {
@@ -111,13 +123,20 @@ func commandLogin(login *LOGIN) error {
 		}
 		return fmt.Errorf("error al obtener la partición montada '%s': %w", login.id, err)
 	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_magic != 0xEF53 {
 		return fmt.Errorf("la partición '%s' no tiene un sistema de archivos EXT2 válido (magic number incorrecto)", login.id)
 	}
 
 	// Leer /users.txt
-	fmt.Println("Buscando y leyendo /users.txt...")
-	_, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando y leyendo /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
 		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
 	}
@@ -125,16 +144,16 @@ func commandLogin(login *LOGIN) error {
 		return errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	content, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	content, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	if errRead != nil {
 		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
 	}
-	fmt.Println("Contenido leído de /users.txt.")
+	logger.DebugContext(ctx, "Contenido leído de /users.txt.")
 
 	// Verificar si el contenido está vacío
 	lines := strings.Split(content, "\n")
 	foundUser := false
-	var storedPassword string
+	var record utils.UserRecord
 
 	// Buscar el usuario en las líneas
 	for _, line := range lines {
@@ -148,14 +167,13 @@ func commandLogin(login *LOGIN) error {
 			fields[i] = strings.TrimSpace(fields[i])
 		}
 
-		if len(fields) == 5 && fields[1] == "U" {
+		if utils.IsUserLine(fields) {
 			fileUsername := fields[3]
-			filePassword := fields[4]
 
 			if strings.EqualFold(fileUsername, login.user) {
 				foundUser = true
-				storedPassword = filePassword
-				fmt.Printf("Usuario '%s' encontrado.\n", login.user)
+				record = utils.ParseUserRecord(fields)
+				logger.DebugContext(ctx, "Usuario encontrado", "user", login.user)
 				break
 			}
 		}
@@ -163,18 +181,78 @@ func commandLogin(login *LOGIN) error {
 
 	// Verificar si se encontró el usuario
 	if !foundUser {
-		return fmt.Errorf("el usuario '%s' no existe en la partición '%s'", login.user, login.id)
+		return NewError(CodeNotFound, "el usuario '%s' no existe en la partición '%s'", login.user, login.id)
+	}
+
+	// Verificar la contraseña y el estado de la cuenta
+	logger.DebugContext(ctx, "Verificando contraseña...")
+	if err := verifyAccountPassword(login.id, record, login.pass); err != nil {
+		return err
 	}
 
-	// Verificar la contraseña
-	fmt.Println("Verificando contraseña...")
-	if storedPassword != login.pass { // Comparación exacta (case-sensitive) para contraseñas
-		return fmt.Errorf("contraseña incorrecta para el usuario '%s'", login.user)
+	// Migrar /users.txt al formato actual si todavía tiene contraseñas en texto plano
+	migratedContent, changed, err := utils.MigrateUsersContent(content)
+	if err != nil {
+		return fmt.Errorf("error migrando /users.txt: %w", err)
+	}
+	if changed {
+		logger.DebugContext(ctx, "Migrando /users.txt al formato actual", "version", utils.UsersFileVersion)
+		if err := writeUsersFile(partitionSuperblock, mountedPartition, dev, usersInodeIndex, usersInode, migratedContent); err != nil {
+			return fmt.Errorf("error guardando /users.txt migrado: %w", err)
+		}
+	}
+
+	// La sesión empieza en el directorio personal del usuario, si tiene uno
+	cwd := "/"
+	if _, homeInode, err := structures.FindInodeByPath(partitionSuperblock, dev, utils.HomeDir(record.Name)); err == nil && homeInode.I_type[0] == '0' {
+		cwd = utils.HomeDir(record.Name)
 	}
 
 	// Si la validación es exitosa, establecer el estado de autenticación
-	fmt.Println("Login exitoso.")
-	stores.Auth.Login(login.user, login.pass, login.id)
+	logger.DebugContext(ctx, "Login exitoso.")
+	if _, err := auth.Login(login.user, login.id, cwd); err != nil {
+		return fmt.Errorf("error al crear la sesión: %w", err)
+	}
+
+	return nil
+}
+
+// verifyAccountPassword comprueba que la cuenta de un usuario pueda usarse (que no esté bloqueada temporalmente
+// por intentos fallidos, bloqueada por root ni expirada) y luego su contraseña. Lleva la cuenta de los
+// intentos fallidos (ver stores.LoginLockout).
+func verifyAccountPassword(partitionID string, record utils.UserRecord, password string) error {
+	if lockedUntil, locked := stores.LoginLockedUntil(partitionID, record.Name); locked {
+		return fmt.Errorf("%w: el usuario '%s' está bloqueado temporalmente, intente de nuevo en %s",
+			ErrTooManyFailedLogins, record.Name, lockedUntil.Sub(stores.Clock()).Round(time.Second))
+	}
+
+	// Las cuentas bloqueadas o expiradas se rechazan antes de ver la contraseña: la respuesta es la misma sea
+	// correcta o no, así no sirven para adivinarla
+	if record.Locked {
+		return fmt.Errorf("%w: el usuario '%s' fue bloqueado por root", ErrAccountLocked, record.Name)
+	}
+	if record.Expired(time.Now()) {
+		return fmt.Errorf("%w: la cuenta del usuario '%s' expiró el %s", ErrAccountExpired, record.Name, record.Expires)
+	}
+
+	validPassword, err := utils.VerifyPassword(record.Password, password) // Acepta hashes y contraseñas en texto plano (formato v1)
+	if err != nil {
+		return fmt.Errorf("error verificando la contraseña de '%s': %w", record.Name, err)
+	}
+	if !validPassword {
+		remaining, lockedUntil := stores.RecordLoginFailure(partitionID, record.Name)
+		switch {
+		case !lockedUntil.IsZero():
+			logger.Warn("Usuario bloqueado por intentos fallidos", "user", record.Name, "until", lockedUntil.Format(time.DateTime))
+			return fmt.Errorf("%w: contraseña incorrecta, el usuario '%s' queda bloqueado por %s",
+				ErrTooManyFailedLogins, record.Name, stores.LoginLockout.Duration)
+		case remaining > 0:
+			return fmt.Errorf("contraseña incorrecta para el usuario '%s' (intentos restantes: %d)", record.Name, remaining)
+		default:
+			return fmt.Errorf("contraseña incorrecta para el usuario '%s'", record.Name)
+		}
+	}
 
+	stores.ResetLoginFailures(partitionID, record.Name)
 	return nil
 }
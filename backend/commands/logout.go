@@ -2,20 +2,21 @@ package commands
 
 import (
 	stores "backend/stores"
+	"context"
 	"errors"
 )
 
-func ParseLogout(tokens []string) (string, error) {
+func ParseLogout(ctx context.Context, tokens []string) (string, error) {
 	if len(tokens) != 0 {
 		return "", errors.New("el comando logout no acepta parámetros")
 	}
 	// Verifica si hay una sesión activa
-
-	if !stores.Auth.IsAuthenticated() {
-		return "", errors.New("no hay ninguna sesión activa")
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "no hay ninguna sesión activa")
 	}
 
 	// Cierra la sesión
-	stores.Auth.Logout()
+	auth.Logout()
 	return "Sesión terminada", nil
 }
@@ -4,9 +4,11 @@ import (
 	stores "backend/stores"
 	structures "backend/structures"
 	utils "backend/utils"
+	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"log/slog"
+	"path"
 	"regexp"
 	"strings"
 )
@@ -17,7 +19,7 @@ type MKDIR struct {
 	p    bool   // Opción -p (crea directorios padres si no existen)
 }
 
-func ParseMkdir(tokens []string) (string, error) {
+func ParseMkdir(ctx context.Context, tokens []string) (string, error) {
 	cmd := &MKDIR{} // Crea una nueva instancia de MKDIR
 
 	// Unir tokens en una sola cadena y luego dividir por espacios, respetando las comillas
@@ -32,7 +34,7 @@ func ParseMkdir(tokens []string) (string, error) {
 		// Identificar el parámetro inválido
 		for _, token := range tokens {
 			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
 			}
 		}
 	}
@@ -47,7 +49,7 @@ func ParseMkdir(tokens []string) (string, error) {
 		switch key {
 		case "-path":
 			if len(kv) != 2 {
-				return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+				return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 			}
 			value := kv[1]
 			// Remove quotes from value if present
@@ -59,17 +61,17 @@ func ParseMkdir(tokens []string) (string, error) {
 			cmd.p = true
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que el parámetro -path haya sido proporcionado
 	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -path")
 	}
 
 	// Aquí se puede agregar la lógica para ejecutar el comando mkdir con los parámetros proporcionados
-	err := commandMkdir(cmd)
+	err := commandMkdir(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -77,34 +79,56 @@ func ParseMkdir(tokens []string) (string, error) {
 	return fmt.Sprintf("MKDIR: Directorio %s creado correctamente.", cmd.path), nil // Devuelve el comando MKDIR creado
 }
 
-func commandMkdir(mkdir *MKDIR) error {
+func commandMkdir(ctx context.Context, mkdir *MKDIR) error {
+	auth := stores.AuthFromContext(ctx)
+
 	//Obtengo la parción Motada (como siempre)
 	var partitionID string
-	if stores.Auth.IsAuthenticated() {
-		partitionID = stores.Auth.GetPartitionID()
+	if auth.IsAuthenticated() {
+		partitionID = auth.GetPartitionID()
 	} else {
-		return errors.New("no se ha iniciado sesión en ninguna partición")
+		return NewError(CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
 	}
 	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
 
-	//Valido el path
-	cleanPath := strings.TrimSuffix(mkdir.path, "/")
-	if !strings.HasPrefix(cleanPath, "/") {
-		return errors.New("el path debe ser absoluto (empezar con /)")
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
 	}
+	defer structures.CloseDevice(dev)
+
+	//Valido el path (los relativos se resuelven desde el directorio de trabajo)
+	cleanPath := utils.ResolvePath(auth.Cwd, mkdir.path)
 	if cleanPath == "/" {
 		return errors.New("no se puede crear el directorio raíz '/'")
 	}
-	if cleanPath == "" {
-		return errors.New("el path no puede estar vacío")
+
+	// Las carpetas nuevas son del usuario de la sesión y cuentan para su cuota (un inodo y un bloque cada una, más
+	// los bloques que necesite la carpeta donde se agregan)
+	currentUser, _ := auth.GetCurrentUser()
+	owner, err := sessionOwner(partitionSuperblock, dev, currentUser)
+	if err != nil {
+		return err
+	}
+	newFolders := int32(1)
+	if mkdir.p {
+		newFolders = missingDirs(partitionSuperblock, dev, cleanPath)
+	}
+	parentBlocks, err := parentBlocksNeeded(partitionSuperblock, dev, cleanPath, owner)
+	if err != nil {
+		return err
+	}
+	if err := checkQuota(partitionID, partitionSuperblock, dev, owner, newFolders, newFolders+parentBlocks); err != nil {
+		return err
 	}
 
 	//validacion de la p
 	if mkdir.p {
-		fmt.Println("Creando directorios padres si es necesario...")
+		logger.DebugContext(ctx, "Creando directorios padres si es necesario...")
 		components := strings.Split(cleanPath, "/") // Divide el path en componentes
 		currentPathToCheck := "/"
 
@@ -122,10 +146,10 @@ func commandMkdir(mkdir *MKDIR) error {
 				currentPathToCheck += "/" + component
 			}
 
-			fmt.Printf("Verificando/Creando: %s\n", currentPathToCheck)
+			logger.DebugContext(ctx, "Verificando/Creando directorio", "path", currentPathToCheck)
 
 			// Verificar si existe el directorio actual en la secuencia
-			_, inode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, currentPathToCheck)
+			_, inode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, currentPathToCheck)
 
 			if errFind != nil {
 				// Si hay un error, se asueme que el directorio no existe
@@ -133,31 +157,30 @@ func commandMkdir(mkdir *MKDIR) error {
 				// TODO: Sería ideal verificar si el error es específicamente "no encontrado"
 				// pero como no lo haré así se queda xd
 
-				fmt.Printf("Directorio '%s' no encontrado. Intentando crear...\n", currentPathToCheck)
-				parentDirs, destDir := utils.GetParentDirectories(currentPathToCheck)
-				errCreate := partitionSuperblock.CreateFolder(partitionPath, parentDirs, destDir)
+				logger.DebugContext(ctx, "Directorio no encontrado; intentando crear", "path", currentPathToCheck)
+				_, errCreate := createOwnedFolder(partitionSuperblock, dev, currentPathToCheck, owner.UID, owner.GID, folderPerm)
 				if errCreate != nil {
 					return fmt.Errorf("error al crear directorio intermedio '%s': %w", currentPathToCheck, errCreate)
 				}
-				fmt.Printf("Directorio '%s' creado.\n", currentPathToCheck)
+				logger.DebugContext(ctx, "Directorio creado", "path", currentPathToCheck)
 			} else {
 				// Si existe, verificar que sea un directorio
 				if inode.I_type[0] != '0' {
 					return fmt.Errorf("error: '%s' existe pero no es un directorio", currentPathToCheck)
 				}
-				fmt.Printf("Directorio '%s' ya existe.\n", currentPathToCheck)
+				logger.DebugContext(ctx, "El directorio ya existe", "path", currentPathToCheck)
 			}
 		}
 	} else {
 		// si no hay -p, solo verifico el path completo
-		parentPath := filepath.Dir(cleanPath) // Obtengo el directorio padre
-		fmt.Printf("Verificando existencia del directorio padre: %s\n", parentPath)
+		parentPath := path.Dir(cleanPath) // Obtengo el directorio padre
+		logger.DebugContext(ctx, "Verificando existencia del directorio padre", "path", parentPath)
 
 		// Verificar si el padre existe y es un directorio
-		_, parentInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, parentPath)
+		_, parentInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, parentPath)
 		if errFind != nil {
 			// Si hay cualquier error al buscar el padre, asumimos que no existe o es inaccesible
-			return fmt.Errorf("error: no se puede crear '%s', el directorio padre '%s' no existe o no se pudo acceder (%w)", mkdir.path, parentPath, errFind)
+			return NewError(CodeNotFound, "error: no se puede crear '%s', el directorio padre '%s' no existe o no se pudo acceder (%w)", mkdir.path, parentPath, errFind)
 		}
 		if parentInode.I_type[0] != '0' {
 			// El padre existe pero no es un directorio
@@ -165,9 +188,8 @@ func commandMkdir(mkdir *MKDIR) error {
 		}
 
 		// El padre existe y es un directorio, proceder a crear solo el directorio final
-		fmt.Printf("Padre '%s' existe. Creando directorio final '%s'...\n", parentPath, filepath.Base(cleanPath))
-		parentDirs, destDir := utils.GetParentDirectories(cleanPath)
-		errCreate := partitionSuperblock.CreateFolder(partitionPath, parentDirs, destDir)
+		logger.DebugContext(ctx, "Padre existe; creando directorio final", "parent", parentPath, "name", path.Base(cleanPath))
+		_, errCreate := createOwnedFolder(partitionSuperblock, dev, cleanPath, owner.UID, owner.GID, folderPerm)
 		if errCreate != nil {
 			// Aquí podría haber un error si el directorio final ya existe.
 			// CreateFolder debería idealmente retornar un error específico para "ya existe".
@@ -175,15 +197,18 @@ func commandMkdir(mkdir *MKDIR) error {
 		}
 	}
 	//Serializo el superbloque después de crear el directorio
-	fmt.Println("\nSerializando SuperBlock después de MKDIR...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de MKDIR...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		// Nota: Si la serialización falla, los cambios podrían perderse al desmontar/reiniciar.
 		return fmt.Errorf("error al serializar el superbloque después de mkdir: %w", err)
 	}
 
-	partitionSuperblock.PrintInodes(partitionPath)
-	partitionSuperblock.PrintBlocks(partitionPath)
+	// Volcado completo de inodos y bloques, solo con el log de commands en debug
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		partitionSuperblock.PrintInodes(dev)
+		partitionSuperblock.PrintBlocks(dev)
+	}
 
 	return nil 
 }
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	stores "backend/stores"
 	structures "backend/structures"
 	utils "backend/utils"
+	"context"
 	"errors"        // Paquete para manejar errores y crear nuevos errores con mensajes personalizados
 	"fmt"           // Paquete para formatear cadenas y realizar operaciones de entrada/salida
 	"math/rand"     // Paquete para generar números aleatorios
@@ -16,10 +18,11 @@ import (
 
 // MKDISK estructura que representa el comando mkdisk con sus parámetros
 type MKDISK struct {
-	size int    // Tamaño del disco
-	unit string // Unidad de medida del tamaño (K o M)
-	fit  string // Tipo de ajuste (BF, FF, WF)
-	path string // Ruta del archivo del disco
+	size     int             // Tamaño del disco
+	unit     string          // Unidad de medida del tamaño (K o M)
+	fit      string          // Tipo de ajuste (BF, FF, WF)
+	path     string          // Ruta del archivo del disco
+	progress *utils.Progress // Avance de la escritura del disco (nil si nadie lo sigue)
 }
 
 /*
@@ -29,8 +32,8 @@ type MKDISK struct {
     mkdisk -size=10 -path="/home/mis discos/Disco4.mia"
 */
 
-func ParseMkdisk(tokens []string) (string, error) {
-	cmd := &MKDISK{}
+func ParseMkdisk(ctx context.Context, tokens []string) (string, error) {
+	cmd := &MKDISK{progress: utils.ProgressFromContext(ctx)}
 	foundParams := make(map[string]bool)
 
 	originalInput := strings.Join(tokens, " ")
@@ -110,6 +113,12 @@ func ParseMkdisk(tokens []string) (string, error) {
 		return "a", errors.New("falta parámetro requerido: -path")
 	}
 
+	resolvedPath, err := stores.ResolveDiskPath(cmd.path)
+	if err != nil {
+		return "a", err
+	}
+	cmd.path = resolvedPath
+
 	if !foundParams["-unit"] {
 		cmd.unit = "M"
 	}
@@ -117,7 +126,7 @@ func ParseMkdisk(tokens []string) (string, error) {
 		cmd.fit = "FF"
 	}
 
-	err := commandMkdisk(cmd)
+	err = commandMkdisk(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error al ejecutar mkdisk: %w", err)
 	}
@@ -133,44 +142,55 @@ func commandMkdisk(mkdisk *MKDISK) error {
 	// Convertir el tamaño a bytes
 	sizeBytes, err := utils.ConvertToBytes(mkdisk.size, mkdisk.unit)
 	if err != nil {
-		fmt.Println("Error converting size:", err)
+		logger.Debug("Error converting size", "error", err)
 		return err
 	}
 
 	// Crear el disco con el tamaño proporcionado
 	err = createDisk(mkdisk, sizeBytes)
 	if err != nil {
-		fmt.Println("Error creating disk:", err)
+		logger.Debug("Error creating disk", "error", err)
 		return err
 	}
 
 	// Crear el MBR con el tamaño proporcionado
 	err = createMBR(mkdisk, sizeBytes)
 	if err != nil {
-		fmt.Println("Error creating MBR:", err)
+		logger.Debug("Error creating MBR", "error", err)
 		return err
 	}
 
+	stores.RegisterDisk(mkdisk.path)
 	return nil
 }
 
 func createDisk(mkdisk *MKDISK, sizeBytes int) error {
+	// Los discos en memoria no tocan el sistema de archivos del host
+	if structures.IsMemoryPath(mkdisk.path) {
+		_, err := structures.CreateMemoryDevice(mkdisk.path, int64(sizeBytes))
+		return err
+	}
+
 	// Crear las carpetas necesarias
 	err := os.MkdirAll(filepath.Dir(mkdisk.path), os.ModePerm)
 	if err != nil {
-		fmt.Println("Error creating directories:", err)
+		logger.Debug("Error creating directories", "error", err)
 		return err
 	}
 
+	// Si el disco ya estaba abierto, su caché deja de ser válida al recrear el archivo
+	structures.DiscardDevice(mkdisk.path)
+
 	// Crear el archivo binario
 	file, err := os.Create(mkdisk.path)
 	if err != nil {
-		fmt.Println("Error creating file:", err)
+		logger.Debug("Error creating file", "error", err)
 		return err
 	}
 	defer file.Close()
 
 	// Escribir en el archivo usando un buffer de 1 MB
+	mkdisk.progress.Start(int64(sizeBytes), "bytes")
 	buffer := make([]byte, 1024*1024) // Crea un buffer de 1 MB
 	for sizeBytes > 0 {
 		writeSize := len(buffer)
@@ -181,6 +201,7 @@ func createDisk(mkdisk *MKDISK, sizeBytes int) error {
 			return err // Devuelve un error si la escritura falla
 		}
 		sizeBytes -= writeSize // Resta el tamaño escrito del tamaño total
+		mkdisk.progress.Add(int64(writeSize))
 	}
 	return nil
 }
@@ -196,7 +217,7 @@ func createMBR(mkdisk *MKDISK, sizeBytes int) error {
 	case "WF":
 		fitByte = 'W'
 	default:
-		fmt.Println("Invalid fit type")
+		logger.Debug("Invalid fit type")
 		return nil
 	}
 
@@ -218,13 +239,19 @@ func createMBR(mkdisk *MKDISK, sizeBytes int) error {
 
 	/* SOLO PARA VERIFICACIÓN */
 	// Imprimir MBR
-	fmt.Println("\nMBR creado:")
-	mbr.PrintMBR()
+	logger.Debug("MBR creado", "mbr", mbr)
+
+	// Abrir el dispositivo recién creado
+	dev, err := structures.OpenDevice(mkdisk.path)
+	if err != nil {
+		return err
+	}
+	defer structures.CloseDevice(dev)
 
-	// Serializar el MBR en el archivo
-	err := mbr.Serialize(mkdisk.path)
+	// Serializar el MBR en el dispositivo
+	err = mbr.Serialize(dev)
 	if err != nil {
-		fmt.Println("Error:", err)
+		return fmt.Errorf("error al serializar el MBR: %w", err)
 	}
 	return nil
 }
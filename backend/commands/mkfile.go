@@ -1,14 +1,16 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os" // Necesario para leer archivo con -cont
-	"path/filepath"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	sandbox "backend/sandbox"
 	stores "backend/stores"
 	structures "backend/structures"
 	utils "backend/utils"
@@ -23,7 +25,7 @@ type MKFILE struct {
 }
 
 // ParseMkfile analiza los tokens para el comando mkfile
-func ParseMkfile(tokens []string) (string, error) {
+func ParseMkfile(ctx context.Context, tokens []string) (string, error) {
 	cmd := &MKFILE{size: 0} // Inicializar tamaño a 0 por defecto
 
 	args := strings.Join(tokens, " ")
@@ -85,19 +87,24 @@ func ParseMkfile(tokens []string) (string, error) {
 	}
 	// Validaciones obligatorias
 	if cmd.path == "" {
-		return "", errors.New("parámetro obligatorio faltante: -path")
+		return "", NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -path")
 	}
 	if cmd.cont != "" && cmd.size != 0 && len(matches) > 0 {
-		fmt.Println("Parámetro -size ignorado porque -cont fue proporcionado.")
+		logger.DebugContext(ctx, "Parámetro -size ignorado porque -cont fue proporcionado.")
 		cmd.size = 0
 	}
-	// Validar existencia de archivo en -cont si se proporcionó
+	// Validar que el archivo de -cont esté en la carpeta de importación y que exista
 	if cmd.cont != "" {
+		contentPath, err := sandbox.Imports.Resolve(cmd.cont)
+		if err != nil {
+			return "", err
+		}
+		cmd.cont = contentPath
 		if _, err := os.Stat(cmd.cont); os.IsNotExist(err) {
 			return "", fmt.Errorf("el archivo especificado en -cont no existe: %s", cmd.cont)
 		}
 	}
-	err := commandMkfile(cmd)
+	err := commandMkfile(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
@@ -105,17 +112,18 @@ func ParseMkfile(tokens []string) (string, error) {
 }
 
 // commandMkfile contiene la lógica principal para crear el archivo
-func commandMkfile(mkfile *MKFILE) error {
+func commandMkfile(ctx context.Context, mkfile *MKFILE) error {
+	auth := stores.AuthFromContext(ctx)
+
 	//Obtener Autenticación y Partición Montada
-	var userID int32 = 1 
-	var groupID int32 = 1 
 	var partitionID string
+	var currentUser string
 
-	if stores.Auth.IsAuthenticated() {
-		partitionID = stores.Auth.GetPartitionID()
-		fmt.Printf("Usuario autenticado: %s (Usando UID=1, GID=1 por defecto)\n", stores.Auth.Username)
+	if auth.IsAuthenticated() {
+		currentUser, partitionID = auth.GetCurrentUser()
+		logger.DebugContext(ctx, "Usuario autenticado", "user", currentUser)
 	} else {
-		return errors.New("no se ha iniciado sesión en ninguna partición")
+		return NewError(CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
 	}
 
 	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
@@ -123,25 +131,26 @@ func commandMkfile(mkfile *MKFILE) error {
 		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
 	// Validar tamaños para división por cero
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
 		return fmt.Errorf("tamaño de inodo o bloque inválido en superbloque: inode=%d, block=%d", partitionSuperblock.S_inode_size, partitionSuperblock.S_block_size)
 	}
 
-	// Limpiar Path y Obtener Padre/Nombre
-	cleanPath := strings.TrimSuffix(mkfile.path, "/")
-	if !strings.HasPrefix(cleanPath, "/") {
-		return errors.New("el path debe ser absoluto (empezar con /)")
-	}
+	// Limpiar Path (los relativos se resuelven desde el directorio de trabajo) y Obtener Padre/Nombre
+	cleanPath := utils.ResolvePath(auth.Cwd, mkfile.path)
 	if cleanPath == "/" {
 		return errors.New("no se puede crear archivo en la raíz '/' con este comando")
 	}
-	if cleanPath == "" {
-		return errors.New("el path no puede estar vacío")
-	}
 
-	parentPath := filepath.Dir(cleanPath)
-	fileName := filepath.Base(cleanPath)
+	parentPath := path.Dir(cleanPath)
+	fileName := path.Base(cleanPath)
 	if fileName == "" || fileName == "." || fileName == ".." {
 		return fmt.Errorf("nombre de archivo inválido: %s", fileName)
 	}
@@ -149,32 +158,12 @@ func commandMkfile(mkfile *MKFILE) error {
 		return fmt.Errorf("el nombre del archivo '%s' excede los 12 caracteres permitidos", fileName)
 	}
 
-	// Asegurar que el nombre no contenga caracteres inválidos
-	fmt.Printf("Asegurando directorio padre: %s\n", parentPath)
-	parentInodeIndex, parentInode, err := ensureParentDirExists(parentPath, mkfile.r, partitionSuperblock, partitionPath)
-	if err != nil {
-		return err 
-	}
-
-	fmt.Printf("Verificando si '%s' ya existe en inodo %d...\n", fileName, parentInodeIndex)
-	exists, _, existingInodeType := findEntryInParent(parentInode, fileName, partitionSuperblock, partitionPath)
-	if exists {
-		existingTypeStr := "elemento"
-		if existingInodeType == '0' {
-			existingTypeStr = "directorio"
-		}
-		if existingInodeType == '1' {
-			existingTypeStr = "archivo"
-		}
-		return fmt.Errorf("error: el %s '%s' ya existe en '%s'", existingTypeStr, fileName, parentPath)
-	}
-
 	// Determinar Contenido y Tamaño
 	var contentBytes []byte
 	var fileSize int32
 
 	if mkfile.cont != "" {
-		fmt.Printf("Leyendo contenido desde archivo local: %s\n", mkfile.cont)
+		logger.DebugContext(ctx, "Leyendo contenido desde archivo local", "path", mkfile.cont)
 		hostContent, errRead := os.ReadFile(mkfile.cont)
 		if errRead != nil {
 			return fmt.Errorf("error leyendo archivo de contenido '%s': %w", mkfile.cont, errRead)
@@ -184,7 +173,7 @@ func commandMkfile(mkfile *MKFILE) error {
 	} else {
 		fileSize = int32(mkfile.size)
 		if fileSize > 0 {
-			fmt.Printf("Generando contenido de %d bytes (0-9 repetido)...\n", fileSize)
+			logger.DebugContext(ctx, "Generando contenido (0-9 repetido)", "bytes", fileSize)
 			contentBuilder := strings.Builder{}
 			for i := int32(0); i < fileSize; i++ {
 				contentBuilder.WriteByte(byte('0' + (i % 10)))
@@ -194,7 +183,45 @@ func commandMkfile(mkfile *MKFILE) error {
 			contentBytes = []byte{}
 		}
 	}
-	fmt.Printf("Tamaño final del archivo: %d bytes\n", fileSize)
+	logger.DebugContext(ctx, "Tamaño final del archivo", "bytes", fileSize)
+
+	// El archivo (y las carpetas que cree -r) son del usuario de la sesión y cuentan para su cuota
+	owner, err := sessionOwner(partitionSuperblock, dev, currentUser)
+	if err != nil {
+		return err
+	}
+	newFolders := int32(0)
+	if mkfile.r {
+		newFolders = missingDirs(partitionSuperblock, dev, parentPath)
+	}
+	parentBlocks, err := parentBlocksNeeded(partitionSuperblock, dev, cleanPath, owner)
+	if err != nil {
+		return err
+	}
+	err = checkQuota(partitionID, partitionSuperblock, dev, owner, 1+newFolders, fileBlocksNeeded(fileSize, partitionSuperblock.S_block_size)+newFolders+parentBlocks)
+	if err != nil {
+		return err
+	}
+
+	// Asegurar que el nombre no contenga caracteres inválidos
+	logger.DebugContext(ctx, "Asegurando directorio padre", "path", parentPath)
+	parentInodeIndex, parentInode, err := ensureParentDirExists(parentPath, mkfile.r, owner, partitionSuperblock, dev)
+	if err != nil {
+		return err 
+	}
+
+	logger.DebugContext(ctx, "Verificando si el archivo ya existe", "name", fileName, "parent_inode", parentInodeIndex)
+	exists, _, existingInodeType := findEntryInParent(parentInode, fileName, partitionSuperblock, dev)
+	if exists {
+		existingTypeStr := "elemento"
+		if existingInodeType == '0' {
+			existingTypeStr = "directorio"
+		}
+		if existingInodeType == '1' {
+			existingTypeStr = "archivo"
+		}
+		return NewError(CodeAlreadyExists, "error: el %s '%s' ya existe en '%s'", existingTypeStr, fileName, parentPath)
+	}
 
 	// Calcular bloques necesarios 
 	blockSize := partitionSuperblock.S_block_size
@@ -204,17 +231,17 @@ func commandMkfile(mkfile *MKFILE) error {
 	}
 
 	// Asignar Bloques de Datos y Punteros
-	fmt.Printf("Asignando %d bloque(s) de datos y punteros necesarios...\n", numBlocksNeeded)
+	logger.DebugContext(ctx, "Asignando bloques de datos y punteros necesarios", "blocks", numBlocksNeeded)
 	var allocatedBlockIndices [15]int32
-	allocatedBlockIndices, err = allocateDataBlocks(contentBytes, fileSize, partitionSuperblock, partitionPath)
+	allocatedBlockIndices, err = allocateDataBlocks(contentBytes, fileSize, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("falló la asignación de bloques: %w", err)
 	}
 
 	// Asignar Inodo
-	fmt.Println("Asignando inodo...")
+	logger.DebugContext(ctx, "Asignando inodo...")
 	newInodeIndex := (partitionSuperblock.S_first_ino - partitionSuperblock.S_inode_start) / partitionSuperblock.S_inode_size
-	err = partitionSuperblock.UpdateBitmapInode(partitionPath, newInodeIndex)
+	err = partitionSuperblock.UpdateBitmapInode(dev, newInodeIndex)
 	if err != nil {
 		return fmt.Errorf("error actualizando bitmap para inodo %d: %w", newInodeIndex, err)
 	}
@@ -224,28 +251,28 @@ func commandMkfile(mkfile *MKFILE) error {
 	// Crear y Serializar Estructura Inodo
 	currentTime := float32(time.Now().Unix())
 	newInode := &structures.Inode{
-		I_uid: userID, I_gid: groupID, I_size: fileSize,
+		I_uid: owner.UID, I_gid: owner.GID, I_size: fileSize,
 		I_atime: currentTime, I_ctime: currentTime, I_mtime: currentTime,
 		I_type: [1]byte{'1'}, I_perm: [3]byte{'6', '6', '4'},
 	}
 	newInode.I_block = allocatedBlockIndices
 
 	inodeOffset := int64(partitionSuperblock.S_inode_start) + int64(newInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = newInode.Serialize(partitionPath, inodeOffset)
+	err = newInode.Serialize(dev, inodeOffset)
 	if err != nil {
 		return fmt.Errorf("error serializando nuevo inodo %d: %w", newInodeIndex, err)
 	}
 
 	// Añadir Entrada al Directorio Padre
-	fmt.Printf("Añadiendo entrada '%s' al directorio padre (inodo %d)...\n", fileName, parentInodeIndex)
-	err = addEntryToParent(parentInodeIndex, fileName, newInodeIndex, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Añadiendo entrada al directorio padre", "name", fileName, "parent_inode", parentInodeIndex)
+	err = addEntryToParent(parentInodeIndex, fileName, newInodeIndex, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("error añadiendo entrada '%s' al directorio padre: %w", fileName, err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("\nSerializando SuperBlock después de MKFILE...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de MKFILE...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque después de mkfile: %w", err)
 	}
@@ -253,13 +280,13 @@ func commandMkfile(mkfile *MKFILE) error {
 }
 
 // Retorna el índice y el inodo del padre directo si todo va bien.
-func ensureParentDirExists(targetParentPath string, createRecursively bool, sb *structures.SuperBlock, partitionPath string) (int32, *structures.Inode, error) {
-	fmt.Printf("Asegurando que exista: %s (Recursivo: %v)\n", targetParentPath, createRecursively)
+func ensureParentDirExists(targetParentPath string, createRecursively bool, owner Owner, sb *structures.SuperBlock, dev structures.BlockDevice) (int32, *structures.Inode, error) {
+	logger.Debug("Asegurando que exista el directorio", "path", targetParentPath, "recursive", createRecursively)
 	//El padre es la raíz "/"
 	if targetParentPath == "/" {
 		inode := &structures.Inode{}
 		offset := int64(sb.S_inode_start) // Raíz es inodo 0
-		err := inode.Deserialize(partitionPath, offset)
+		err := inode.Deserialize(dev, offset)
 		if err != nil {
 			return -1, nil, fmt.Errorf("error crítico: no se pudo deserializar inodo raíz (0): %w", err)
 		}
@@ -270,7 +297,7 @@ func ensureParentDirExists(targetParentPath string, createRecursively bool, sb *
 	}
 
 	// Verificar si el padre objetivo ya existe
-	parentInodeIndex, parentInode, errFind := structures.FindInodeByPath(sb, partitionPath, targetParentPath)
+	parentInodeIndex, parentInode, errFind := structures.FindInodeByPath(sb, dev, targetParentPath)
 
 	if errFind == nil { // Padre encontrado
 		// Verificar si es un directorio
@@ -278,38 +305,37 @@ func ensureParentDirExists(targetParentPath string, createRecursively bool, sb *
 			return -1, nil, fmt.Errorf("error: el path padre '%s' existe pero no es un directorio", targetParentPath)
 		}
 		// Padre existe y es directorio, todo bien
-		fmt.Printf("Directorio padre '%s' (inodo %d) encontrado.\n", targetParentPath, parentInodeIndex)
+		logger.Debug("Directorio padre encontrado", "path", targetParentPath, "inode", parentInodeIndex)
 		return parentInodeIndex, parentInode, nil
 	}
 
 	// Padre no encontrado
-	fmt.Printf("Padre '%s' no encontrado (%v).\n", targetParentPath, errFind)
+	logger.Debug("Directorio padre no encontrado", "path", targetParentPath, "error", errFind)
 	if !createRecursively {
 		// Si no es recursivo, fallamos
-		return -1, nil, fmt.Errorf("el directorio padre '%s' no existe y la opción -r no fue especificada", targetParentPath)
+		return -1, nil, NewError(CodeNotFound, "el directorio padre '%s' no existe y la opción -r no fue especificada", targetParentPath)
 	}
 
 	//Intentar crear el padre
-	grandParentPath := filepath.Dir(targetParentPath)
-	parentDirName := filepath.Base(targetParentPath)
+	grandParentPath := path.Dir(targetParentPath)
+	parentDirName := path.Base(targetParentPath)
 
-	_, _, errEnsureGrandParent := ensureParentDirExists(grandParentPath, true, sb, partitionPath) // Llamada recursiva
+	_, _, errEnsureGrandParent := ensureParentDirExists(grandParentPath, true, owner, sb, dev) // Llamada recursiva
 	if errEnsureGrandParent != nil {
 		// Si falla crear el abuelo, no podemos crear el padre
 		return -1, nil, fmt.Errorf("error asegurando ancestro '%s': %w", grandParentPath, errEnsureGrandParent)
 	}
 
 	// Ahora que el abuelo, creamos el padre
-	fmt.Printf("Creando directorio padre faltante: '%s' dentro de '%s'\n", parentDirName, grandParentPath)
-	parentDirsForCreate, destDirForCreate := utils.GetParentDirectories(targetParentPath)
-	errCreate := sb.CreateFolder(partitionPath, parentDirsForCreate, destDirForCreate)
+	logger.Debug("Creando directorio padre faltante", "name", parentDirName, "parent", grandParentPath)
+	_, errCreate := createOwnedFolder(sb, dev, targetParentPath, owner.UID, owner.GID, folderPerm)
 	if errCreate != nil {
 		return -1, nil, fmt.Errorf("falló la creación recursiva del directorio padre '%s': %w", targetParentPath, errCreate)
 	}
 
 	// Si llegamos aquí, buscamos de nuevo el padre recién creado
-	fmt.Printf("Verificando padre recién creado '%s'\n", targetParentPath)
-	parentInodeIndex, parentInode, errFindAgain := structures.FindInodeByPath(sb, partitionPath, targetParentPath)
+	logger.Debug("Verificando directorio padre recién creado", "path", targetParentPath)
+	parentInodeIndex, parentInode, errFindAgain := structures.FindInodeByPath(sb, dev, targetParentPath)
 	if errFindAgain != nil {
 		return -1, nil, fmt.Errorf("error crítico: no se encontró el directorio padre '%s' después de crearlo: %w", targetParentPath, errFindAgain)
 	}
@@ -317,12 +343,12 @@ func ensureParentDirExists(targetParentPath string, createRecursively bool, sb *
 		return -1, nil, fmt.Errorf("error crítico: el directorio padre '%s' recién creado no es un directorio", targetParentPath)
 	}
 
-	fmt.Printf("Directorio padre '%s' (inodo %d) creado y verificado.\n", targetParentPath, parentInodeIndex)
+	logger.Debug("Directorio padre creado y verificado", "path", targetParentPath, "inode", parentInodeIndex)
 	return parentInodeIndex, parentInode, nil
 }
 
 // Retorna si existe, el índice del inodo encontrado y su tipo
-func findEntryInParent(parentInode *structures.Inode, entryName string, sb *structures.SuperBlock, partitionPath string) (exists bool, foundInodeIndex int32, foundInodeType byte) {
+func findEntryInParent(parentInode *structures.Inode, entryName string, sb *structures.SuperBlock, dev structures.BlockDevice) (exists bool, foundInodeIndex int32, foundInodeType byte) {
 	exists = false
 	foundInodeIndex = -1
 	foundInodeType = '?'
@@ -341,8 +367,8 @@ func findEntryInParent(parentInode *structures.Inode, entryName string, sb *stru
 
 		folderBlock := &structures.FolderBlock{}
 		offset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-		if err := folderBlock.Deserialize(partitionPath, offset); err != nil {
-			fmt.Printf("Advertencia: No se pudo leer el bloque de directorio %d al buscar '%s'\n", blockPtr, entryName)
+		if err := folderBlock.Deserialize(dev, offset); err != nil {
+			logger.Warn("No se pudo leer el bloque de directorio al buscar la entrada", "block", blockPtr, "name", entryName)
 			continue
 		}
 
@@ -354,7 +380,7 @@ func findEntryInParent(parentInode *structures.Inode, entryName string, sb *stru
 					foundInodeIndex = content.B_inodo
 					tempInode := &structures.Inode{}
 					tempOffset := int64(sb.S_inode_start) + int64(foundInodeIndex)*int64(sb.S_inode_size)
-					if err := tempInode.Deserialize(partitionPath, tempOffset); err == nil {
+					if err := tempInode.Deserialize(dev, tempOffset); err == nil {
 						foundInodeType = tempInode.I_type[0]
 					}
 					return
@@ -365,11 +391,11 @@ func findEntryInParent(parentInode *structures.Inode, entryName string, sb *stru
 	return
 }
 
-func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex int32, sb *structures.SuperBlock, partitionPath string) error {
+func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex int32, sb *structures.SuperBlock, dev structures.BlockDevice) error {
 
 	parentInode := &structures.Inode{}
 	parentOffset := int64(sb.S_inode_start) + int64(parentInodeIndex)*int64(sb.S_inode_size)
-	if err := parentInode.Deserialize(partitionPath, parentOffset); err != nil {
+	if err := parentInode.Deserialize(dev, parentOffset); err != nil {
 		return fmt.Errorf("no se pudo leer inodo padre %d para añadir entrada: %w", parentInodeIndex, err)
 	}
 	if parentInode.I_type[0] != '0' {
@@ -377,21 +403,21 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 	}
 
 	// Buscar slot libre en bloques existentes
-	fmt.Printf("Buscando slot libre en bloques existentes del padre %d...\n", parentInodeIndex)
+	logger.Debug("Buscando slot libre en bloques existentes del padre", "parent_inode", parentInodeIndex)
 	// Función auxiliar para buscar en un bloque carpeta
 	findAndAddInFolderBlock := func(blockPtr int32) (bool, error) {
 		if blockPtr == -1 {
 			return false, nil
 		} // No es un bloque válido
 		if blockPtr < 0 || blockPtr >= sb.S_blocks_count {
-			fmt.Printf("Advertencia: Puntero inválido %d encontrado al buscar slot libre.\n", blockPtr)
+			logger.Warn("Puntero inválido al buscar slot libre", "block", blockPtr)
 			return false, nil // Saltar puntero inválido
 		}
 
 		folderBlock := &structures.FolderBlock{}
 		blockOffset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-		if err := folderBlock.Deserialize(partitionPath, blockOffset); err != nil {
-			fmt.Printf("Advertencia: No se pudo leer bloque %d del padre %d para añadir entrada\n", blockPtr, parentInodeIndex)
+		if err := folderBlock.Deserialize(dev, blockOffset); err != nil {
+			logger.Warn("No se pudo leer el bloque del padre para añadir la entrada", "block", blockPtr, "parent_inode", parentInodeIndex)
 			return false, nil
 		}
 
@@ -401,17 +427,17 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 			isDotDot := (nameBytes[0] == '.' && nameBytes[1] == '.' && (len(nameBytes) < 3 || nameBytes[2] == 0))
 
 			if folderBlock.B_content[i].B_inodo == -1 && !isDot && !isDotDot { // Slot libre encontrado!
-				fmt.Printf("Encontrado slot libre %d en bloque existente %d del padre %d\n", i, blockPtr, parentInodeIndex)
+				logger.Debug("Slot libre encontrado en bloque existente", "slot", i, "block", blockPtr, "parent_inode", parentInodeIndex)
 				folderBlock.B_content[i].B_inodo = entryInodeIndex
 				copy(folderBlock.B_content[i].B_name[:], entryName)
-				if err := folderBlock.Serialize(partitionPath, blockOffset); err != nil { // Serializar bloque modificado
+				if err := folderBlock.Serialize(dev, blockOffset); err != nil { // Serializar bloque modificado
 					return false, fmt.Errorf("falló al escribir la nueva entrada en el bloque existente %d: %w", blockPtr, err)
 				}
 				// Actualizar tiempos del padre y serializar padre
 				currentTime := float32(time.Now().Unix())
 				parentInode.I_mtime = currentTime
 				parentInode.I_atime = currentTime
-				if err := parentInode.Serialize(partitionPath, parentOffset); err != nil {
+				if err := parentInode.Serialize(dev, parentOffset); err != nil {
 					return false, fmt.Errorf("falló al actualizar tiempos del inodo padre %d tras añadir en bloque existente: %w", parentInodeIndex, err)
 				}
 				return true, nil
@@ -433,10 +459,10 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 
 	// Buscar en bloques apuntados por indirecto simple
 	if parentInode.I_block[12] != -1 {
-		fmt.Printf("Buscando slot libre en bloques de indirección simple (L1 en %d)...\n", parentInode.I_block[12])
+		logger.Debug("Buscando slot libre en bloques de indirección simple", "pointer_block", parentInode.I_block[12])
 		l1Block := &structures.PointerBlock{}
 		l1Offset := int64(sb.S_block_start) + int64(parentInode.I_block[12])*int64(sb.S_block_size)
-		if err := l1Block.Deserialize(partitionPath, l1Offset); err == nil {
+		if err := l1Block.Deserialize(dev, l1Offset); err == nil {
 			for _, folderBlockPtr := range l1Block.P_pointers {
 				found, err := findAndAddInFolderBlock(folderBlockPtr)
 				if err != nil {
@@ -447,12 +473,12 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 				}
 			}
 		} else {
-			fmt.Printf("Advertencia: No se pudo leer el bloque de punteros L1 %d\n", parentInode.I_block[12])
+			logger.Warn("No se pudo leer el bloque de punteros L1", "block", parentInode.I_block[12])
 		}
 	}
 
 	//Si no se encontró slot, buscar un PUNTERO libre para un NUEVO bloque
-	fmt.Printf("No se encontró slot libre en bloques existentes del padre %d. Buscando puntero libre...\n", parentInodeIndex)
+	logger.Debug("No hay slot libre en bloques existentes del padre; buscando puntero libre", "parent_inode", parentInodeIndex)
 
 	// Función auxiliar para asignar y preparar un nuevo bloque carpeta
 	allocateAndPrepareNewFolderBlock := func() (int32, *structures.FolderBlock, error) {
@@ -465,7 +491,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 			return -1, nil, errors.New("error interno: S_first_blo fuera de límites al asignar nuevo bloque")
 		}
 		// Actualizar bitmap y SB
-		err := sb.UpdateBitmapBlock(partitionPath, newBlockIndex)
+		err := sb.UpdateBitmapBlock(dev, newBlockIndex)
 		if err != nil {
 			return -1, nil, fmt.Errorf("error bitmap para nuevo bloque dir %d: %w", newBlockIndex, err)
 		}
@@ -477,17 +503,17 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 			newFolderBlock.B_content[i].B_inodo = -1
 		}
 		newBlockOffset := int64(sb.S_block_start) + int64(newBlockIndex)*int64(sb.S_block_size)
-		if err := newFolderBlock.Serialize(partitionPath, newBlockOffset); err != nil {
+		if err := newFolderBlock.Serialize(dev, newBlockOffset); err != nil {
 			return -1, nil, fmt.Errorf("falló al inicializar/serializar nuevo bloque dir %d: %w", newBlockIndex, err)
 		}
-		fmt.Printf("Nuevo bloque carpeta vacío asignado y serializado en índice %d\n", newBlockIndex)
+		logger.Debug("Nuevo bloque carpeta vacío asignado", "block", newBlockIndex)
 		return newBlockIndex, newFolderBlock, nil // Devuelve índice Y el bloque en memoria
 	}
 
 	// Buscar en punteros directos
 	for k := 0; k < 12; k++ {
 		if parentInode.I_block[k] == -1 {
-			fmt.Printf("Encontrado puntero directo libre en I_block[%d]. Asignando nuevo bloque carpeta...\n", k)
+			logger.Debug("Puntero directo libre encontrado; asignando nuevo bloque carpeta", "pointer", k)
 			newBlockIndex, newFolderBlock, err := allocateAndPrepareNewFolderBlock()
 			if err != nil {
 				return err
@@ -498,7 +524,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 			currentTime := float32(time.Now().Unix())
 			parentInode.I_mtime = currentTime
 			parentInode.I_atime = currentTime
-			if err := parentInode.Serialize(partitionPath, parentOffset); err != nil {
+			if err := parentInode.Serialize(dev, parentOffset); err != nil {
 				return fmt.Errorf("falló al actualizar I_block[%d] del padre %d: %w", k, parentInodeIndex, err)
 			}
 
@@ -507,22 +533,22 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 			newFolderBlock.B_content[0].B_inodo = entryInodeIndex
 			copy(newFolderBlock.B_content[0].B_name[:], entryName)
 			newBlockOffset := int64(sb.S_block_start) + int64(newBlockIndex)*int64(sb.S_block_size)
-			if err := newFolderBlock.Serialize(partitionPath, newBlockOffset); err != nil { // Sobrescribir con la entrada añadida
+			if err := newFolderBlock.Serialize(dev, newBlockOffset); err != nil { // Sobrescribir con la entrada añadida
 				return fmt.Errorf("falló al serializar nuevo bloque dir %d con la entrada: %w", newBlockIndex, err)
 			}
-			fmt.Printf("Nueva entrada '%s' -> %d añadida al nuevo bloque %d vía puntero directo.\n", entryName, entryInodeIndex, newBlockIndex)
+			logger.Debug("Entrada añadida al nuevo bloque vía puntero directo", "name", entryName, "inode", entryInodeIndex, "block", newBlockIndex)
 			return nil
 		}
 	}
 
 	// Buscar en puntero indirecto simple
-	fmt.Println("Punteros directos llenos. Verificando indirección simple (I_block[12])...")
+	logger.Debug("Punteros directos llenos. Verificando indirección simple (I_block[12])...")
 	l1Ptr := parentInode.I_block[12]
 	var l1Block *structures.PointerBlock
 	var l1BlockIndex int32
 
 	if l1Ptr == -1 { // Necesitamos crear el bloque L1
-		fmt.Println("I_block[12] no existe. Creando bloque de punteros L1...")
+		logger.Debug("I_block[12] no existe. Creando bloque de punteros L1...")
 		if sb.S_free_blocks_count < 2 { // Necesitamos espacio para L1 y para el nuevo FolderBlock
 			return errors.New("no hay suficientes bloques libres para crear bloque L1 y bloque de carpeta")
 		}
@@ -531,7 +557,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 		if l1BlockIndex >= sb.S_blocks_count {
 			return errors.New("error interno: S_first_blo fuera de límites al asignar L1")
 		}
-		err := sb.UpdateBitmapBlock(partitionPath, l1BlockIndex)
+		err := sb.UpdateBitmapBlock(dev, l1BlockIndex)
 		if err != nil {
 			return fmt.Errorf("error bitmap para bloque L1 %d: %w", l1BlockIndex, err)
 		}
@@ -543,7 +569,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 		currentTime := float32(time.Now().Unix())
 		parentInode.I_mtime = currentTime
 		parentInode.I_atime = currentTime
-		if err := parentInode.Serialize(partitionPath, parentOffset); err != nil {
+		if err := parentInode.Serialize(dev, parentOffset); err != nil {
 			return fmt.Errorf("falló al actualizar I_block[12] del padre %d: %w", parentInodeIndex, err)
 		}
 
@@ -552,13 +578,13 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 		for i := range l1Block.P_pointers {
 			l1Block.P_pointers[i] = -1
 		}
-		fmt.Printf("Bloque punteros L1 creado en índice %d\n", l1BlockIndex)
+		logger.Debug("Bloque de punteros L1 creado", "block", l1BlockIndex)
 	} else { // El bloque L1 ya existe
 		l1BlockIndex = l1Ptr
-		fmt.Printf("Bloque punteros L1 ya existe en índice %d. Cargando...\n", l1BlockIndex)
+		logger.Debug("Bloque de punteros L1 existente; cargando", "block", l1BlockIndex)
 		l1Block = &structures.PointerBlock{}
 		l1Offset := int64(sb.S_block_start) + int64(l1BlockIndex)*int64(sb.S_block_size)
-		if err := l1Block.Deserialize(partitionPath, l1Offset); err != nil {
+		if err := l1Block.Deserialize(dev, l1Offset); err != nil {
 			return fmt.Errorf("no se pudo leer bloque de punteros L1 %d existente: %w", l1BlockIndex, err)
 		}
 	}
@@ -573,7 +599,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 	}
 
 	if foundL1PointerSlot != -1 {
-		fmt.Printf("Encontrado puntero libre en L1[%d]. Asignando nuevo bloque carpeta...\n", foundL1PointerSlot)
+		logger.Debug("Puntero libre encontrado en L1; asignando nuevo bloque carpeta", "pointer", foundL1PointerSlot)
 		// Asignar el nuevo bloque carpeta (ya verifica espacio libre)
 		newBlockIndex, newFolderBlock, err := allocateAndPrepareNewFolderBlock()
 		if err != nil {
@@ -583,7 +609,7 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 		// Actualizar el bloque L1 para que apunte al nuevo bloque
 		l1Block.P_pointers[foundL1PointerSlot] = newBlockIndex
 		l1Offset := int64(sb.S_block_start) + int64(l1BlockIndex)*int64(sb.S_block_size)
-		if err := l1Block.Serialize(partitionPath, l1Offset); err != nil {
+		if err := l1Block.Serialize(dev, l1Offset); err != nil {
 			return fmt.Errorf("falló al serializar bloque puntero L1 %d actualizado: %w", l1BlockIndex, err)
 		}
 
@@ -591,17 +617,17 @@ func addEntryToParent(parentInodeIndex int32, entryName string, entryInodeIndex
 		newFolderBlock.B_content[0].B_inodo = entryInodeIndex
 		copy(newFolderBlock.B_content[0].B_name[:], entryName)
 		newBlockOffset := int64(sb.S_block_start) + int64(newBlockIndex)*int64(sb.S_block_size)
-		if err := newFolderBlock.Serialize(partitionPath, newBlockOffset); err != nil {
+		if err := newFolderBlock.Serialize(dev, newBlockOffset); err != nil {
 			return fmt.Errorf("falló al serializar nuevo bloque dir %d con la entrada: %w", newBlockIndex, err)
 		}
-		fmt.Printf("Nueva entrada '%s' -> %d añadida al nuevo bloque %d vía puntero indirecto simple.\n", entryName, entryInodeIndex, newBlockIndex)
+		logger.Debug("Entrada añadida al nuevo bloque vía puntero indirecto simple", "name", entryName, "inode", entryInodeIndex, "block", newBlockIndex)
 		return nil
 	}
 
 	return fmt.Errorf("directorio padre (inodo %d) lleno: no hay espacio en bloques existentes ni en punteros directos/indirectos simples. Indirección doble/triple no implementada para directorios", parentInodeIndex)
 }
 
-func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.SuperBlock, partitionPath string) ([15]int32, error) {
+func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.SuperBlock, dev structures.BlockDevice) ([15]int32, error) {
 	allocatedBlockIndices := [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1} // Inicializar I_block con -1
 
 	if fileSize == 0 {
@@ -611,7 +637,7 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 	blockSize := sb.S_block_size
 	numBlocksNeeded := (fileSize + blockSize - 1) / blockSize
 
-	fmt.Printf("Allocate: Necesitando %d bloques para %d bytes (tamaño bloque: %d)\n", numBlocksNeeded, fileSize, blockSize)
+	logger.Debug("Asignando bloques de datos", "blocks", numBlocksNeeded, "bytes", fileSize, "block_size", blockSize)
 
 	directLimit := int32(12)
 	simpleLimit := directLimit + 16                                      // 12 + 16 = 28
@@ -643,7 +669,7 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 		}
 
 		// Actualizar bitmap y SB para el bloque de DATOS
-		err := sb.UpdateBitmapBlock(partitionPath, dataBlockIndex)
+		err := sb.UpdateBitmapBlock(dev, dataBlockIndex)
 		if err != nil {
 			return allocatedBlockIndices, fmt.Errorf("error bitmap bloque datos %d: %w", dataBlockIndex, err)
 		}
@@ -660,7 +686,7 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 		bytesToWrite := contentBytes[start:end]
 		copy(fileBlock.B_content[:], bytesToWrite)
 		blockOffset := int64(sb.S_block_start) + int64(dataBlockIndex)*int64(sb.S_block_size)
-		err = fileBlock.Serialize(partitionPath, blockOffset)
+		err = fileBlock.Serialize(dev, blockOffset)
 		if err != nil {
 			return allocatedBlockIndices, fmt.Errorf("error serializando bloque datos %d: %w", dataBlockIndex, err)
 		}
@@ -668,24 +694,24 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 		// Directos (0-11)
 		if b < directLimit {
 			allocatedBlockIndices[b] = dataBlockIndex
-			fmt.Printf("Allocate: Bloque datos %d asignado a I_block[%d]\n", dataBlockIndex, b)
+			logger.Debug("Bloque de datos en puntero directo", "block", dataBlockIndex, "slot", b)
 			continue
 		}
 
 		// Indirecto Simple (12-27)
 		if b < simpleLimit {
 			idxInSimple := b - directLimit // Índice dentro del bloque de punteros simple (0-15)
-			fmt.Printf("Allocate: Bloque datos %d necesita ir en Indirecto Simple (idx %d)\n", dataBlockIndex, idxInSimple)
+			logger.Debug("Bloque de datos en indirecto simple", "block", dataBlockIndex, "slot", idxInSimple)
 
 			// Asignar el bloque de punteros L1 si es la primera vez
 			if indirect1Block == nil {
-				fmt.Println("Allocate: Asignando Bloque Punteros L1 (Simple)...")
+				logger.Debug("Asignando bloque de punteros L1 (simple)")
 				indirect1BlockIndex = (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
 				if indirect1BlockIndex >= sb.S_blocks_count {
 					return allocatedBlockIndices, errors.New("error interno: S_first_blo fuera de límites al asignar puntero L1")
 				}
 
-				err = sb.UpdateBitmapBlock(partitionPath, indirect1BlockIndex)
+				err = sb.UpdateBitmapBlock(dev, indirect1BlockIndex)
 				if err != nil {
 					return allocatedBlockIndices, fmt.Errorf("error bitmap bloque punteros L1 %d: %w", indirect1BlockIndex, err)
 				}
@@ -697,11 +723,10 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 				for i := range indirect1Block.P_pointers {
 					indirect1Block.P_pointers[i] = -1
 				}
-				fmt.Printf("Allocate: Bloque Punteros L1 (Simple) asignado al índice %d\n", indirect1BlockIndex)
+				logger.Debug("Bloque de punteros L1 (simple) asignado", "block", indirect1BlockIndex)
 			}
 			// Guardar puntero al bloque de datos en el struct del bloque de punteros L1
 			indirect1Block.P_pointers[idxInSimple] = dataBlockIndex
-			fmt.Printf("Allocate: Puntero a datos %d guardado en P_pointers[%d] del Bloque L1 (Simple)\n", dataBlockIndex, idxInSimple)
 			continue
 		}
 
@@ -710,17 +735,17 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 			relIdxDouble := b - simpleLimit          // Índice relativo al inicio del doble indirecto (0-255)
 			idxL1 := relIdxDouble / pointersPerBlock // Índice en el bloque L1 (0-15)
 			idxL2 := relIdxDouble % pointersPerBlock // Índice en el bloque L2 (0-15)
-			fmt.Printf("Allocate: Bloque datos %d necesita ir en Indirecto Doble (L1[%d], L2[%d])\n", dataBlockIndex, idxL1, idxL2)
+			logger.Debug("Bloque de datos en indirecto doble", "block", dataBlockIndex, "l1", idxL1, "l2", idxL2)
 
 			// Asignar el bloque de punteros L1 si es la primera vez para Doble
 			if indirect2L1Block == nil {
-				fmt.Println("Allocate: Asignando Bloque Punteros L1 (Doble)...")
+				logger.Debug("Asignando bloque de punteros L1 (doble)")
 				indirect2L1BlockIndex = (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
 				if indirect2L1BlockIndex >= sb.S_blocks_count {
 					return allocatedBlockIndices, errors.New("error interno: S_first_blo fuera de límites al asignar puntero L1 doble")
 				}
 
-				err = sb.UpdateBitmapBlock(partitionPath, indirect2L1BlockIndex)
+				err = sb.UpdateBitmapBlock(dev, indirect2L1BlockIndex)
 				if err != nil {
 					return allocatedBlockIndices, fmt.Errorf("error bitmap bloque punteros L1 doble %d: %w", indirect2L1BlockIndex, err)
 				}
@@ -732,18 +757,18 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 				for i := range indirect2L1Block.P_pointers {
 					indirect2L1Block.P_pointers[i] = -1
 				}
-				fmt.Printf("Allocate: Bloque Punteros L1 (Doble) asignado al índice %d\n", indirect2L1BlockIndex)
+				logger.Debug("Bloque de punteros L1 (doble) asignado", "block", indirect2L1BlockIndex)
 			}
 
 			// Asignar el bloque de punteros L2 si es la primera vez para este índice L1
 			if indirect2Blocks[idxL1] == nil {
-				fmt.Printf("Allocate: Asignando Bloque Punteros L2 (para L1[%d])...\n", idxL1)
+				logger.Debug("Asignando bloque de punteros L2", "l1", idxL1)
 				blockIndexL2 := (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
 				if blockIndexL2 >= sb.S_blocks_count {
 					return allocatedBlockIndices, errors.New("error interno: S_first_blo fuera de límites al asignar puntero L2")
 				}
 
-				err = sb.UpdateBitmapBlock(partitionPath, blockIndexL2)
+				err = sb.UpdateBitmapBlock(dev, blockIndexL2)
 				if err != nil {
 					return allocatedBlockIndices, fmt.Errorf("error bitmap bloque punteros L2 %d: %w", blockIndexL2, err)
 				}
@@ -756,11 +781,11 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 				for i := range indirect2Blocks[idxL1].P_pointers {
 					indirect2Blocks[idxL1].P_pointers[i] = -1
 				}
-				fmt.Printf("Allocate: Bloque Punteros L2 asignado al índice %d (guardado en L1[%d])\n", blockIndexL2, idxL1)
+				logger.Debug("Bloque de punteros L2 asignado", "block", blockIndexL2, "l1", idxL1)
 
 				// Serializar L1 AHORA porque cambió su puntero a L2
 				offsetL1 := int64(sb.S_block_start) + int64(indirect2L1BlockIndex)*int64(sb.S_block_size)
-				err = indirect2L1Block.Serialize(partitionPath, offsetL1)
+				err = indirect2L1Block.Serialize(dev, offsetL1)
 				if err != nil {
 					return allocatedBlockIndices, fmt.Errorf("error serializando bloque puntero L1 doble %d: %w", indirect2L1BlockIndex, err)
 				}
@@ -768,7 +793,6 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 
 			// Guardar puntero al bloque de datos en el struct del bloque de punteros L2 correspondiente
 			indirect2Blocks[idxL1].P_pointers[idxL2] = dataBlockIndex
-			fmt.Printf("Allocate: Puntero a datos %d guardado en P_pointers[%d] del Bloque L2 (índice %d)\n", dataBlockIndex, idxL2, indirect2BlockIndices[idxL1])
 			continue
 		}
 
@@ -781,9 +805,9 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 
 	// Serializar Bloques de Punteros Pendientes
 	if indirect1Block != nil {
-		fmt.Printf("Allocate: Serializando Bloque Punteros L1 (Simple) final %d\n", indirect1BlockIndex)
+		logger.Debug("Serializando bloque de punteros L1 (simple)", "block", indirect1BlockIndex)
 		offset := int64(sb.S_block_start) + int64(indirect1BlockIndex)*int64(sb.S_block_size)
-		err := indirect1Block.Serialize(partitionPath, offset)
+		err := indirect1Block.Serialize(dev, offset)
 		if err != nil {
 			return allocatedBlockIndices, fmt.Errorf("error serializando bloque puntero L1 simple %d: %w", indirect1BlockIndex, err)
 		}
@@ -794,9 +818,9 @@ func allocateDataBlocks(contentBytes []byte, fileSize int32, sb *structures.Supe
 		for idxL1 := 0; idxL1 < len(indirect2Blocks); idxL1++ {
 			if indirect2Blocks[idxL1] != nil {
 				idxL2 := indirect2BlockIndices[idxL1]
-				fmt.Printf("Allocate: Serializando Bloque Punteros L2 final %d (desde L1[%d])\n", idxL2, idxL1)
+				logger.Debug("Serializando bloque de punteros L2", "block", idxL2, "l1", idxL1)
 				offsetL2 := int64(sb.S_block_start) + int64(idxL2)*int64(sb.S_block_size)
-				err := indirect2Blocks[idxL1].Serialize(partitionPath, offsetL2)
+				err := indirect2Blocks[idxL1].Serialize(dev, offsetL2)
 				if err != nil {
 					return allocatedBlockIndices, fmt.Errorf("error serializando bloque puntero L2 %d: %w", idxL2, err)
 				}
@@ -34,7 +34,7 @@ func ParseMkfs(tokens []string) (string, error) {
 		// Divide cada parte en clave y valor usando "=" como delimitador
 		kv := strings.SplitN(match, "=", 2)
 		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+			return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 		}
 		key, value := strings.ToLower(kv[0]), kv[1]
 
@@ -59,13 +59,13 @@ func ParseMkfs(tokens []string) (string, error) {
 			cmd.typ = value
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que el parámetro -id haya sido proporcionado
 	if cmd.id == "" {
-		return "", errors.New("faltan parámetros requeridos: -id")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -id")
 	}
 
 	// Si no se proporcionó el tipo, se establece por defecto a "full"
@@ -76,7 +76,6 @@ func ParseMkfs(tokens []string) (string, error) {
 	// Aquí se puede agregar la lógica para ejecutar el comando mkfs con los parámetros proporcionados
 	err := commandMkfs(cmd)
 	if err != nil {
-		fmt.Println("Error:", err)
 		return "", err
 	}
 
@@ -94,41 +93,45 @@ func commandMkfs(mkfs *MKFS) error {
 		return err
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
 	// Verificar la partición montada
-	fmt.Println("\nPatición montada:")
-	mountedPartition.PrintPartition()
+	logger.Debug("Partición montada", "partition", mountedPartition)
 
 	// Calcular el valor de n
 	n := calculateN(mountedPartition)
 
 	// Verificar el valor de n
-	fmt.Println("\nValor de n:", n)
+	logger.Debug("Valor de n", "n", n)
 
 	// Inicializar un nuevo superbloque
 	superBlock := createSuperBlock(mountedPartition, n)
 
 	// Verificar el superbloque
-	fmt.Println("\nSuperBlock:")
-	superBlock.Print()
+	logger.Debug("SuperBlock", "superblock", superBlock)
 
 	// Crear los bitmaps
-	err = superBlock.CreateBitMaps(partitionPath)
+	err = superBlock.CreateBitMaps(dev)
 	if err != nil {
 		return err
 	}
 
 	// Crear archivo users.txt
-	err = superBlock.CreateUsersFile(partitionPath)
+	err = superBlock.CreateUsersFile(dev)
 	if err != nil {
 		return err
 	}
 
 	// Verificar superbloque actualizado
-	fmt.Println("\nSuperBlock actualizado:")
-	superBlock.Print()
+	logger.Debug("SuperBlock actualizado", "superblock", superBlock)
 
 	// Serializar el superbloque
-	err = superBlock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	err = superBlock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return err
 	}
@@ -165,7 +168,7 @@ func createSuperBlock(partition *structures.Partition, n int32) *structures.Supe
 
 	// Validar que los tamaños no sean cero para evitar división por cero
 	if inodeSize == 0 || blockSize == 0 {
-		fmt.Println("Error crítico: Tamaño de Inodo o Bloque es cero.")
+		logger.Debug("Error crítico: Tamaño de Inodo o Bloque es cero.")
 		return nil // O pánico, ya que esto no debería ocurrir
 	}
 
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -17,7 +18,7 @@ type MKGRP struct {
 }
 
 // ParseMkgrp analiza los tokens para el comando mkgrp
-func ParseMkgrp(tokens []string) (string, error) {
+func ParseMkgrp(ctx context.Context, tokens []string) (string, error) {
 	cmd := &MKGRP{}
 
 	if len(tokens) != 1 {
@@ -28,7 +29,7 @@ func ParseMkgrp(tokens []string) (string, error) {
 	match := re.FindStringSubmatch(tokens[0])
 
 	if match == nil {
-		return "", fmt.Errorf("parámetro inválido o formato incorrecto: %s. Uso: mkgrp -name=<nombre>", tokens[0])
+		return "", NewError(CodeInvalidArgument, "parámetro inválido o formato incorrecto: %s. Uso: mkgrp -name=<nombre>", tokens[0])
 	}
 
 	value := match[1] // El valor capturado (puede tener comillas)
@@ -46,7 +47,7 @@ func ParseMkgrp(tokens []string) (string, error) {
 	cmd.name = value
 
 	// Llamar a la lógica principal del comando
-	err := commandMkgrp(cmd)
+	err := commandMkgrp(ctx, cmd)
 	if err != nil {
 		return "", err // Retornar el error de commandMkgrp
 	}
@@ -55,14 +56,16 @@ func ParseMkgrp(tokens []string) (string, error) {
 }
 
 // commandMkgrp contiene la lógica principal para crear el grupo
-func commandMkgrp(mkgrp *MKGRP) error {
+func commandMkgrp(ctx context.Context, mkgrp *MKGRP) error {
+	auth := stores.AuthFromContext(ctx)
+
 	// 1. Verificar Autenticación y Permisos (Root)
-	if !stores.Auth.IsAuthenticated() {
-		return errors.New("comando mkgrp requiere inicio de sesión")
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando mkgrp requiere inicio de sesión")
 	}
-	currentUser, _, partitionID := stores.Auth.GetCurrentUser()
+	currentUser, partitionID := auth.GetCurrentUser()
 	if currentUser != "root" {
-		return fmt.Errorf("permiso denegado: solo el usuario 'root' puede ejecutar mkgrp (usuario actual: %s)", currentUser)
+		return NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar mkgrp (usuario actual: %s)", currentUser)
 	}
 
 	// 2. Obtener Partición y Superbloque
@@ -70,13 +73,20 @@ func commandMkgrp(mkgrp *MKGRP) error {
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
 	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
 		return errors.New("tamaño de inodo o bloque inválido en superbloque")
 	}
 
 	// 3. Encontrar y Leer Inodo/Contenido de /users.txt
-	fmt.Println("Buscando inodo para /users.txt...")
-	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando inodo para /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
 		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
 	}
@@ -84,15 +94,15 @@ func commandMkgrp(mkgrp *MKGRP) error {
 		return errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	fmt.Println("Leyendo contenido actual de /users.txt...")
-	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	logger.DebugContext(ctx, "Leyendo contenido actual de /users.txt...")
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	if errRead != nil {
 		// Si ReadFileContent retorna "" para archivo vacío, esto está bien.
 		// Si retorna error, lo manejamos.
 		if oldContent != "" { // Solo retornar error si no pudimos leer nada y hubo error
 			return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
 		}
-		fmt.Println("Advertencia: /users.txt parece vacío o hubo un error menor al leer. Continuando...")
+		logger.WarnContext(ctx, "/users.txt parece vacío o hubo un error menor al leer. Continuando...")
 		oldContent = "" // Asegurar que sea un string vacío si hubo error menor o estaba vacío
 	}
 	// Asegurar que el contenido termine con un salto de línea para anexar fácilmente
@@ -101,7 +111,7 @@ func commandMkgrp(mkgrp *MKGRP) error {
 	}
 
 	// 4. Parsear Contenido, Validar Grupo Existente y Obtener Nuevo GID
-	fmt.Println("Validando nombre de grupo y buscando GID disponible...")
+	logger.DebugContext(ctx, "Validando nombre de grupo y buscando GID disponible...")
 	lines := strings.Split(oldContent, "\n")
 	highestGID := int32(0) // Asumimos que GID 0 no se usa, root es 1
 
@@ -123,7 +133,7 @@ func commandMkgrp(mkgrp *MKGRP) error {
 		// Verificar si es línea de grupo y si el nombre ya existe
 		if fields[1] == "G" {
 			if strings.EqualFold(fields[2], mkgrp.name) {
-				return fmt.Errorf("el grupo '%s' ya existe", mkgrp.name)
+				return NewError(CodeAlreadyExists, "el grupo '%s' ya existe", mkgrp.name)
 			}
 			// Rastrear GID más alto
 			gid64, errConv := strconv.ParseInt(fields[0], 10, 32)
@@ -136,7 +146,7 @@ func commandMkgrp(mkgrp *MKGRP) error {
 		}
 	}
 	newGID := highestGID + 1
-	fmt.Printf("Nuevo GID asignado: %d\n", newGID)
+	logger.DebugContext(ctx, "Nuevo GID asignado", "gid", newGID)
 
 	// Preparar Nuevo Contenido
 	newLine := fmt.Sprintf("%d,G,%s\n", newGID, mkgrp.name)
@@ -144,40 +154,40 @@ func commandMkgrp(mkgrp *MKGRP) error {
 	newSize := int32(len(newContent))
 
 	// Liberar Bloques Antiguos de users.txt
-	fmt.Println("Liberando bloques antiguos de /users.txt...")
-	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Liberando bloques antiguos de /users.txt...")
+	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, dev)
 	if errFree != nil {
 		// Es importante loguear esto pero intentamos continuar si es posible
-		fmt.Printf("Error al liberar bloques antiguos de users.txt: %v. Puede haber bloques perdidos.\n", errFree)
+		logger.WarnContext(ctx, "Error al liberar bloques antiguos de users.txt; puede haber bloques perdidos", "error", errFree)
 		// return fmt.Errorf("error liberando bloques antiguos: %w", errFree) // Opcional: Fallar aquí
 	} else {
-		fmt.Println("Bloques antiguos liberados.")
+		logger.DebugContext(ctx, "Bloques antiguos liberados.")
 	}
 
 	// Asignar Nuevos Bloques para el nuevo contenido
-	fmt.Printf("Asignando bloques para nuevo tamaño (%d bytes)...\n", newSize)
+	logger.DebugContext(ctx, "Asignando bloques para el nuevo tamaño", "bytes", newSize)
 	var newAllocatedBlockIndices [15]int32
-	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, partitionPath)
+	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
 	}
 
 	// Actualizar Inodo de users.txt
-	fmt.Println("Actualizando inodo /users.txt...")
+	logger.DebugContext(ctx, "Actualizando inodo /users.txt...")
 	usersInode.I_size = newSize
 	usersInode.I_mtime = float32(time.Now().Unix())
 	usersInode.I_atime = usersInode.I_mtime
 	usersInode.I_block = newAllocatedBlockIndices // Actualizar con los nuevos bloques
 
 	usersInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(usersInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = usersInode.Serialize(partitionPath, usersInodeOffset)
+	err = usersInode.Serialize(dev, usersInodeOffset)
 	if err != nil {
 		return fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("Serializando SuperBlock después de MKGRP...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de MKGRP...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque después de mkgrp: %w", err)
 	}
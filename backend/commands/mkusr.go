@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -10,28 +11,38 @@ import (
 
 	stores "backend/stores"
 	structures "backend/structures"
+	utils "backend/utils"
 )
 
 type MKUSR struct {
-	user string
-	pass string
-	grp  string
+	user    string
+	pass    string
+	grp     string
+	expires string // Fecha de expiración AAAA-MM-DD (opcional)
+	home    bool   // Crear el directorio personal /home/<usuario> (opcional, -home)
 }
 
-func ParseMkusr(tokens []string) (string, error) {
+func ParseMkusr(ctx context.Context, tokens []string) (string, error) {
 	cmd := &MKUSR{}
 	expectedArgs := map[string]bool{"-user": false, "-pass": false, "-grp": false}
 
 	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-(user|pass|grp)=("[^"]+"|[^\s]+)`)
+	re := regexp.MustCompile(`-(user|pass|grp|expires)=("[^"]+"|[^\s]+)`)
 	matches := re.FindAllStringSubmatch(args, -1)
 
-	if len(matches) != 3 {
-		return "", fmt.Errorf("número incorrecto de parámetros. Se esperan -user, -pass, -grp. Encontrados: %d", len(matches))
+	if len(matches) < 3 || len(matches) > 4 {
+		return "", fmt.Errorf("número incorrecto de parámetros. Se esperan -user, -pass, -grp y opcionalmente -expires. Encontrados: %d", len(matches))
 	}
 
 	parsedArgs := make(map[string]bool)
 
+	// -home es una bandera sin valor
+	for _, token := range tokens {
+		if strings.EqualFold(token, "-home") {
+			cmd.home = true
+		}
+	}
+
 	for _, match := range matches {
 		key := strings.ToLower(match[1])
 		value := match[2]
@@ -51,7 +62,7 @@ func ParseMkusr(tokens []string) (string, error) {
 		}
 
 		if len(value) > 10 {
-			return "", fmt.Errorf("el valor para '-%s' ('%s') excede los 10 caracteres", key, value)
+			return "", fmt.Errorf("el valor para '-%s' excede los 10 caracteres", key)
 		}
 		if value == "" {
 			return "", fmt.Errorf("el valor para '-%s' no puede estar vacío", key)
@@ -67,32 +78,46 @@ func ParseMkusr(tokens []string) (string, error) {
 		case "grp":
 			cmd.grp = value
 			expectedArgs["-grp"] = true
+		case "expires":
+			expires, err := utils.ParseExpiryDate(value)
+			if err != nil {
+				return "", err
+			}
+			cmd.expires = expires
 		default:
-			return "", fmt.Errorf("parámetro desconocido detectado por regex: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido detectado por regex: %s", key)
 		}
 	}
 	for key, found := range expectedArgs {
 		if !found {
-			return "", fmt.Errorf("parámetro obligatorio faltante: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro obligatorio faltante: %s", key)
 		}
 	}
-	err := commandMkusr(cmd)
+	if err := utils.CurrentPasswordPolicy.Validate(cmd.user, cmd.pass); err != nil {
+		return "", NewError(CodeInvalidArgument, "%w", err)
+	}
+	err := commandMkusr(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
 
+	if cmd.home {
+		return fmt.Sprintf("MKUSR: Usuario '%s' creado correctamente con directorio personal %s.", cmd.user, utils.HomeDir(cmd.user)), nil
+	}
 	return fmt.Sprintf("MKUSR: Usuario '%s' creado correctamente.", cmd.user), nil
 }
 
 // commandMkusr contiene la lógica principal para crear el usuario
-func commandMkusr(mkusr *MKUSR) error {
+func commandMkusr(ctx context.Context, mkusr *MKUSR) error {
+	auth := stores.AuthFromContext(ctx)
+
 	//Verificar Permisos
-	if !stores.Auth.IsAuthenticated() {
-		return errors.New("comando mkusr requiere inicio de sesión")
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando mkusr requiere inicio de sesión")
 	}
-	currentUser, _, partitionID := stores.Auth.GetCurrentUser()
+	currentUser, partitionID := auth.GetCurrentUser()
 	if currentUser != "root" {
-		return fmt.Errorf("permiso denegado: solo el usuario 'root' puede ejecutar mkusr (usuario actual: %s)", currentUser)
+		return NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar mkusr (usuario actual: %s)", currentUser)
 	}
 
 	// Obtener Partición y Superbloque
@@ -100,13 +125,20 @@ func commandMkusr(mkusr *MKUSR) error {
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
 	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
 		return errors.New("tamaño de inodo o bloque inválido en superbloque")
 	}
 
 	// Encontrar y Leer Inodo/Contenido de /users.txt
-	fmt.Println("Buscando inodo para /users.txt...")
-	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando inodo para /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
 		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
 	}
@@ -114,8 +146,8 @@ func commandMkusr(mkusr *MKUSR) error {
 		return errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	fmt.Println("Leyendo contenido actual de /users.txt...")
-	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	logger.DebugContext(ctx, "Leyendo contenido actual de /users.txt...")
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	if errRead != nil && oldContent == "" {
 		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
 	}
@@ -124,11 +156,12 @@ func commandMkusr(mkusr *MKUSR) error {
 	}
 
 	// Parsear Contenido
-	fmt.Printf("Validando usuario '%s' y grupo '%s'...\n", mkusr.user, mkusr.grp)
+	logger.DebugContext(ctx, "Validando usuario y grupo", "user", mkusr.user, "group", mkusr.grp)
 	lines := strings.Split(oldContent, "\n")
 	highestID := int32(0)
 	userExists := false
 	groupExists := false
+	groupID := ""
 
 	for _, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
@@ -155,66 +188,86 @@ func commandMkusr(mkusr *MKUSR) error {
 		}
 
 		// Verificar si el usuario ya existe
-        if len(fields) == 5 && fields[1] == "U" && strings.EqualFold(fields[3], mkusr.user) { // <-- CORRECTO: Usa índice 3 y verifica longitud 5
+        if utils.IsUserLine(fields) && strings.EqualFold(fields[3], mkusr.user) {
             userExists = true
         }
 
 		// Verificar si el grupo existe y obtener su GID
 		if fields[1] == "G" && strings.EqualFold(fields[2], mkusr.grp) {
 			groupExists = true
+			groupID = fields[0]
 		}
 	}
 
 	if userExists {
-		return fmt.Errorf("error: el usuario '%s' ya existe", mkusr.user)
+		return NewError(CodeAlreadyExists, "error: el usuario '%s' ya existe", mkusr.user)
 	}
 	if !groupExists {
-		return fmt.Errorf("error: el grupo '%s' no existe", mkusr.grp)
+		return NewError(CodeNotFound, "error: el grupo '%s' no existe", mkusr.grp)
 	}
 
 	newUID := highestID + 1
-	fmt.Printf("Nuevo UID asignado: %d. Pertenecerá al grupo '%s'.\n", newUID, mkusr.grp)
+	logger.DebugContext(ctx, "Nuevo UID asignado", "uid", newUID, "group", mkusr.grp)
 
-	// Preparar Nuevo Contenido
-	newLine := fmt.Sprintf("%d,U,%s,%s,%s\n", newUID, mkusr.grp, mkusr.user, mkusr.pass) // Usa mkusr.grp (nombre)
-	newContent := oldContent + newLine
+	// Preparar Nuevo Contenido, la contraseña se guarda hasheada
+	passwordHash, err := utils.HashPassword(mkusr.pass)
+	if err != nil {
+		return err
+	}
+	record := utils.UserRecord{UID: strconv.Itoa(int(newUID)), Group: mkusr.grp, Name: mkusr.user, Password: passwordHash, Expires: mkusr.expires} // Usa mkusr.grp (nombre)
+	newLine := record.Line() + "\n"
+	newContent, _, err := utils.MigrateUsersContent(oldContent + newLine) // La línea puede usar campos de la versión actual
+	if err != nil {
+		return fmt.Errorf("error migrando /users.txt: %w", err)
+	}
 	newSize := int32(len(newContent))
-	fmt.Printf("Nuevo contenido de users.txt preparado (%d bytes).\n", newSize)
+	logger.DebugContext(ctx, "Nuevo contenido de users.txt preparado", "bytes", newSize)
+
+	// Crear el directorio personal antes de tocar /users.txt, para no dejar el usuario a medias si falla
+	if mkusr.home {
+		gid, err := strconv.ParseInt(groupID, 10, 32)
+		if err != nil {
+			return fmt.Errorf("error: GID inválido '%s' para el grupo '%s'", groupID, mkusr.grp)
+		}
+		if err := createHome(partitionSuperblock, dev, mkusr.user, newUID, int32(gid)); err != nil {
+			return err
+		}
+	}
 
 	// Liberar Bloques Antiguos de users.txt
-	fmt.Println("Liberando bloques antiguos de /users.txt...")
-	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Liberando bloques antiguos de /users.txt...")
+	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, dev)
 	if errFree != nil {
-		fmt.Printf("ADVERTENCIA: Error al liberar bloques antiguos de users.txt: %v. Puede haber bloques perdidos.\n", errFree)
+		logger.WarnContext(ctx, "Error al liberar bloques antiguos de users.txt; puede haber bloques perdidos", "error", errFree)
 		return fmt.Errorf("error liberando bloques antiguos: %w", errFree)
 	} else {
-		fmt.Println("Bloques antiguos liberados.")
+		logger.DebugContext(ctx, "Bloques antiguos liberados.")
 	}
 
 	// Asignar Nuevos Bloques para el nuevo contenido
-	fmt.Printf("Asignando bloques para nuevo tamaño (%d bytes)...\n", newSize)
+	logger.DebugContext(ctx, "Asignando bloques para el nuevo tamaño", "bytes", newSize)
 	var newAllocatedBlockIndices [15]int32
-	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, partitionPath)
+	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
 	}
 
 	// Actualizar Inodo de users.txt
-	fmt.Println("Actualizando inodo /users.txt...")
+	logger.DebugContext(ctx, "Actualizando inodo /users.txt...")
 	usersInode.I_size = newSize
 	usersInode.I_mtime = float32(time.Now().Unix())
 	usersInode.I_atime = usersInode.I_mtime
 	usersInode.I_block = newAllocatedBlockIndices
 
 	usersInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(usersInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = usersInode.Serialize(partitionPath, usersInodeOffset)
+	err = usersInode.Serialize(dev, usersInodeOffset)
 	if err != nil {
 		return fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("Serializando SuperBlock después de MKUSR...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de MKUSR...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque después de mkusr: %w", err)
 	}
@@ -40,7 +40,7 @@ func ParseMount(tokens []string) (string, error) {
 		// Divide cada parte en clave y valor usando "=" como delimitador
 		kv := strings.SplitN(match, "=", 2)
 		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+			return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 		}
 		key, value := strings.ToLower(kv[0]), kv[1]
 
@@ -65,20 +65,25 @@ func ParseMount(tokens []string) (string, error) {
 			cmd.name = value
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que los parámetros -path y -name hayan sido proporcionados
 	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -path")
 	}
 	if cmd.name == "" {
-		return "", errors.New("faltan parámetros requeridos: -name")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -name")
 	}
+	resolvedPath, err := stores.ResolveDiskPath(cmd.path)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = resolvedPath
 
 	// Montamos la partición
-	err := commandMount(cmd)
+	err = commandMount(cmd)
 	if err != nil {
 		return "", err
 	}
@@ -99,32 +104,39 @@ func ParseMount(tokens []string) (string, error) {
 }
 
 func commandMount(mount *MOUNT) error {
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(mount.path)
+	if err != nil {
+		logger.Debug("Error abriendo el disco", "error", err)
+		return err
+	}
+	defer structures.CloseDevice(dev)
+
 	// Crear una instancia de MBR
 	var mbr structures.MBR
 
-	// Deserializar la estructura MBR desde un archivo binario
-	err := mbr.Deserialize(mount.path)
+	// Deserializar la estructura MBR desde el dispositivo
+	err = mbr.Deserialize(dev)
 	if err != nil {
-		fmt.Println("Error deserializando el MBR:", err)
+		logger.Debug("Error deserializando el MBR", "error", err)
 		return err
 	}
 
 	// Buscar la partición con el nombre especificado
 	partition, indexPartition := mbr.GetPartitionByName(mount.name)
 	if partition == nil {
-		fmt.Println("Error: la partición no existe")
-		return errors.New("la partición no existe")
+		logger.Debug("Error: la partición no existe")
+		return NewError(CodeNotFound, "la partición no existe")
 	}
 
 	/* SOLO PARA VERIFICACIÓN */
-	// Print para verificar que la partición se encontró correctamente
-	fmt.Println("\nPartición disponible:")
-	partition.PrintPartition()
+	// Registrar para verificar que la partición se encontró correctamente
+	logger.Debug("Partición disponible", "partition", partition)
 
 	//Aquí verifico si no se montó antes
 	for _, valor:= range stores.ListPatitions{
 		if valor == mount.name{
-			fmt.Println("Error: la partición ya está montada")
+			logger.Debug("Error: la partición ya está montada")
 			return errors.New("la partición ya está montada")
 		}
 	}
@@ -132,7 +144,7 @@ func commandMount(mount *MOUNT) error {
 	// Generar un id único para la partición
 	idPartition, partitionCorrelative, err := generatePartitionID(mount)
 	if err != nil {
-		fmt.Println("Error generando el id de partición:", err)
+		logger.Debug("Error generando el id de partición", "error", err)
 		return err
 	}
 
@@ -147,17 +159,16 @@ func commandMount(mount *MOUNT) error {
 	partition.MountPartition(partitionCorrelative, idPartition)
 
 	/* SOLO PARA VERIFICACIÓN */
-	// Print para verificar que la partición se haya montado correctamente
-	fmt.Println("\nPartición montada (modificada):")
-	partition.PrintPartition()
+	// Registrar para verificar que la partición se haya montado correctamente
+	logger.Debug("Partición montada (modificada)", "partition", partition)
 
 	// Guardar la partición modificada en el MBR
 	mbr.Mbr_partitions[indexPartition] = *partition
 
-	// Serializar la estructura MBR en el archivo binario
-	err = mbr.Serialize(mount.path)
+	// Serializar la estructura MBR en el dispositivo
+	err = mbr.Serialize(dev)
 	if err != nil {
-		fmt.Println("Error serializando el MBR:", err)
+		logger.Debug("Error serializando el MBR", "error", err)
 		return err
 	}
 
@@ -168,7 +179,7 @@ func generatePartitionID(mount *MOUNT) (string, int, error) {
 	// Asignar una letra a la partición y obtener el índice
 	letter, partitionCorrelative, err := utils.GetLetterAndPartitionCorrelative(mount.path)
 	if err != nil {
-		fmt.Println("Error obteniendo la letra:", err)
+		logger.Debug("Error obteniendo la letra", "error", err)
 		return "", 0, err
 	}
 
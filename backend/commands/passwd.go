@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type PASSWD struct {
+	user string // Usuario al que se le cambia la contraseña (por defecto, el de la sesión)
+	pass string // Nueva contraseña
+	old  string // Contraseña actual, obligatoria cuando un usuario que no es root cambia la suya
+}
+
+func ParsePasswd(ctx context.Context, tokens []string) (string, error) {
+	cmd := &PASSWD{}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user|pass|old)=("[^"]+"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+
+	for _, match := range matches {
+		key := strings.ToLower(match[1])
+		value := match[2]
+
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = strings.Trim(value, "\"")
+		}
+
+		if len(value) > 10 {
+			return "", fmt.Errorf("el valor para '-%s' excede los 10 caracteres", key)
+		}
+		if value == "" {
+			return "", fmt.Errorf("el valor para '-%s' no puede estar vacío", key)
+		}
+
+		switch key {
+		case "user":
+			cmd.user = value
+		case "pass":
+			cmd.pass = value
+		case "old":
+			cmd.old = value
+		default:
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido detectado por regex: %s", key)
+		}
+	}
+	if cmd.pass == "" {
+		return "", NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -pass")
+	}
+
+	err := commandPasswd(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("PASSWD: Contraseña del usuario '%s' actualizada correctamente.", cmd.user), nil
+}
+
+// commandPasswd cambia la contraseña propia o, si la sesión es de root, la de cualquier usuario. Un usuario que
+// no es root debe confirmar su contraseña actual con -old.
+func commandPasswd(ctx context.Context, passwd *PASSWD) error {
+	auth := stores.AuthFromContext(ctx)
+
+	//Verificar Permisos
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando passwd requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if passwd.user == "" {
+		passwd.user = currentUser
+	}
+	if currentUser != "root" && !strings.EqualFold(passwd.user, currentUser) {
+		return NewError(CodePermissionDenied, "permiso denegado: solo 'root' puede cambiar la contraseña de otro usuario (usuario actual: %s)", currentUser)
+	}
+	if currentUser != "root" && passwd.old == "" {
+		return NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -old (contraseña actual)")
+	}
+	if err := utils.CurrentPasswordPolicy.Validate(passwd.user, passwd.pass); err != nil {
+		return NewError(CodeInvalidArgument, "%w", err)
+	}
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	// Encontrar y Leer Inodo/Contenido de /users.txt
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
+	if errFind != nil {
+		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
+	}
+	if usersInode.I_type[0] != '1' {
+		return errors.New("error crítico: /users.txt no es un archivo")
+	}
+
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
+	if errRead != nil {
+		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
+	}
+
+	// Verificar la contraseña actual; los intentos fallidos cuentan para el bloqueo, igual que en login
+	if currentUser != "root" {
+		fields := utils.FindUser(oldContent, passwd.user)
+		if fields == nil {
+			return NewError(CodeNotFound, "error: el usuario '%s' no existe", passwd.user)
+		}
+		if err := verifyAccountPassword(partitionID, utils.ParseUserRecord(fields), passwd.old); err != nil {
+			return err
+		}
+	}
+
+	// Reemplazar el hash del usuario
+	passwordHash, err := utils.HashPassword(passwd.pass)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(oldContent, "\n")
+	newLines := make([]string, 0, len(lines))
+	foundUser := false
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+
+		fields := strings.Split(trimmedLine, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		if utils.IsUserLine(fields) && strings.EqualFold(fields[3], passwd.user) {
+			fields[4] = passwordHash
+			trimmedLine = strings.Join(fields, ",")
+			foundUser = true
+		}
+		newLines = append(newLines, trimmedLine)
+	}
+	if !foundUser {
+		return NewError(CodeNotFound, "error: el usuario '%s' no existe", passwd.user)
+	}
+
+	// Aprovechar la reescritura para dejar el archivo en el formato actual
+	newContent, _, err := utils.MigrateUsersContent(strings.Join(newLines, "\n"))
+	if err != nil {
+		return fmt.Errorf("error migrando /users.txt: %w", err)
+	}
+
+	logger.DebugContext(ctx, "Actualizando contraseña en /users.txt", "user", passwd.user)
+	return writeUsersFile(partitionSuperblock, mountedPartition, dev, usersInodeIndex, usersInode, newContent)
+}
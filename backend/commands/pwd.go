@@ -0,0 +1,22 @@
+package commands
+
+import (
+	stores "backend/stores"
+	"context"
+	"errors"
+)
+
+func ParsePwd(ctx context.Context, tokens []string) (string, error) {
+	if len(tokens) != 0 {
+		return "", errors.New("el comando pwd no acepta parámetros")
+	}
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "no hay ninguna sesión activa")
+	}
+
+	if auth.Cwd == "" {
+		return "/", nil
+	}
+	return auth.Cwd, nil
+}
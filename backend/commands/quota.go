@@ -0,0 +1,363 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// ErrQuotaExceeded se devuelve cuando mkdir o mkfile superarían la cuota del usuario o de su grupo
+var ErrQuotaExceeded = errors.New("cuota excedida")
+
+// Permisos de /quota.txt: solo root lo modifica
+var quotaFilePerm = [3]byte{'6', '4', '0'}
+
+// Permisos de las carpetas que crean mkdir y mkfile -r (los mismos que usa CreateFolder)
+var folderPerm = [3]byte{'7', '7', '5'}
+
+// Owner es el dueño con el que se crean los archivos y carpetas de una sesión
+type Owner struct {
+	User  string
+	Group string
+	UID   int32
+	GID   int32
+}
+
+type QUOTA struct {
+	user string // Usuario a consultar (solo root puede consultar otros)
+	grp  string // Grupo a consultar (solo root)
+}
+
+// ParseQuota muestra el uso de bloques e inodos del usuario de la sesión (o del -user/-grp indicado) contra sus cuotas
+func ParseQuota(ctx context.Context, tokens []string) (string, error) {
+	cmd := &QUOTA{}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user|grp)=("[^"]+"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(matches) != len(tokens) {
+		for _, token := range tokens {
+			if !re.MatchString(token) {
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
+			}
+		}
+	}
+
+	for _, match := range matches {
+		value := strings.Trim(match[2], "\"")
+		if len(value) > 10 {
+			return "", fmt.Errorf("el valor para '-%s' ('%s') excede los 10 caracteres", match[1], value)
+		}
+		switch strings.ToLower(match[1]) {
+		case "user":
+			cmd.user = value
+		case "grp":
+			cmd.grp = value
+		}
+	}
+	if cmd.user != "" && cmd.grp != "" {
+		return "", errors.New("use solo uno de -user o -grp")
+	}
+
+	return commandQuota(ctx, cmd)
+}
+
+func commandQuota(ctx context.Context, quota *QUOTA) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "comando quota requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if (quota.user != "" && !strings.EqualFold(quota.user, currentUser)) || quota.grp != "" {
+		if currentUser != "root" {
+			return "", NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede consultar cuotas ajenas (usuario actual: %s)", currentUser)
+		}
+	}
+
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return "", fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	_, _, usersContent, err := readUsersFile(partitionSuperblock, dev)
+	if err != nil {
+		return "", err
+	}
+	_, _, table, err := readQuotaFile(partitionSuperblock, dev)
+	if err != nil {
+		return "", err
+	}
+	byUser, byGroup, err := usageByOwner(partitionID, partitionSuperblock, dev)
+	if err != nil {
+		return "", err
+	}
+
+	if quota.grp != "" {
+		gid, group, err := lookupGroup(usersContent, quota.grp)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("QUOTA: Grupo '%s' (GID %d): %s", group, gid, formatQuotaUsage(byGroup[gid], table.Group(group))), nil
+	}
+
+	username := quota.user
+	if username == "" {
+		username = currentUser
+	}
+	owner, err := lookupOwner(usersContent, username)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("QUOTA: Usuario '%s' (UID %d): %s. Grupo '%s' (GID %d): %s",
+		owner.User, owner.UID, formatQuotaUsage(byUser[owner.UID], table.User(owner.User)),
+		owner.Group, owner.GID, formatQuotaUsage(byGroup[owner.GID], table.Group(owner.Group))), nil
+}
+
+// formatQuotaUsage muestra el uso contra los límites, por ejemplo "bloques 12/50, inodos 3/-"
+func formatQuotaUsage(usage structures.Usage, quota utils.Quota) string {
+	return fmt.Sprintf("bloques %d/%s, inodos %d/%s", usage.Blocks, utils.FormatQuotaLimit(quota.Blocks), usage.Inodes, utils.FormatQuotaLimit(quota.Inodes))
+}
+
+// lookupOwner busca en el contenido de /users.txt el UID del usuario y el GID de su grupo principal
+func lookupOwner(usersContent, username string) (Owner, error) {
+	var owner Owner
+	found := false
+	for _, line := range strings.Split(usersContent, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if !utils.IsUserLine(fields) || !strings.EqualFold(fields[3], username) {
+			continue
+		}
+		uid, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			return owner, fmt.Errorf("error: UID inválido '%s' para el usuario '%s'", fields[0], fields[3])
+		}
+		owner = Owner{User: fields[3], Group: fields[2], UID: int32(uid)}
+		found = true
+		break
+	}
+	if !found {
+		return owner, NewError(CodeNotFound, "error: el usuario '%s' no existe", username)
+	}
+
+	gid, group, err := lookupGroup(usersContent, owner.Group)
+	if err != nil {
+		return owner, fmt.Errorf("error: el grupo '%s' del usuario '%s' no existe", owner.Group, owner.User)
+	}
+	owner.GID, owner.Group = gid, group
+	return owner, nil
+}
+
+// lookupGroup busca en el contenido de /users.txt el GID de un grupo y su nombre tal como está escrito
+func lookupGroup(usersContent, group string) (int32, string, error) {
+	for _, line := range strings.Split(usersContent, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 3 || strings.TrimSpace(fields[1]) != "G" || !strings.EqualFold(strings.TrimSpace(fields[2]), group) {
+			continue
+		}
+		gid, err := strconv.ParseInt(strings.TrimSpace(fields[0]), 10, 32)
+		if err != nil {
+			return 0, "", fmt.Errorf("error: GID inválido '%s' para el grupo '%s'", fields[0], group)
+		}
+		return int32(gid), strings.TrimSpace(fields[2]), nil
+	}
+	return 0, "", NewError(CodeNotFound, "error: el grupo '%s' no existe", group)
+}
+
+// sessionOwner devuelve el dueño de lo que crea el usuario de la sesión
+func sessionOwner(sb *structures.SuperBlock, dev structures.BlockDevice, username string) (Owner, error) {
+	_, _, usersContent, err := readUsersFile(sb, dev)
+	if err != nil {
+		return Owner{}, err
+	}
+	return lookupOwner(usersContent, username)
+}
+
+// readQuotaFile lee /quota.txt. Si no existe devuelve índice -1 y una tabla vacía (nadie tiene cuota).
+func readQuotaFile(sb *structures.SuperBlock, dev structures.BlockDevice) (int32, *structures.Inode, utils.QuotaTable, error) {
+	quotaInodeIndex, quotaInode, err := structures.FindInodeByPath(sb, dev, utils.QuotaFilePath)
+	if err != nil {
+		return -1, nil, utils.ParseQuotas(""), nil
+	}
+	if quotaInode.I_type[0] != '1' {
+		return -1, nil, utils.QuotaTable{}, fmt.Errorf("error: %s no es un archivo", utils.QuotaFilePath)
+	}
+	content, err := structures.ReadFileContent(sb, dev, quotaInode)
+	if err != nil {
+		return -1, nil, utils.QuotaTable{}, fmt.Errorf("error leyendo el contenido de %s: %w", utils.QuotaFilePath, err)
+	}
+	return quotaInodeIndex, quotaInode, utils.ParseQuotas(content), nil
+}
+
+// checkQuota verifica que el dueño pueda ocupar newInodes inodos y newBlocks bloques más sin pasarse
+// de su cuota de usuario ni de la de su grupo. root no tiene cuota.
+//
+// La cuota de grupo se cobra al grupo de cada inodo (I_gid), como en las cuotas de Linux. Lo nuevo se crea con el
+// grupo principal del dueño, así que solo se verifica la cuota de ese grupo: los grupos secundarios (usermod
+// -addgrp) dan permisos pero no se cobran por lo que crea el usuario.
+func checkQuota(partitionID string, sb *structures.SuperBlock, dev structures.BlockDevice, owner Owner, newInodes, newBlocks int32) error {
+	if owner.User == "root" {
+		return nil
+	}
+	_, _, table, err := readQuotaFile(sb, dev)
+	if err != nil {
+		return err
+	}
+	userQuota, groupQuota := table.User(owner.User), table.Group(owner.Group)
+	if userQuota.Unlimited() && groupQuota.Unlimited() {
+		return nil
+	}
+
+	byUser, byGroup, err := usageByOwner(partitionID, sb, dev)
+	if err != nil {
+		return err
+	}
+	if err := exceedsQuota(fmt.Sprintf("el usuario '%s'", owner.User), byUser[owner.UID], userQuota, newInodes, newBlocks); err != nil {
+		return err
+	}
+	return exceedsQuota(fmt.Sprintf("el grupo '%s'", owner.Group), byGroup[owner.GID], groupQuota, newInodes, newBlocks)
+}
+
+func exceedsQuota(who string, usage structures.Usage, quota utils.Quota, newInodes, newBlocks int32) error {
+	if quota.Blocks > 0 && usage.Blocks+newBlocks > quota.Blocks {
+		return fmt.Errorf("%w: %s usa %d de %d bloques y se necesitan %d más", ErrQuotaExceeded, who, usage.Blocks, quota.Blocks, newBlocks)
+	}
+	if quota.Inodes > 0 && usage.Inodes+newInodes > quota.Inodes {
+		return fmt.Errorf("%w: %s usa %d de %d inodos y se necesitan %d más", ErrQuotaExceeded, who, usage.Inodes, quota.Inodes, newInodes)
+	}
+	return nil
+}
+
+// Uso por dueño de una partición, guardado para no recorrer todos los inodos en cada mkdir o mkfile
+type partitionUsage struct {
+	superblock structures.SuperBlock // Superbloque con el que se calculó
+	byUser     map[int32]structures.Usage
+	byGroup    map[int32]structures.Usage
+}
+
+// Uso por dueño de cada partición montada, por id. El uso solo cambia al asignar o liberar inodos y bloques, y eso
+// siempre cambia el superbloque (los contadores de libres, o S_first_ino y S_first_blo, que avanzan sin reutilizar):
+// el uso guardado sirve mientras el superbloque sea el mismo.
+var (
+	usageCacheMu sync.Mutex
+	usageCache   = make(map[string]partitionUsage)
+)
+
+// usageByOwner devuelve el uso de la partición por UID y por GID. Solo recorre los inodos si el superbloque cambió
+// desde la última vez. Los mapas devueltos son compartidos y no se deben modificar.
+func usageByOwner(partitionID string, sb *structures.SuperBlock, dev structures.BlockDevice) (map[int32]structures.Usage, map[int32]structures.Usage, error) {
+	usageCacheMu.Lock()
+	cached, exists := usageCache[partitionID]
+	usageCacheMu.Unlock()
+	if exists && cached.superblock == *sb {
+		return cached.byUser, cached.byGroup, nil
+	}
+
+	byUser, byGroup, err := sb.UsageByOwner(dev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error calculando el uso de la partición: %w", err)
+	}
+	usageCacheMu.Lock()
+	usageCache[partitionID] = partitionUsage{superblock: *sb, byUser: byUser, byGroup: byGroup}
+	usageCacheMu.Unlock()
+	return byUser, byGroup, nil
+}
+
+// fileBlocksNeeded cuenta los bloques que ocupa un archivo de size bytes, incluidos los bloques de punteros:
+// 12 directos, 16 por el indirecto simple, 16x16 por el doble y 16x16x16 por el triple
+func fileBlocksNeeded(size, blockSize int32) int32 {
+	if size <= 0 {
+		return 0
+	}
+	const pointers = 16 // Punteros por bloque de punteros
+	dataBlocks := (size + blockSize - 1) / blockSize
+	blocks := dataBlocks
+	if dataBlocks > 12 {
+		blocks++ // Bloque de punteros simple
+	}
+	if beyond := dataBlocks - 12 - pointers; beyond > 0 {
+		blocks += 1 + ceilDiv(min(beyond, pointers*pointers), pointers) // Bloque doble y sus bloques de segundo nivel
+	}
+	if beyond := dataBlocks - 12 - pointers - pointers*pointers; beyond > 0 {
+		// Bloque triple, sus bloques de segundo nivel y los de tercer nivel
+		blocks += 1 + ceilDiv(beyond, pointers*pointers) + ceilDiv(beyond, pointers)
+	}
+	return blocks
+}
+
+func ceilDiv(a, b int32) int32 {
+	return (a + b - 1) / b
+}
+
+// parentBlocksNeeded cuenta los bloques que necesita la carpeta donde se agrega la entrada de entryPath (la primera
+// que ya existe subiendo desde su padre) para tener lugar para ella: 0 si le queda un espacio libre, 1 si hay que
+// agregarle un bloque carpeta y 2 si además hay que crear su bloque de punteros simple (ver addEntryToParent).
+// Los bloques de una carpeta se cobran a su dueño y a su grupo, así que solo cuentan si la carpeta es del dueño
+// indicado o de su grupo.
+func parentBlocksNeeded(sb *structures.SuperBlock, dev structures.BlockDevice, entryPath string, owner Owner) (int32, error) {
+	var parent *structures.Inode
+	for current := path.Dir(entryPath); ; current = path.Dir(current) {
+		if _, inode, err := structures.FindInodeByPath(sb, dev, current); err == nil {
+			parent = inode
+			break
+		}
+		if current == "/" {
+			return 0, nil
+		}
+	}
+	if parent.I_type[0] != '0' {
+		return 0, nil // El comando falla después: el padre no es una carpeta
+	}
+	if parent.I_uid != owner.UID && parent.I_gid != owner.GID {
+		return 0, nil
+	}
+
+	blocks, err := sb.FileDataBlocks(dev, parent)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo los bloques de la carpeta padre: %w", err)
+	}
+	for _, block := range blocks {
+		folderBlock := &structures.FolderBlock{}
+		if err := folderBlock.Deserialize(dev, int64(sb.S_block_start)+int64(block)*int64(sb.S_block_size)); err != nil {
+			return 0, fmt.Errorf("error leyendo el bloque %d de la carpeta padre: %w", block, err)
+		}
+		for _, entry := range folderBlock.B_content {
+			if entry.B_inodo == -1 {
+				return 0, nil
+			}
+		}
+	}
+	if slices.Contains(parent.I_block[:12], -1) || parent.I_block[12] != -1 {
+		return 1, nil
+	}
+	return 2, nil
+}
+
+// missingDirs cuenta cuántas carpetas del path todavía no existen (las que crearía mkdir -p)
+func missingDirs(sb *structures.SuperBlock, dev structures.BlockDevice, dirPath string) int32 {
+	missing := int32(0)
+	for current := dirPath; current != "/" && current != "."; current = path.Dir(current) {
+		if _, _, err := structures.FindInodeByPath(sb, dev, current); err == nil {
+			break
+		}
+		missing++
+	}
+	return missing
+}
@@ -2,9 +2,14 @@ package commands
 
 import (
 	reports "backend/reports"
+	sandbox "backend/sandbox"
 	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"slices"
 	"strings"
@@ -16,16 +21,18 @@ type REP struct {
 	path         string // Ruta del archivo del disco
 	name         string // Nombre del reporte
 	path_file_ls string // Ruta del archivo ls (opcional)
+	audit        utils.AuditFilter // Filtro del reporte audit (-user, -from, -to)
+	progress     *utils.Progress   // Avance de los reportes que recorren los inodos (nil si nadie lo sigue)
 }
 
 // ParserRep parsea el comando rep y devuelve una instancia de REP
-func ParseRep(tokens []string) (string, error) {
-	cmd := &REP{} // Crea una nueva instancia de REP
+func ParseRep(ctx context.Context, tokens []string) (string, error) {
+	cmd := &REP{progress: utils.ProgressFromContext(ctx)} // Crea una nueva instancia de REP
 
 	// Unir tokens en una sola cadena y luego dividir por espacios, respetando las comillas
 	args := strings.Join(tokens, " ")
 	// Expresión regular para encontrar los parámetros del comando rep
-	re := regexp.MustCompile(`-id=[^\s]+|-path="[^"]+"|-path=[^\s]+|-name=[^\s]+|-path_file_ls="[^"]+"|-path_file_ls=[^\s]+`)
+	re := regexp.MustCompile(`-id=[^\s]+|-path="[^"]+"|-path=[^\s]+|-name=[^\s]+|-path_file_ls="[^"]+"|-path_file_ls=[^\s]+|-user=[^\s]+|-from="[^"]+"|-from=[^\s]+|-to="[^"]+"|-to=[^\s]+`)
 	// Encuentra todas las coincidencias de la expresión regular en la cadena de argumentos
 	matches := re.FindAllString(args, -1)
 
@@ -34,7 +41,7 @@ func ParseRep(tokens []string) (string, error) {
 		// Divide cada parte en clave y valor usando "=" como delimitador
 		kv := strings.SplitN(match, "=", 2)
 		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+			return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 		}
 		key, value := strings.ToLower(kv[0]), kv[1]
 
@@ -59,22 +66,34 @@ func ParseRep(tokens []string) (string, error) {
 			cmd.path = value
 		case "-name":
 			// Verifica que el nombre sea uno de los valores permitidos
-			validNames := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "sb", "file", "ls", "tree"}
+			validNames := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "sb", "file", "ls", "tree", "frag", "audit", "quota"}
 			if !contains(validNames, value) {
-				return "", errors.New("nombre inválido, debe ser uno de los siguientes: mbr, disk, inode, block, bm_inode, bm_block, sb, file, ls, tree")
+				return "", NewError(CodeInvalidArgument, "nombre inválido, debe ser uno de los siguientes: mbr, disk, inode, block, bm_inode, bm_block, sb, file, ls, tree, frag, audit, quota")
 			}
 			cmd.name = value
 		case "-path_file_ls":
 			cmd.path_file_ls = value
+		case "-user":
+			cmd.audit.User = value
+		case "-from", "-to":
+			parsed, err := utils.ParseAuditTime(value, key == "-to")
+			if err != nil {
+				return "", err
+			}
+			if key == "-from" {
+				cmd.audit.From = parsed
+			} else {
+				cmd.audit.To = parsed
+			}
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que los parámetros obligatorios hayan sido proporcionados
 	if cmd.id == "" || cmd.path == "" || cmd.name == "" {
-		return "", errors.New("faltan parámetros requeridos: -id, -path, -name")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -id, -path, -name")
 	}
 
 	if cmd.name == "file" && cmd.path_file_ls == "" {
@@ -85,10 +104,41 @@ func ParseRep(tokens []string) (string, error) {
 		return "", errors.New("el parámetro -path_file_ls es requerido para el reporte 'ls'")
 	}
 
+	// El reporte solo se puede escribir dentro de la carpeta de reportes, si hay una configurada
+	outputPath, err := sandbox.Reports.Resolve(cmd.path)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = outputPath
+
+	// -path_file_ls relativo se resuelve desde el directorio de trabajo si la sesión es de la misma partición
+	if cmd.path_file_ls != "" {
+		cwd := "/"
+		if auth := stores.AuthFromContext(ctx); auth.IsAuthenticated() && auth.GetPartitionID() == cmd.id {
+			cwd = auth.Cwd
+		}
+		cmd.path_file_ls = utils.ResolvePath(cwd, cmd.path_file_ls)
+	}
+
+	// El log de auditoría solo lo puede ver root, tanto en su reporte como en el de archivo
+	if cmd.name == "audit" || (cmd.name == "file" && cmd.path_file_ls == utils.AuditLogPath) {
+		if err := CheckAuditAccess(ctx, cmd.id); err != nil {
+			return "", err
+		}
+	}
+
 	// Aquí se puede agregar la lógica para ejecutar el comando rep con los parámetros proporcionados
-	err := commandRep(cmd)
+	err = commandRep(cmd)
 	if err != nil {
-		fmt.Println("Error:", err)
+		return "", err
+	}
+	// Archivos generados: la salida y, en los reportes de Graphviz, el .dot
+	dotFileName, outputImage := utils.GetFileNames(cmd.path)
+	addArtifact(ctx, outputImage)
+	stores.RegisterReport(outputImage, cmd.id)
+	if _, err := os.Stat(dotFileName); err == nil && dotFileName != outputImage {
+		addArtifact(ctx, dotFileName)
+		stores.RegisterReport(dotFileName, cmd.id)
 	}
 
 	return fmt.Sprintf("REP: Reporte generado exitosamente\n"+
@@ -118,72 +168,84 @@ func commandRep(rep *REP) error {
 		return err
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(mountedDiskPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", mountedDiskPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
 	// Switch para manejar diferentes tipos de reportes
 	switch rep.name {
 	case "mbr":
-		err = reports.ReportMBR(mountedMbr, mountedDiskPath, rep.path)
+		err = reports.ReportMBR(mountedMbr, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 		}
 	case "inode":
-		err = reports.ReportInode(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportInode(mountedSb, dev, rep.path, rep.progress)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 
 		}
 	case "bm_inode":
-		err = reports.ReportBMInode(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportBMInode(mountedSb, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 
 		}
 	case "disk":
-		err = reports.ReportDisk(mountedMbr, mountedDiskPath, rep.path)
+		err = reports.ReportDisk(mountedMbr, dev, mountedDiskPath, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 
 		}
 	case "bm_block":
-		err = reports.ReportBMBlock(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportBMBlock(mountedSb, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 
 		}
 	case "sb":
-		err = reports.ReportSuperBlock(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportSuperBlock(mountedSb, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 
 		}
 	case "block":
-		err = reports.ReportBlock(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportBlock(mountedSb, dev, rep.path, rep.progress)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 		}
 	case "tree":
-		err = reports.ReportTree(mountedSb, mountedDiskPath, rep.path)
+		err = reports.ReportTree(mountedSb, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return	 err
 		}
 
 	case "file":
-		err = reports.ReportFile(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls)
+		err = reports.ReportFile(mountedSb, dev, rep.path, rep.path_file_ls)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 		}
 	case "ls":
-		err = reports.ReportLS(mountedSb, mountedDiskPath, rep.path, rep.path_file_ls)
+		err = reports.ReportLS(mountedSb, dev, rep.path, rep.path_file_ls)
+		if err != nil {
+			return err
+		}
+	case "frag":
+		err = reports.ReportFrag(mountedSb, dev, rep.path)
+		if err != nil {
+			return err
+		}
+	case "audit":
+		err = reports.ReportAudit(mountedSb, dev, rep.path, rep.audit)
+		if err != nil {
+			return err
+		}
+	case "quota":
+		err = reports.ReportQuota(mountedSb, dev, rep.path)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
 			return err
 		}
 
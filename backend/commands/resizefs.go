@@ -0,0 +1,148 @@
+package commands
+
+import (
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RESIZEFS estructura que representa el comando resizefs con sus parámetros
+type RESIZEFS struct {
+	id   string // ID de la partición montada
+	size int    // Nuevo tamaño del sistema de archivos (opcional, por defecto el tamaño de la partición)
+	unit string // Unidad del tamaño (K o M)
+}
+
+/*
+	resizefs -id=201A
+	resizefs -id=201A -size=512 -unit=K
+*/
+
+// ParseResizefs parsea el comando resizefs y lo ejecuta
+func ParseResizefs(tokens []string) (string, error) {
+	cmd := &RESIZEFS{}
+
+	// Unir tokens en una sola cadena
+	args := strings.Join(tokens, " ")
+	// Expresión regular para encontrar los parámetros del comando resizefs
+	re := regexp.MustCompile(`-id=[^\s]+|-size=\d+|-unit=[kKmM]`)
+	matches := re.FindAllString(args, -1)
+
+	for _, match := range matches {
+		kv := strings.SplitN(match, "=", 2)
+		if len(kv) != 2 {
+			return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
+		}
+		key, value := strings.ToLower(kv[0]), strings.Trim(kv[1], "\"")
+
+		switch key {
+		case "-id":
+			if value == "" {
+				return "", errors.New("el id no puede estar vacío")
+			}
+			cmd.id = value
+		case "-size":
+			size, err := strconv.Atoi(value)
+			if err != nil || size <= 0 {
+				return "", errors.New("el tamaño debe ser un número entero positivo")
+			}
+			cmd.size = size
+		case "-unit":
+			cmd.unit = strings.ToUpper(value)
+		default:
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
+		}
+	}
+
+	if cmd.id == "" {
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -id")
+	}
+
+	// Igual que fdisk, la unidad por defecto es M
+	if cmd.unit == "" {
+		cmd.unit = "M"
+	}
+
+	result, err := commandResizefs(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("RESIZEFS: Sistema de archivos redimensionado exitosamente\n"+
+		"-> ID: %s\n"+
+		"-> Inodos: %d -> %d (%d en uso)\n"+
+		"-> Bloques: %d -> %d (%d en uso)%s",
+		cmd.id,
+		result.OldInodes, result.NewInodes, result.UsedInodes,
+		result.OldBlocks, result.NewBlocks, result.UsedBlocks,
+		func() string {
+			if result.Defragmented {
+				return "\n-> La partición se compactó antes de reducir"
+			}
+			return ""
+		}()), nil
+}
+
+func commandResizefs(resizefs *RESIZEFS) (*structures.ResizeResult, error) {
+	// Obtener el superbloque de la partición montada
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(resizefs.id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la partición montada '%s': %w", resizefs.id, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	if partitionSuperblock.S_magic != 0xEF53 {
+		return nil, errors.New("la partición no tiene un sistema de archivos EXT2 (ejecute mkfs primero)")
+	}
+
+	// Calcular el tamaño objetivo: por defecto todo el tamaño actual de la partición
+	target := *mountedPartition
+	if resizefs.size > 0 {
+		sizeBytes, err := utils.ConvertToBytes(resizefs.size, resizefs.unit)
+		if err != nil {
+			return nil, err
+		}
+		if sizeBytes > int(mountedPartition.Part_size) {
+			return nil, fmt.Errorf("el tamaño solicitado (%d bytes) es mayor que la partición (%d bytes)", sizeBytes, mountedPartition.Part_size)
+		}
+		target.Part_size = int32(sizeBytes)
+	}
+
+	// Calcular el nuevo layout igual que mkfs
+	n := calculateN(&target)
+	if n <= 0 {
+		return nil, errors.New("el tamaño solicitado no alcanza para un sistema de archivos EXT2")
+	}
+	if n == partitionSuperblock.S_inodes_count {
+		return nil, errors.New("el sistema de archivos ya tiene ese tamaño")
+	}
+	layout := createSuperBlock(&target, n)
+	if layout == nil {
+		return nil, errors.New("no se pudo calcular el nuevo layout del sistema de archivos")
+	}
+
+	result, err := partitionSuperblock.Resize(dev, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guardar el superbloque con el nuevo layout
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el superbloque: %w", err)
+	}
+
+	logger.Debug("SuperBlock redimensionado", "superblock", partitionSuperblock)
+	return result, nil
+}
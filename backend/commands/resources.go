@@ -0,0 +1,357 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// Consultas de solo lectura que devuelven datos con tipo en lugar de texto, para la API REST.
+// No toman locks: quien las llama (ver analyzer/resources.go) toma los mismos que el comando equivalente.
+
+// PartitionInfo describe una partición de un disco (las extendidas incluyen sus lógicas)
+type PartitionInfo struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"` // P, E o L
+	Fit     string          `json:"fit"`
+	Status  string          `json:"status"`
+	Start   int32           `json:"start"`
+	Size    int32           `json:"size"`
+	ID      string          `json:"id,omitempty"` // ID de montaje, si está montada
+	Logical []PartitionInfo `json:"logical,omitempty"`
+}
+
+// DiskInfo describe un disco a partir de su MBR
+type DiskInfo struct {
+	Path       string          `json:"path"`
+	Size       int32           `json:"size"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Signature  int32           `json:"signature"`
+	Fit        string          `json:"fit"`
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
+// MountInfo es una partición montada
+type MountInfo struct {
+	ID        string `json:"id"`
+	DiskPath  string `json:"disk_path"`
+	Partition string `json:"partition"`
+}
+
+// FileInfo describe un archivo o carpeta de una partición; Children solo se llena para carpetas
+type FileInfo struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	Type     string     `json:"type"` // "dir" o "file"
+	Size     int32      `json:"size"`
+	Owner    string     `json:"owner"`
+	Group    string     `json:"group"`
+	Perm     string     `json:"perm"`
+	Modified time.Time  `json:"modified"`
+	Children []FileInfo `json:"children,omitempty"`
+}
+
+// UserInfo es un usuario de /users.txt
+type UserInfo struct {
+	UID     int32    `json:"uid"`
+	Name    string   `json:"name"`
+	Group   string   `json:"group"`
+	Groups  []string `json:"groups,omitempty"` // Grupos secundarios
+	Expires string   `json:"expires,omitempty"`
+	Locked  bool     `json:"locked"`
+}
+
+// GroupInfo es un grupo de /users.txt
+type GroupInfo struct {
+	GID  int32  `json:"gid"`
+	Name string `json:"name"`
+}
+
+// DescribeDisk lee el MBR del disco y sus EBR. El path pasa por la carpeta de discos, como en los comandos.
+func DescribeDisk(path string) (*DiskInfo, error) {
+	path, err := stores.ResolveDiskPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !structures.IsMemoryPath(path) && !diskFileExists(path) {
+		return nil, NewError(CodeNotFound, "no existe el disco %s", path)
+	}
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		return nil, NewError(CodeNotFound, "error al abrir el disco '%s': %w", path, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	var mbr structures.MBR
+	if err := mbr.Deserialize(dev); err != nil {
+		return nil, fmt.Errorf("error al leer el MBR de '%s': %w", path, err)
+	}
+
+	disk := &DiskInfo{
+		Path:       path,
+		Size:       mbr.Mbr_size,
+		CreatedAt:  time.Unix(int64(mbr.Mbr_creation_date), 0),
+		Signature:  mbr.Mbr_disk_signature,
+		Fit:        byteString(mbr.Mbr_disk_fit[:]),
+		Partitions: []PartitionInfo{},
+	}
+	for _, part := range mbr.Mbr_partitions {
+		if part.Part_size <= 0 {
+			continue
+		}
+		info := PartitionInfo{
+			Name:   byteString(part.Part_name[:]),
+			Type:   byteString(part.Part_type[:]),
+			Fit:    byteString(part.Part_fit[:]),
+			Status: byteString(part.Part_status[:]),
+			Start:  part.Part_start,
+			Size:   part.Part_size,
+		}
+		if info.Status == "1" {
+			info.ID = byteString(part.Part_id[:])
+		}
+		if info.Type == "E" {
+			info.Logical = logicalPartitions(dev, &mbr, part.Part_start)
+		}
+		disk.Partitions = append(disk.Partitions, info)
+	}
+	return disk, nil
+}
+
+// logicalPartitions recorre la lista de EBR de una partición extendida (igual que el reporte mbr)
+func logicalPartitions(dev structures.BlockDevice, mbr *structures.MBR, offset int32) []PartitionInfo {
+	var logical []PartitionInfo
+	for {
+		var ebr structures.EBR
+		if err := ebr.Deserialize(dev, int64(offset)); err != nil || ebr.Part_size <= 0 {
+			break
+		}
+		logical = append(logical, PartitionInfo{
+			Name:   byteString(ebr.Part_name[:]),
+			Type:   "L",
+			Fit:    byteString(ebr.Part_fit[:]),
+			Status: byteString(ebr.Part_status[:]),
+			Start:  ebr.Part_start,
+			Size:   ebr.Part_size,
+		})
+		if ebr.Part_next <= 0 || ebr.Part_next >= mbr.Mbr_size {
+			break
+		}
+		offset = ebr.Part_next
+	}
+	return logical
+}
+
+// ListMounts devuelve las particiones montadas en el orden en que se montaron
+func ListMounts() []MountInfo {
+	mounts := []MountInfo{}
+	for i, id := range stores.ListMounted {
+		mount := MountInfo{ID: id, DiskPath: stores.MountedPartitions[id]}
+		if i < len(stores.ListPatitions) {
+			mount.Partition = stores.ListPatitions[i] // mount agrega a las dos listas a la vez
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+// DescribePath describe el archivo o carpeta del path en la partición de la sesión. Los paths relativos se
+// resuelven desde el directorio de trabajo. depth es cuántos niveles de subcarpetas incluir (negativo: todos).
+func DescribePath(ctx context.Context, partitionID, path string, depth int) (*FileInfo, error) {
+	sb, dev, cwd, err := openSessionPartition(ctx, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer structures.CloseDevice(dev)
+
+	_, _, usersContent, err := readUsersFile(sb, dev)
+	if err != nil {
+		return nil, err
+	}
+	users, groups := ownerNames(usersContent)
+
+	cleanPath := utils.ResolvePath(cwd, path)
+	inodeIndex, inode, err := structures.FindInodeByPath(sb, dev, cleanPath)
+	if err != nil {
+		return nil, NewError(CodeNotFound, "no existe '%s': %w", cleanPath, err)
+	}
+	return describeInode(sb, dev, inodeIndex, inode, cleanPath, depth, users, groups)
+}
+
+func describeInode(sb *structures.SuperBlock, dev structures.BlockDevice, inodeIndex int32, inode *structures.Inode, path string, depth int, users, groups map[int32]string) (*FileInfo, error) {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if path == "/" {
+		name = "/"
+	}
+	info := &FileInfo{
+		Name:     name,
+		Path:     path,
+		Type:     "file",
+		Size:     inode.I_size,
+		Owner:    nameOrID(users, inode.I_uid),
+		Group:    nameOrID(groups, inode.I_gid),
+		Perm:     string(inode.I_perm[:]),
+		Modified: time.Unix(int64(inode.I_mtime), 0),
+	}
+	if inode.I_type[0] != '0' {
+		return info, nil
+	}
+
+	info.Type = "dir"
+	info.Children = []FileInfo{}
+	if depth == 0 {
+		return info, nil
+	}
+	entries, err := sb.DirectoryEntries(dev, inode)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo la carpeta '%s' (inodo %d): %w", path, inodeIndex, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	for _, entry := range entries {
+		child := &structures.Inode{}
+		if err := child.Deserialize(dev, int64(sb.S_inode_start)+int64(entry.Inode)*int64(sb.S_inode_size)); err != nil {
+			return nil, fmt.Errorf("error deserializando inodo %d ('%s'): %w", entry.Inode, entry.Name, err)
+		}
+		childPath := strings.TrimSuffix(path, "/") + "/" + entry.Name
+		childInfo, err := describeInode(sb, dev, entry.Inode, child, childPath, depth-1, users, groups)
+		if err != nil {
+			return nil, err
+		}
+		info.Children = append(info.Children, *childInfo)
+	}
+	return info, nil
+}
+
+// ReadFile devuelve el contenido de un archivo de la partición de la sesión (igual que cat)
+func ReadFile(ctx context.Context, partitionID, path string) (string, error) {
+	sb, dev, cwd, err := openSessionPartition(ctx, partitionID)
+	if err != nil {
+		return "", err
+	}
+	defer structures.CloseDevice(dev)
+
+	cleanPath := utils.ResolvePath(cwd, path)
+	if cleanPath == utils.AuditLogPath {
+		if err := CheckAuditAccess(ctx, partitionID); err != nil {
+			return "", err
+		}
+	}
+	_, inode, err := structures.FindInodeByPath(sb, dev, cleanPath)
+	if err != nil {
+		return "", NewError(CodeNotFound, "no existe '%s': %w", cleanPath, err)
+	}
+	if inode.I_type[0] != '1' {
+		return "", NewError(CodeInvalidArgument, "'%s' no es un archivo", cleanPath)
+	}
+	return structures.ReadFileContent(sb, dev, inode)
+}
+
+// ListUsers devuelve los usuarios y grupos de la partición de la sesión
+func ListUsers(ctx context.Context, partitionID string) ([]UserInfo, []GroupInfo, error) {
+	sb, dev, _, err := openSessionPartition(ctx, partitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer structures.CloseDevice(dev)
+
+	_, _, content, err := readUsersFile(sb, dev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []UserInfo{}
+	groups := []GroupInfo{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			continue // Línea de versión u otra línea que no es de usuario ni grupo
+		}
+		switch {
+		case fields[1] == "G":
+			groups = append(groups, GroupInfo{GID: int32(id), Name: fields[2]})
+		case utils.IsUserLine(fields):
+			record := utils.ParseUserRecord(fields)
+			users = append(users, UserInfo{UID: int32(id), Name: record.Name, Group: record.Group, Groups: record.Supplementary, Expires: record.Expires, Locked: record.Locked})
+		}
+	}
+	return users, groups, nil
+}
+
+// openSessionPartition verifica que haya una sesión en la partición indicada y abre su disco.
+// Devuelve el superbloque, el dispositivo (el llamador lo cierra) y el directorio de trabajo.
+func openSessionPartition(ctx context.Context, partitionID string) (*structures.SuperBlock, structures.BlockDevice, string, error) {
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return nil, nil, "", NewError(CodeUnauthenticated, "no se ha iniciado sesión en ninguna partición")
+	}
+	if auth.GetPartitionID() != partitionID {
+		return nil, nil, "", NewError(CodePermissionDenied, "permiso denegado: la sesión es de la partición '%s', no de '%s'", auth.GetPartitionID(), partitionID)
+	}
+	sb, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	return sb, dev, auth.Cwd, nil
+}
+
+// ownerNames arma los mapas de UID y GID a nombre a partir del contenido de /users.txt
+func ownerNames(usersContent string) (map[int32]string, map[int32]string) {
+	users := map[int32]string{}
+	groups := map[int32]string{}
+	for _, line := range strings.Split(usersContent, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		if fields[1] == "G" {
+			groups[int32(id)] = fields[2]
+		} else if utils.IsUserLine(fields) {
+			users[int32(id)] = fields[3]
+		}
+	}
+	return users, groups
+}
+
+func nameOrID(names map[int32]string, id int32) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(int(id))
+}
+
+// diskFileExists indica si existe el archivo de un disco en el sistema anfitrión
+func diskFileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// byteString convierte un campo de bytes de tamaño fijo en string, sin los nulos del final
+func byteString(b []byte) string {
+	return strings.TrimRight(string(b), "\x00 ")
+}
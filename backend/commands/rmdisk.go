@@ -1,7 +1,8 @@
 package commands
 
 import (
-	"errors"
+	stores "backend/stores"
+	structures "backend/structures"
 	"fmt"
 	"os"
 	"regexp"
@@ -28,7 +29,7 @@ func ParseRmdisk(tokens []string) (string, error) {
 		// Identificar el parámetro inválido
 		for _, token := range tokens {
 			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
 			}
 		}
 	}
@@ -43,7 +44,7 @@ func ParseRmdisk(tokens []string) (string, error) {
 		switch key {
 		case "-path":
 			if len(kv) != 2 {
-				return "", fmt.Errorf("formato de parámetro inválido: %s", match)
+				return "", NewError(CodeInvalidArgument, "formato de parámetro inválido: %s", match)
 			}
 			value := kv[1]
 			if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
@@ -52,17 +53,22 @@ func ParseRmdisk(tokens []string) (string, error) {
 			cmd.path = value
 		default:
 			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido: %s", key)
 		}
 	}
 
 	// Verifica que el parámetro -path haya sido proporcionado
 	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -path")
 	}
+	resolvedPath, err := stores.ResolveDiskPath(cmd.path)
+	if err != nil {
+		return "", err
+	}
+	cmd.path = resolvedPath
 
 	// Aquí se puede agregar la lógica para ejecutar el comando mkdir con los parámetros proporcionados
-	err := commandRmdisk(cmd)
+	err = commandRmdisk(cmd)
 	if err != nil {
 		return "", err
 	}
@@ -72,18 +78,32 @@ func ParseRmdisk(tokens []string) (string, error) {
 }
 
 func commandRmdisk(rmdisk *RMDISK) error {
+	// Los discos en memoria solo se quitan del registro
+	if structures.IsMemoryPath(rmdisk.path) {
+		err := structures.RemoveMemoryDevice(rmdisk.path)
+		if err != nil {
+			return err
+		}
+		stores.ForgetDisk(rmdisk.path)
+		logger.Debug("Disco eliminado", "path", rmdisk.path)
+		return nil
+	}
 
 	if _, err := os.Stat(rmdisk.path); os.IsNotExist(err) {
-		return fmt.Errorf("no existe el archivo %s", rmdisk.path)
+		return NewError(CodeNotFound, "no existe el archivo %s", rmdisk.path)
 	}
 
+	// Cerrar el handle cacheado del disco, sus cambios pendientes ya no importan
+	structures.DiscardDevice(rmdisk.path)
+
 	// Intentar eliminar el archivo
 	err := os.Remove(rmdisk.path)
 	if err != nil {
 		return fmt.Errorf("error al eliminar el archivo %s: %v", rmdisk.path, err)
 	}
 
-	fmt.Printf("Disco %s eliminado exitosamente.\n", rmdisk.path)
+	stores.ForgetDisk(rmdisk.path)
+	logger.Debug("Disco eliminado", "path", rmdisk.path)
 
 	return nil
 }
@@ -1,21 +1,24 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	stores "backend/stores"
 	structures "backend/structures"
+	utils "backend/utils"
 )
 
 type RMGRP struct {
 	name string 
 }
 
-func ParseRmgrp(tokens []string) (string, error) {
+func ParseRmgrp(ctx context.Context, tokens []string) (string, error) {
 	cmd := &RMGRP{}
 
 	if len(tokens) != 1 {
@@ -26,7 +29,7 @@ func ParseRmgrp(tokens []string) (string, error) {
 	match := re.FindStringSubmatch(tokens[0])
 
 	if match == nil {
-		return "", fmt.Errorf("parámetro inválido o formato incorrecto: %s. Uso: rmgrp -name=<nombre>", tokens[0])
+		return "", NewError(CodeInvalidArgument, "parámetro inválido o formato incorrecto: %s. Uso: rmgrp -name=<nombre>", tokens[0])
 	}
 
 	value := match[1] 
@@ -40,7 +43,7 @@ func ParseRmgrp(tokens []string) (string, error) {
 
 	cmd.name = value
 
-	err := commandRmgrp(cmd)
+	err := commandRmgrp(ctx, cmd)
 	if err != nil {
 		return "", err 
 	}
@@ -49,14 +52,16 @@ func ParseRmgrp(tokens []string) (string, error) {
 }
 
 // commandRmgrp contiene la lógica principal para eliminar el grupo
-func commandRmgrp(rmgrp *RMGRP) error {
+func commandRmgrp(ctx context.Context, rmgrp *RMGRP) error {
+	auth := stores.AuthFromContext(ctx)
+
 	// Verificar Permisos
-	if !stores.Auth.IsAuthenticated() {
-		return errors.New("comando rmgrp requiere inicio de sesión")
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando rmgrp requiere inicio de sesión")
 	}
-	currentUser, _, partitionID := stores.Auth.GetCurrentUser()
+	currentUser, partitionID := auth.GetCurrentUser()
 	if currentUser != "root" {
-		return fmt.Errorf("permiso denegado: solo el usuario 'root' puede ejecutar rmgrp (usuario actual: %s)", currentUser)
+		return NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar rmgrp (usuario actual: %s)", currentUser)
 	}
 
 	// Obtener Partición y Superbloque
@@ -64,13 +69,20 @@ func commandRmgrp(rmgrp *RMGRP) error {
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
 	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
 		return errors.New("tamaño de inodo o bloque inválido en superbloque")
 	}
 
 	// Encontrar y Leer Inodo/Contenido de /users.txt
-	fmt.Println("Buscando inodo para /users.txt...")
-	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando inodo para /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
 		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
 	}
@@ -78,15 +90,15 @@ func commandRmgrp(rmgrp *RMGRP) error {
 		return errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	fmt.Println("Leyendo contenido actual de /users.txt...")
-	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	logger.DebugContext(ctx, "Leyendo contenido actual de /users.txt...")
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	// Retorna error si falla la lectura de bloques.
 	if errRead != nil {
 		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
 	}
 
 	// Parsear Contenido y Validar Grupo a Eliminar
-	fmt.Printf("Buscando grupo '%s' para eliminar...\n", rmgrp.name)
+	logger.DebugContext(ctx, "Buscando grupo para eliminar", "group", rmgrp.name)
 	lines := strings.Split(oldContent, "\n")
 	newLines := []string{} // Slice para guardar las líneas que SÍ queremos mantener
 	foundGroup := false
@@ -95,6 +107,8 @@ func commandRmgrp(rmgrp *RMGRP) error {
 		return errors.New("error: el grupo 'root' no puede ser eliminado")
 	}
 
+	primaryMembers := []string{} // Usuarios que tienen el grupo como principal
+
 	for _, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
 		if trimmedLine == "" {
@@ -107,8 +121,18 @@ func commandRmgrp(rmgrp *RMGRP) error {
 		}
 
 		if len(fields) >= 3 && fields[1] == "G" && strings.EqualFold(fields[2], rmgrp.name) {
-			fmt.Printf("Grupo '%s' encontrado (línea: '%s'). Marcado para eliminación.\n", rmgrp.name, line)
+			logger.DebugContext(ctx, "Grupo encontrado y marcado para eliminación", "group", rmgrp.name, "line", line)
 			foundGroup = true
+		} else if utils.IsUserLine(fields) && strings.EqualFold(fields[2], rmgrp.name) {
+			primaryMembers = append(primaryMembers, fields[3])
+			newLines = append(newLines, line)
+		} else if utils.IsUserLine(fields) && utils.HasGroup(utils.SupplementaryGroups(fields), rmgrp.name) {
+			// Quitar el grupo de los grupos secundarios del usuario
+			record := utils.ParseUserRecord(fields)
+			record.Supplementary = slices.DeleteFunc(record.Supplementary, func(g string) bool { return strings.EqualFold(g, rmgrp.name) })
+			newLine := record.Line()
+			logger.DebugContext(ctx, "Usuario desvinculado del grupo", "user", fields[3], "group", rmgrp.name, "line", newLine)
+			newLines = append(newLines, newLine)
 		} else {
 			newLines = append(newLines, line) 
 		}
@@ -116,7 +140,12 @@ func commandRmgrp(rmgrp *RMGRP) error {
 
 	// Verificar si se encontró el grupo
 	if !foundGroup {
-		return fmt.Errorf("error: el grupo '%s' no fue encontrado", rmgrp.name)
+		return NewError(CodeNotFound, "error: el grupo '%s' no fue encontrado", rmgrp.name)
+	}
+
+	// Un usuario no puede quedarse sin grupo principal
+	if len(primaryMembers) > 0 {
+		return fmt.Errorf("error: el grupo '%s' es el grupo principal de: %s. Cámbialo con chgrp antes de eliminarlo", rmgrp.name, strings.Join(primaryMembers, ", "))
 	}
 
 
@@ -127,29 +156,29 @@ func commandRmgrp(rmgrp *RMGRP) error {
 		newContent += "\n"
 	}
 	newSize := int32(len(newContent))
-	fmt.Printf("Nuevo contenido de users.txt preparado (%d bytes).\n", newSize)
+	logger.DebugContext(ctx, "Nuevo contenido de users.txt preparado", "bytes", newSize)
 
 	// Liberar Bloques Antiguos de users.txt
-	fmt.Println("Liberando bloques antiguos de /users.txt...")
-	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Liberando bloques antiguos de /users.txt...")
+	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, dev)
 	if errFree != nil {
-		fmt.Printf("ADVERTENCIA: Error al liberar bloques antiguos de users.txt: %v. Puede haber bloques perdidos.\n", errFree)
+		logger.WarnContext(ctx, "Error al liberar bloques antiguos de users.txt; puede haber bloques perdidos", "error", errFree)
 		return fmt.Errorf("error liberando bloques antiguos: %w", errFree)
 	} else {
-		fmt.Println("Bloques antiguos liberados.")
+		logger.DebugContext(ctx, "Bloques antiguos liberados.")
 	}
 
 	// Asignar Nuevos Bloques para el nuevo contenido
-	fmt.Printf("Asignando bloques para nuevo tamaño (%d bytes)...\n", newSize)
+	logger.DebugContext(ctx, "Asignando bloques para el nuevo tamaño", "bytes", newSize)
 	var newAllocatedBlockIndices [15]int32
 	// Usar allocateDataBlocks existente
-	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, partitionPath)
+	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, dev)
 	if err != nil {
 		return fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
 	}
 
 	// Actualizar Inodo de users.txt
-	fmt.Println("Actualizando inodo /users.txt...")
+	logger.DebugContext(ctx, "Actualizando inodo /users.txt...")
 	usersInode.I_size = newSize                     // Actualizar tamaño
 	usersInode.I_mtime = float32(time.Now().Unix()) // Actualizar tiempo de modificación
 	usersInode.I_atime = usersInode.I_mtime         // Actualizar tiempo de acceso
@@ -157,14 +186,14 @@ func commandRmgrp(rmgrp *RMGRP) error {
 
 	// Serializar el inodo actualizado
 	usersInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(usersInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = usersInode.Serialize(partitionPath, usersInodeOffset)
+	err = usersInode.Serialize(dev, usersInodeOffset)
 	if err != nil {
 		return fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("Serializando SuperBlock después de RMGRP...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de RMGRP...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al serializar el superbloque después de rmgrp: %w", err)
 	}
@@ -1,32 +1,45 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	stores "backend/stores"
 	structures "backend/structures"
+	utils "backend/utils"
 )
 
 type RMUSR struct {
-	user string
+	user  string
+	purge bool // Eliminar también el directorio personal (-purge)
 }
 
-func ParseRmusr(tokens []string) (string, error) {
+func ParseRmusr(ctx context.Context, tokens []string) (string, error) {
 	cmd := &RMUSR{}
 
-	if len(tokens) != 1 {
-		return "", errors.New("formato incorrecto. Uso: rmusr -user=<nombre>")
+	// -purge es una bandera sin valor
+	var userTokens []string
+	for _, token := range tokens {
+		if strings.EqualFold(token, "-purge") {
+			cmd.purge = true
+		} else {
+			userTokens = append(userTokens, token)
+		}
+	}
+	if len(userTokens) != 1 {
+		return "", errors.New("formato incorrecto. Uso: rmusr -user=<nombre> [-purge]")
 	}
 
 	re := regexp.MustCompile(`^-user=("[^"]+"|[^\s]+)$`)
-	match := re.FindStringSubmatch(tokens[0])
+	match := re.FindStringSubmatch(userTokens[0])
 
 	if match == nil {
-		return "", fmt.Errorf("parámetro inválido o formato incorrecto: %s. Uso: rmusr -user=<nombre>", tokens[0])
+		return "", NewError(CodeInvalidArgument, "parámetro inválido o formato incorrecto: %s. Uso: rmusr -user=<nombre> [-purge]", userTokens[0])
 	}
 
 	value := match[1]
@@ -40,63 +53,78 @@ func ParseRmusr(tokens []string) (string, error) {
 
 	cmd.user = value
 
-	err := commandRmusr(cmd)
+	purged, err := commandRmusr(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
 
+	if purged != "" {
+		return fmt.Sprintf("RMUSR: Usuario '%s' eliminado correctamente (%s).", cmd.user, purged), nil
+	}
 	return fmt.Sprintf("RMUSR: Usuario '%s' eliminado correctamente.", cmd.user), nil
 }
 
-func commandRmusr(rmusr *RMUSR) error {
+// commandRmusr elimina la línea del usuario de /users.txt, cierra sus sesiones y, con -purge, borra su directorio personal.
+// Devuelve la descripción de lo que se liberó al purgar (vacío si no se purgó nada).
+func commandRmusr(ctx context.Context, rmusr *RMUSR) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+
 	// Verificar Permisos (Root)
-	if !stores.Auth.IsAuthenticated() {
-		return errors.New("comando rmusr requiere inicio de sesión")
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "comando rmusr requiere inicio de sesión")
 	}
-	currentUser, _, partitionID := stores.Auth.GetCurrentUser()
+	currentUser, partitionID := auth.GetCurrentUser()
 	if currentUser != "root" {
-		return fmt.Errorf("permiso denegado: solo el usuario 'root' puede ejecutar rmusr (usuario actual: %s)", currentUser)
+		return "", NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar rmusr (usuario actual: %s)", currentUser)
 	}
 
 	// No permitir eliminar el usuario root
 	if strings.EqualFold(rmusr.user, "root") {
-		return errors.New("error: el usuario 'root' no puede ser eliminado")
+		return "", errors.New("error: el usuario 'root' no puede ser eliminado")
 	}
 	// No permitir eliminar al usuario actualmente logueado
 	if strings.EqualFold(rmusr.user, currentUser) && currentUser != "root" {
-		return fmt.Errorf("error: no puedes eliminar al usuario '%s' mientras está logueado", currentUser)
+		return "", fmt.Errorf("error: no puedes eliminar al usuario '%s' mientras está logueado", currentUser)
 	}
 
 	// Obtener Partición y Superbloque
 	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
 	if err != nil {
-		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+		return "", fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return "", fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
 	}
+	defer structures.CloseDevice(dev)
 	if partitionSuperblock.S_inode_size <= 0 || partitionSuperblock.S_block_size <= 0 {
-		return errors.New("tamaño de inodo o bloque inválido en superbloque")
+		return "", errors.New("tamaño de inodo o bloque inválido en superbloque")
 	}
 
 	// Encontrar y Leer Inodo/Contenido de /users.txt
-	fmt.Println("Buscando inodo para /users.txt...")
-	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, partitionPath, "/users.txt")
+	logger.DebugContext(ctx, "Buscando inodo para /users.txt...")
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
 	if errFind != nil {
-		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
+		return "", fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
 	}
 	if usersInode.I_type[0] != '1' {
-		return errors.New("error crítico: /users.txt no es un archivo")
+		return "", errors.New("error crítico: /users.txt no es un archivo")
 	}
 
-	fmt.Println("Leyendo contenido actual de /users.txt...")
-	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, partitionPath, usersInode)
+	logger.DebugContext(ctx, "Leyendo contenido actual de /users.txt...")
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
 	if errRead != nil {
-		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
+		return "", fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
 	}
 
 	// Parsear Contenido y Validar Usuario a Eliminar
-	fmt.Printf("Buscando usuario '%s' para eliminar...\n", rmusr.user)
+	logger.DebugContext(ctx, "Buscando usuario para eliminar", "user", rmusr.user)
 	lines := strings.Split(oldContent, "\n")
 	newLines := []string{}
 	foundUser := false
+	var userName, userID string
 
 	for _, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
@@ -110,8 +138,9 @@ func commandRmusr(rmusr *RMUSR) error {
 		}
 
 		if len(fields) >= 4 && fields[1] == "U" && strings.EqualFold(fields[3], rmusr.user) { // <-- Cambiado fields[2] a fields[3]
-			fmt.Printf("Usuario '%s' encontrado (línea: '%s'). Marcado para eliminación.\n", rmusr.user, line)
+			logger.DebugContext(ctx, "Usuario encontrado y marcado para eliminación", "user", rmusr.user, "line", line)
 			foundUser = true
+			userName, userID = fields[3], fields[0]
 		} else {
 			// Conservar la línea original
 			newLines = append(newLines, line)
@@ -119,7 +148,25 @@ func commandRmusr(rmusr *RMUSR) error {
 	}
 	// Verificar si se encontró el usuario
 	if !foundUser {
-		return fmt.Errorf("error: el usuario '%s' no fue encontrado", rmusr.user)
+		return "", NewError(CodeNotFound, "error: el usuario '%s' no fue encontrado", rmusr.user)
+	}
+
+	// Eliminar el directorio personal antes de tocar /users.txt: si no se puede, el usuario se conserva
+	purged := ""
+	if rmusr.purge {
+		uid, errConv := strconv.ParseInt(userID, 10, 32)
+		if errConv != nil {
+			return "", fmt.Errorf("error: UID inválido '%s' para el usuario '%s'", userID, userName)
+		}
+		removed, freedInodes, freedBlocks, errPurge := purgeHome(partitionSuperblock, dev, userName, int32(uid))
+		if errPurge != nil {
+			return "", errPurge
+		}
+		if removed {
+			purged = fmt.Sprintf("%s eliminado: %d inodos y %d bloques liberados", utils.HomeDir(userName), freedInodes, freedBlocks)
+		} else {
+			purged = "no tenía directorio personal"
+		}
 	}
 
 	// Preparar Nuevo Contenido Final
@@ -128,45 +175,49 @@ func commandRmusr(rmusr *RMUSR) error {
 		newContent += "\n"
 	}
 	newSize := int32(len(newContent))
-	fmt.Printf("Nuevo contenido de users.txt preparado (%d bytes).\n", newSize)
+	logger.DebugContext(ctx, "Nuevo contenido de users.txt preparado", "bytes", newSize)
 
 	// Liberar Bloques Antiguos de users.txt
-	fmt.Println("Liberando bloques antiguos de /users.txt...")
-	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, partitionPath)
+	logger.DebugContext(ctx, "Liberando bloques antiguos de /users.txt...")
+	errFree := structures.FreeInodeBlocks(usersInode, partitionSuperblock, dev)
 	if errFree != nil {
-		fmt.Printf("ADVERTENCIA: Error al liberar bloques antiguos de users.txt: %v. Puede haber bloques perdidos.\n", errFree)
-		return fmt.Errorf("error liberando bloques antiguos: %w", errFree)
+		logger.WarnContext(ctx, "Error al liberar bloques antiguos de users.txt; puede haber bloques perdidos", "error", errFree)
+		return "", fmt.Errorf("error liberando bloques antiguos: %w", errFree)
 	} else {
-		fmt.Println("Bloques antiguos liberados.")
+		logger.DebugContext(ctx, "Bloques antiguos liberados.")
 	}
 
 	// Asignar Nuevos Bloques para el nuevo contenido
-	fmt.Printf("Asignando bloques para nuevo tamaño (%d bytes)...\n", newSize)
+	logger.DebugContext(ctx, "Asignando bloques para el nuevo tamaño", "bytes", newSize)
 	var newAllocatedBlockIndices [15]int32
-	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, partitionPath)
+	newAllocatedBlockIndices, err = allocateDataBlocks([]byte(newContent), newSize, partitionSuperblock, dev)
 	if err != nil {
-		return fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
+		return "", fmt.Errorf("falló la re-asignación de bloques para /users.txt: %w", err)
 	}
 
 	// Actualizar Inodo de users.txt
-	fmt.Println("Actualizando inodo /users.txt...")
+	logger.DebugContext(ctx, "Actualizando inodo /users.txt...")
 	usersInode.I_size = newSize
 	usersInode.I_mtime = float32(time.Now().Unix())
 	usersInode.I_atime = usersInode.I_mtime
 	usersInode.I_block = newAllocatedBlockIndices
 
 	usersInodeOffset := int64(partitionSuperblock.S_inode_start) + int64(usersInodeIndex)*int64(partitionSuperblock.S_inode_size)
-	err = usersInode.Serialize(partitionPath, usersInodeOffset)
+	err = usersInode.Serialize(dev, usersInodeOffset)
 	if err != nil {
-		return fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
+		return "", fmt.Errorf("error serializando inodo /users.txt actualizado: %w", err)
 	}
 
 	// Serializar Superbloque
-	fmt.Println("Serializando SuperBlock después de RMUSR...")
-	err = partitionSuperblock.Serialize(partitionPath, int64(mountedPartition.Part_start))
+	logger.DebugContext(ctx, "Serializando SuperBlock después de RMUSR...")
+	err = partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start))
 	if err != nil {
-		return fmt.Errorf("error al serializar el superbloque después de rmusr: %w", err)
+		return "", fmt.Errorf("error al serializar el superbloque después de rmusr: %w", err)
 	}
 
-	return nil // Éxito
+	// Cerrar las sesiones que el usuario tenía abiertas, como lockusr
+	closed := stores.Sessions.DeleteUser(partitionID, userName)
+	logger.DebugContext(ctx, "Sesiones cerradas del usuario eliminado", "user", userName, "sessions", closed)
+
+	return purged, nil // Éxito
 }
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	stores "backend/stores"
+)
+
+type SESSIONS struct {
+	kill string // Identificador corto de la sesión a terminar (vacío para solo listar)
+}
+
+// ParseSessions lista las sesiones activas de la partición de root, o termina una con -kill=<id>
+func ParseSessions(ctx context.Context, tokens []string) (string, error) {
+	cmd := &SESSIONS{}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-kill=([0-9a-fA-F]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(matches) != len(tokens) {
+		for _, token := range tokens {
+			if !re.MatchString(token) {
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
+			}
+		}
+	}
+	if len(matches) > 1 {
+		return "", errors.New("parámetro '-kill' especificado más de una vez")
+	}
+	if len(matches) == 1 {
+		cmd.kill = matches[0][1]
+	}
+
+	return commandSessions(ctx, cmd)
+}
+
+func commandSessions(ctx context.Context, sessions *SESSIONS) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+
+	//Verificar Permisos
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "comando sessions requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return "", NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar sessions (usuario actual: %s)", currentUser)
+	}
+
+	if sessions.kill != "" {
+		if strings.EqualFold(sessions.kill, auth.SessionID()) {
+			return "", errors.New("error: no se puede terminar la sesión actual, use logout")
+		}
+		terminated, err := stores.Sessions.Terminate(partitionID, sessions.kill)
+		if err != nil {
+			return "", err
+		}
+		logger.InfoContext(ctx, "Sesión terminada", "session", terminated.ID(), "user", terminated.Username, "by", currentUser)
+		return fmt.Sprintf("SESSIONS: Sesión %s del usuario '%s' terminada.", terminated.ID(), terminated.Username), nil
+	}
+
+	list := stores.Sessions.List(partitionID)
+	var lines []string
+	lines = append(lines, fmt.Sprintf("SESSIONS: %d sesiones activas en %s (inactividad máxima: %s, duración máxima: %s)",
+		len(list), partitionID, formatTimeout(stores.SessionTimeouts.Idle), formatTimeout(stores.SessionTimeouts.Absolute)))
+	for _, session := range list {
+		marker := " "
+		if session.ID() == auth.SessionID() {
+			marker = "*" // Sesión desde la que se ejecuta el comando
+		}
+		user := session.Username
+		if len(session.Previous) > 0 {
+			user += fmt.Sprintf(" (su desde %s)", strings.Join(session.Previous, " → "))
+		}
+		lines = append(lines, fmt.Sprintf("%s %s usuario: %s, directorio: %s, inicio: %s, último uso: %s, expira: %s",
+			marker, session.ID(), user, session.Cwd, session.CreatedAt.Format(sessionTimeLayout),
+			session.LastUsedAt.Format(sessionTimeLayout), formatDeadline(session.ExpiresAt, session.IdleDeadline())))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// formatTimeout muestra un límite de sesión, o "sin límite" si está desactivado
+func formatTimeout(limit time.Duration) string {
+	if limit <= 0 {
+		return "sin límite"
+	}
+	return limit.String()
+}
+
+// formatDeadline muestra el primero de los dos vencimientos de una sesión (los valores cero no cuentan)
+func formatDeadline(absolute, idle time.Time) string {
+	deadline := absolute
+	if deadline.IsZero() || (!idle.IsZero() && idle.Before(deadline)) {
+		deadline = idle
+	}
+	if deadline.IsZero() {
+		return "nunca"
+	}
+	return deadline.Format(sessionTimeLayout)
+}
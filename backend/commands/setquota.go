@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type SETQUOTA struct {
+	user   string // Usuario al que se le asigna la cuota
+	grp    string // Grupo al que se le asigna la cuota
+	blocks int32  // Máximo de bloques (0 = sin límite, -1 = no se cambia)
+	inodes int32  // Máximo de inodos (0 = sin límite, -1 = no se cambia)
+}
+
+// ParseSetquota asigna la cuota de bloques y/o inodos de un usuario (-user) o de un grupo (-grp)
+func ParseSetquota(ctx context.Context, tokens []string) (string, error) {
+	cmd := &SETQUOTA{blocks: -1, inodes: -1}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user|grp)=("[^"]+"|[^\s]+)|-(blocks|inodes)=(\d+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(matches) != len(tokens) {
+		for _, token := range tokens {
+			if !re.MatchString(token) {
+				return "", NewError(CodeInvalidArgument, "parámetro inválido: %s", token)
+			}
+		}
+	}
+
+	for _, match := range matches {
+		if match[3] != "" {
+			limit, err := strconv.ParseInt(match[4], 10, 32)
+			if err != nil {
+				return "", fmt.Errorf("valor de -%s inválido: %s", match[3], match[4])
+			}
+			if strings.ToLower(match[3]) == "blocks" {
+				cmd.blocks = int32(limit)
+			} else {
+				cmd.inodes = int32(limit)
+			}
+			continue
+		}
+		value := strings.Trim(match[2], "\"")
+		if len(value) > 10 {
+			return "", fmt.Errorf("el valor para '-%s' ('%s') excede los 10 caracteres", match[1], value)
+		}
+		if strings.ToLower(match[1]) == "user" {
+			cmd.user = value
+		} else {
+			cmd.grp = value
+		}
+	}
+
+	if (cmd.user == "") == (cmd.grp == "") {
+		return "", errors.New("debe indicar uno de -user o -grp")
+	}
+	if cmd.blocks < 0 && cmd.inodes < 0 {
+		return "", NewError(CodeInvalidArgument, "faltan parámetros requeridos: -blocks y/o -inodes")
+	}
+
+	quota, err := commandSetquota(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	target := fmt.Sprintf("usuario '%s'", cmd.user)
+	if cmd.grp != "" {
+		target = fmt.Sprintf("grupo '%s'", cmd.grp)
+	}
+	if quota.Unlimited() {
+		return fmt.Sprintf("SETQUOTA: El %s ya no tiene cuota.", target), nil
+	}
+	return fmt.Sprintf("SETQUOTA: Cuota del %s: %s bloques, %s inodos.", target, utils.FormatQuotaLimit(quota.Blocks), utils.FormatQuotaLimit(quota.Inodes)), nil
+}
+
+// commandSetquota guarda la cuota en /quota.txt (lo crea si no existe) y devuelve la cuota resultante.
+// Una cuota menor que el uso actual se acepta: solo impide crear más.
+func commandSetquota(ctx context.Context, setquota *SETQUOTA) (utils.Quota, error) {
+	auth := stores.AuthFromContext(ctx)
+
+	//Verificar Permisos
+	if !auth.IsAuthenticated() {
+		return utils.Quota{}, NewError(CodeUnauthenticated, "comando setquota requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return utils.Quota{}, NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar setquota (usuario actual: %s)", currentUser)
+	}
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return utils.Quota{}, fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return utils.Quota{}, fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	// El usuario o grupo debe existir; se guarda con el nombre tal como está en /users.txt
+	_, _, usersContent, err := readUsersFile(partitionSuperblock, dev)
+	if err != nil {
+		return utils.Quota{}, err
+	}
+	kind, name := "U", setquota.user
+	if setquota.grp != "" {
+		kind = "G"
+		if _, name, err = lookupGroup(usersContent, setquota.grp); err != nil {
+			return utils.Quota{}, err
+		}
+		setquota.grp = name
+	} else {
+		owner, err := lookupOwner(usersContent, setquota.user)
+		if err != nil {
+			return utils.Quota{}, err
+		}
+		name = owner.User
+		setquota.user = name
+	}
+
+	quotaInodeIndex, quotaInode, table, err := readQuotaFile(partitionSuperblock, dev)
+	if err != nil {
+		return utils.Quota{}, err
+	}
+	quota := table.User(name)
+	if kind == "G" {
+		quota = table.Group(name)
+	}
+	if setquota.blocks >= 0 {
+		quota.Blocks = setquota.blocks
+	}
+	if setquota.inodes >= 0 {
+		quota.Inodes = setquota.inodes
+	}
+	table.Set(kind, name, quota)
+	content := table.Format()
+
+	if quotaInodeIndex >= 0 {
+		logger.DebugContext(ctx, "Actualizando archivo de cuotas", "path", utils.QuotaFilePath)
+		return quota, rewriteFile(partitionSuperblock, mountedPartition, dev, quotaInodeIndex, quotaInode, content, utils.QuotaFilePath)
+	}
+
+	logger.DebugContext(ctx, "Creando archivo de cuotas", "path", utils.QuotaFilePath)
+	if err := createOwnedFile(partitionSuperblock, dev, 0, strings.TrimPrefix(utils.QuotaFilePath, "/"), []byte(content), 1, 1, quotaFilePerm); err != nil {
+		return utils.Quota{}, fmt.Errorf("error al crear %s: %w", utils.QuotaFilePath, err)
+	}
+	if err := partitionSuperblock.Serialize(dev, int64(mountedPartition.Part_start)); err != nil {
+		return utils.Quota{}, fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+	return quota, nil
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type SU struct {
+	user string // Usuario al que se cambia (por defecto, root)
+	pass string // Contraseña del usuario destino (root puede omitirla)
+}
+
+func ParseSu(ctx context.Context, tokens []string) (string, error) {
+	cmd := &SU{user: "root"}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user|pass)=("[^"]*"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+	if len(matches) != len(tokens) {
+		return "", errors.New("formato incorrecto. Uso: su [-user=<usuario>] -pass=<contraseña>")
+	}
+
+	for _, match := range matches {
+		key := strings.ToLower(match[1])
+		value := match[2]
+
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = strings.Trim(value, "\"")
+		}
+		if value == "" {
+			return "", fmt.Errorf("el valor para '-%s' no puede estar vacío", key)
+		}
+
+		switch key {
+		case "user":
+			cmd.user = value
+		case "pass":
+			cmd.pass = value
+		}
+	}
+
+	err := commandSu(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("SU: Ahora ejecutas como '%s'. Usa 'exit' para volver al usuario anterior.", cmd.user), nil
+}
+
+// commandSu cambia el usuario de la sesión después de verificar la contraseña del usuario destino.
+// Tanto los cambios exitosos como los fallidos quedan en el log de auditoría.
+func commandSu(ctx context.Context, su *SU) error {
+	auth := stores.AuthFromContext(ctx)
+
+	// Verificar sesión
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando su requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+
+	err := verifySu(auth, su)
+	entry := utils.AuditEntry{Event: "su", Target: su.user, Result: "ok"}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	if auditErr := recordAudit(auth, entry); auditErr != nil {
+		return fmt.Errorf("error al registrar su en el log de auditoría de '%s': %w", partitionID, auditErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.DebugContext(ctx, "Cambiando usuario de la sesión", "from", currentUser, "to", su.user)
+	return auth.SwitchUser(su.user)
+}
+
+// verifySu comprueba que el usuario destino exista y que la contraseña sea la suya
+func verifySu(auth *stores.AuthStore, su *SU) error {
+	currentUser, partitionID := auth.GetCurrentUser()
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	_, _, content, err := readUsersFile(partitionSuperblock, dev)
+	if err != nil {
+		return err
+	}
+	fields := utils.FindUser(content, su.user)
+	if fields == nil {
+		return NewError(CodeNotFound, "el usuario '%s' no existe en la partición '%s'", su.user, partitionID)
+	}
+	su.user = fields[3] // Usar el nombre tal como está en /users.txt
+
+	// root puede cambiar a cualquier usuario sin conocer su contraseña
+	if currentUser == "root" {
+		return nil
+	}
+	if su.pass == "" {
+		return NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -pass")
+	}
+	return verifyAccountPassword(partitionID, utils.ParseUserRecord(fields), su.pass)
+}
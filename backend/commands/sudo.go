@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+// CommandRunner ejecuta un comando ya separado de sus argumentos. sudo la recibe del analizador
+// para ejecutar el comando elevado sin que este paquete dependa de él.
+type CommandRunner func(ctx context.Context, command string, arguments []string) (string, error)
+
+type SUDO struct {
+	pass      string   // Contraseña del propio usuario
+	command   string   // Comando a ejecutar como root
+	arguments []string // Argumentos del comando
+}
+
+func ParseSudo(ctx context.Context, tokens []string, run CommandRunner) (string, error) {
+	cmd := &SUDO{}
+
+	if len(tokens) < 2 {
+		return "", errors.New("formato incorrecto. Uso: sudo -pass=<tu contraseña> <comando> [parámetros]")
+	}
+	re := regexp.MustCompile(`(?i)^-pass=("[^"]*"|[^\s]+)$`)
+	match := re.FindStringSubmatch(tokens[0])
+	if match == nil {
+		return "", fmt.Errorf("se esperaba -pass=<tu contraseña> antes del comando, se encontró: %s", tokens[0])
+	}
+	cmd.pass = strings.Trim(match[1], "\"")
+	if cmd.pass == "" {
+		return "", errors.New("el valor para '-pass' no puede estar vacío")
+	}
+	cmd.command = strings.ToLower(tokens[1])
+	cmd.arguments = tokens[2:]
+	if cmd.command == "sudo" {
+		return "", errors.New("no se puede anidar sudo")
+	}
+
+	return commandSudo(ctx, cmd, run)
+}
+
+// commandSudo verifica que el usuario pertenezca al grupo wheel y que la contraseña sea la suya,
+// ejecuta el comando como root y registra el intento en el log de auditoría, haya funcionado o no.
+func commandSudo(ctx context.Context, sudo *SUDO, run CommandRunner) (string, error) {
+	auth := stores.AuthFromContext(ctx)
+
+	// Verificar sesión
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "comando sudo requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+
+	// root no necesita elevarse
+	if currentUser == "root" {
+		return run(ctx, sudo.command, sudo.arguments)
+	}
+
+	entry := utils.AuditEntry{
+		Event:   "sudo",
+		Target:  "root",
+		Command: utils.RedactPasswords(strings.Join(append([]string{sudo.command}, sudo.arguments...), " ")),
+	}
+
+	var result string
+	err := verifySudo(auth, sudo)
+	if err == nil {
+		logger.InfoContext(ctx, "SUDO: comando ejecutado como root", "user", currentUser, "command", sudo.command)
+		result, err = run(stores.WithAuth(ctx, auth.Elevated()), sudo.command, sudo.arguments)
+	}
+
+	entry.Result = "ok"
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	if auditErr := recordAudit(auth, entry); auditErr != nil {
+		return "", errors.Join(err, fmt.Errorf("error al registrar sudo en el log de auditoría de '%s': %w", partitionID, auditErr))
+	}
+	return result, err
+}
+
+// verifySudo comprueba que el usuario de la sesión esté en el grupo wheel, que la contraseña sea la suya y que
+// su cuenta pueda usarse
+func verifySudo(auth *stores.AuthStore, sudo *SUDO) error {
+	currentUser, partitionID := auth.GetCurrentUser()
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	_, _, content, err := readUsersFile(partitionSuperblock, dev)
+	if err != nil {
+		return err
+	}
+	fields := utils.FindUser(content, currentUser)
+	if fields == nil {
+		return NewError(CodeNotFound, "el usuario '%s' ya no existe en la partición '%s'", currentUser, partitionID)
+	}
+	if !utils.HasGroup(utils.UserGroups(fields), utils.SudoGroup) {
+		return NewError(CodePermissionDenied, "permiso denegado: '%s' no pertenece al grupo '%s'", currentUser, utils.SudoGroup)
+	}
+
+	// Los intentos fallidos cuentan para el bloqueo, igual que en login y su
+	return verifyAccountPassword(partitionID, utils.ParseUserRecord(fields), sudo.pass)
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	structures "backend/structures"
+	"errors"
+	"fmt"
+)
+
+// SyncDisk vuelca y sincroniza un disco tomando su lock (lo provee el analizador, que es quien maneja los locks).
+// Devuelve false si el disco ya no estaba abierto.
+type SyncDisk func(path string) (bool, error)
+
+// ParseSync analiza el comando sync, que no recibe parámetros
+func ParseSync(tokens []string, syncDisk SyncDisk) (string, error) {
+	if len(tokens) != 0 {
+		return "", errors.New("el comando sync no acepta parámetros")
+	}
+	return commandSync(syncDisk)
+}
+
+// commandSync vuelca los cambios en caché de todos los discos abiertos y los sincroniza físicamente
+func commandSync(syncDisk SyncDisk) (string, error) {
+	var errs []error
+	count := 0
+	for _, path := range structures.CachedDevicePaths() {
+		synced, err := syncDisk(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if synced {
+			count++
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", fmt.Errorf("error al sincronizar los discos: %w", err)
+	}
+	return fmt.Sprintf("SYNC: %d disco(s) sincronizado(s)", count), nil
+}
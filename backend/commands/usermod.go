@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+	utils "backend/utils"
+)
+
+type USERMOD struct {
+	user    string // Usuario a modificar
+	addgrp  string // Grupo secundario a agregar (opcional)
+	delgrp  string // Grupo secundario a quitar (opcional)
+	expires string // Fecha de expiración AAAA-MM-DD o "never" para quitarla (opcional)
+}
+
+func ParseUsermod(ctx context.Context, tokens []string) (string, error) {
+	cmd := &USERMOD{}
+
+	args := strings.Join(tokens, " ")
+	re := regexp.MustCompile(`-(user|addgrp|delgrp|expires)=("[^"]+"|[^\s]+)`)
+	matches := re.FindAllStringSubmatch(args, -1)
+
+	for _, match := range matches {
+		key := strings.ToLower(match[1])
+		value := match[2]
+
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = strings.Trim(value, "\"")
+		}
+
+		if len(value) > 10 {
+			return "", fmt.Errorf("el valor para '-%s' ('%s') excede los 10 caracteres", key, value)
+		}
+		if value == "" {
+			return "", fmt.Errorf("el valor para '-%s' no puede estar vacío", key)
+		}
+
+		switch key {
+		case "user":
+			cmd.user = value
+		case "addgrp":
+			cmd.addgrp = value
+		case "delgrp":
+			cmd.delgrp = value
+		case "expires":
+			if !strings.EqualFold(value, "never") {
+				expires, err := utils.ParseExpiryDate(value)
+				if err != nil {
+					return "", err
+				}
+				value = expires
+			}
+			cmd.expires = value
+		default:
+			return "", NewError(CodeInvalidArgument, "parámetro desconocido detectado por regex: %s", key)
+		}
+	}
+	if cmd.user == "" {
+		return "", NewError(CodeInvalidArgument, "parámetro obligatorio faltante: -user")
+	}
+	if cmd.addgrp == "" && cmd.delgrp == "" && cmd.expires == "" {
+		return "", errors.New("se requiere -addgrp, -delgrp o -expires")
+	}
+	if cmd.addgrp != "" && strings.EqualFold(cmd.addgrp, cmd.delgrp) {
+		return "", errors.New("-addgrp y -delgrp no pueden indicar el mismo grupo")
+	}
+
+	err := commandUsermod(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	var changes []string
+	if cmd.addgrp != "" {
+		changes = append(changes, fmt.Sprintf("agregado al grupo '%s'", cmd.addgrp))
+	}
+	if cmd.delgrp != "" {
+		changes = append(changes, fmt.Sprintf("quitado del grupo '%s'", cmd.delgrp))
+	}
+	if strings.EqualFold(cmd.expires, "never") {
+		changes = append(changes, "sin fecha de expiración")
+	} else if cmd.expires != "" {
+		changes = append(changes, fmt.Sprintf("expira el %s", cmd.expires))
+	}
+	return fmt.Sprintf("USERMOD: Usuario '%s' %s.", cmd.user, strings.Join(changes, " y ")), nil
+}
+
+// commandUsermod agrega o quita grupos secundarios de un usuario y cambia su fecha de expiración.
+// El grupo principal se cambia con chgrp.
+func commandUsermod(ctx context.Context, usermod *USERMOD) error {
+	auth := stores.AuthFromContext(ctx)
+
+	//Verificar Permisos
+	if !auth.IsAuthenticated() {
+		return NewError(CodeUnauthenticated, "comando usermod requiere inicio de sesión")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+	if currentUser != "root" {
+		return NewError(CodePermissionDenied, "permiso denegado: solo el usuario 'root' puede ejecutar usermod (usuario actual: %s)", currentUser)
+	}
+
+	// Obtener Partición y Superbloque
+	partitionSuperblock, mountedPartition, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	// Encontrar y Leer Inodo/Contenido de /users.txt
+	usersInodeIndex, usersInode, errFind := structures.FindInodeByPath(partitionSuperblock, dev, "/users.txt")
+	if errFind != nil {
+		return fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", errFind)
+	}
+	if usersInode.I_type[0] != '1' {
+		return errors.New("error crítico: /users.txt no es un archivo")
+	}
+
+	oldContent, errRead := structures.ReadFileContent(partitionSuperblock, dev, usersInode)
+	if errRead != nil {
+		return fmt.Errorf("error leyendo el contenido de /users.txt: %w", errRead)
+	}
+
+	lines := strings.Split(oldContent, "\n")
+	parsedLines := make([][]string, 0, len(lines))
+	groupExists := false
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+		fields := strings.Split(trimmedLine, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if usermod.addgrp != "" && len(fields) == 3 && fields[1] == "G" && strings.EqualFold(fields[2], usermod.addgrp) {
+			groupExists = true
+		}
+		parsedLines = append(parsedLines, fields)
+	}
+	if usermod.addgrp != "" && !groupExists {
+		return NewError(CodeNotFound, "error: el grupo '%s' no existe", usermod.addgrp)
+	}
+
+	// Modificar la línea del usuario
+	newLines := make([]string, 0, len(parsedLines))
+	foundUser := false
+	for _, fields := range parsedLines {
+		if !utils.IsUserLine(fields) || !strings.EqualFold(fields[3], usermod.user) {
+			newLines = append(newLines, strings.Join(fields, ","))
+			continue
+		}
+		foundUser = true
+
+		record := utils.ParseUserRecord(fields)
+		if usermod.delgrp != "" {
+			if strings.EqualFold(fields[2], usermod.delgrp) {
+				return fmt.Errorf("error: '%s' es el grupo principal de '%s'; usa chgrp para cambiarlo", usermod.delgrp, usermod.user)
+			}
+			if !utils.HasGroup(record.Supplementary, usermod.delgrp) {
+				return fmt.Errorf("error: el usuario '%s' no pertenece al grupo '%s'", usermod.user, usermod.delgrp)
+			}
+			record.Supplementary = slices.DeleteFunc(record.Supplementary, func(g string) bool { return strings.EqualFold(g, usermod.delgrp) })
+		}
+		if usermod.addgrp != "" {
+			if utils.HasGroup(utils.UserGroups(fields), usermod.addgrp) {
+				return fmt.Errorf("error: el usuario '%s' ya pertenece al grupo '%s'", usermod.user, usermod.addgrp)
+			}
+			record.Supplementary = append(record.Supplementary, usermod.addgrp)
+		}
+		if strings.EqualFold(usermod.expires, "never") {
+			record.Expires = ""
+		} else if usermod.expires != "" {
+			record.Expires = usermod.expires
+		}
+
+		newLine := record.Line()
+		logger.DebugContext(ctx, "Línea del usuario modificada", "user", usermod.user, "line", newLine)
+		newLines = append(newLines, newLine)
+	}
+	if !foundUser {
+		return NewError(CodeNotFound, "error: el usuario '%s' no existe", usermod.user)
+	}
+
+	// Dejar el archivo en el formato actual, que admite grupos secundarios y expiración
+	newContent, _, err := utils.MigrateUsersContent(strings.Join(newLines, "\n"))
+	if err != nil {
+		return fmt.Errorf("error migrando /users.txt: %w", err)
+	}
+
+	logger.DebugContext(ctx, "Actualizando usuario en /users.txt", "user", usermod.user)
+	return writeUsersFile(partitionSuperblock, mountedPartition, dev, usersInodeIndex, usersInode, newContent)
+}
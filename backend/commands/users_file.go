@@ -0,0 +1,63 @@
+package commands
+
+import (
+	structures "backend/structures"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// readUsersFile busca /users.txt y devuelve su índice de inodo, el inodo y el contenido
+func readUsersFile(sb *structures.SuperBlock, dev structures.BlockDevice) (int32, *structures.Inode, string, error) {
+	usersInodeIndex, usersInode, err := structures.FindInodeByPath(sb, dev, "/users.txt")
+	if err != nil {
+		return -1, nil, "", fmt.Errorf("error crítico: no se pudo encontrar el archivo /users.txt: %w", err)
+	}
+	if usersInode.I_type[0] != '1' {
+		return -1, nil, "", errors.New("error crítico: /users.txt no es un archivo")
+	}
+	content, err := structures.ReadFileContent(sb, dev, usersInode)
+	if err != nil {
+		return -1, nil, "", fmt.Errorf("error leyendo el contenido de /users.txt: %w", err)
+	}
+	return usersInodeIndex, usersInode, content, nil
+}
+
+// writeUsersFile reemplaza el contenido de /users.txt: libera sus bloques, asigna los necesarios para el nuevo
+// contenido y guarda el inodo y el superbloque. Es la misma secuencia que usan mkusr, rmusr, mkgrp, rmgrp y chgrp.
+func writeUsersFile(sb *structures.SuperBlock, partition *structures.Partition, dev structures.BlockDevice, usersInodeIndex int32, usersInode *structures.Inode, content string) error {
+	return rewriteFile(sb, partition, dev, usersInodeIndex, usersInode, content, "/users.txt")
+}
+
+// rewriteFile reemplaza el contenido del archivo path (inodo inodeIndex) como lo hace writeUsersFile
+func rewriteFile(sb *structures.SuperBlock, partition *structures.Partition, dev structures.BlockDevice, inodeIndex int32, inode *structures.Inode, content string, path string) error {
+	newSize := int32(len(content))
+
+	// Liberar Bloques Antiguos del archivo
+	if err := structures.FreeInodeBlocks(inode, sb, dev); err != nil {
+		return fmt.Errorf("error liberando bloques antiguos de %s: %w", path, err)
+	}
+
+	// Asignar Nuevos Bloques para el nuevo contenido
+	newAllocatedBlockIndices, err := allocateDataBlocks([]byte(content), newSize, sb, dev)
+	if err != nil {
+		return fmt.Errorf("falló la re-asignación de bloques para %s: %w", path, err)
+	}
+
+	// Actualizar Inodo del archivo
+	inode.I_size = newSize
+	inode.I_mtime = float32(time.Now().Unix())
+	inode.I_atime = inode.I_mtime
+	inode.I_block = newAllocatedBlockIndices
+
+	inodeOffset := int64(sb.S_inode_start) + int64(inodeIndex)*int64(sb.S_inode_size)
+	if err := inode.Serialize(dev, inodeOffset); err != nil {
+		return fmt.Errorf("error serializando inodo %s actualizado: %w", path, err)
+	}
+
+	// Serializar Superbloque
+	if err := sb.Serialize(dev, int64(partition.Part_start)); err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %w", err)
+	}
+	return nil
+}
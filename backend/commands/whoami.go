@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	stores "backend/stores"
+	structures "backend/structures"
+)
+
+// Formato de fechas y horas de los comandos de sesión
+const sessionTimeLayout = "2006-01-02 15:04:05"
+
+// ParseWhoami muestra el usuario, grupo y partición de la sesión y cuándo se inició
+func ParseWhoami(ctx context.Context, tokens []string) (string, error) {
+	if len(tokens) != 0 {
+		return "", errors.New("el comando whoami no acepta parámetros")
+	}
+	auth := stores.AuthFromContext(ctx)
+	if !auth.IsAuthenticated() {
+		return "", NewError(CodeUnauthenticated, "no hay ninguna sesión activa")
+	}
+	currentUser, partitionID := auth.GetCurrentUser()
+
+	partitionSuperblock, _, partitionPath, err := stores.GetMountedPartitionSuperblock(partitionID)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener la partición montada '%s': %w", partitionID, err)
+	}
+	dev, err := structures.OpenDevice(partitionPath)
+	if err != nil {
+		return "", fmt.Errorf("error al abrir el disco '%s': %w", partitionPath, err)
+	}
+	defer structures.CloseDevice(dev)
+
+	owner, err := sessionOwner(partitionSuperblock, dev, currentUser)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("WHOAMI: Usuario: %s (UID %d), Grupo: %s (GID %d), Partición: %s, Inicio de sesión: %s",
+		owner.User, owner.UID, owner.Group, owner.GID, partitionID, auth.LoginAt.Format(sessionTimeLayout))
+	if len(auth.Previous) > 0 {
+		result += fmt.Sprintf(", su desde: %s", strings.Join(auth.Previous, " → "))
+	}
+	if auth.ElevatedBy != "" {
+		result += fmt.Sprintf(", sudo por: %s", auth.ElevatedBy)
+	}
+	return result, nil
+}
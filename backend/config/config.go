@@ -0,0 +1,379 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuración del servidor. Se arma en capas, cada una reemplaza a la anterior: los valores por defecto que
+// recibe Load, el archivo JSON (-config o CONFIG_FILE), las variables de entorno y las opciones de la línea de
+// comandos. Ejemplo de archivo:
+//
+//	{
+//	  "server": {"listen": ":3001", "cors_origins": ["http://localhost:5173"], "body_limit": "8M"},
+//	  "paths": {"disks_root": "/srv/mia/discos", "reports_root": "/srv/mia/reportes", "imports_root": "/srv/mia/importar"},
+//	  "mount": {"id_prefix": "20"},
+//	  "sessions": {"idle_timeout": "30m", "max_age": "8h"}
+//	}
+
+// Config es la configuración completa del servidor
+type Config struct {
+	Server    Server    `json:"server"`
+	Paths     Paths     `json:"paths"`
+	Mount     Mount     `json:"mount"`
+	Reports   Reports   `json:"reports"`
+	Logging   Logging   `json:"logging"`
+	Passwords Passwords `json:"passwords"`
+	Login     Login     `json:"login"`
+	Sessions  Sessions  `json:"sessions"`
+	Jobs      Jobs      `json:"jobs"`
+	Debug     Debug     `json:"debug"`
+}
+
+// Server es la configuración del servidor HTTP
+type Server struct {
+	Listen       string   `json:"listen"`        // Dirección en la que escucha, por ejemplo ":3001"
+	TLSCert      string   `json:"tls_cert"`      // Certificado y clave para HTTPS (los dos o ninguno)
+	TLSKey       string   `json:"tls_key"`       //
+	CORSOrigins  []string `json:"cors_origins"`  // Orígenes permitidos; ["*"] permite cualquiera
+	BodyLimit    ByteSize `json:"body_limit"`    // Tamaño máximo del cuerpo de una petición
+	ReadTimeout  Duration `json:"read_timeout"`  // Tiempo máximo para leer una petición (0: sin límite)
+	WriteTimeout Duration `json:"write_timeout"` // Tiempo máximo para escribir una respuesta (0: sin límite; corta los streams largos)
+	IdleTimeout  Duration `json:"idle_timeout"`  // Tiempo que se mantiene abierta una conexión sin peticiones (0: sin límite)
+}
+
+// Paths son las carpetas fuera de las cuales los comandos no pueden usar archivos del servidor (ver el paquete
+// sandbox). Los paths relativos se toman desde el directorio de trabajo.
+type Paths struct {
+	DisksRoot   string `json:"disks_root"`   // Discos de mkdisk, rmdisk, fdisk y mount
+	ReportsRoot string `json:"reports_root"` // Salida de rep
+	ImportsRoot string `json:"imports_root"` // Archivos que importa mkfile -cont
+}
+
+// Mount es la configuración de los ids de montaje
+type Mount struct {
+	IDPrefix string `json:"id_prefix"` // Prefijo de los ids que genera mount (por defecto, el carnet)
+}
+
+// Reports es la configuración de los reportes
+type Reports struct {
+	Graphviz string `json:"graphviz"` // Ejecutable de Graphviz
+}
+
+// Logging es la configuración del log (ver logging.ApplyLevels)
+type Logging struct {
+	Level  string `json:"level"`  // Por ejemplo "info,structures=debug"
+	Format string `json:"format"` // "text" o "json"
+}
+
+// Passwords es la política de contraseñas nuevas (ver utils.PasswordPolicy)
+type Passwords struct {
+	MinLength     int  `json:"min_length"`
+	RequireLetter bool `json:"require_letter"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	AllowUsername bool `json:"allow_username"`
+}
+
+// Login es el bloqueo por intentos fallidos (ver stores.LoginLockoutPolicy)
+type Login struct {
+	MaxFailures int      `json:"max_failures"`
+	Lockout     Duration `json:"lockout"`
+}
+
+// Sessions son los límites de las sesiones (ver stores.SessionTimeoutPolicy)
+type Sessions struct {
+	IdleTimeout Duration `json:"idle_timeout"`
+	MaxAge      Duration `json:"max_age"`
+}
+
+// Jobs es la configuración de la cola de trabajos (ver jobs.Config)
+type Jobs struct {
+	Workers   int      `json:"workers"`
+	QueueSize int      `json:"queue_size"`
+	Retention Duration `json:"retention"`
+}
+
+// Debug habilita los endpoints de diagnóstico
+type Debug struct {
+	State bool `json:"state"` // GET /debug/state
+}
+
+// Largo máximo del prefijo de los ids de montaje: Part_id tiene 4 bytes y el id agrega el correlativo y la letra
+const MaxMountIDPrefix = 2
+
+var mountIDPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// Validate verifica que la configuración sea coherente
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Server.Listen == "" {
+		problems = append(problems, "server.listen no puede estar vacío")
+	}
+	if (c.Server.TLSCert == "") != (c.Server.TLSKey == "") {
+		problems = append(problems, "server.tls_cert y server.tls_key se configuran juntos")
+	}
+	if c.Server.BodyLimit <= 0 {
+		problems = append(problems, "server.body_limit debe ser mayor que 0")
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		problems = append(problems, "server.cors_origins no puede estar vacío (use [\"*\"] para permitir cualquiera)")
+	}
+	for name, timeout := range map[string]Duration{"server.read_timeout": c.Server.ReadTimeout, "server.write_timeout": c.Server.WriteTimeout, "server.idle_timeout": c.Server.IdleTimeout} {
+		if timeout < 0 {
+			problems = append(problems, fmt.Sprintf("%s no puede ser negativo", name))
+		}
+	}
+	if len(c.Mount.IDPrefix) == 0 || len(c.Mount.IDPrefix) > MaxMountIDPrefix || !mountIDPrefixPattern.MatchString(c.Mount.IDPrefix) {
+		problems = append(problems, fmt.Sprintf("mount.id_prefix debe tener de 1 a %d letras o dígitos", MaxMountIDPrefix))
+	}
+	for name, root := range map[string]string{"paths.disks_root": c.Paths.DisksRoot, "paths.reports_root": c.Paths.ReportsRoot, "paths.imports_root": c.Paths.ImportsRoot} {
+		if root == "" {
+			problems = append(problems, fmt.Sprintf("%s no puede estar vacío", name))
+		}
+	}
+	if c.Reports.Graphviz == "" {
+		problems = append(problems, "reports.graphviz no puede estar vacío")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("configuración inválida: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// setting es un valor que se puede dar por variable de entorno y, si tiene flag, por opción de línea de comandos
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value flag.Value
+}
+
+// settings enlaza las variables de entorno y las opciones con los campos de cfg
+func settings(cfg *Config) []setting {
+	return []setting{
+		{"LISTEN_ADDR", "listen", "dirección en la que escucha el servidor", (*stringValue)(&cfg.Server.Listen)},
+		{"TLS_CERT", "tls-cert", "certificado para HTTPS", (*stringValue)(&cfg.Server.TLSCert)},
+		{"TLS_KEY", "tls-key", "clave del certificado para HTTPS", (*stringValue)(&cfg.Server.TLSKey)},
+		{"CORS_ORIGINS", "cors-origins", "orígenes permitidos separados por comas (* para cualquiera)", (*listValue)(&cfg.Server.CORSOrigins)},
+		{"BODY_LIMIT", "body-limit", "tamaño máximo del cuerpo de una petición (por ejemplo 8M)", &cfg.Server.BodyLimit},
+		{"READ_TIMEOUT", "read-timeout", "tiempo máximo para leer una petición", &cfg.Server.ReadTimeout},
+		{"WRITE_TIMEOUT", "write-timeout", "tiempo máximo para escribir una respuesta", &cfg.Server.WriteTimeout},
+		{"IDLE_TIMEOUT", "idle-timeout", "tiempo máximo de una conexión sin peticiones", &cfg.Server.IdleTimeout},
+		{"DISKS_ROOT", "disks-root", "carpeta fuera de la cual no se pueden usar discos", (*stringValue)(&cfg.Paths.DisksRoot)},
+		{"REPORTS_ROOT", "reports-root", "carpeta fuera de la cual no se pueden crear reportes", (*stringValue)(&cfg.Paths.ReportsRoot)},
+		{"IMPORTS_ROOT", "imports-root", "carpeta fuera de la cual mkfile -cont no puede leer", (*stringValue)(&cfg.Paths.ImportsRoot)},
+		{"MOUNT_ID_PREFIX", "mount-id-prefix", "prefijo de los ids de montaje", (*stringValue)(&cfg.Mount.IDPrefix)},
+		{"GRAPHVIZ_BIN", "graphviz", "ejecutable de Graphviz", (*stringValue)(&cfg.Reports.Graphviz)},
+		{"LOG_LEVEL", "log-level", "niveles de log, por ejemplo info,structures=debug", (*stringValue)(&cfg.Logging.Level)},
+		{"LOG_FORMAT", "log-format", "formato de log: text o json", (*stringValue)(&cfg.Logging.Format)},
+		{"PASSWORD_MIN_LENGTH", "", "", (*intValue)(&cfg.Passwords.MinLength)},
+		{"PASSWORD_REQUIRE_LETTER", "", "", (*boolValue)(&cfg.Passwords.RequireLetter)},
+		{"PASSWORD_REQUIRE_DIGIT", "", "", (*boolValue)(&cfg.Passwords.RequireDigit)},
+		{"PASSWORD_REQUIRE_SYMBOL", "", "", (*boolValue)(&cfg.Passwords.RequireSymbol)},
+		{"PASSWORD_ALLOW_USERNAME", "", "", (*boolValue)(&cfg.Passwords.AllowUsername)},
+		{"LOGIN_MAX_FAILURES", "", "", (*intValue)(&cfg.Login.MaxFailures)},
+		{"LOGIN_LOCKOUT", "", "", &cfg.Login.Lockout},
+		{"SESSION_IDLE_TIMEOUT", "", "", &cfg.Sessions.IdleTimeout},
+		{"SESSION_MAX_AGE", "", "", &cfg.Sessions.MaxAge},
+		{"JOB_WORKERS", "", "", (*intValue)(&cfg.Jobs.Workers)},
+		{"JOB_QUEUE_SIZE", "", "", (*intValue)(&cfg.Jobs.QueueSize)},
+		{"JOB_RETENTION", "", "", &cfg.Jobs.Retention},
+		{"DEBUG_STATE", "debug-state", "habilita GET /debug/state", (*boolValue)(&cfg.Debug.State)},
+	}
+}
+
+// Load completa cfg (que trae los valores por defecto) con el archivo de configuración, las variables de
+// entorno y las opciones de args (sin el nombre del programa), en ese orden, y valida el resultado. Las
+// opciones de la configuración se agregan a flags, que puede traer otras propias de quien llama. Devuelve los
+// argumentos que no son opciones; las opciones pueden ir antes o después de ellos.
+func Load(cfg *Config, flags *flag.FlagSet, args []string) ([]string, error) {
+	all := settings(cfg)
+
+	// Las opciones se aplican al final, pero se leen primero para conocer -config
+	type pendingFlag struct {
+		setting setting
+		raw     string
+	}
+	var pending []pendingFlag
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "archivo de configuración JSON (variable CONFIG_FILE)")
+	for _, s := range all {
+		if s.flag == "" {
+			continue
+		}
+		s := s
+		record := func(raw string) error {
+			pending = append(pending, pendingFlag{s, raw})
+			return nil
+		}
+		usage := fmt.Sprintf("%s (variable %s)", s.usage, s.env)
+		if _, isBool := s.value.(*boolValue); isBool {
+			flags.BoolFunc(s.flag, usage, record)
+		} else {
+			flags.Func(s.flag, usage, record)
+		}
+	}
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range all {
+		if raw, exists := os.LookupEnv(s.env); exists {
+			if err := s.value.Set(raw); err != nil {
+				return nil, fmt.Errorf("valor inválido para %s ('%s'): %w", s.env, raw, err)
+			}
+		}
+	}
+	for _, p := range pending {
+		if err := p.setting.value.Set(p.raw); err != nil {
+			return nil, fmt.Errorf("valor inválido para -%s ('%s'): %w", p.setting.flag, p.raw, err)
+		}
+	}
+	return positional, cfg.Validate()
+}
+
+// loadFile aplica sobre cfg las claves del archivo JSON. Una clave desconocida es un error, para que un
+// nombre mal escrito no se ignore en silencio.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error al leer el archivo de configuración: %w", err)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("archivo de configuración '%s' inválido: %w", path, err)
+	}
+	return nil
+}
+
+// Duration es un time.Duration que en JSON se escribe como texto ("30m", "8h")
+type Duration time.Duration
+
+func (d *Duration) Set(raw string) error {
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("se esperaba una duración como texto, por ejemplo \"30m\": %w", err)
+	}
+	return d.Set(raw)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+// ByteSize es un tamaño en bytes que se puede escribir con sufijo K, M o G ("512K", "8M") o como número
+type ByteSize int
+
+var byteSizePattern = regexp.MustCompile(`^(?i)(\d+)\s*([KMG]?)B?$`)
+
+func (b *ByteSize) Set(raw string) error {
+	match := byteSizePattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if match == nil {
+		return errors.New("se esperaba un tamaño como 1048576, 512K u 8M")
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return err
+	}
+	switch strings.ToUpper(match[2]) {
+	case "K":
+		size *= 1024
+	case "M":
+		size *= 1024 * 1024
+	case "G":
+		size *= 1024 * 1024 * 1024
+	}
+	*b = ByteSize(size)
+	return nil
+}
+
+func (b ByteSize) String() string { return strconv.Itoa(int(b)) }
+
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		*b = ByteSize(number)
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.New("se esperaba un tamaño como 1048576 o \"8M\"")
+	}
+	return b.Set(raw)
+}
+
+// Adaptadores de flag.Value para los campos de tipos básicos
+
+type stringValue string
+
+func (s *stringValue) Set(raw string) error { *s = stringValue(raw); return nil }
+func (s *stringValue) String() string       { return string(*s) }
+
+type intValue int
+
+func (i *intValue) Set(raw string) error {
+	parsed, err := strconv.Atoi(raw)
+	if err != nil {
+		return err
+	}
+	*i = intValue(parsed)
+	return nil
+}
+func (i *intValue) String() string { return strconv.Itoa(int(*i)) }
+
+type boolValue bool
+
+func (b *boolValue) Set(raw string) error {
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		return err
+	}
+	*b = boolValue(parsed)
+	return nil
+}
+func (b *boolValue) String() string { return strconv.FormatBool(bool(*b)) }
+
+// listValue es una lista separada por comas
+type listValue []string
+
+func (l *listValue) Set(raw string) error {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*l = items
+	return nil
+}
+func (l *listValue) String() string { return strings.Join(*l, ",") }
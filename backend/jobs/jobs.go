@@ -0,0 +1,220 @@
+package jobs
+
+import (
+	analyzer "backend/analyzer"
+	commands "backend/commands"
+	logging "backend/logging"
+	stores "backend/stores"
+	utils "backend/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cola de trabajos para los comandos largos (ver analyzer.IsLongRunning). Cada trabajo se ejecuta en un
+// grupo fijo de workers con el mismo Analyzer que un script, así que toma los mismos locks y queda en el log
+// de auditoría. El trabajo no depende de la petición que lo creó: sigue aunque el cliente se desconecte.
+// Cada trabajo es del usuario que lo encoló (en la partición de su sesión) y solo él lo puede consultar.
+
+// Status es el estado de un trabajo
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Config es la configuración de la cola
+type Config struct {
+	Workers   int           // Trabajos que se ejecutan a la vez
+	QueueSize int           // Trabajos que pueden esperar; con la cola llena Submit devuelve ErrQueueFull
+	Retention time.Duration // Tiempo que se guarda un trabajo terminado
+}
+
+// Pool es la configuración con la que Start crea los workers
+var Pool = Config{Workers: 2, QueueSize: 32, Retention: time.Hour}
+
+var (
+	ErrQueueFull   = errors.New("la cola de trabajos está llena, intente más tarde")
+	ErrJobNotFound = errors.New("no existe el trabajo")
+)
+
+// Info es el estado de un trabajo que se devuelve a los clientes
+type Info struct {
+	ID         string                 `json:"id"`
+	Command    string                 `json:"command"` // Línea de comando sin contraseñas (ver utils.RedactPasswords)
+	Status     Status                 `json:"status"`
+	Progress   utils.ProgressSnapshot `json:"progress"`
+	Result     *analyzer.Result       `json:"result,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	StartedAt  *time.Time             `json:"started_at,omitempty"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// owner identifica al dueño de un trabajo: el usuario y la partición de la sesión que lo encoló
+type owner struct {
+	user      string
+	partition string
+}
+
+// ownerOf devuelve el dueño que corresponde a la sesión del contexto
+func ownerOf(ctx context.Context) owner {
+	auth := stores.AuthFromContext(ctx)
+	user, partition := auth.GetCurrentUser()
+	return owner{user: user, partition: partition}
+}
+
+// job es un trabajo en la cola; los campos de info se protegen con mu
+type job struct {
+	mu       sync.Mutex
+	info     Info
+	command  string // Línea de comando que se ejecuta, con las contraseñas
+	owner    owner
+	ctx      context.Context
+	progress *utils.Progress
+}
+
+var logger = logging.Logger(logging.Jobs)
+
+var (
+	startOnce sync.Once
+	queue     chan *job
+	jobsMu    sync.Mutex
+	jobs      = map[string]*job{}
+)
+
+// Start crea los workers con la configuración de Pool. Llamarla más de una vez no hace nada.
+func Start() {
+	startOnce.Do(func() {
+		workers, size := max(Pool.Workers, 1), max(Pool.QueueSize, 0)
+		queue = make(chan *job, size)
+		for i := 0; i < workers; i++ {
+			go worker()
+		}
+		logger.Info("Cola de trabajos iniciada", "workers", workers, "queue_size", size)
+	})
+}
+
+// Submit encola una línea de comando para ejecutarla con la sesión del contexto y devuelve su estado inicial.
+// Requiere una sesión iniciada: el trabajo queda a nombre de su usuario.
+func Submit(ctx context.Context, command string) (Info, error) {
+	if !stores.AuthFromContext(ctx).IsAuthenticated() {
+		return Info{}, commands.NewError(commands.CodeUnauthenticated, "encolar un trabajo requiere inicio de sesión")
+	}
+	Start()
+	id, err := newJobID()
+	if err != nil {
+		return Info{}, err
+	}
+	progress := &utils.Progress{}
+	j := &job{
+		info:     Info{ID: id, Command: utils.RedactPasswords(command), Status: StatusQueued, CreatedAt: time.Now()},
+		command:  command,
+		owner:    ownerOf(ctx),
+		ctx:      utils.WithProgress(context.WithoutCancel(ctx), progress),
+		progress: progress,
+	}
+
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	removeExpired(time.Now())
+	select {
+	case queue <- j:
+	default:
+		return Info{}, ErrQueueFull
+	}
+	jobs[id] = j
+	return j.snapshot(), nil
+}
+
+// Get devuelve el estado de un trabajo del usuario de la sesión del contexto. Los trabajos de otros usuarios
+// se tratan como inexistentes.
+func Get(ctx context.Context, id string) (Info, error) {
+	jobsMu.Lock()
+	j, exists := jobs[id]
+	jobsMu.Unlock()
+	if !exists || j.owner != ownerOf(ctx) {
+		return Info{}, fmt.Errorf("%w: '%s'", ErrJobNotFound, id)
+	}
+	return j.snapshot(), nil
+}
+
+// List devuelve el estado de los trabajos guardados del usuario de la sesión del contexto, del más nuevo al más viejo
+func List(ctx context.Context) []Info {
+	caller := ownerOf(ctx)
+	jobsMu.Lock()
+	removeExpired(time.Now())
+	list := make([]Info, 0, len(jobs))
+	for _, j := range jobs {
+		if j.owner == caller {
+			list = append(list, j.snapshot())
+		}
+	}
+	jobsMu.Unlock()
+
+	sort.Slice(list, func(a, b int) bool { return list[a].CreatedAt.After(list[b].CreatedAt) })
+	return list
+}
+
+// worker ejecuta los trabajos de la cola uno por uno
+func worker() {
+	for j := range queue {
+		j.mu.Lock()
+		started := time.Now()
+		j.info.Status, j.info.StartedAt = StatusRunning, &started
+		j.mu.Unlock()
+
+		results := analyzer.RunScript(j.ctx, j.command)
+
+		j.mu.Lock()
+		finished := time.Now()
+		j.info.Status, j.info.FinishedAt = StatusDone, &finished
+		if len(results) > 0 {
+			results[0].Command = utils.RedactPasswords(results[0].Command)
+			j.info.Result = &results[0]
+			if results[0].Status == analyzer.StatusError {
+				j.info.Status = StatusFailed
+			}
+		}
+		status := j.info.Status
+		j.mu.Unlock()
+		logger.InfoContext(j.ctx, "Trabajo terminado", "job", j.info.ID, "command", j.info.Command, "status", status, "duration", finished.Sub(started))
+	}
+}
+
+// snapshot copia el estado del trabajo con su avance actual
+func (j *job) snapshot() Info {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := j.info
+	info.Progress = j.progress.Snapshot()
+	return info
+}
+
+// removeExpired borra los trabajos que terminaron hace más de Pool.Retention. Requiere jobsMu tomado.
+func removeExpired(now time.Time) {
+	for id, j := range jobs {
+		j.mu.Lock()
+		expired := j.info.FinishedAt != nil && now.Sub(*j.info.FinishedAt) > Pool.Retention
+		j.mu.Unlock()
+		if expired {
+			delete(jobs, id)
+		}
+	}
+}
+
+// newJobID genera el id de un trabajo
+func newJobID() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error al generar el id del trabajo: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
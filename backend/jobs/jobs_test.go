@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	stores "backend/stores"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestContext devuelve el contexto de una sesión iniciada del usuario en la partición indicada
+func newTestContext(user, partition string) context.Context {
+	return stores.WithAuth(context.Background(), &stores.AuthStore{IsLoggedIn: true, Username: user, PartitionID: partition})
+}
+
+// waitJob espera a que el trabajo termine y devuelve su estado final
+func waitJob(t *testing.T, ctx context.Context, id string) Info {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		info, err := Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if info.Status == StatusDone || info.Status == StatusFailed {
+			return info
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("el trabajo %s no terminó a tiempo", id)
+	return Info{}
+}
+
+func TestSubmitRequiresSession(t *testing.T) {
+	anonymous := stores.WithAuth(context.Background(), &stores.AuthStore{})
+	if _, err := Submit(anonymous, "mkdisk -size=1 -unit=K -path=mem://JobAnonimo.mia"); err == nil {
+		t.Error("Submit sin sesión: se esperaba un error")
+	}
+}
+
+func TestJobsVisibleOnlyToOwner(t *testing.T) {
+	owner := newTestContext("root", "101A")
+	other := newTestContext("user1", "101A")
+
+	// El parámetro desconocido hace fallar mkdisk, pero la línea guardada no debe tener la contraseña
+	info, err := Submit(owner, "mkdisk -size=1 -unit=K -path=mem://JobDueno.mia -pass=secreto")
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if strings.Contains(info.Command, "secreto") {
+		t.Errorf("el trabajo guardó la contraseña: %q", info.Command)
+	}
+	final := waitJob(t, owner, info.ID)
+	if final.Result == nil || strings.Contains(final.Result.Command, "secreto") {
+		t.Errorf("el resultado del trabajo no está o tiene la contraseña: %+v", final.Result)
+	}
+
+	if _, err := Get(other, info.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Get de otro usuario = %v, se esperaba ErrJobNotFound", err)
+	}
+	for _, listed := range List(other) {
+		if listed.ID == info.ID {
+			t.Error("List de otro usuario incluye el trabajo")
+		}
+	}
+	found := false
+	for _, listed := range List(owner) {
+		found = found || listed.ID == info.ID
+	}
+	if !found {
+		t.Error("List del dueño no incluye el trabajo")
+	}
+}
@@ -0,0 +1,241 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registro de eventos con niveles por subsistema. Cada paquete pide su logger con Logger(subsistema) y el nivel
+// de cada subsistema se cambia en tiempo de ejecución (variable LOG_LEVEL, opción -log-level o comando debug).
+// Las líneas de una petición HTTP llevan su request_id si se registran con el contexto de la petición
+// (logger.InfoContext(ctx, ...)); las funciones que no reciben el contexto, como las de structures, registran
+// sin request_id. Con WithSink, las líneas registradas con un contexto también se entregan a quien ejecuta esa
+// petición (por ejemplo, el stream de un script), sin mezclarse con las de las demás.
+
+// Subsistemas
+const (
+	Analyzer   = "analyzer"
+	Commands   = "commands"
+	Structures = "structures"
+	Reports    = "reports"
+	Stores     = "stores"
+	Jobs       = "jobs"
+	HTTP       = "http"
+	Server     = "server" // Arranque y configuración del servidor
+	CLI        = "cli"    // Terminal interactiva y ejecución de scripts por línea de comandos
+)
+
+// Subsystems son los subsistemas con nivel propio, en orden alfabético
+var Subsystems = []string{Analyzer, CLI, Commands, HTTP, Jobs, Reports, Server, Stores, Structures}
+
+var (
+	levels = func() map[string]*slog.LevelVar {
+		vars := make(map[string]*slog.LevelVar, len(Subsystems))
+		for _, subsystem := range Subsystems {
+			vars[subsystem] = new(slog.LevelVar) // Info por defecto
+		}
+		return vars
+	}()
+
+	outputMu     sync.Mutex
+	outputFormat              = "text"
+	outputTarget io.Writer    = os.Stdout
+	output       slog.Handler = newHandler(outputTarget, outputFormat)
+)
+
+// Logger devuelve el logger de un subsistema (uno de Subsystems)
+func Logger(subsystem string) *slog.Logger {
+	level, ok := levels[subsystem]
+	if !ok {
+		panic(fmt.Sprintf("subsistema de logging desconocido: %s", subsystem))
+	}
+	return slog.New(&subsystemHandler{level: level}).With("subsystem", subsystem)
+}
+
+// SetFormat elige el formato de salida: "text" (clave=valor) o "json"
+func SetFormat(format string) error {
+	format = strings.ToLower(format)
+	if format != "text" && format != "json" {
+		return fmt.Errorf("formato de log inválido '%s' (text o json)", format)
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	outputFormat = format
+	output = newHandler(outputTarget, outputFormat)
+	return nil
+}
+
+// SetOutput cambia el destino del log. Por defecto es la salida estándar; la terminal (paquete cli) lo manda a la salida de errores para no mezclarlo con la salida de los comandos.
+func SetOutput(w io.Writer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	outputTarget = w
+	output = newHandler(outputTarget, outputFormat)
+}
+
+// SetLevel cambia el nivel de un subsistema, o de todos con "all"
+func SetLevel(subsystem string, level slog.Level) error {
+	subsystem = strings.ToLower(subsystem)
+	if subsystem == "all" {
+		for _, levelVar := range levels {
+			levelVar.Set(level)
+		}
+		return nil
+	}
+	levelVar, ok := levels[subsystem]
+	if !ok {
+		return fmt.Errorf("subsistema desconocido '%s' (válidos: all, %s)", subsystem, strings.Join(Subsystems, ", "))
+	}
+	levelVar.Set(level)
+	return nil
+}
+
+// Levels devuelve el nivel actual de cada subsistema
+func Levels() map[string]slog.Level {
+	current := make(map[string]slog.Level, len(levels))
+	for subsystem, levelVar := range levels {
+		current[subsystem] = levelVar.Level()
+	}
+	return current
+}
+
+// FormatLevels describe los niveles actuales como "subsistema=nivel", en orden alfabético
+func FormatLevels() string {
+	current := Levels()
+	names := make([]string, 0, len(current))
+	for subsystem := range current {
+		names = append(names, subsystem)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, subsystem := range names {
+		parts[i] = fmt.Sprintf("%s=%s", subsystem, strings.ToLower(current[subsystem].String()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseLevel convierte "debug", "info", "warn" o "error" en un nivel
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("nivel de log inválido '%s' (debug, info, warn o error)", name)
+	}
+	return level, nil
+}
+
+// ApplyLevels aplica una especificación como "info,structures=debug,commands=warn": un nivel sin subsistema
+// vale para todos y los siguientes lo ajustan por subsistema
+func ApplyLevels(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		subsystem, name, found := strings.Cut(part, "=")
+		if !found {
+			subsystem, name = "all", part
+		}
+		level, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		if err := SetLevel(strings.TrimSpace(subsystem), level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clave para guardar el id de la petición en el contexto
+type requestIDContextKey struct{}
+
+// WithRequestID devuelve un contexto cuyas líneas de log llevan el id de la petición
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID devuelve el id de la petición del contexto, o "" si no tiene
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// Clave para guardar en el contexto la función que recibe las líneas de log de una ejecución
+type sinkContextKey struct{}
+
+// WithSink devuelve un contexto cuyas líneas de log, además de escribirse en la salida, se entregan a sink (una
+// línea por llamada, sin el salto final). sink se llama desde la goroutine que registra y no debe bloquearse.
+func WithSink(ctx context.Context, sink func(line string)) context.Context {
+	return context.WithValue(ctx, sinkContextKey{}, sink)
+}
+
+// newHandler crea el handler que escribe en w con el formato indicado
+func newHandler(w io.Writer, format string) slog.Handler {
+	options := &slog.HandlerOptions{Level: slog.LevelDebug} // El filtro por nivel lo hace subsystemHandler
+	if format == "json" {
+		return slog.NewJSONHandler(w, options)
+	}
+	return slog.NewTextHandler(w, options)
+}
+
+// subsystemHandler filtra por el nivel de su subsistema, agrega el request_id y delega en el handler de salida
+type subsystemHandler struct {
+	level *slog.LevelVar
+	attrs []slog.Attr
+	group string
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	outputMu.Lock()
+	handler, format := output, outputFormat
+	outputMu.Unlock()
+
+	err := h.wrap(handler).Handle(ctx, record.Clone())
+
+	// La línea se arma de nuevo con el mismo formato para entregarla al sink de la ejecución
+	if sink, ok := ctx.Value(sinkContextKey{}).(func(string)); ok && sink != nil {
+		var line bytes.Buffer
+		if sinkErr := h.wrap(newHandler(&line, format)).Handle(ctx, record); sinkErr == nil {
+			sink(strings.TrimSuffix(line.String(), "\n"))
+		}
+	}
+	return err
+}
+
+// wrap agrega al handler de salida los atributos y el grupo del logger
+func (h *subsystemHandler) wrap(handler slog.Handler) slog.Handler {
+	handler = handler.WithAttrs(h.attrs)
+	if h.group != "" {
+		handler = handler.WithGroup(h.group)
+	}
+	return handler
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &next
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	if next.group != "" {
+		name = next.group + "." + name
+	}
+	next.group = name
+	return &next
+}
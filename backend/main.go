@@ -2,66 +2,307 @@ package main
 
 import (
 	analyzer "backend/analyzer"
+	api "backend/api"
+	cli "backend/cli"
+	config "backend/config"
+	jobs "backend/jobs"
+	logging "backend/logging"
+	reports "backend/reports"
+	sandbox "backend/sandbox"
+	stores "backend/stores"
+	utils "backend/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt" // Importa el paquete "fmt" para formatear e imprimir texto
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
-//EStructura para representar el comando de solicitud
-type CommandRequest struct {
-	Command string `json:"command"`
-}
-
 //Estructura para representar la respuesta del comando
 type CommandResponse struct {
-	Output string `json:"output"`
+	Output  string            `json:"output"`          // Salida de todos los comandos como texto (compatibilidad con clientes anteriores)
+	Results []analyzer.Result `json:"results"`         // Resultado de cada comando del script
+	Token   string            `json:"token,omitempty"` // Token de la sesión activa al terminar la petición (lo emite login)
 }
 
 
+var logger = logging.Logger(logging.Server)
+
+// Modos de ejecución: el servidor HTTP (por defecto), la terminal interactiva y la ejecución de scripts
+const (
+	modeServe = "serve"
+	modeREPL  = "repl"
+	modeRun   = "run"
+)
+
+// main inicia el modo indicado en el primer argumento:
+//
+//	backend [serve] [opciones]                           servidor HTTP
+//	backend repl [opciones]                              terminal interactiva
+//	backend run [--keep-going] [opciones] script.mias... ejecuta los scripts y termina
 func main() {
-	app := fiber.New()
+	mode, args := modeServe, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		mode, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("backend "+mode, flag.ContinueOnError)
+	cfg := defaultConfig()
+	var keepGoing *bool
+	switch mode {
+	case modeServe:
+	case modeREPL, modeRun:
+		// En la terminal el log va a la salida de errores y solo muestra errores, salvo que se configure otro nivel
+		logging.SetOutput(os.Stderr)
+		cfg.Logging.Level = "error"
+		if mode == modeRun {
+			keepGoing = flags.Bool("keep-going", false, "seguir con los comandos siguientes cuando uno falla")
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Modo desconocido '%s' (%s, %s o %s)\n", mode, modeServe, modeREPL, modeRun)
+		os.Exit(2)
+	}
+
+	arguments, err := config.Load(&cfg, flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		logger.Error("Configuración inválida", "error", err)
+		os.Exit(2)
+	}
+	configureLogging(cfg.Logging)
+	configureAccountPolicies(cfg)
+	configurePaths(cfg)
+
+	switch mode {
+	case modeREPL:
+		if len(arguments) > 0 {
+			logger.Error("repl no recibe argumentos", "arguments", arguments)
+			os.Exit(2)
+		}
+		if err := cli.REPL(context.Background(), os.Stdin, os.Stdout); err != nil {
+			logger.Error("Error en la terminal", "error", err)
+			os.Exit(1)
+		}
+	case modeRun:
+		if len(arguments) == 0 {
+			fmt.Fprintln(os.Stderr, "Uso: backend run [--keep-going] script.mias...")
+			os.Exit(2)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := cli.Run(ctx, arguments, *keepGoing, os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	default:
+		if len(arguments) > 0 {
+			logger.Error("Argumentos inesperados", "arguments", arguments)
+			os.Exit(2)
+		}
+		serve(cfg)
+	}
+}
+
+// serve inicia la cola de trabajos y el servidor HTTP
+func serve(cfg config.Config) {
+	configureJobs(cfg.Jobs)
+	api.DebugStateEnabled = cfg.Debug.State
 
-	app.Use(cors.New(cors.Config{}))
+	app := fiber.New(fiber.Config{
+		BodyLimit:    int(cfg.Server.BodyLimit),
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout),
+	})
+
+	app.Use(cors.New(cors.Config{AllowOrigins: strings.Join(cfg.Server.CORSOrigins, ",")}))
+	app.Use(api.RequestID(), api.AccessLog())
 
 	app.Post("/", func(c *fiber.Ctx) error {
-		var req CommandRequest
+		var req api.ScriptRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(400).JSON(CommandResponse{
 				Output: "Error: Petición inválida",
 			})
 		}
 
-		commands := strings.Split(req.Command, "\n")
-		output := ""
+		// Recuperar la sesión de la petición a partir de su token (ver api.ScriptSession)
+		auth, err := api.ScriptSession(c, req.Token, req.Command)
+		if err != nil {
+			return c.Status(401).JSON(CommandResponse{
+				Output:  fmt.Sprintf("Error: %s", err.Error()),
+				Results: []analyzer.Result{analyzer.RequestError(err)},
+			})
+		}
+		ctx := stores.WithAuth(api.RequestContext(c), auth)
 
-		for _, cmd := range commands {
-			if strings.TrimSpace(cmd) == "" {
-				continue
-			}
+		results := analyzer.RunScript(ctx, req.Command)
+		return c.JSON(CommandResponse{
+			Output:  analyzer.FormatOutput(results),
+			Results: results,
+			Token:   auth.Token,
+		})
+	})
+
+	// Log de auditoría de una partición como JSON por líneas (un registro por línea), filtrable por usuario y fechas
+	app.Get("/audit/:id", func(c *fiber.Ctx) error {
+		auth, err := stores.ResumeSession(api.RequestToken(c, c.Query("token")))
+		if err != nil {
+			return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		}
 
-			result, err := analyzer.Analyzer(cmd)
-			if err != nil {
-				output += fmt.Sprintf("Error: %s\n", err.Error())
-			} else {
-				output += fmt.Sprintf("%s\n", result)
+		filter := utils.AuditFilter{User: c.Query("user")}
+		if from := c.Query("from"); from != "" {
+			if filter.From, err = utils.ParseAuditTime(from, false); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+		if to := c.Query("to"); to != "" {
+			if filter.To, err = utils.ParseAuditTime(to, true); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 			}
 		}
 
-		if output == "" {
-			output = "No se ejecutó ningún comando"
+		entries, err := analyzer.ReadAuditLog(stores.WithAuth(api.RequestContext(c), auth), c.Params("id"), filter)
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return c.JSON(CommandResponse{
-			Output: output,
+		// Enviar los registros a medida que se serializan
+		c.Set("Content-Type", "application/x-ndjson")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			encoder := json.NewEncoder(w)
+			for _, entry := range entries {
+				if err := encoder.Encode(entry); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
 		})
+		return nil
 	})
 
-	app.Listen(":3001")
+	// API REST de discos, particiones, montajes, archivos, usuarios y reportes (documentada en /openapi.json)
+	api.Register(app)
+
+	if err := listen(app, cfg.Server); err != nil {
+		logger.Error("El servidor se detuvo", "error", err)
+		os.Exit(1)
+	}
+}
+
+// defaultConfig arma la configuración por defecto con los valores iniciales de cada paquete
+func defaultConfig() config.Config {
+	return config.Config{
+		Server: config.Server{
+			Listen:      ":3001",
+			CORSOrigins: []string{"*"},
+			BodyLimit:   fiber.DefaultBodyLimit,
+		},
+		Paths:   config.Paths{DisksRoot: sandbox.Disks.Dir(), ReportsRoot: sandbox.Reports.Dir(), ImportsRoot: sandbox.Imports.Dir()},
+		Mount:   config.Mount{IDPrefix: stores.Carnet},
+		Reports: config.Reports{Graphviz: reports.GraphvizBinary},
+		Passwords: config.Passwords{
+			MinLength:     utils.CurrentPasswordPolicy.MinLength,
+			RequireLetter: utils.CurrentPasswordPolicy.RequireLetter,
+			RequireDigit:  utils.CurrentPasswordPolicy.RequireDigit,
+			RequireSymbol: utils.CurrentPasswordPolicy.RequireSymbol,
+			AllowUsername: utils.CurrentPasswordPolicy.AllowUsernameSame,
+		},
+		Login: config.Login{MaxFailures: stores.LoginLockout.MaxFailures, Lockout: config.Duration(stores.LoginLockout.Duration)},
+		Sessions: config.Sessions{
+			IdleTimeout: config.Duration(stores.SessionTimeouts.Idle),
+			MaxAge:      config.Duration(stores.SessionTimeouts.Absolute),
+		},
+		Jobs: config.Jobs{Workers: jobs.Pool.Workers, QueueSize: jobs.Pool.QueueSize, Retention: config.Duration(jobs.Pool.Retention)},
+	}
 }
 
+// listen atiende peticiones en la dirección configurada, con HTTPS si hay certificado
+func listen(app *fiber.App, server config.Server) error {
+	if server.TLSCert != "" {
+		logger.Info("Escuchando con HTTPS", "listen", server.Listen, "cert", server.TLSCert)
+		return app.ListenTLS(server.Listen, server.TLSCert, server.TLSKey)
+	}
+	logger.Info("Escuchando con HTTP", "listen", server.Listen)
+	return app.Listen(server.Listen)
+}
+
+// configureLogging define los niveles de log por subsistema (por ejemplo "info,structures=debug") y el formato
+// ("text" o "json"). Los niveles se pueden cambiar después con el comando debug.
+func configureLogging(settings config.Logging) {
+	if settings.Format != "" {
+		if err := logging.SetFormat(settings.Format); err != nil {
+			logger.Warn("Formato de log inválido", "error", err)
+		}
+	}
+	if err := logging.ApplyLevels(settings.Level); err != nil {
+		logger.Warn("Niveles de log inválidos", "error", err)
+	}
+	logger.Info("Niveles de log", "levels", logging.FormatLevels())
+}
+
+// configureAccountPolicies ajusta la política de contraseñas, el bloqueo por intentos fallidos y los límites de las
+// sesiones
+func configureAccountPolicies(cfg config.Config) {
+	policy := &utils.CurrentPasswordPolicy
+	policy.MinLength = cfg.Passwords.MinLength
+	policy.RequireLetter = cfg.Passwords.RequireLetter
+	policy.RequireDigit = cfg.Passwords.RequireDigit
+	policy.RequireSymbol = cfg.Passwords.RequireSymbol
+	policy.AllowUsernameSame = cfg.Passwords.AllowUsername
+	if policy.MinLength > utils.MaxPasswordLength {
+		logger.Warn("El largo mínimo de contraseña supera el máximo de caracteres", "value", policy.MinLength, "max", utils.MaxPasswordLength)
+		policy.MinLength = utils.MaxPasswordLength
+	}
 
+	stores.LoginLockout.MaxFailures = cfg.Login.MaxFailures
+	stores.LoginLockout.Duration = time.Duration(cfg.Login.Lockout)
 
+	stores.SessionTimeouts.Idle = time.Duration(cfg.Sessions.IdleTimeout)
+	stores.SessionTimeouts.Absolute = time.Duration(cfg.Sessions.MaxAge)
 
+	logger.Info("Política de contraseñas", "policy", policy.String())
+	logger.Info("Bloqueo de login", "max_failures", stores.LoginLockout.MaxFailures, "duration", stores.LoginLockout.Duration)
+	logger.Info("Sesiones (0 = sin límite)", "idle", stores.SessionTimeouts.Idle, "max_age", stores.SessionTimeouts.Absolute)
+}
 
+// configurePaths define las carpetas de las que no pueden salir los discos, los reportes y los archivos que
+// importa mkfile, el ejecutable de Graphviz y el prefijo de los ids de montaje
+func configurePaths(cfg config.Config) {
+	sandbox.Disks.Set(cfg.Paths.DisksRoot)
+	sandbox.Reports.Set(cfg.Paths.ReportsRoot)
+	sandbox.Imports.Set(cfg.Paths.ImportsRoot)
+	reports.GraphvizBinary = cfg.Reports.Graphviz
+	stores.Carnet = cfg.Mount.IDPrefix
+
+	for _, root := range []struct {
+		name string
+		dir  string
+	}{{"discos", sandbox.Disks.Dir()}, {"reportes", sandbox.Reports.Dir()}, {"importación", sandbox.Imports.Dir()}} {
+		if err := os.MkdirAll(root.dir, 0755); err != nil {
+			logger.Warn("No se pudo crear la carpeta", "kind", root.name, "root", root.dir, "error", err)
+		}
+		logger.Info("Paths restringidos a una carpeta", "kind", root.name, "root", root.dir)
+	}
+	logger.Info("Reportes", "graphviz", reports.GraphvizBinary)
+	logger.Info("Prefijo de los ids de montaje", "prefix", stores.Carnet)
+}
+
+// configureJobs ajusta la cola de trabajos y crea sus workers
+func configureJobs(settings config.Jobs) {
+	jobs.Pool.Workers = settings.Workers
+	jobs.Pool.QueueSize = settings.QueueSize
+	jobs.Pool.Retention = time.Duration(settings.Retention)
+	jobs.Start()
+}
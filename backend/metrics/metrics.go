@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Métricas en el formato de texto de Prometheus (https://prometheus.io/docs/instrumenting/exposition_formats/).
+// Los contadores e histogramas se actualizan mientras el servidor trabaja; los valores que se leen en el momento
+// de la consulta (como el espacio libre de cada partición) se escriben con WriteGauge.
+
+// ContentType es el tipo de contenido de la salida de las métricas
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets son los límites de los histogramas de duración, en segundos
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Label es una etiqueta de una muestra
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample es una muestra de un gauge
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// CounterVec es un contador con etiquetas
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec crea un contador con las etiquetas indicadas
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: map[string]*counterValue{}}
+}
+
+// Inc suma uno al contador con los valores de etiqueta indicados (en el orden de NewCounterVec)
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, exists := c.values[key]
+	if !exists {
+		entry = &counterValue{labels: labelValues}
+		c.values[key] = entry
+	}
+	entry.value++
+}
+
+// Write escribe el contador en formato de Prometheus
+func (c *CounterVec) Write(w io.Writer) error {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, entry := range c.values {
+		samples = append(samples, Sample{Labels: zipLabels(c.labels, entry.labels), Value: entry.value})
+	}
+	c.mu.Unlock()
+
+	return writeFamily(w, c.name, c.help, "counter", samples)
+}
+
+// HistogramVec es un histograma con etiquetas
+type HistogramVec struct {
+	name    string
+	help    string
+	buckets []float64
+	labels  []string
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // Observaciones por bucket (no acumuladas)
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec crea un histograma con los límites (ordenados de menor a mayor) y las etiquetas indicadas
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, buckets: buckets, labels: labels, values: map[string]*histogramValue{}}
+}
+
+// Observe registra un valor en el histograma con los valores de etiqueta indicados
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, exists := h.values[key]
+	if !exists {
+		entry = &histogramValue{labels: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = entry
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		entry.counts[i]++
+	}
+	entry.count++
+	entry.sum += value
+}
+
+// Write escribe el histograma en formato de Prometheus: un _bucket acumulado por límite, _sum y _count
+func (h *HistogramVec) Write(w io.Writer) error {
+	h.mu.Lock()
+	var samples []namedSample
+	for _, entry := range h.values {
+		labels := zipLabels(h.labels, entry.labels)
+		cumulative := uint64(0)
+		for i, limit := range h.buckets {
+			cumulative += entry.counts[i]
+			bucketLabels := append(append([]Label{}, labels...), Label{"le", formatValue(limit)})
+			samples = append(samples, namedSample{h.name + "_bucket", Sample{bucketLabels, float64(cumulative)}})
+		}
+		infLabels := append(append([]Label{}, labels...), Label{"le", "+Inf"})
+		samples = append(samples,
+			namedSample{h.name + "_bucket", Sample{infLabels, float64(entry.count)}},
+			namedSample{h.name + "_sum", Sample{labels, entry.sum}},
+			namedSample{h.name + "_count", Sample{labels, float64(entry.count)}},
+		)
+	}
+	h.mu.Unlock()
+
+	// Ordenar por etiquetas (sin le) para que las series de un mismo histograma queden juntas
+	sort.SliceStable(samples, func(a, b int) bool {
+		return seriesKey(samples[a].sample.Labels) < seriesKey(samples[b].sample.Labels)
+	})
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, escapeHelp(h.help), h.name); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		if err := writeSample(w, s.name, s.sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type namedSample struct {
+	name   string
+	sample Sample
+}
+
+// WriteGauge escribe un gauge con las muestras indicadas
+func WriteGauge(w io.Writer, name, help string, samples ...Sample) error {
+	return writeFamily(w, name, help, "gauge", samples)
+}
+
+// writeFamily escribe una familia de métricas con sus muestras ordenadas por etiquetas
+func writeFamily(w io.Writer, name, help, kind string, samples []Sample) error {
+	sort.Slice(samples, func(a, b int) bool { return seriesKey(samples[a].Labels) < seriesKey(samples[b].Labels) })
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind); err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		if err := writeSample(w, name, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSample escribe una línea "nombre{etiqueta="valor",...} valor"
+func writeSample(w io.Writer, name string, sample Sample) error {
+	var line strings.Builder
+	line.WriteString(name)
+	if len(sample.Labels) > 0 {
+		line.WriteByte('{')
+		for i, label := range sample.Labels {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			fmt.Fprintf(&line, "%s=\"%s\"", label.Name, escapeLabel(label.Value))
+		}
+		line.WriteByte('}')
+	}
+	line.WriteByte(' ')
+	line.WriteString(formatValue(sample.Value))
+	line.WriteByte('\n')
+	_, err := io.WriteString(w, line.String())
+	return err
+}
+
+func zipLabels(names, values []string) []Label {
+	labels := make([]Label, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			labels[i] = Label{name, values[i]}
+		} else {
+			labels[i] = Label{Name: name}
+		}
+	}
+	return labels
+}
+
+// seriesKey ordena las series por sus etiquetas, sin contar le
+func seriesKey(labels []Label) string {
+	var key strings.Builder
+	for _, label := range labels {
+		if label.Name != "le" {
+			key.WriteString(label.Value)
+			key.WriteByte(0)
+		}
+	}
+	return key.String()
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string { return labelEscaper.Replace(value) }
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string { return helpEscaper.Replace(help) }
@@ -0,0 +1,5 @@
+package reports
+
+import logging "backend/logging"
+
+var logger = logging.Logger(logging.Reports)
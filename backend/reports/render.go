@@ -0,0 +1,58 @@
+package reports
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// OutputFormat es un formato de salida de los reportes de Graphviz
+type OutputFormat struct {
+	Graphviz    string // Valor de -T para dot (vacío: la salida es el mismo .dot)
+	ContentType string // Tipo de contenido para servir el archivo por HTTP
+}
+
+// Formatos según la extensión del archivo de salida. Una extensión que no está aquí genera PNG, como antes.
+var outputFormats = map[string]OutputFormat{
+	".png":  {Graphviz: "png", ContentType: "image/png"},
+	".svg":  {Graphviz: "svg", ContentType: "image/svg+xml"},
+	".pdf":  {Graphviz: "pdf", ContentType: "application/pdf"},
+	".jpg":  {Graphviz: "jpg", ContentType: "image/jpeg"},
+	".jpeg": {Graphviz: "jpg", ContentType: "image/jpeg"},
+	".dot":  {ContentType: "text/vnd.graphviz; charset=utf-8"},
+	".txt":  {Graphviz: "plain", ContentType: "text/plain; charset=utf-8"},
+}
+
+// GraphvizBinary es el ejecutable de Graphviz que genera las imágenes
+var GraphvizBinary = "dot"
+
+// FormatFor devuelve el formato que corresponde a la extensión del path
+func FormatFor(path string) OutputFormat {
+	if format, ok := outputFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	return outputFormats[".png"]
+}
+
+// IsKnownFormat indica si la extensión del path es una de las de outputFormats
+func IsKnownFormat(path string) bool {
+	_, ok := outputFormats[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// renderDot genera outputImage a partir del archivo .dot, con el formato de la extensión de outputImage.
+// Si outputImage es el mismo .dot no hay nada que generar.
+func renderDot(dotFileName, outputImage string) error {
+	format := FormatFor(outputImage)
+	if format.Graphviz == "" || dotFileName == outputImage {
+		return nil
+	}
+	cmd := exec.Command(GraphvizBinary, "-T"+format.Graphviz, dotFileName, "-o", outputImage)
+	cmdOutput, err := cmd.CombinedOutput()
+	if err != nil {
+		logger.Warn("Graphviz terminó con error", "output", string(cmdOutput), "error", err)
+		return fmt.Errorf("error al ejecutar Graphviz: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package reports
+
+import (
+	structures "backend/structures"
+	utils "backend/utils"
+	"fmt"
+	"html"
+	"os"
+)
+
+// ReportAudit genera un reporte con los registros del log de auditoría de la partición que cumplen el filtro
+func ReportAudit(superblock *structures.SuperBlock, dev structures.BlockDevice, outputPath string, filter utils.AuditFilter) error {
+	// Crear las carpetas padre si no existen
+	err := utils.CreateParentDirs(outputPath)
+	if err != nil {
+		return err
+	}
+
+	// Obtener nombres base del archivo DOT y la imagen de salida
+	dotFileName, outputImage := utils.GetFileNames(outputPath)
+
+	// Leer el log de auditoría (si todavía no existe, el reporte queda vacío)
+	var entries []utils.AuditEntry
+	if _, auditInode, err := structures.FindInodeByPath(superblock, dev, utils.AuditLogPath); err == nil {
+		content, err := structures.ReadFileContent(superblock, dev, auditInode)
+		if err != nil {
+			return fmt.Errorf("error al leer %s: %w", utils.AuditLogPath, err)
+		}
+		for _, entry := range utils.ParseAuditLog(content) {
+			if filter.Matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	// Describir el filtro aplicado
+	filterText := "todos los registros"
+	if filter.User != "" || !filter.From.IsZero() || !filter.To.IsZero() {
+		filterText = ""
+		if filter.User != "" {
+			filterText += fmt.Sprintf("usuario %s ", filter.User)
+		}
+		if !filter.From.IsZero() {
+			filterText += fmt.Sprintf("desde %s ", filter.From.Format("2006-01-02 15:04:05"))
+		}
+		if !filter.To.IsZero() {
+			filterText += fmt.Sprintf("hasta %s", filter.To.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	// Iniciar el contenido DOT con una tabla
+	dotContent := fmt.Sprintf(`digraph G {
+	node [shape=plaintext]
+	tabla [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+			<tr><td colspan="6" bgcolor="gray"><b> REPORTE AUDITORÍA </b></td></tr>
+			<tr><td colspan="2" bgcolor="lightgray"><b>Filtro</b></td><td colspan="4">%s</td></tr>
+			<tr><td colspan="2" bgcolor="lightgray"><b>Registros</b></td><td colspan="4">%d</td></tr>
+			<tr>
+				<td bgcolor="lightblue"><b>Fecha</b></td>
+				<td bgcolor="lightblue"><b>Usuario</b></td>
+				<td bgcolor="lightblue"><b>Sesión</b></td>
+				<td bgcolor="lightblue"><b>Evento</b></td>
+				<td bgcolor="lightblue"><b>Comando</b></td>
+				<td bgcolor="lightblue"><b>Resultado</b></td>
+			</tr>
+		`, html.EscapeString(filterText), len(entries))
+
+	// Una fila por registro; los fallidos en rojo
+	for _, entry := range entries {
+		color := "lightgreen"
+		if entry.Result != "ok" {
+			color = "salmon"
+		}
+
+		event := entry.Event
+		if entry.Target != "" {
+			event += " → " + entry.Target
+		}
+
+		dotContent += fmt.Sprintf(`<tr>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td bgcolor="%s">%s</td>
+			</tr>
+		`, entry.Time.Local().Format("2006-01-02 15:04:05"), html.EscapeString(entry.User), html.EscapeString(entry.Session),
+			html.EscapeString(event), html.EscapeString(entry.Command), color, html.EscapeString(entry.Result))
+	}
+
+	// Cerrar la tabla y el contenido DOT
+	dotContent += "</table>>] }"
+
+	// Guardar el contenido DOT en un archivo
+	file, err := os.Create(dotFileName)
+	if err != nil {
+		return fmt.Errorf("error al crear el archivo DOT: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(dotContent)
+	if err != nil {
+		return fmt.Errorf("error al escribir en el archivo DOT: %v", err)
+	}
+
+	// Ejecutar el comando Graphviz para generar la imagen
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
+	}
+
+	logger.Debug("Reporte de auditoría generado", "path", outputImage)
+	return nil
+}
@@ -6,13 +6,12 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 )
 
 // ReporteBloque genera un reporte detallado de los bloques usados,
 // evitando duplicados y conectándolos secuencialmente según se descubren.
-func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string) error {
+func ReportBlock(superblock *structures.SuperBlock, dev structures.BlockDevice, path string, progress *utils.Progress) error {
 	err := utils.CreateParentDirs(path)
 	if err != nil {
 		return err
@@ -25,17 +24,7 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 		return fmt.Errorf("s_inodes_count inválido: %d", inodeBitmapSize)
 	}
 	inodeBitmap := make([]byte, inodeBitmapSize)
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return fmt.Errorf("error al abrir disco para leer bitmap de inodos: %w", err)
-	}
-	_, err = file.Seek(int64(superblock.S_bm_inode_start), 0)
-	if err != nil {
-		file.Close()
-		return fmt.Errorf("error al buscar inicio de bitmap de inodos: %w", err)
-	}
-	bytesRead, err := file.Read(inodeBitmap)
-	file.Close()
+	bytesRead, err := dev.ReadAt(inodeBitmap, int64(superblock.S_bm_inode_start))
 	if err != nil || int32(bytesRead) != inodeBitmapSize {
 		return fmt.Errorf("error al leer bitmap de inodos completo: %w", err)
 	}
@@ -50,7 +39,9 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
     `
 
 	// Iterar sobre los posibles slots de inodo
+	progress.Start(int64(superblock.S_inodes_count), "inodos")
 	for i := int32(0); i < superblock.S_inodes_count; i++ {
+		progress.Add(1)
 
 		// Filtrar: Solo procesar inodos usados
 		if inodeBitmap[i] != '1' {
@@ -60,9 +51,9 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 		// Inodo 'i' está usado
 		inode := &structures.Inode{}
 		inodeOffset := int64(superblock.S_inode_start + (i * superblock.S_inode_size))
-		err := inode.Deserialize(diskPath, inodeOffset)
+		err := inode.Deserialize(dev, inodeOffset)
 		if err != nil {
-			fmt.Printf("Error deserializando inodo %d para reporte de bloques: %v. Saltando inodo.\n", i, err)
+			logger.Debug("Error deserializando inodo para el reporte de bloques; se salta", "inode", i, "error", err)
 			// Podríamos generar un nodo inodo de error si quisiéramos verlo
 			// dotContent += fmt.Sprintf("\tinode_err%d [label=\"Error Inodo %d\"];\n", i, i)
 			continue // Saltar al siguiente inodo
@@ -75,7 +66,7 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 				continue
 			} // Puntero no usado
 			if blockPtr < 0 || blockPtr >= superblock.S_blocks_count {
-				fmt.Printf("Puntero de bloque inválido (%d) encontrado en inodo %d, i_block[%d]. Saltando.\n", blockPtr, i, k)
+				logger.Debug("Puntero de bloque inválido; se salta", "block", blockPtr, "inode", i, "pointer", k)
 				continue // Saltar puntero inválido
 			}
 
@@ -110,7 +101,7 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 			if blockIsPointer {
 				// Bloque de Apuntadores (Simple, Doble, Triple)
 				block := &structures.PointerBlock{}
-				err := block.Deserialize(diskPath, blockOffset)
+				err := block.Deserialize(dev, blockOffset)
 				if err == nil {
 					var label strings.Builder
 					label.WriteString(`<table border="0" cellborder="1" cellspacing="0" cellpadding="4">`)
@@ -133,7 +124,7 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 					blockLabel = label.String()
 					blockGenerated = true
 				} else {
-					fmt.Printf("Error deserializando Bloque Apuntadores %d: %v\n", blockPtr, err)
+					logger.Debug("Error deserializando bloque de apuntadores", "block", blockPtr, "error", err)
 				}
 
 			} else {
@@ -141,7 +132,7 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 				switch inode.I_type[0] {
 				case '0': // Carpeta
 					block := &structures.FolderBlock{}
-					err := block.Deserialize(diskPath, blockOffset)
+					err := block.Deserialize(dev, blockOffset)
 					if err == nil {
 						var label strings.Builder
 						label.WriteString(`<table border="0" cellborder="1" cellspacing="0" cellpadding="4">`)
@@ -158,12 +149,12 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 						blockLabel = label.String()
 						blockGenerated = true
 					} else {
-						fmt.Printf("Error deserializando Bloque Carpeta %d: %v\n", blockPtr, err)
+						logger.Debug("Error deserializando bloque carpeta", "block", blockPtr, "error", err)
 					}
 
 				case '1': // Archivo
 					block := &structures.FileBlock{}
-					err := block.Deserialize(diskPath, blockOffset)
+					err := block.Deserialize(dev, blockOffset)
 					if err == nil {
 						content := string(bytes.TrimRight(block.B_content[:], "\x00"))
 						content = strings.ReplaceAll(content, "&", "&amp;")
@@ -176,10 +167,10 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
                         </table>`, blockPtr, content)
 						blockGenerated = true
 					} else {
-						fmt.Printf("Error deserializando Bloque Archivo %d: %v\n", blockPtr, err)
+						logger.Debug("Error deserializando bloque archivo", "block", blockPtr, "error", err)
 					}
 				default:
-					fmt.Printf("Tipo de inodo desconocido '%c' para bloque de datos %d\n", inode.I_type[0], blockPtr)
+					logger.Debug("Tipo de inodo desconocido para bloque de datos", "type", string(inode.I_type[0]), "block", blockPtr)
 					blockLabel = fmt.Sprintf("Bloque %d (Tipo Inodo Desconocido)", blockPtr)
 				} // End switch inode.I_type
 			} // End else (Bloque de Datos)
@@ -211,13 +202,10 @@ func ReportBlock(superblock *structures.SuperBlock, diskPath string, path string
 		return err
 	}
 
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("Error ejecutando Graphviz para ReportBlock:\n%s\n", string(cmdOutput))
-		return fmt.Errorf("error al ejecutar Graphviz: %w", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Imagen de los bloques generada:", outputImage)
+	logger.Debug("Imagen de los bloques generada", "path", outputImage)
 	return nil
 }
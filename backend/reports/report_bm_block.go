@@ -9,7 +9,7 @@ import (
 )
 
 // ReportBlock genera un reporte de bloques y lo guarda en la ruta especificada
-func ReportBMBlock(superblock *structures.SuperBlock, diskPath string, path string) error {
+func ReportBMBlock(superblock *structures.SuperBlock, dev structures.BlockDevice, path string) error {
 
 	// Crear las carpetas padre si no existen
 	err := utils.CreateParentDirs(path)
@@ -17,13 +17,6 @@ func ReportBMBlock(superblock *structures.SuperBlock, diskPath string, path stri
 		return err
 	}
 
-	// Abrir el archivo de disco
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
-	}
-	defer file.Close()
-
 	//Calcular el número total de bloques
 	totalBlocks := superblock.S_blocks_count+superblock.S_free_blocks_count
 
@@ -31,17 +24,11 @@ func ReportBMBlock(superblock *structures.SuperBlock, diskPath string, path stri
 	var bitmapContent strings.Builder
 
 	for i := int32(0); i < totalBlocks; i++ {
-		// Establecer el puntero
-		_, err := file.Seek(int64(superblock.S_bm_inode_start+i), 0)
-		if err != nil {
-			return fmt.Errorf("error al establecer el puntero en el archivo: %v", err)
-		}
-
-		// Leer un byte (carácter '0' o '1')
+		// Leer un byte (carácter '0' o '1') en la posición del bitmap
 		char := make([]byte, 1)
-		_, err = file.Read(char)
+		_, err := dev.ReadAt(char, int64(superblock.S_bm_inode_start+i))
 		if err != nil {
-			return fmt.Errorf("error al leer el byte del archivo: %v", err)
+			return fmt.Errorf("error al leer el byte del disco: %v", err)
 		}
 
 		// Agregar el carácter al contenido del bitmap
@@ -66,7 +53,7 @@ func ReportBMBlock(superblock *structures.SuperBlock, diskPath string, path stri
 		return fmt.Errorf("error al escribir en el archivo TXT: %v", err)
 	}
 
-	fmt.Println("Archivo del bitmap de bloques generado:", path)
+	logger.Debug("Archivo del bitmap de bloques generado", "path", path)
 	return nil
 
 }
\ No newline at end of file
@@ -9,20 +9,13 @@ import (
 )
 
 // ReportBMInode genera un reporte del bitmap de inodos y lo guarda en la ruta especificada
-func ReportBMInode(superblock *structures.SuperBlock, diskPath string, path string) error {
+func ReportBMInode(superblock *structures.SuperBlock, dev structures.BlockDevice, path string) error {
 	// Crear las carpetas padre si no existen
 	err := utils.CreateParentDirs(path)
 	if err != nil {
 		return err
 	}
 
-	// Abrir el archivo de disco
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return fmt.Errorf("error al abrir el archivo de disco: %v", err)
-	}
-	defer file.Close()
-
 	// Calcular el número total de inodos
 	totalInodes := superblock.S_inodes_count + superblock.S_free_inodes_count
 
@@ -30,17 +23,11 @@ func ReportBMInode(superblock *structures.SuperBlock, diskPath string, path stri
 	var bitmapContent strings.Builder
 
 	for i := int32(0); i < totalInodes; i++ {
-		// Establecer el puntero
-		_, err := file.Seek(int64(superblock.S_bm_inode_start+i), 0)
-		if err != nil {
-			return fmt.Errorf("error al establecer el puntero en el archivo: %v", err)
-		}
-
-		// Leer un byte (carácter '0' o '1')
+		// Leer un byte (carácter '0' o '1') en la posición del bitmap
 		char := make([]byte, 1)
-		_, err = file.Read(char)
+		_, err := dev.ReadAt(char, int64(superblock.S_bm_inode_start+i))
 		if err != nil {
-			return fmt.Errorf("error al leer el byte del archivo: %v", err)
+			return fmt.Errorf("error al leer el byte del disco: %v", err)
 		}
 
 		// Agregar el carácter al contenido del bitmap
@@ -65,6 +52,6 @@ func ReportBMInode(superblock *structures.SuperBlock, diskPath string, path stri
 		return fmt.Errorf("error al escribir en el archivo TXT: %v", err)
 	}
 
-	fmt.Println("Archivo del bitmap de inodos generado:", path)
+	logger.Debug("Archivo del bitmap de inodos generado", "path", path)
 	return nil
 }
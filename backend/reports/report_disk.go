@@ -3,14 +3,12 @@ package reports
 import (
 	structures "backend/structures"
 	utils "backend/utils"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 )
 
-func ReportDisk(mbr *structures.MBR, diskPath string, outputPath string) error {
+func ReportDisk(mbr *structures.MBR, dev structures.BlockDevice, diskPath string, outputPath string) error {
 	err := utils.CreateParentDirs(outputPath)
 	if err != nil {
 		return err
@@ -56,19 +54,12 @@ func ReportDisk(mbr *structures.MBR, diskPath string, outputPath string) error {
 				dotContent += "\t\t\t\t<TR><TD COLSPAN=\"100\" ALIGN=\"CENTER\"><B>Extendida</B></TD></TR>\n"
 				dotContent += "\t\t\t\t<TR>\n"
 
-				file, err := os.Open(diskPath)
-				if err != nil {
-					return fmt.Errorf("error abriendo el archivo del disco: %v", err)
-				}
-				defer file.Close()
-
 				var ebr structures.EBR
 				offset := part.Part_start
 				logicalCount := 0
 
 				for {
-					file.Seek(int64(offset), os.SEEK_SET)
-					err := binary.Read(file, binary.LittleEndian, &ebr)
+					err := ebr.Deserialize(dev, int64(offset))
 					if err != nil || ebr.Part_size <= 0 {
 						break
 					}
@@ -125,12 +116,10 @@ func ReportDisk(mbr *structures.MBR, diskPath string, outputPath string) error {
 	}
 
 	// Ejecutar el comando Graphviz para generar la imagen
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Reporte DISK generado:", outputImage)
+	logger.Debug("Reporte DISK generado", "path", outputImage)
 	return nil
 }
\ No newline at end of file
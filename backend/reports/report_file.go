@@ -10,13 +10,13 @@ import (
 )
 
 // ReportFile genera un reporte con el contenido de un archivo del sistema ext2
-func ReportFile(superblock *structures.SuperBlock, diskPath string, outputPath string, filePath string) error {
+func ReportFile(superblock *structures.SuperBlock, dev structures.BlockDevice, outputPath string, filePath string) error {
 	// Asegurar que el filePath sea absoluto
 	if !strings.HasPrefix(filePath, "/") {
 		filePath = "/" + filePath
 	}
 	// Buscar el inodo del archivo
-	_, inode, err := structures.FindInodeByPath(superblock, diskPath, filePath)
+	_, inode, err := structures.FindInodeByPath(superblock, dev, filePath)
 	if err != nil {
 		return fmt.Errorf("error al buscar el inodo: %v", err)
 	}
@@ -28,7 +28,7 @@ func ReportFile(superblock *structures.SuperBlock, diskPath string, outputPath s
 		return fmt.Errorf("'%s' no es un archivo regular", filePath)
 	}
 	// Leer contenido del archivo
-	content, err := structures.ReadFileContent(superblock, diskPath, inode)
+	content, err := structures.ReadFileContent(superblock, dev, inode)
 	if err != nil {
 		return fmt.Errorf("error al leer el contenido: %v", err)
 	}
@@ -0,0 +1,105 @@
+package reports
+
+import (
+	structures "backend/structures"
+	utils "backend/utils"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// ReportFrag genera un reporte con la fragmentación de cada archivo y carpeta de la partición
+func ReportFrag(superblock *structures.SuperBlock, dev structures.BlockDevice, outputPath string) error {
+	// Crear las carpetas padre si no existen
+	err := utils.CreateParentDirs(outputPath)
+	if err != nil {
+		return err
+	}
+
+	// Obtener nombres base del archivo DOT y la imagen de salida
+	dotFileName, outputImage := utils.GetFileNames(outputPath)
+
+	// Calcular la fragmentación de la partición
+	report, err := superblock.AnalyzeFragmentation(dev)
+	if err != nil {
+		return fmt.Errorf("error al analizar la fragmentación: %w", err)
+	}
+
+	// Iniciar el contenido DOT con una tabla
+	dotContent := fmt.Sprintf(`digraph G {
+	node [shape=plaintext]
+	tabla [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+			<tr><td colspan="6" bgcolor="gray"><b> REPORTE FRAGMENTACIÓN </b></td></tr>
+			<tr><td colspan="3" bgcolor="lightgray"><b>Fragmentación total</b></td><td colspan="3">%.2f%%</td></tr>
+			<tr><td colspan="3" bgcolor="lightgray"><b>Bloques huérfanos</b></td><td colspan="3">%d</td></tr>
+			<tr>
+				<td bgcolor="lightblue"><b>Inodo</b></td>
+				<td bgcolor="lightblue"><b>Path</b></td>
+				<td bgcolor="lightblue"><b>Tipo</b></td>
+				<td bgcolor="lightblue"><b>Tramos / Bloques</b></td>
+				<td bgcolor="lightblue"><b>Fragmentación</b></td>
+				<td bgcolor="lightblue"><b>Bloques</b></td>
+			</tr>
+		`, report.Score(), len(report.OrphanBlocks))
+
+	// Una fila por inodo, coloreada según su fragmentación
+	for _, frag := range report.Inodes {
+		color := "lightgreen"
+		switch score := frag.Score(); {
+		case score >= 50:
+			color = "salmon"
+		case score > 0:
+			color = "lightyellow"
+		}
+
+		typeName := "Archivo"
+		if frag.Type == '0' {
+			typeName = "Carpeta"
+		}
+
+		path := frag.Path
+		if path == "" {
+			path = "(sin enlazar)"
+		}
+
+		blocks := make([]string, len(frag.Blocks))
+		for i, block := range frag.Blocks {
+			blocks[i] = fmt.Sprintf("%d", block)
+		}
+
+		dotContent += fmt.Sprintf(`<tr>
+				<td>%d</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%d / %d</td>
+				<td bgcolor="%s">%.2f%%</td>
+				<td>%s</td>
+			</tr>
+		`, frag.InodeIndex, html.EscapeString(path), typeName, frag.Runs, len(frag.Blocks), color, frag.Score(), strings.Join(blocks, ", "))
+	}
+
+	// Cerrar la tabla y el contenido DOT
+	dotContent += "</table>>] }"
+
+	// Guardar el contenido DOT en un archivo
+	file, err := os.Create(dotFileName)
+	if err != nil {
+		return fmt.Errorf("error al crear el archivo DOT: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(dotContent)
+	if err != nil {
+		return fmt.Errorf("error al escribir en el archivo DOT: %v", err)
+	}
+
+	// Ejecutar el comando Graphviz para generar la imagen
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
+	}
+
+	logger.Debug("Reporte de fragmentación generado", "path", outputImage)
+	return nil
+}
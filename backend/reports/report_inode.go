@@ -5,12 +5,11 @@ import (
 	utils "backend/utils"
 	"fmt"
 	"os"
-	"os/exec"
 	"time"
 )
 
 // ReportInode genera un reporte de un inodo y lo guarda en la ruta especificada
-func ReportInode(superblock *structures.SuperBlock, diskPath string, path string) error {
+func ReportInode(superblock *structures.SuperBlock, dev structures.BlockDevice, path string, progress *utils.Progress) error {
 	// Crear las carpetas padre si no existen
 	err := utils.CreateParentDirs(path)
 	if err != nil {
@@ -27,18 +26,8 @@ func ReportInode(superblock *structures.SuperBlock, diskPath string, path string
 	}
 
 	inodeBitmap := make([]byte, inodeBitmapSize)
-	file, err := os.Open(diskPath)
-	if err != nil {
-		return fmt.Errorf("error al abrir disco para leer bitmap de inodos: %w", err)
-	}
-	// Asegurarse de buscar desde el inicio del archivo para el offset del bitmap
-	_, err = file.Seek(int64(superblock.S_bm_inode_start), 0) // SEEK_SET = 0
-	if err != nil {
-		file.Close()
-		return fmt.Errorf("error al buscar inicio de bitmap de inodos: %w", err)
-	}
-	bytesRead, err := file.Read(inodeBitmap)
-	file.Close() // Cerrar el archivo después de leer
+	// Leer el bitmap completo desde su offset en el dispositivo
+	bytesRead, err := dev.ReadAt(inodeBitmap, int64(superblock.S_bm_inode_start))
 	if err != nil || int32(bytesRead) != inodeBitmapSize {
 		return fmt.Errorf("error al leer bitmap de inodos completo (leídos %d, esperados %d): %w", bytesRead, inodeBitmapSize, err)
 	}
@@ -52,7 +41,9 @@ func ReportInode(superblock *structures.SuperBlock, diskPath string, path string
 	var lastValidInodeIndex int32 = -1 // Para rastrear el último inodo VÁLIDO
 
 	// Iterar sobre cada inodo
+	progress.Start(int64(superblock.S_inodes_count), "inodos")
 	for i := int32(0); i < superblock.S_inodes_count; i++ {
+		progress.Add(1)
 
 		if inodeBitmap[i] != '1' {
 			continue // Saltar
@@ -63,10 +54,10 @@ func ReportInode(superblock *structures.SuperBlock, diskPath string, path string
 		inode := &structures.Inode{}
 		// Deserializar el inodo
 		inodeOffset := int64(superblock.S_inode_start + (currentIndex * superblock.S_inode_size))
-		err := inode.Deserialize(diskPath, inodeOffset)
+		err := inode.Deserialize(dev, inodeOffset)
 		if err != nil {
 			// Si está marcado como usado pero falla la deserialización, es un error del FS
-			fmt.Printf("Error deserializando inodo %d (marcado como usado): %v. Generando nodo de error.\n", currentIndex, err)
+			logger.Debug("Error deserializando inodo marcado como usado; se genera un nodo de error", "inode", currentIndex, "error", err)
 			dotContent += fmt.Sprintf("\tinode%d [label=\"Error Inodo %d\", shape=box, style=filled, fillcolor=red];\n", currentIndex, currentIndex)
 			lastValidInodeIndex = -1 // No conectar desde/hacia nodos de error
 			continue                 // Continuar al siguiente índice ----------------------------------------------------------------------------------------------
@@ -132,13 +123,10 @@ func ReportInode(superblock *structures.SuperBlock, diskPath string, path string
 		return err
 	}
 
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("Error ejecutando Graphviz para ReportInode:\n%s\n", string(cmdOutput))
-		return fmt.Errorf("error al ejecutar Graphviz: %w", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Imagen de los inodos generada:", outputImage)
+	logger.Debug("Imagen de los inodos generada", "path", outputImage)
 	return nil
 }
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -14,8 +13,8 @@ import (
 
 // --- Implementación del Reporte LS ---
 
-func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, targetPath string) error {
-	fmt.Printf("Generando reporte LS para: %s en disco: %s, salida: %s\n", targetPath, diskPath, outputPath)
+func ReportLS(sb *structures.SuperBlock, dev structures.BlockDevice, outputPath string, targetPath string) error {
+	logger.Debug("Generando reporte LS", "path", targetPath, "output", outputPath)
 
 	// 0. Crear directorios de salida y obtener nombres de archivo
 	err := utils.CreateParentDirs(outputPath)
@@ -25,7 +24,7 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 	dotFileName, outputImage := utils.GetFileNames(outputPath)
 
 	// 1. Encontrar el inodo del directorio objetivo (targetPath)
-	targetInodeNum, targetInode, err := structures.FindInodeByPath(sb, diskPath, targetPath)
+	targetInodeNum, targetInode, err := structures.FindInodeByPath(sb, dev, targetPath)
 	if err != nil {
 		return fmt.Errorf("error al buscar el path '%s' para reporte LS: %v", targetPath, err)
 	}
@@ -36,7 +35,7 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 	}
 
 	// 3. Obtener los mapas de UID/GID a Nombres desde users.txt
-	uidMap, gidMap, err := getUserGroupNameMaps(sb, diskPath)
+	uidMap, gidMap, uidGroups, err := getUserGroupNameMaps(sb, dev)
 	if err != nil {
 		// Podrías decidir continuar y mostrar IDs numéricos, o fallar.
 		// Por ahora, fallaremos si hay un error irrecuperable en getUserGroupNameMaps.
@@ -59,6 +58,7 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Permisos</B></TD>\n"
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Owner</B></TD>\n"
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Grupo</B></TD>\n"
+	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Grupos Owner</B></TD>\n"
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Size (Bytes)</B></TD>\n"
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Fecha Mod.</B></TD>\n"
 	dotContent += "\t\t\t<TD BGCOLOR=\"lightgrey\"><B>Hora Mod.</B></TD>\n"
@@ -75,14 +75,14 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 			continue // Puntero no usado
 		}
 		if blockPtr < 0 || blockPtr >= sb.S_blocks_count {
-			fmt.Printf("Advertencia: Puntero de bloque inválido (%d) encontrado en inodo %d.\n", blockPtr, targetInodeNum)
+			logger.Warn("Puntero de bloque inválido", "block", blockPtr, "inode", targetInodeNum)
 			continue
 		}
 
 		blockOffset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-		err := inodeBlock.Deserialize(diskPath, blockOffset)
+		err := inodeBlock.Deserialize(dev, blockOffset)
 		if err != nil {
-			fmt.Printf("Advertencia: Error al leer bloque de directorio %d: %v. Saltando bloque.\n", blockPtr, err)
+			logger.Warn("Error al leer bloque de directorio; se salta", "block", blockPtr, "error", err)
 			continue
 		}
 
@@ -92,7 +92,7 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 				continue // Entrada no usada
 			}
 			if entry.B_inodo < 0 || entry.B_inodo >= sb.S_inodes_count {
-				fmt.Printf("Advertencia: Puntero de inodo inválido (%d) encontrado en bloque %d.\n", entry.B_inodo, blockPtr)
+				logger.Warn("Puntero de inodo inválido", "inode", entry.B_inodo, "block", blockPtr)
 				continue
 			}
 
@@ -103,9 +103,9 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 
 			// 7. Obtener el inodo de la entrada
 			entryInodeOffset := int64(sb.S_inode_start) + int64(entry.B_inodo)*int64(sb.S_inode_size)
-			err := entryInode.Deserialize(diskPath, entryInodeOffset)
+			err := entryInode.Deserialize(dev, entryInodeOffset)
 			if err != nil {
-				fmt.Printf("Advertencia: Error al leer inodo %d para '%s': %v. Saltando entrada.\n", entry.B_inodo, entryName, err)
+				logger.Warn("Error al leer inodo; se salta la entrada", "inode", entry.B_inodo, "name", entryName, "error", err)
 				continue
 			}
 
@@ -119,6 +119,10 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 			if !ok {
 				groupName = fmt.Sprintf("%d", entryInode.I_gid) // Mostrar ID si no se encuentra el nombre
 			}
+			ownerGroups := strings.Join(uidGroups[entryInode.I_uid], ", ") // Principal y secundarios del owner
+			if ownerGroups == "" {
+				ownerGroups = "-"
+			}
 			size := entryInode.I_size
 			modTime := time.Unix(int64(entryInode.I_mtime), 0)
 			fechaMod := modTime.Format("02/01/2006") // Formato DD/MM/YYYY
@@ -133,6 +137,7 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", permisos)
 			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", ownerName)
 			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", groupName)
+			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", ownerGroups)
 			dotContent += fmt.Sprintf("\t\t\t<TD ALIGN=\"RIGHT\">%d</TD>\n", size) // Alinear tamaño a la derecha
 			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", fechaMod)
 			dotContent += fmt.Sprintf("\t\t\t<TD>%s</TD>\n", horaMod)
@@ -158,19 +163,14 @@ func ReportLS(sb *structures.SuperBlock, diskPath string, outputPath string, tar
 	if err != nil {
 		return fmt.Errorf("error al escribir en el archivo DOT para reporte LS: %v", err)
 	}
-	fmt.Println("Archivo DOT generado:", dotFileName)
+	logger.Debug("Archivo DOT generado", "path", dotFileName)
 
 	// 12. Ejecutar Graphviz para generar la imagen
-	// Asegúrate de que 'dot' esté en el PATH del sistema
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("Salida de Graphviz:\n%s\n", string(cmdOutput))
-		return fmt.Errorf("error al ejecutar Graphviz para reporte LS: %v", err)
-
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Reporte LS generado exitosamente:", outputImage)
+	logger.Debug("Reporte LS generado exitosamente", "path", outputImage)
 	return nil
 }
 
@@ -214,10 +214,12 @@ func formatPermissions(perm [3]byte, fileType byte) string {
 	return permStr
 }
 
-// parseUsersTxt analiza el contenido de users.txt y devuelve mapas de UID/GID a nombres.
-func parseUsersTxt(content string) (map[int32]string, map[int32]string, error) {
+// parseUsersTxt analiza el contenido de users.txt y devuelve mapas de UID/GID a nombres
+// y de UID a todos los grupos del usuario (principal y secundarios).
+func parseUsersTxt(content string) (map[int32]string, map[int32]string, map[int32][]string, error) {
 	uidToName := make(map[int32]string)
 	gidToName := make(map[int32]string)
+	uidToGroups := make(map[int32][]string)
 	lines := strings.Split(content, "\n")
 
 	for _, line := range lines {
@@ -226,13 +228,13 @@ func parseUsersTxt(content string) (map[int32]string, map[int32]string, error) {
 		}
 		parts := strings.Split(line, ",")
 		if len(parts) < 3 {
-			fmt.Printf("Advertencia: Línea mal formada en users.txt: %s\n", line)
+			logger.Warn("Línea mal formada en users.txt", "line", line)
 			continue
 		}
 
 		id64, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 32)
 		if err != nil {
-			fmt.Printf("Advertencia: ID inválido en users.txt: %s\n", parts[0])
+			logger.Warn("ID inválido en users.txt", "id", parts[0])
 			continue
 		}
 		id := int32(id64)
@@ -240,11 +242,15 @@ func parseUsersTxt(content string) (map[int32]string, map[int32]string, error) {
 		name := strings.TrimSpace(parts[2])
 
 		if userType == "U" { // Usuario
-			if len(parts) < 4 { // Necesita al menos ID, U, username, password
-				fmt.Printf("Advertencia: Línea de usuario incompleta en users.txt: %s\n", line)
+			for i := range parts {
+				parts[i] = strings.TrimSpace(parts[i])
+			}
+			if !utils.IsUserLine(parts) { // Necesita al menos ID, U, grupo, username, password
+				logger.Warn("Línea de usuario incompleta en users.txt", "line", line)
 				continue
 			}
-			uidToName[id] = name
+			uidToName[id] = parts[3]
+			uidToGroups[id] = utils.UserGroups(parts)
 		} else if userType == "G" { // Grupo
 			gidToName[id] = name
 		}
@@ -258,29 +264,29 @@ func parseUsersTxt(content string) (map[int32]string, map[int32]string, error) {
 		gidToName[1] = "root"
 	}
 
-	return uidToName, gidToName, nil
+	return uidToName, gidToName, uidToGroups, nil
 }
 
 // getUserGroupName obtiene el nombre de usuario o grupo desde users.txt
 // Necesita leer y parsear users.txt
-func getUserGroupNameMaps(sb *structures.SuperBlock, diskPath string) (map[int32]string, map[int32]string, error) {
+func getUserGroupNameMaps(sb *structures.SuperBlock, dev structures.BlockDevice) (map[int32]string, map[int32]string, map[int32][]string, error) {
 	// Asumimos que users.txt está en el inodo 1 (según tu CreateUsersFile)
 	usersInode := &structures.Inode{}
 	usersInodeOffset := int64(sb.S_inode_start) + 1*int64(sb.S_inode_size) // Offset del inodo 1
-	err := usersInode.Deserialize(diskPath, usersInodeOffset)
+	err := usersInode.Deserialize(dev, usersInodeOffset)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error al leer inodo de users.txt: %v", err)
+		return nil, nil, nil, fmt.Errorf("error al leer inodo de users.txt: %v", err)
 	}
 
 	if usersInode.I_type[0] != '1' {
-		return nil, nil, errors.New("el inodo 1 no es un archivo (se esperaba users.txt)")
+		return nil, nil, nil, errors.New("el inodo 1 no es un archivo (se esperaba users.txt)")
 	}
 
-	usersContent, err := structures.ReadFileContent(sb, diskPath, usersInode)
+	usersContent, err := structures.ReadFileContent(sb, dev, usersInode)
 	if err != nil {
 		// Intenta devolver mapas vacíos si no se puede leer users.txt
-		fmt.Printf("Advertencia: No se pudo leer el contenido de users.txt: %v. Se usarán IDs numéricos.\n", err)
-		return make(map[int32]string), make(map[int32]string), nil // Devuelve mapas vacíos en lugar de error fatal
+		logger.Warn("No se pudo leer el contenido de users.txt; se usarán IDs numéricos", "error", err)
+		return make(map[int32]string), make(map[int32]string), make(map[int32][]string), nil // Devuelve mapas vacíos en lugar de error fatal
 		// return nil, nil, fmt.Errorf("error al leer contenido de users.txt: %v", err)
 	}
 
@@ -3,16 +3,14 @@ package reports
 import (
 	structures "backend/structures"
 	utils "backend/utils"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
 
 // ReportMBR genera un reporte del MBR con particiones primarias, extendidas y lógicas
-func ReportMBR(mbr *structures.MBR, diskPath string, outputPath string) error {
+func ReportMBR(mbr *structures.MBR, dev structures.BlockDevice, outputPath string) error {
 	// Crear las carpetas padre si no existen
 	err := utils.CreateParentDirs(outputPath)
 	if err != nil {
@@ -72,19 +70,11 @@ func ReportMBR(mbr *structures.MBR, diskPath string, outputPath string) error {
 		if partType == 'E' {
 			dotContent += `<tr><td colspan="2" bgcolor="lightgreen"><b> Particiones Lógicas </b></td></tr>`
 
-			// Abrir el archivo para leer los EBRs
-			file, err := os.Open(diskPath)
-			if err != nil {
-				return fmt.Errorf("error abriendo el archivo del disco: %v", err)
-			}
-			defer file.Close()
-
 			var ebr structures.EBR
 			offset := part.Part_start
 			for {
-				// Moverse al inicio del EBR
-				file.Seek(int64(offset), os.SEEK_SET)
-				err := binary.Read(file, binary.LittleEndian, &ebr)
+				// Leer el EBR ubicado en el offset actual
+				err := ebr.Deserialize(dev, int64(offset))
 				if err != nil || ebr.Part_size <= 0 {
 					break
 				}
@@ -130,12 +120,10 @@ func ReportMBR(mbr *structures.MBR, diskPath string, outputPath string) error {
 	}
 
 	// Ejecutar el comando Graphviz para generar la imagen
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Reporte MBR generado:", outputImage)
+	logger.Debug("Reporte MBR generado", "path", outputImage)
 	return nil
 }
@@ -0,0 +1,146 @@
+package reports
+
+import (
+	structures "backend/structures"
+	utils "backend/utils"
+	"fmt"
+	"html"
+	"os"
+	"sort"
+)
+
+// quotaRow es una fila del reporte de cuotas: un usuario o un grupo con su uso y sus límites
+type quotaRow struct {
+	kind  string
+	name  string
+	id    int32
+	usage structures.Usage
+	quota utils.Quota
+}
+
+// ReportQuota genera un reporte con el uso de bloques e inodos de cada usuario y grupo contra sus cuotas.
+// El uso se calcula recorriendo el dueño de cada inodo en uso.
+func ReportQuota(superblock *structures.SuperBlock, dev structures.BlockDevice, outputPath string) error {
+	// Crear las carpetas padre si no existen
+	err := utils.CreateParentDirs(outputPath)
+	if err != nil {
+		return err
+	}
+
+	// Obtener nombres base del archivo DOT y la imagen de salida
+	dotFileName, outputImage := utils.GetFileNames(outputPath)
+
+	uidToName, gidToName, _, err := getUserGroupNameMaps(superblock, dev)
+	if err != nil {
+		return err
+	}
+	byUser, byGroup, err := superblock.UsageByOwner(dev)
+	if err != nil {
+		return fmt.Errorf("error calculando el uso de la partición: %w", err)
+	}
+
+	// Leer las cuotas (si /quota.txt no existe nadie tiene cuota)
+	quotas := utils.ParseQuotas("")
+	if _, quotaInode, err := structures.FindInodeByPath(superblock, dev, utils.QuotaFilePath); err == nil {
+		content, err := structures.ReadFileContent(superblock, dev, quotaInode)
+		if err != nil {
+			return fmt.Errorf("error al leer %s: %w", utils.QuotaFilePath, err)
+		}
+		quotas = utils.ParseQuotas(content)
+	}
+
+	// Una fila por usuario y por grupo existente, y por cada dueño de inodos que ya no exista
+	var rows []quotaRow
+	for uid, usage := range byUser {
+		if _, ok := uidToName[uid]; !ok {
+			rows = append(rows, quotaRow{kind: "Usuario", name: fmt.Sprintf("(UID %d)", uid), id: uid, usage: usage})
+		}
+	}
+	for uid, name := range uidToName {
+		rows = append(rows, quotaRow{kind: "Usuario", name: name, id: uid, usage: byUser[uid], quota: quotas.User(name)})
+	}
+	for gid, usage := range byGroup {
+		if _, ok := gidToName[gid]; !ok {
+			rows = append(rows, quotaRow{kind: "Grupo", name: fmt.Sprintf("(GID %d)", gid), id: gid, usage: usage})
+		}
+	}
+	for gid, name := range gidToName {
+		rows = append(rows, quotaRow{kind: "Grupo", name: name, id: gid, usage: byGroup[gid], quota: quotas.Group(name)})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].kind != rows[j].kind {
+			return rows[i].kind == "Usuario"
+		}
+		return rows[i].id < rows[j].id
+	})
+
+	// Iniciar el contenido DOT con una tabla
+	dotContent := fmt.Sprintf(`digraph G {
+	node [shape=plaintext]
+	tabla [label=<
+		<table border="0" cellborder="1" cellspacing="0">
+			<tr><td colspan="7" bgcolor="gray"><b> REPORTE CUOTAS </b></td></tr>
+			<tr><td colspan="2" bgcolor="lightgray"><b>Bloques libres</b></td><td colspan="5">%d de %d</td></tr>
+			<tr><td colspan="2" bgcolor="lightgray"><b>Inodos libres</b></td><td colspan="5">%d de %d</td></tr>
+			<tr>
+				<td bgcolor="lightblue"><b>Tipo</b></td>
+				<td bgcolor="lightblue"><b>Nombre</b></td>
+				<td bgcolor="lightblue"><b>ID</b></td>
+				<td bgcolor="lightblue"><b>Bloques</b></td>
+				<td bgcolor="lightblue"><b>Límite bloques</b></td>
+				<td bgcolor="lightblue"><b>Inodos</b></td>
+				<td bgcolor="lightblue"><b>Límite inodos</b></td>
+			</tr>
+		`, superblock.S_free_blocks_count, superblock.S_blocks_count, superblock.S_free_inodes_count, superblock.S_inodes_count)
+
+	// Los usos que alcanzan el límite en rojo
+	for _, row := range rows {
+		dotContent += fmt.Sprintf(`<tr>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%d</td>
+				<td bgcolor="%s">%d</td>
+				<td>%s</td>
+				<td bgcolor="%s">%d</td>
+				<td>%s</td>
+			</tr>
+		`, row.kind, html.EscapeString(row.name), row.id,
+			quotaColor(row.usage.Blocks, row.quota.Blocks), row.usage.Blocks, utils.FormatQuotaLimit(row.quota.Blocks),
+			quotaColor(row.usage.Inodes, row.quota.Inodes), row.usage.Inodes, utils.FormatQuotaLimit(row.quota.Inodes))
+	}
+
+	// Cerrar la tabla y el contenido DOT
+	dotContent += "</table>>] }"
+
+	// Guardar el contenido DOT en un archivo
+	file, err := os.Create(dotFileName)
+	if err != nil {
+		return fmt.Errorf("error al crear el archivo DOT: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(dotContent)
+	if err != nil {
+		return fmt.Errorf("error al escribir en el archivo DOT: %v", err)
+	}
+
+	// Ejecutar el comando Graphviz para generar la imagen
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
+	}
+
+	logger.Debug("Reporte de cuotas generado", "path", outputImage)
+	return nil
+}
+
+// quotaColor pinta el uso: blanco sin límite, verde dentro del límite y rojo si lo alcanzó o lo pasó
+func quotaColor(used, limit int32) string {
+	switch {
+	case limit <= 0:
+		return "white"
+	case used >= limit:
+		return "salmon"
+	default:
+		return "lightgreen"
+	}
+}
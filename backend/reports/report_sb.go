@@ -5,12 +5,11 @@ import (
 	utils "backend/utils"
 	"fmt"
 	"os"
-	"os/exec"
 	"time"
 )
 
 // ReportSuperBlock genera un reporte del Superbloque con colores diferenciados para información de inodos y bloques
-func ReportSuperBlock(superblock *structures.SuperBlock, diskPath string, outputPath string) error {
+func ReportSuperBlock(superblock *structures.SuperBlock, dev structures.BlockDevice, outputPath string) error {
 	// Crear las carpetas padre si no existen
 	err := utils.CreateParentDirs(outputPath)
 	if err != nil {
@@ -63,12 +62,10 @@ func ReportSuperBlock(superblock *structures.SuperBlock, diskPath string, output
 	}
 
 	// Ejecutar el comando Graphviz para generar la imagen
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error al ejecutar Graphviz: %v", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Reporte Super Bloque generado:", outputImage)
+	logger.Debug("Reporte Super Bloque generado", "path", outputImage)
 	return nil
 }
\ No newline at end of file
@@ -3,15 +3,14 @@ package reports
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 	structures "backend/structures"
 	utils "backend/utils"
 )
 
-func ReportTree(sb *structures.SuperBlock, diskPath string, outputPath string) error {
-	fmt.Printf("Generando reporte TREE en: %s\n", outputPath)
+func ReportTree(sb *structures.SuperBlock, dev structures.BlockDevice, outputPath string) error {
+	logger.Debug("Generando reporte TREE", "output", outputPath)
 
 	err := utils.CreateParentDirs(outputPath)
 	if err != nil {
@@ -29,9 +28,9 @@ func ReportTree(sb *structures.SuperBlock, diskPath string, outputPath string) e
 	dotContent.WriteString("\tnode [shape=none, margin=0];\n") // Usaré labels HTML
 
 	// Recorrer el árbol de inodos
-	err = generateTreeRecursive(0, sb, diskPath, &dotContent, generatedNodes, generatedEdges)
+	err = generateTreeRecursive(0, sb, dev, &dotContent, generatedNodes, generatedEdges)
 	if err != nil {
-		fmt.Printf("Advertencia durante la generación del árbol: %v\n", err)
+		logger.Warn("Error durante la generación del árbol", "error", err)
 		return fmt.Errorf("error generando el árbol de inodos: %v", err)
 	}
 
@@ -48,16 +47,13 @@ func ReportTree(sb *structures.SuperBlock, diskPath string, outputPath string) e
 	if err != nil {
 		return fmt.Errorf("error al escribir en el archivo DOT para reporte TREE: %v", err)
 	}
-	fmt.Println("Archivo DOT generado:", dotFileName)
+	logger.Debug("Archivo DOT generado", "path", dotFileName)
 
-	cmd := exec.Command("dot", "-Tpng", dotFileName, "-o", outputImage)
-	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf("Salida de Graphviz:\n%s\n", string(cmdOutput))
-		return fmt.Errorf("error al ejecutar Graphviz para reporte TREE: %v", err)
+	if err := renderDot(dotFileName, outputImage); err != nil {
+		return err
 	}
 
-	fmt.Println("Reporte TREE generado exitosamente:", outputImage)
+	logger.Debug("Reporte TREE generado exitosamente", "path", outputImage)
 	return nil
 }
 
@@ -65,7 +61,7 @@ func ReportTree(sb *structures.SuperBlock, diskPath string, outputPath string) e
 func generateTreeRecursive(
 	inodeIndex int32,
 	sb *structures.SuperBlock,
-	diskPath string,
+	dev structures.BlockDevice,
 	dotContent *strings.Builder,
 	generatedNodes map[string]bool,
 	generatedEdges map[string]bool,
@@ -84,8 +80,8 @@ func generateTreeRecursive(
 	generatedNodes[inodeNodeID] = true
 	inode := &structures.Inode{}
 	inodeOffset := int64(sb.S_inode_start) + int64(inodeIndex)*int64(sb.S_inode_size)
-	if err := inode.Deserialize(diskPath, inodeOffset); err != nil {
-		fmt.Printf("Error deserializando inodo %d: %v. Saltando.\n", inodeIndex, err)
+	if err := inode.Deserialize(dev, inodeOffset); err != nil {
+		logger.Debug("Error deserializando inodo; se salta", "inode", inodeIndex, "error", err)
 
 		// Solo coloco un mensaje de error y un nodo de error en el DOT y sigo
 		dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error Inodo %d\", shape=box, style=filled, fillcolor=red];\n", inodeNodeID, inodeIndex))
@@ -103,7 +99,7 @@ func generateTreeRecursive(
 			continue // Pointer not used
 		}
 		if blockPtr < 0 || blockPtr >= sb.S_blocks_count {
-			fmt.Printf("Puntero de bloque inválido (%d) encontrado en inodo %d, i_block[%d]. Saltando.\n", blockPtr, inodeIndex, k)
+			logger.Debug("Puntero de bloque inválido; se salta", "block", blockPtr, "inode", inodeIndex, "pointer", k)
 			continue
 		}
 		blockNodeID := fmt.Sprintf("block_%d", blockPtr)
@@ -123,8 +119,8 @@ func generateTreeRecursive(
 			case k < 12: // Bloques directos porque no tengo indirectos
 				if inode.I_type[0] == '0' { // Folder Block
 					folderBlock := &structures.FolderBlock{}
-					if err := folderBlock.Deserialize(diskPath, blockOffset); err != nil {
-						fmt.Printf("Error deserializando FolderBlock %d: %v\n", blockPtr, err)
+					if err := folderBlock.Deserialize(dev, blockOffset); err != nil {
+						logger.Debug("Error deserializando FolderBlock", "block", blockPtr, "error", err)
 						dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error FolderBlock %d\", shape=box, style=filled, fillcolor=red];\n", blockNodeID, blockPtr))
 					} else {
 						label := createFolderBlockLabel(blockPtr, folderBlock)
@@ -142,9 +138,9 @@ func generateTreeRecursive(
 									generatedEdges[entryEdgeID] = true
 								}
 								// Recursividad para procesar el inodo hijo
-								err := generateTreeRecursive(childInodeIndex, sb, diskPath, dotContent, generatedNodes, generatedEdges)
+								err := generateTreeRecursive(childInodeIndex, sb, dev, dotContent, generatedNodes, generatedEdges)
 								if err != nil {
-									fmt.Printf("Error en subárbol de inodo %d (desde bloque %d): %v\n", childInodeIndex, blockPtr, err)
+									logger.Debug("Error en subárbol de inodo", "inode", childInodeIndex, "block", blockPtr, "error", err)
 								}
 							}
 						}
@@ -152,8 +148,8 @@ func generateTreeRecursive(
 					}
 				} else { // File Block
 					fileBlock := &structures.FileBlock{}
-					if err := fileBlock.Deserialize(diskPath, blockOffset); err != nil {
-						fmt.Printf("Error deserializando FileBlock %d: %v\n", blockPtr, err)
+					if err := fileBlock.Deserialize(dev, blockOffset); err != nil {
+						logger.Debug("Error deserializando FileBlock", "block", blockPtr, "error", err)
 						dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error FileBlock %d\", shape=box, style=filled, fillcolor=red];\n", blockNodeID, blockPtr))
 					} else {
 						label := createFileBlockLabel(blockPtr, fileBlock)
@@ -163,8 +159,8 @@ func generateTreeRecursive(
 				// Bloques directos terminan aquí------------------------------------------------------------------------------------------------------------
 			case k == 12:
 				pointerBlock := &structures.PointerBlock{}
-				if err := pointerBlock.Deserialize(diskPath, blockOffset); err != nil {
-					fmt.Printf("Error deserializando PointerBlock %d (indirecto simple): %v\n", blockPtr, err)
+				if err := pointerBlock.Deserialize(dev, blockOffset); err != nil {
+					logger.Debug("Error deserializando PointerBlock (indirecto simple)", "block", blockPtr, "error", err)
 					dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error PointerBlock %d\", shape=box, style=filled, fillcolor=red];\n", blockNodeID, blockPtr))
 				} else {
 					label := createPointerBlockLabel(blockPtr, pointerBlock)
@@ -175,7 +171,7 @@ func generateTreeRecursive(
 							continue
 						}
 						if dataBlockPtr < 0 || dataBlockPtr >= sb.S_blocks_count {
-							fmt.Printf("Puntero de bloque inválido (%d) encontrado en PointerBlock %d, P_pointers[%d]. Saltando.\n", dataBlockPtr, blockPtr, ptrIdx)
+							logger.Debug("Puntero de bloque inválido en PointerBlock; se salta", "block", dataBlockPtr, "pointer_block", blockPtr, "pointer", ptrIdx)
 							continue
 						}
 						dataBlockNodeID := fmt.Sprintf("block_%d", dataBlockPtr)
@@ -186,9 +182,9 @@ func generateTreeRecursive(
 							dotContent.WriteString(fmt.Sprintf("\t%s:%s -> %s [label=\"ptr[%d]\"];\n", blockNodeID, pointerPort, dataBlockNodeID, ptrIdx))
 							generatedEdges[ptrEdgeID] = true
 						}
-						err := ensureBlockNodeExists(dataBlockPtr, inode.I_type[0], sb, diskPath, dotContent, generatedNodes, generatedEdges)
+						err := ensureBlockNodeExists(dataBlockPtr, inode.I_type[0], sb, dev, dotContent, generatedNodes, generatedEdges)
 						if err != nil {
-							fmt.Printf("Error asegurando nodo para bloque de datos %d (desde bloque puntero %d): %v\n", dataBlockPtr, blockPtr, err)
+							logger.Debug("Error asegurando nodo para bloque de datos", "block", dataBlockPtr, "pointer_block", blockPtr, "error", err)
 						}
 					}
 				}
@@ -207,7 +203,7 @@ func ensureBlockNodeExists(
 	blockIndex int32,
 	originalInodeType byte, // Tipo original del inodo (0=folder, 1=file)
 	sb *structures.SuperBlock,
-	diskPath string,
+	dev structures.BlockDevice,
 	dotContent *strings.Builder,
 	generatedNodes map[string]bool,
 	generatedEdges map[string]bool, // Pasa también los edges generados
@@ -225,8 +221,8 @@ func ensureBlockNodeExists(
 	// Genera el bloque del Inodo
 	if originalInodeType == '0' { // Folder Block
 		folderBlock := &structures.FolderBlock{}
-		if err := folderBlock.Deserialize(diskPath, blockOffset); err != nil {
-			fmt.Printf("Error deserializando FolderBlock %d (indirecto): %v\n", blockIndex, err)
+		if err := folderBlock.Deserialize(dev, blockOffset); err != nil {
+			logger.Debug("Error deserializando FolderBlock (indirecto)", "block", blockIndex, "error", err)
 			dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error FolderBlock %d\", shape=box, style=filled, fillcolor=red];\n", blockNodeID, blockIndex))
 			return err
 		}
@@ -247,17 +243,17 @@ func ensureBlockNodeExists(
 					generatedEdges[entryEdgeID] = true
 				}
 				// Verifica si el inodo hijo ya fue generado
-				err := generateTreeRecursive(childInodeIndex, sb, diskPath, dotContent, generatedNodes, generatedEdges)
+				err := generateTreeRecursive(childInodeIndex, sb, dev, dotContent, generatedNodes, generatedEdges)
 				if err != nil {
-					fmt.Printf("Error en subárbol de inodo %d (desde bloque %d indirecto): %v\n", childInodeIndex, blockIndex, err)
+					logger.Debug("Error en subárbol de inodo (indirecto)", "inode", childInodeIndex, "block", blockIndex, "error", err)
 				}
 			}
 		}
 
 	} else { // File Block
 		fileBlock := &structures.FileBlock{}
-		if err := fileBlock.Deserialize(diskPath, blockOffset); err != nil {
-			fmt.Printf("Error deserializando FileBlock %d (indirecto): %v\n", blockIndex, err)
+		if err := fileBlock.Deserialize(dev, blockOffset); err != nil {
+			logger.Debug("Error deserializando FileBlock (indirecto)", "block", blockIndex, "error", err)
 			dotContent.WriteString(fmt.Sprintf("\t%s [label=\"Error FileBlock %d\", shape=box, style=filled, fillcolor=red];\n", blockNodeID, blockIndex))
 			return err
 		}
@@ -0,0 +1,104 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Política de paths del sistema anfitrión. Los comandos que leen o escriben archivos del servidor pasan el path
+// por la carpeta permitida de su tipo: los discos de mkdisk, rmdisk, fdisk y mount por Disks, la salida de rep
+// por Reports y el archivo que importa mkfile -cont por Imports. Con la carpeta configurada, el path se
+// canoniza (absoluto, sin "." ni ".." y con los enlaces simbólicos resueltos) y se rechaza si queda fuera de
+// ella; los paths relativos se toman desde la carpeta. Todos los tipos tienen carpeta: por defecto, una carpeta
+// dentro del directorio de trabajo, y sin carpeta se rechaza cualquier path.
+
+// Root es la carpeta fuera de la cual no se pueden usar los paths de un tipo
+type Root struct {
+	kind string // Tipo de archivo, para los mensajes ("discos", "reportes", ...)
+	dir  string
+}
+
+// Carpetas permitidas por tipo, relativas al directorio de trabajo salvo que se configuren al iniciar (ver el
+// paquete config)
+var (
+	Disks   = &Root{kind: "discos", dir: "disks"}
+	Reports = &Root{kind: "reportes", dir: "output"}
+	Imports = &Root{kind: "importación", dir: "imports"}
+)
+
+// ErrPathDenied se devuelve cuando un path queda fuera de su carpeta o no se puede verificar
+var ErrPathDenied = errors.New("path no permitido")
+
+// Set configura la carpeta
+func (r *Root) Set(dir string) {
+	r.dir = dir
+}
+
+// Dir devuelve la carpeta configurada
+func (r *Root) Dir() string {
+	return r.dir
+}
+
+// Resolve valida el path contra la carpeta y devuelve el path canónico que se debe usar
+func (r *Root) Resolve(path string) (string, error) {
+	if r.dir == "" {
+		return "", fmt.Errorf("%w: no hay carpeta de %s configurada", ErrPathDenied, r.kind)
+	}
+	if path == "" {
+		return "", fmt.Errorf("%w: path vacío", ErrPathDenied)
+	}
+	root, err := canonical(r.dir)
+	if err != nil {
+		return "", fmt.Errorf("carpeta de %s inválida '%s': %w", r.kind, r.dir, err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := canonical(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrPathDenied, err)
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%w: '%s' está fuera de la carpeta de %s (%s)", ErrPathDenied, path, r.kind, root)
+	}
+	return resolved, nil
+}
+
+// canonical devuelve el path absoluto y limpio con los enlaces simbólicos de la parte que existe resueltos. La
+// parte que todavía no existe se agrega tal cual: no puede tener enlaces. Un enlace roto es un error, porque
+// al crear el archivo se escribiría donde apunta el enlace.
+func canonical(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing, missing := absolute, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		if _, lstatErr := os.Lstat(existing); lstatErr == nil {
+			return "", fmt.Errorf("'%s' es un enlace simbólico roto", existing)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return absolute, nil
+		}
+		missing = filepath.Join(filepath.Base(existing), missing)
+		existing = parent
+	}
+}
+
+// within indica si path está dentro de root (sin ser root)
+func within(root, path string) bool {
+	relative, err := filepath.Rel(root, path)
+	return err == nil && relative != "." && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
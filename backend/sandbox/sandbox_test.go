@@ -0,0 +1,127 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRoot crea una carpeta permitida con un subdirectorio y otra carpeta por fuera. Devuelve la carpeta
+// permitida (canónica) y la de afuera.
+func newTestRoot(t *testing.T) (*Root, string, string) {
+	t.Helper()
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks: %v", err)
+	}
+	dir := filepath.Join(base, "disks")
+	outside := filepath.Join(base, "outside")
+	for _, d := range []string{filepath.Join(dir, "sub"), outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+	}
+	return &Root{kind: "discos", dir: dir}, dir, outside
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("no se pueden crear enlaces simbólicos: %v", err)
+	}
+}
+
+func TestResolveAllowed(t *testing.T) {
+	root, dir, _ := newTestRoot(t)
+
+	tests := map[string]string{
+		"Disco1.mia":                            filepath.Join(dir, "Disco1.mia"),
+		"sub/Disco1.mia":                        filepath.Join(dir, "sub", "Disco1.mia"),
+		"./sub/../Disco1.mia":                   filepath.Join(dir, "Disco1.mia"),
+		filepath.Join(dir, "sub", "Disco1.mia"): filepath.Join(dir, "sub", "Disco1.mia"),
+		filepath.Join(dir, "nueva", "x.mia"):    filepath.Join(dir, "nueva", "x.mia"), // Carpetas que no existen
+	}
+	for path, want := range tests {
+		got, err := root.Resolve(path)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Resolve(%q) = %q, se esperaba %q", path, got, want)
+		}
+	}
+}
+
+func TestResolveDenied(t *testing.T) {
+	root, dir, outside := newTestRoot(t)
+
+	for _, path := range []string{
+		"",
+		dir,
+		"../outside/Disco1.mia",
+		"sub/../../Disco1.mia",
+		filepath.Join(outside, "Disco1.mia"),
+		dir + "2/Disco1.mia", // Comparte el prefijo pero es otra carpeta
+		"/etc/passwd",
+	} {
+		if got, err := root.Resolve(path); !errors.Is(err, ErrPathDenied) {
+			t.Errorf("Resolve(%q) = (%q, %v), se esperaba ErrPathDenied", path, got, err)
+		}
+	}
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	root, dir, outside := newTestRoot(t)
+	if err := os.WriteFile(filepath.Join(outside, "Disco1.mia"), nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// Enlaces dentro de la carpeta que apuntan afuera: ni la carpeta ni el archivo enlazado se pueden usar
+	symlink(t, outside, filepath.Join(dir, "escape"))
+	symlink(t, filepath.Join(outside, "Disco1.mia"), filepath.Join(dir, "enlace.mia"))
+	// Enlace roto: al crear el archivo se escribiría fuera de la carpeta
+	symlink(t, filepath.Join(outside, "noexiste.mia"), filepath.Join(dir, "roto.mia"))
+	for _, path := range []string{"escape/Disco1.mia", "escape/nuevo.mia", "enlace.mia", "roto.mia"} {
+		if got, err := root.Resolve(path); !errors.Is(err, ErrPathDenied) {
+			t.Errorf("Resolve(%q) = (%q, %v), se esperaba ErrPathDenied", path, got, err)
+		}
+	}
+
+	// Un enlace que queda dentro de la carpeta se resuelve a su destino
+	symlink(t, filepath.Join(dir, "sub"), filepath.Join(dir, "atajo"))
+	got, err := root.Resolve("atajo/Disco1.mia")
+	if err != nil {
+		t.Fatalf("Resolve con un enlace interno: %v", err)
+	}
+	if want := filepath.Join(dir, "sub", "Disco1.mia"); got != want {
+		t.Errorf("Resolve con un enlace interno = %q, se esperaba %q", got, want)
+	}
+}
+
+func TestResolveRootThroughSymlink(t *testing.T) {
+	_, dir, outside := newTestRoot(t)
+	link := filepath.Join(outside, "disks-link")
+	symlink(t, dir, link)
+
+	// La carpeta configurada es un enlace: se compara contra su destino
+	root := &Root{kind: "discos", dir: link}
+	got, err := root.Resolve(filepath.Join(link, "Disco1.mia"))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if want := filepath.Join(dir, "Disco1.mia"); got != want {
+		t.Errorf("Resolve = %q, se esperaba %q", got, want)
+	}
+	if _, err := root.Resolve(filepath.Join(outside, "Disco1.mia")); !errors.Is(err, ErrPathDenied) {
+		t.Errorf("Resolve fuera del destino del enlace: se esperaba ErrPathDenied, se obtuvo %v", err)
+	}
+}
+
+func TestResolveWithoutRoot(t *testing.T) {
+	root := &Root{kind: "discos"}
+	if _, err := root.Resolve("Disco1.mia"); !errors.Is(err, ErrPathDenied) {
+		t.Errorf("Resolve sin carpeta configurada: se esperaba ErrPathDenied, se obtuvo %v", err)
+	}
+}
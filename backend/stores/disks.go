@@ -0,0 +1,65 @@
+package stores
+
+import (
+	sandbox "backend/sandbox"
+	structures "backend/structures"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ResolveDiskPath pasa el path de un disco por la carpeta de discos (ver sandbox.Disks) y devuelve el path que
+// se debe usar. Los discos en memoria no son archivos del servidor y no se validan.
+func ResolveDiskPath(path string) (string, error) {
+	if structures.IsMemoryPath(path) {
+		return path, nil
+	}
+	return sandbox.Disks.Resolve(path)
+}
+
+// Registro de los discos creados con mkdisk desde que inició el servidor (para listarlos en la API)
+var (
+	knownDisksMu sync.Mutex
+	knownDisks   = make(map[string]bool)
+)
+
+// RegisterDisk agrega un disco al registro
+func RegisterDisk(path string) {
+	knownDisksMu.Lock()
+	defer knownDisksMu.Unlock()
+	knownDisks[path] = true
+}
+
+// ForgetDisk quita un disco del registro (rmdisk)
+func ForgetDisk(path string) {
+	knownDisksMu.Lock()
+	defer knownDisksMu.Unlock()
+	delete(knownDisks, path)
+}
+
+// KnownDisks devuelve, ordenados, los discos registrados y los que tienen particiones montadas que todavía existen.
+// Requiere StateLock tomado (lee MountedPartitions).
+func KnownDisks() []string {
+	knownDisksMu.Lock()
+	paths := make(map[string]bool, len(knownDisks))
+	for path := range knownDisks {
+		paths[path] = true
+	}
+	knownDisksMu.Unlock()
+	for _, path := range MountedPartitions {
+		paths[path] = true
+	}
+
+	var disks []string
+	for path := range paths {
+		if structures.IsMemoryPath(path) {
+			if structures.MemoryDeviceExists(path) {
+				disks = append(disks, path)
+			}
+		} else if _, err := os.Stat(path); err == nil {
+			disks = append(disks, path)
+		}
+	}
+	sort.Strings(disks)
+	return disks
+}
@@ -0,0 +1,83 @@
+package stores
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginLockoutPolicy define cuántas contraseñas incorrectas seguidas se toleran antes de bloquear
+// temporalmente la cuenta, y por cuánto tiempo.
+type LoginLockoutPolicy struct {
+	MaxFailures int           // Intentos fallidos seguidos que provocan el bloqueo (0 desactiva el bloqueo)
+	Duration    time.Duration // Duración del bloqueo temporal
+}
+
+// Política de bloqueo que usan login y su; el servidor la puede reemplazar al iniciar
+var LoginLockout = LoginLockoutPolicy{MaxFailures: 3, Duration: 5 * time.Minute}
+
+// loginFailures son los intentos fallidos de un usuario de una partición
+type loginFailures struct {
+	count       int
+	lockedUntil time.Time
+}
+
+// Los contadores se guardan en memoria: se pierden al reiniciar el servidor y no se escriben en /users.txt,
+// que se reasigna completo en cada escritura.
+var (
+	loginFailuresMu sync.Mutex
+	loginFailuresBy = make(map[string]*loginFailures)
+)
+
+func loginFailuresKey(partitionID, username string) string {
+	return partitionID + "/" + strings.ToLower(username)
+}
+
+// LoginLockedUntil indica si el usuario está bloqueado temporalmente por intentos fallidos y hasta cuándo
+func LoginLockedUntil(partitionID, username string) (time.Time, bool) {
+	loginFailuresMu.Lock()
+	defer loginFailuresMu.Unlock()
+
+	failures, exists := loginFailuresBy[loginFailuresKey(partitionID, username)]
+	if !exists || failures.lockedUntil.IsZero() {
+		return time.Time{}, false
+	}
+	if Clock().After(failures.lockedUntil) {
+		// El bloqueo terminó: el usuario vuelve a tener todos sus intentos
+		delete(loginFailuresBy, loginFailuresKey(partitionID, username))
+		return time.Time{}, false
+	}
+	return failures.lockedUntil, true
+}
+
+// RecordLoginFailure suma un intento fallido. Devuelve los intentos que quedan antes del bloqueo
+// y, si este intento provocó el bloqueo, hasta cuándo dura.
+func RecordLoginFailure(partitionID, username string) (int, time.Time) {
+	policy := LoginLockout
+	if policy.MaxFailures <= 0 {
+		return -1, time.Time{}
+	}
+
+	loginFailuresMu.Lock()
+	defer loginFailuresMu.Unlock()
+
+	key := loginFailuresKey(partitionID, username)
+	failures, exists := loginFailuresBy[key]
+	if !exists {
+		failures = &loginFailures{}
+		loginFailuresBy[key] = failures
+	}
+	failures.count++
+	if failures.count < policy.MaxFailures {
+		return policy.MaxFailures - failures.count, time.Time{}
+	}
+	failures.lockedUntil = Clock().Add(policy.Duration)
+	return 0, failures.lockedUntil
+}
+
+// ResetLoginFailures borra los intentos fallidos y el bloqueo temporal del usuario (login exitoso o unlockusr)
+func ResetLoginFailures(partitionID, username string) {
+	loginFailuresMu.Lock()
+	defer loginFailuresMu.Unlock()
+	delete(loginFailuresBy, loginFailuresKey(partitionID, username))
+}
@@ -0,0 +1,81 @@
+package stores
+
+import (
+	"testing"
+	"time"
+)
+
+// withLockoutPolicy reemplaza la política de bloqueo durante la prueba
+func withLockoutPolicy(t *testing.T, policy LoginLockoutPolicy) {
+	t.Helper()
+	previous := LoginLockout
+	LoginLockout = policy
+	t.Cleanup(func() { LoginLockout = previous })
+}
+
+func TestLoginLockout(t *testing.T) {
+	withLockoutPolicy(t, LoginLockoutPolicy{MaxFailures: 3, Duration: time.Hour})
+	t.Cleanup(func() { ResetLoginFailures("201A", "user1") })
+
+	for want := 2; want >= 1; want-- {
+		if left, until := RecordLoginFailure("201A", "user1"); left != want || !until.IsZero() {
+			t.Errorf("RecordLoginFailure = (%d, %v), se esperaba (%d, cero)", left, until, want)
+		}
+		if _, locked := LoginLockedUntil("201A", "user1"); locked {
+			t.Fatal("la cuenta se bloqueó antes de agotar los intentos")
+		}
+	}
+
+	// El tercer intento bloquea la cuenta; el nombre no distingue mayúsculas y cada partición cuenta aparte
+	left, until := RecordLoginFailure("201A", "USER1")
+	if left != 0 || until.IsZero() {
+		t.Fatalf("RecordLoginFailure = (%d, %v), se esperaba el bloqueo", left, until)
+	}
+	if lockedUntil, locked := LoginLockedUntil("201A", "user1"); !locked || !lockedUntil.Equal(until) {
+		t.Errorf("LoginLockedUntil = (%v, %v), se esperaba (%v, true)", lockedUntil, locked, until)
+	}
+	if _, locked := LoginLockedUntil("201B", "user1"); locked {
+		t.Error("el bloqueo alcanzó a la misma cuenta en otra partición")
+	}
+
+	ResetLoginFailures("201A", "user1")
+	if _, locked := LoginLockedUntil("201A", "user1"); locked {
+		t.Error("la cuenta sigue bloqueada después de ResetLoginFailures")
+	}
+	if left, _ := RecordLoginFailure("201A", "user1"); left != 2 {
+		t.Errorf("después de ResetLoginFailures quedan %d intentos, se esperaban 2", left)
+	}
+}
+
+func TestLoginLockoutExpires(t *testing.T) {
+	withLockoutPolicy(t, LoginLockoutPolicy{MaxFailures: 1, Duration: time.Hour})
+	t.Cleanup(func() { ResetLoginFailures("201A", "user2") })
+
+	if _, until := RecordLoginFailure("201A", "user2"); until.IsZero() {
+		t.Fatal("RecordLoginFailure no bloqueó la cuenta")
+	}
+	loginFailuresMu.Lock()
+	loginFailuresBy[loginFailuresKey("201A", "user2")].lockedUntil = time.Now().Add(-time.Second)
+	loginFailuresMu.Unlock()
+
+	// Al vencer el bloqueo el usuario recupera todos sus intentos
+	if _, locked := LoginLockedUntil("201A", "user2"); locked {
+		t.Error("la cuenta sigue bloqueada después de vencer el bloqueo")
+	}
+	if _, until := RecordLoginFailure("201A", "user2"); until.IsZero() {
+		t.Error("después del bloqueo el contador no volvió a empezar")
+	}
+}
+
+func TestLoginLockoutDisabled(t *testing.T) {
+	withLockoutPolicy(t, LoginLockoutPolicy{MaxFailures: 0, Duration: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if left, until := RecordLoginFailure("201A", "user3"); left != -1 || !until.IsZero() {
+			t.Fatalf("RecordLoginFailure con el bloqueo desactivado = (%d, %v)", left, until)
+		}
+	}
+	if _, locked := LoginLockedUntil("201A", "user3"); locked {
+		t.Error("la cuenta se bloqueó con el bloqueo desactivado")
+	}
+}
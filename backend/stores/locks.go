@@ -0,0 +1,86 @@
+package stores
+
+import (
+	structures "backend/structures"
+	"path/filepath"
+	"sync"
+)
+
+// Modelo de concurrencia:
+//   - StateLock protege el estado global de montajes (MountedPartitions, ListPatitions, ListMounted y las letras
+//     asignadas en utils). mount lo toma en escritura y el resto de comandos en lectura mientras se ejecutan.
+//     Las sesiones no dependen de este lock: la tabla Sessions tiene su propio mutex.
+//   - Cada disco tiene su propio RWMutex: en escritura para los comandos que modifican el MBR o el archivo completo
+//     (mkdisk, rmdisk, fdisk, mount) y en lectura para los que trabajan dentro de una partición.
+//   - Cada partición montada tiene su propio RWMutex: en escritura para los comandos que modifican el sistema de
+//     archivos y en lectura para cat y rep.
+//   - Cada partición montada tiene además un mutex para su log de auditoría: el registro de un comando se agrega
+//     con la partición en lectura y este mutex, así los comandos de solo lectura no necesitan la partición en
+//     escritura para quedar registrados. Se toma siempre después del lock de la partición. Crear o reasignar
+//     el log asigna inodos y bloques, así que eso se hace con la partición en escritura.
+//   - Los cambios que un comando deja en la caché de un disco se vuelcan al archivo al terminar, ya liberados sus
+//     locks, tomando el del disco en escritura (también sync): así nunca se escriben los cambios a medias de otro
+//     comando que trabaja en otra partición del mismo disco.
+//
+// Los locks siempre se toman en el orden estado -> disco -> partición -> auditoría para evitar interbloqueos.
+var StateLock sync.RWMutex
+
+var (
+	locksMu        sync.Mutex
+	diskLocks      = make(map[string]*sync.RWMutex)
+	partitionLocks = make(map[string]*sync.RWMutex)
+	auditLocks     = make(map[string]*sync.Mutex)
+)
+
+// DiskKey normaliza el path de un disco para que distintas formas del mismo path compartan lock. Usa el mismo
+// path que el comando (ver ResolveDiskPath); si el path no es válido, el comando falla y basta con limpiarlo.
+func DiskKey(path string) string {
+	if structures.IsMemoryPath(path) {
+		return path
+	}
+	if resolved, err := ResolveDiskPath(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// DiskLock devuelve el lock del disco indicado, creándolo si no existe
+func DiskLock(path string) *sync.RWMutex {
+	key := DiskKey(path)
+
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	lock, exists := diskLocks[key]
+	if !exists {
+		lock = &sync.RWMutex{}
+		diskLocks[key] = lock
+	}
+	return lock
+}
+
+// PartitionLock devuelve el lock de la partición montada con el id indicado, creándolo si no existe
+func PartitionLock(id string) *sync.RWMutex {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	lock, exists := partitionLocks[id]
+	if !exists {
+		lock = &sync.RWMutex{}
+		partitionLocks[id] = lock
+	}
+	return lock
+}
+
+// AuditLock devuelve el mutex del log de auditoría de la partición montada con el id indicado, creándolo si no existe
+func AuditLock(id string) *sync.Mutex {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	lock, exists := auditLocks[id]
+	if !exists {
+		lock = &sync.Mutex{}
+		auditLocks[id] = lock
+	}
+	return lock
+}
@@ -0,0 +1,25 @@
+package stores
+
+import "sync"
+
+// Registro de los reportes generados con rep desde que inició el servidor, con la partición de cada uno (para
+// que la API solo entregue un reporte a las sesiones de esa partición)
+var (
+	reportsMu        sync.Mutex
+	reportPartitions = make(map[string]string)
+)
+
+// RegisterReport agrega al registro un archivo generado por rep a partir de la partición indicada
+func RegisterReport(path, partitionID string) {
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	reportPartitions[path] = partitionID
+}
+
+// ReportPartition devuelve la partición de la que se generó el reporte, si está registrado
+func ReportPartition(path string) (string, bool) {
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	partitionID, exists := reportPartitions[path]
+	return partitionID, exists
+}
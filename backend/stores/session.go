@@ -0,0 +1,326 @@
+package stores
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionTimeoutPolicy son los límites de vida de una sesión: Idle es el tiempo máximo sin ejecutar comandos y
+// Absolute el tiempo máximo desde el login. Un valor de 0 desactiva ese límite.
+type SessionTimeoutPolicy struct {
+	Idle     time.Duration
+	Absolute time.Duration
+}
+
+// Límites de las sesiones del servidor (configurables al iniciar, ver main.go)
+var SessionTimeouts = SessionTimeoutPolicy{Idle: 30 * time.Minute, Absolute: 8 * time.Hour}
+
+// Clock devuelve la hora actual para las sesiones y el bloqueo por intentos fallidos. Es time.Now salvo en las
+// pruebas, que la reemplazan para simular el paso del tiempo sin esperar.
+var Clock = time.Now
+
+// Cuánto tiempo se recuerda por qué terminó una sesión, para explicarlo si el cliente vuelve a usar su token
+const endedSessionRetention = 24 * time.Hour
+
+// Session es una entrada de la tabla de sesiones: a quién pertenece un token y hasta cuándo es válido
+type Session struct {
+	Token       string
+	Username    string
+	PartitionID string
+	Previous    []string // Usuarios anteriores de la sesión, apilados por su (el último es al que vuelve exit)
+	Cwd         string   // Directorio de trabajo de la sesión (al iniciar, el directorio personal del usuario o "/")
+	CreatedAt   time.Time
+	LastUsedAt  time.Time // Último comando ejecutado con la sesión (para el límite de inactividad)
+	ExpiresAt   time.Time // Fin por el límite absoluto (cero si no hay límite)
+}
+
+// ID devuelve el identificador corto de la sesión (ver AuthStore.SessionID)
+func (s *Session) ID() string {
+	return sessionID(s.Token)
+}
+
+// IdleDeadline devuelve el momento en que la sesión expira si no se usa (cero si no hay límite de inactividad)
+func (s *Session) IdleDeadline() time.Time {
+	if SessionTimeouts.Idle <= 0 {
+		return time.Time{}
+	}
+	return s.LastUsedAt.Add(SessionTimeouts.Idle)
+}
+
+// expiredError indica si la sesión ya expiró en el momento indicado, y por qué
+func (s *Session) expiredError(now time.Time) error {
+	if !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt) {
+		return fmt.Errorf("%w: alcanzó el tiempo máximo de %s desde el login; inicie sesión de nuevo", ErrSessionExpired, SessionTimeouts.Absolute)
+	}
+	if idle := s.IdleDeadline(); !idle.IsZero() && now.After(idle) {
+		return fmt.Errorf("%w: pasó más de %s sin ejecutar comandos; inicie sesión de nuevo", ErrSessionExpired, SessionTimeouts.Idle)
+	}
+	return nil
+}
+
+// endedSession recuerda por qué terminó una sesión que ya no está en la tabla
+type endedSession struct {
+	reason error
+	at     time.Time
+}
+
+// SessionStore es la tabla de sesiones activas, indexada por token
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	ended    map[string]endedSession // Sesiones expiradas o terminadas, por token
+}
+
+// Tabla global de sesiones del servidor
+var Sessions = &SessionStore{sessions: make(map[string]*Session), ended: make(map[string]endedSession)}
+
+// ErrInvalidSession se devuelve cuando un token no existe o ya expiró
+var ErrInvalidSession = errors.New("la sesión no existe o ya expiró")
+
+// ErrSessionExpired se devuelve cuando la sesión del token expiró por inactividad o por el tiempo máximo
+var ErrSessionExpired = errors.New("la sesión expiró")
+
+// ErrSessionTerminated se devuelve cuando root terminó la sesión del token con el comando sessions
+var ErrSessionTerminated = errors.New("la sesión fue terminada por root")
+
+// Create registra una nueva sesión para el usuario en la partición indicada, con cwd como directorio de trabajo,
+// y le asigna un token aleatorio
+func (s *SessionStore) Create(username, partitionID, cwd string) (*Session, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("error al generar el token de sesión: %w", err)
+	}
+
+	now := Clock()
+	session := &Session{
+		Token:       hex.EncodeToString(tokenBytes),
+		Username:    username,
+		PartitionID: partitionID,
+		Cwd:         cwd,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+	}
+	if SessionTimeouts.Absolute > 0 {
+		session.ExpiresAt = now.Add(SessionTimeouts.Absolute)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeExpiredLocked(now)
+	s.sessions[session.Token] = session
+	return session, nil
+}
+
+// Get devuelve una copia de la sesión asociada al token, si existe y no ha expirado
+func (s *SessionStore) Get(token string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.activeLocked(token, Clock())
+	if err != nil {
+		return nil, err
+	}
+	copied := *session
+	copied.Previous = slices.Clone(session.Previous)
+	return &copied, nil
+}
+
+// Touch registra que la sesión se acaba de usar, reiniciando su límite de inactividad
+func (s *SessionStore) Touch(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := Clock()
+	session, err := s.activeLocked(token, now)
+	if err != nil {
+		return err
+	}
+	session.LastUsedAt = now
+	return nil
+}
+
+// List devuelve una copia de las sesiones activas de la partición, de la más antigua a la más reciente
+func (s *SessionStore) List(partitionID string) []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpiredLocked(Clock())
+	var list []Session
+	for _, session := range s.sessions {
+		if session.PartitionID == partitionID {
+			copied := *session
+			copied.Previous = slices.Clone(session.Previous)
+			list = append(list, copied)
+		}
+	}
+	slices.SortFunc(list, func(a, b Session) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return list
+}
+
+// All devuelve una copia de todas las sesiones activas, de la más antigua a la más reciente
+func (s *SessionStore) All() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpiredLocked(Clock())
+	list := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		copied := *session
+		copied.Previous = slices.Clone(session.Previous)
+		list = append(list, copied)
+	}
+	slices.SortFunc(list, func(a, b Session) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return list
+}
+
+// Terminate cierra la sesión de la partición cuyo identificador corto (ver Session.ID) es id.
+// Quien vuelva a usar su token recibe ErrSessionTerminated. Devuelve la sesión cerrada.
+func (s *SessionStore) Terminate(partitionID, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, session := range s.sessions {
+		if session.PartitionID == partitionID && strings.EqualFold(session.ID(), id) {
+			delete(s.sessions, token)
+			s.ended[token] = endedSession{reason: ErrSessionTerminated, at: Clock()}
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("no hay ninguna sesión activa con id '%s' en la partición %s", id, partitionID)
+}
+
+// activeLocked devuelve la sesión del token si sigue activa; si expiró la elimina y devuelve el motivo.
+// Requiere s.mu tomado.
+func (s *SessionStore) activeLocked(token string, now time.Time) (*Session, error) {
+	session, exists := s.sessions[token]
+	if !exists {
+		if ended, ok := s.ended[token]; ok {
+			return nil, ended.reason
+		}
+		return nil, ErrInvalidSession
+	}
+	if err := session.expiredError(now); err != nil {
+		delete(s.sessions, token)
+		s.ended[token] = endedSession{reason: err, at: now}
+		return nil, err
+	}
+	return session, nil
+}
+
+// SwitchUser cambia el usuario de la sesión y guarda la pila de usuarios anteriores (su/exit)
+func (s *SessionStore) SwitchUser(token, username string, previous []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.activeLocked(token, Clock())
+	if err != nil {
+		return err
+	}
+	session.Username = username
+	session.Previous = slices.Clone(previous)
+	return nil
+}
+
+// ChangeDir cambia el directorio de trabajo de la sesión (cd)
+func (s *SessionStore) ChangeDir(token, cwd string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.activeLocked(token, Clock())
+	if err != nil {
+		return err
+	}
+	session.Cwd = cwd
+	return nil
+}
+
+// Delete elimina la sesión asociada al token
+func (s *SessionStore) Delete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+}
+
+// DeleteUser cierra las sesiones de la partición en las que participa el usuario (como usuario actual o
+// como uno de los anteriores de su) y devuelve cuántas cerró
+func (s *SessionStore) DeleteUser(partitionID, username string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closed := 0
+	for token, session := range s.sessions {
+		if session.PartitionID != partitionID {
+			continue
+		}
+		if strings.EqualFold(session.Username, username) || slices.ContainsFunc(session.Previous, func(u string) bool { return strings.EqualFold(u, username) }) {
+			delete(s.sessions, token)
+			closed++
+		}
+	}
+	return closed
+}
+
+// removeExpiredLocked elimina las sesiones vencidas (recordando el motivo) y olvida los motivos viejos.
+// Requiere s.mu tomado.
+func (s *SessionStore) removeExpiredLocked(now time.Time) {
+	for token, session := range s.sessions {
+		if err := session.expiredError(now); err != nil {
+			delete(s.sessions, token)
+			s.ended[token] = endedSession{reason: err, at: now}
+		}
+	}
+	for token, ended := range s.ended {
+		if now.Sub(ended.at) > endedSessionRetention {
+			delete(s.ended, token)
+		}
+	}
+}
+
+// sessionID calcula el identificador corto de un token: no permite recuperar el token, así que puede mostrarse
+func sessionID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ResumeSession crea el AuthStore de una petición a partir de su token y cuenta la petición como actividad de la sesión.
+// Si el token está vacío devuelve un AuthStore sin sesión; si es inválido, expiró o fue terminado, además devuelve
+// ErrInvalidSession, ErrSessionExpired o ErrSessionTerminated.
+func ResumeSession(token string) (*AuthStore, error) {
+	auth := &AuthStore{}
+	if token == "" {
+		return auth, nil
+	}
+	if err := Sessions.Touch(token); err != nil {
+		return auth, err
+	}
+	session, err := Sessions.Get(token)
+	if err != nil {
+		return auth, err
+	}
+	auth.loadSession(session)
+	return auth, nil
+}
+
+// Clave para guardar el AuthStore en el contexto de una petición
+type authContextKey struct{}
+
+// WithAuth devuelve un contexto que lleva el AuthStore de la petición
+func WithAuth(ctx context.Context, auth *AuthStore) context.Context {
+	return context.WithValue(ctx, authContextKey{}, auth)
+}
+
+// AuthFromContext devuelve el AuthStore de la petición. Si el contexto no trae uno, devuelve uno vacío (sin sesión).
+func AuthFromContext(ctx context.Context) *AuthStore {
+	if auth, ok := ctx.Value(authContextKey{}).(*AuthStore); ok && auth != nil {
+		return auth
+	}
+	return &AuthStore{}
+}
@@ -0,0 +1,266 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSessions devuelve una tabla de sesiones vacía para que las pruebas no compartan la global
+func newTestSessions() *SessionStore {
+	return &SessionStore{sessions: make(map[string]*Session), ended: make(map[string]endedSession)}
+}
+
+func TestSessionCreateGetDelete(t *testing.T) {
+	store := newTestSessions()
+
+	first, err := store.Create("root", "201A", "/")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second, err := store.Create("root", "201A", "/")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if first.Token == "" || first.Token == second.Token {
+		t.Fatalf("tokens inválidos o repetidos: %q y %q", first.Token, second.Token)
+	}
+	if !first.ExpiresAt.After(first.CreatedAt) {
+		t.Errorf("ExpiresAt (%v) no es posterior a CreatedAt (%v)", first.ExpiresAt, first.CreatedAt)
+	}
+
+	got, err := store.Get(first.Token)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Username != "root" || got.PartitionID != "201A" {
+		t.Errorf("Get = %+v, se esperaba root en 201A", got)
+	}
+
+	// Get devuelve una copia: modificarla no cambia la tabla
+	got.Username = "otro"
+	if again, _ := store.Get(first.Token); again.Username != "root" {
+		t.Errorf("la tabla cambió al modificar la copia: %+v", again)
+	}
+
+	// Cerrar una sesión no afecta a las demás del mismo usuario
+	store.Delete(first.Token)
+	if _, err := store.Get(first.Token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Get de una sesión eliminada: se esperaba ErrInvalidSession, se obtuvo %v", err)
+	}
+	if _, err := store.Get(second.Token); err != nil {
+		t.Errorf("Get de la otra sesión: %v", err)
+	}
+}
+
+func TestSessionGetInvalid(t *testing.T) {
+	store := newTestSessions()
+	session, err := store.Create("user1", "201A", "/")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	store.sessions[session.Token].ExpiresAt = time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"vacío", "", ErrInvalidSession},
+		{"desconocido", "abc123", ErrInvalidSession},
+		{"vencido", session.Token, ErrSessionExpired},
+		{"vencido, otra vez", session.Token, ErrSessionExpired}, // Ya no está en la tabla pero se recuerda el motivo
+	}
+	for _, test := range tests {
+		if _, err := store.Get(test.token); !errors.Is(err, test.want) {
+			t.Errorf("Get con token %s: se esperaba %v, se obtuvo %v", test.name, test.want, err)
+		}
+	}
+	if _, exists := store.sessions[session.Token]; exists {
+		t.Error("la sesión vencida sigue en la tabla")
+	}
+}
+
+func TestSessionCreateRemovesExpired(t *testing.T) {
+	store := newTestSessions()
+	old, _ := store.Create("user1", "201A", "/")
+	store.sessions[old.Token].ExpiresAt = time.Now().Add(-time.Second)
+
+	if _, err := store.Create("user2", "201A", "/"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, exists := store.sessions[old.Token]; exists {
+		t.Error("Create no eliminó la sesión vencida")
+	}
+}
+
+func TestResumeSession(t *testing.T) {
+	auth := &AuthStore{}
+	token, err := auth.Login("root", "201A", "/home/root")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	t.Cleanup(auth.Logout)
+
+	tests := []struct {
+		name          string
+		token         string
+		wantErr       error
+		authenticated bool
+	}{
+		{"sin token", "", nil, false},
+		{"token válido", token, nil, true},
+		{"token inválido", "abc123", ErrInvalidSession, false},
+	}
+	for _, test := range tests {
+		resumed, err := ResumeSession(test.token)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: error = %v, se esperaba %v", test.name, err, test.wantErr)
+		}
+		if resumed == nil || resumed.IsAuthenticated() != test.authenticated {
+			t.Errorf("%s: AuthStore = %+v, se esperaba autenticado=%v", test.name, resumed, test.authenticated)
+			continue
+		}
+		if test.authenticated {
+			if user, partition := resumed.GetCurrentUser(); user != "root" || partition != "201A" {
+				t.Errorf("%s: sesión de %s en %s, se esperaba root en 201A", test.name, user, partition)
+			}
+			if resumed.Cwd != "/home/root" {
+				t.Errorf("%s: directorio de trabajo %q, se esperaba /home/root", test.name, resumed.Cwd)
+			}
+		}
+	}
+
+	// Después del logout el token deja de servir
+	auth.Logout()
+	if _, err := ResumeSession(token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("ResumeSession después de logout: se esperaba ErrInvalidSession, se obtuvo %v", err)
+	}
+	if auth.IsAuthenticated() || auth.Token != "" {
+		t.Errorf("el AuthStore sigue con sesión después de logout: %+v", auth)
+	}
+}
+
+func TestAuthFromContext(t *testing.T) {
+	if auth := AuthFromContext(context.Background()); auth == nil || auth.IsAuthenticated() {
+		t.Errorf("AuthFromContext sin AuthStore = %+v, se esperaba uno vacío", auth)
+	}
+	auth := &AuthStore{IsLoggedIn: true, Username: "root"}
+	if got := AuthFromContext(WithAuth(context.Background(), auth)); got != auth {
+		t.Errorf("AuthFromContext = %p, se esperaba %p", got, auth)
+	}
+}
+
+func TestSessionDeleteUser(t *testing.T) {
+	store := newTestSessions()
+	create := func(username, partitionID string) *Session {
+		t.Helper()
+		session, err := store.Create(username, partitionID, "/")
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		return session
+	}
+	own := create("user1", "201A")
+	other := create("user1", "201B") // Mismo usuario en otra partición
+	elevated := create("user1", "201A")
+	if err := store.SwitchUser(elevated.Token, "root", []string{"user1"}); err != nil {
+		t.Fatalf("SwitchUser: %v", err)
+	}
+	unrelated := create("user2", "201A")
+
+	if closed := store.DeleteUser("201A", "USER1"); closed != 2 {
+		t.Errorf("DeleteUser cerró %d sesiones, se esperaban 2", closed)
+	}
+	tests := []struct {
+		name    string
+		session *Session
+		active  bool
+	}{
+		{"sesión propia", own, false},
+		{"sesión con su desde el usuario", elevated, false},
+		{"otra partición", other, true},
+		{"otro usuario", unrelated, true},
+	}
+	for _, test := range tests {
+		if _, err := store.Get(test.session.Token); (err == nil) != test.active {
+			t.Errorf("%s: activa=%v, se esperaba %v", test.name, err == nil, test.active)
+		}
+	}
+}
+
+// useTestClock reemplaza el reloj de las sesiones por uno fijo que solo avanza con la función devuelta, y
+// los límites de las sesiones por los indicados
+func useTestClock(t *testing.T, timeouts SessionTimeoutPolicy) (advance func(time.Duration)) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	previous := SessionTimeouts
+	Clock, SessionTimeouts = func() time.Time { return now }, timeouts
+	t.Cleanup(func() { Clock, SessionTimeouts = time.Now, previous })
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	advance := useTestClock(t, SessionTimeoutPolicy{Idle: 10 * time.Minute})
+	store := newTestSessions()
+	session, err := store.Create("user1", "201A", "/")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !session.ExpiresAt.IsZero() {
+		t.Errorf("ExpiresAt = %v sin límite absoluto, se esperaba cero", session.ExpiresAt)
+	}
+
+	// Cada uso reinicia el límite de inactividad
+	for i := 0; i < 3; i++ {
+		advance(9 * time.Minute)
+		if err := store.Touch(session.Token); err != nil {
+			t.Fatalf("Touch a los %d minutos: %v", 9*(i+1), err)
+		}
+	}
+	advance(10 * time.Minute)
+	if _, err := store.Get(session.Token); err != nil {
+		t.Errorf("Get justo en el límite de inactividad: %v", err)
+	}
+	advance(time.Second)
+	if _, err := store.Get(session.Token); !errors.Is(err, ErrSessionExpired) || !strings.Contains(err.Error(), "sin ejecutar comandos") {
+		t.Errorf("Get después del límite de inactividad = %v, se esperaba ErrSessionExpired por inactividad", err)
+	}
+	if list := store.List("201A"); len(list) != 0 {
+		t.Errorf("List incluye %d sesiones vencidas", len(list))
+	}
+}
+
+func TestSessionAbsoluteTimeout(t *testing.T) {
+	advance := useTestClock(t, SessionTimeoutPolicy{Idle: 10 * time.Minute, Absolute: time.Hour})
+	store := newTestSessions()
+	session, err := store.Create("user1", "201A", "/")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Aunque se use seguido, la sesión vence una hora después del login
+	for elapsed := time.Duration(0); elapsed < time.Hour; elapsed += 5 * time.Minute {
+		if err := store.Touch(session.Token); err != nil {
+			t.Fatalf("Touch a los %s: %v", elapsed, err)
+		}
+		advance(5 * time.Minute)
+	}
+	advance(time.Second)
+	if err := store.Touch(session.Token); !errors.Is(err, ErrSessionExpired) || !strings.Contains(err.Error(), "desde el login") {
+		t.Errorf("Touch después del límite absoluto = %v, se esperaba ErrSessionExpired por tiempo máximo", err)
+	}
+
+	// El motivo se recuerda hasta endedSessionRetention
+	advance(endedSessionRetention)
+	store.List("201A")
+	if _, err := store.Get(session.Token); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Get dentro de la retención = %v, se esperaba ErrSessionExpired", err)
+	}
+	advance(time.Second)
+	store.List("201A")
+	if _, err := store.Get(session.Token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Get después de la retención = %v, se esperaba ErrInvalidSession", err)
+	}
+}
@@ -3,10 +3,13 @@ package stores
 import (
 	structures "backend/structures"
 	"errors"
+	"slices"
+	"time"
 )
 
-// Carnet de estudiante
-const Carnet string = "20" // 202302220
+// Carnet de estudiante. Es el prefijo de los ids de montaje y se puede cambiar con la configuración (máximo 2
+// caracteres, porque el id se guarda en los 4 bytes de Part_id).
+var Carnet string = "20" // 202302220
 
 // Declaración de variables globales
 var (
@@ -28,11 +31,18 @@ func GetMountedPartition(id string) (*structures.Partition, string, error) {
 		return nil, "", errors.New("la partición no está montada")
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer structures.CloseDevice(dev)
+
 	// Crear una instancia de MBR
 	var mbr structures.MBR
 
-	// Deserializar la estructura MBR desde un archivo binario
-	err := mbr.Deserialize(path)
+	// Deserializar la estructura MBR desde el dispositivo
+	err = mbr.Deserialize(dev)
 	if err != nil {
 		return nil, "", err
 	}
@@ -54,11 +64,18 @@ func GetMountedPartitionRep(id string) (*structures.MBR, *structures.SuperBlock,
 		return nil, nil, "", errors.New("la partición no está montada")
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer structures.CloseDevice(dev)
+
 	// Crear una instancia de MBR
 	var mbr structures.MBR
 
-	// Deserializar la estructura MBR desde un archivo binario
-	err := mbr.Deserialize(path)
+	// Deserializar la estructura MBR desde el dispositivo
+	err = mbr.Deserialize(dev)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -72,8 +89,8 @@ func GetMountedPartitionRep(id string) (*structures.MBR, *structures.SuperBlock,
 	// Crear una instancia de SuperBlock
 	var sb structures.SuperBlock
 
-	// Deserializar la estructura SuperBlock desde un archivo binario
-	err = sb.Deserialize(path, int64(partition.Part_start))
+	// Deserializar la estructura SuperBlock desde el dispositivo
+	err = sb.Deserialize(dev, int64(partition.Part_start))
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -89,11 +106,18 @@ func GetMountedPartitionSuperblock(id string) (*structures.SuperBlock, *structur
 		return nil, nil, "", errors.New("la partición no está montada")
 	}
 
+	// Abrir el dispositivo del disco
+	dev, err := structures.OpenDevice(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer structures.CloseDevice(dev)
+
 	// Crear una instancia de MBR
 	var mbr structures.MBR
 
-	// Deserializar la estructura MBR desde un archivo binario
-	err := mbr.Deserialize(path)
+	// Deserializar la estructura MBR desde el dispositivo
+	err = mbr.Deserialize(dev)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -107,8 +131,8 @@ func GetMountedPartitionSuperblock(id string) (*structures.SuperBlock, *structur
 	// Crear una instancia de SuperBlock
 	var sb structures.SuperBlock
 
-	// Deserializar la estructura SuperBlock desde un archivo binario
-	err = sb.Deserialize(path, int64(partition.Part_start))
+	// Deserializar la estructura SuperBlock desde el dispositivo
+	err = sb.Deserialize(dev, int64(partition.Part_start))
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -116,56 +140,131 @@ func GetMountedPartitionSuperblock(id string) (*structures.SuperBlock, *structur
 	return &sb, partition, path, nil
 }
  // PARTE PARA LA AUTENTICACION CON EL LOGIN
-// AuthStore almacena la información de autenticación del usuario
+// AuthStore almacena la información de autenticación de quien ejecuta los comandos de una petición.
+// Cada petición HTTP tiene su propio AuthStore (ver WithAuth/AuthFromContext); si trae un token válido,
+// se inicializa con la sesión correspondiente de la tabla Sessions.
 type AuthStore struct {
 	IsLoggedIn  bool
 	Username    string
-	Password    string
 	PartitionID string
+	Token       string    // Token de la sesión en la tabla Sessions
+	LoginAt     time.Time // Momento del login
+	ExpiresAt   time.Time // Momento en que la sesión alcanza su tiempo máximo (cero si no hay límite)
+	Previous    []string  // Usuarios a los que vuelve exit, apilados por su
+	ElevatedBy  string    // Usuario que ejecuta con privilegios de root mediante sudo (vacío si no hay elevación)
+	Cwd         string    // Directorio de trabajo de la sesión
 }
 
-var Auth = &AuthStore{
-	IsLoggedIn:  false,
-	Username:    "",
-	Password:    "",
-	PartitionID: "",
-}
-
-func (a *AuthStore) Login(username, password, partitionID string) {
-	a.IsLoggedIn = true
-	a.Username = username
-	a.Password = password
-	a.PartitionID = partitionID
+// Login crea una nueva sesión en la tabla Sessions, con cwd como directorio de trabajo, y la asocia a este AuthStore.
+// Devuelve el token emitido.
+func (a *AuthStore) Login(username, partitionID, cwd string) (string, error) {
+	session, err := Sessions.Create(username, partitionID, cwd)
+	if err != nil {
+		return "", err
+	}
+	a.loadSession(session)
+	return session.Token, nil
 }
 
+// Logout elimina la sesión de la tabla Sessions y limpia este AuthStore
 func (a *AuthStore) Logout() {
+	if a.Token != "" {
+		Sessions.Delete(a.Token)
+	}
 	a.IsLoggedIn = false
 	a.Username = ""
-	a.Password = ""
 	a.PartitionID = ""
+	a.Token = ""
+	a.LoginAt = time.Time{}
+	a.ExpiresAt = time.Time{}
+	a.Previous = nil
+	a.ElevatedBy = ""
+	a.Cwd = ""
 }
 
-func (a *AuthStore) IsAuthenticated() bool {
-	return a.IsLoggedIn
+// SwitchUser cambia el usuario de la sesión recordando el actual para volver con ExitUser (comando su)
+func (a *AuthStore) SwitchUser(username string) error {
+	previous := append(slices.Clone(a.Previous), a.Username)
+	if a.Token != "" {
+		if err := Sessions.SwitchUser(a.Token, username, previous); err != nil {
+			return err
+		}
+	}
+	a.Username = username
+	a.Previous = previous
+	return nil
 }
 
-func (a *AuthStore) GetCurrentUser() (string, string, string) {
-	return a.Username, a.Password, a.PartitionID
+// ExitUser vuelve al usuario anterior a la última llamada a SwitchUser (comando exit). Devuelve el usuario restaurado.
+func (a *AuthStore) ExitUser() (string, error) {
+	if len(a.Previous) == 0 {
+		return "", errors.New("no hay un usuario anterior al cual volver (no se ejecutó su)")
+	}
+	username := a.Previous[len(a.Previous)-1]
+	previous := slices.Clone(a.Previous[:len(a.Previous)-1])
+	if a.Token != "" {
+		if err := Sessions.SwitchUser(a.Token, username, previous); err != nil {
+			return "", err
+		}
+	}
+	a.Username = username
+	a.Previous = previous
+	return username, nil
 }
 
-func (a *AuthStore) GetPartitionID() string {
-	return a.PartitionID
+// ChangeDir cambia el directorio de trabajo de la sesión (comando cd)
+func (a *AuthStore) ChangeDir(cwd string) error {
+	if a.Token != "" {
+		if err := Sessions.ChangeDir(a.Token, cwd); err != nil {
+			return err
+		}
+	}
+	a.Cwd = cwd
+	return nil
 }
 
+// Elevated devuelve una copia de este AuthStore que actúa como root, para ejecutar un comando con sudo.
+// La copia no tiene token, así que lo que haga no modifica la sesión original.
+func (a *AuthStore) Elevated() *AuthStore {
+	return &AuthStore{
+		IsLoggedIn:  a.IsLoggedIn,
+		Username:    "root",
+		PartitionID: a.PartitionID,
+		LoginAt:     a.LoginAt,
+		ExpiresAt:   a.ExpiresAt,
+		ElevatedBy:  a.Username,
+		Cwd:         a.Cwd,
+	}
+}
 
+// SessionID devuelve un identificador corto de la sesión que puede mostrarse o registrarse sin revelar el token
+func (a *AuthStore) SessionID() string {
+	if a.Token == "" {
+		return ""
+	}
+	return sessionID(a.Token)
+}
 
+func (a *AuthStore) IsAuthenticated() bool {
+	return a.IsLoggedIn
+}
 
+func (a *AuthStore) GetCurrentUser() (string, string) {
+	return a.Username, a.PartitionID
+}
 
+func (a *AuthStore) GetPartitionID() string {
+	return a.PartitionID
+}
 
-
-
-
-
-
-
-
+// loadSession copia los datos de una sesión de la tabla
+func (a *AuthStore) loadSession(session *Session) {
+	a.IsLoggedIn = true
+	a.Username = session.Username
+	a.PartitionID = session.PartitionID
+	a.Token = session.Token
+	a.LoginAt = session.CreatedAt
+	a.ExpiresAt = session.ExpiresAt
+	a.Previous = session.Previous
+	a.Cwd = session.Cwd
+}
@@ -0,0 +1,398 @@
+package structures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Bloque dentro del orden lógico de un inodo
+type layoutBlock struct {
+	Index   int32 // Índice del bloque
+	Pointer bool  // true si es un bloque de punteros
+}
+
+// InodeFragmentation describe cómo están repartidos en el disco los bloques de un inodo
+type InodeFragmentation struct {
+	InodeIndex int32
+	Path       string  // Path absoluto del inodo (vacío si no se encontró en el árbol)
+	Type       byte    // '0' carpeta, '1' archivo
+	Blocks     []int32 // Bloques en orden lógico, incluyendo los de punteros
+	Runs       int     // Cantidad de tramos contiguos
+}
+
+// Score devuelve el porcentaje de saltos entre bloques consecutivos (0 = totalmente contiguo)
+func (f *InodeFragmentation) Score() float64 {
+	if len(f.Blocks) < 2 {
+		return 0
+	}
+	return 100 * float64(f.Runs-1) / float64(len(f.Blocks)-1)
+}
+
+// FragmentationReport resume la fragmentación de toda la partición
+type FragmentationReport struct {
+	Inodes       []InodeFragmentation
+	OrphanBlocks []int32 // Bloques marcados como usados en el bitmap que ningún inodo referencia
+}
+
+// Score devuelve el porcentaje global de saltos entre bloques consecutivos de un mismo inodo
+func (r *FragmentationReport) Score() float64 {
+	breaks, pairs := 0, 0
+	for _, f := range r.Inodes {
+		if len(f.Blocks) < 2 {
+			continue
+		}
+		breaks += f.Runs - 1
+		pairs += len(f.Blocks) - 1
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return 100 * float64(breaks) / float64(pairs)
+}
+
+// validBlock indica si un puntero apunta a un bloque existente
+func (sb *SuperBlock) validBlock(blockIndex int32) bool {
+	return blockIndex >= 0 && blockIndex < sb.S_blocks_count
+}
+
+// inodeLayout devuelve los bloques del inodo en el orden en que deberían quedar contiguos:
+// los directos y luego cada bloque de punteros seguido de los bloques que referencia
+func (sb *SuperBlock) inodeLayout(dev BlockDevice, inode *Inode) ([]layoutBlock, error) {
+	var layout []layoutBlock
+	for i := 0; i < 12; i++ {
+		if sb.validBlock(inode.I_block[i]) {
+			layout = append(layout, layoutBlock{Index: inode.I_block[i]})
+		}
+	}
+	for level := 1; level <= 3; level++ {
+		if err := sb.appendIndirectLayout(dev, level, inode.I_block[11+level], &layout); err != nil {
+			return nil, err
+		}
+	}
+	return layout, nil
+}
+
+// appendIndirectLayout agrega un bloque de punteros del nivel indicado y, recursivamente, los bloques que referencia
+func (sb *SuperBlock) appendIndirectLayout(dev BlockDevice, level int, blockPtr int32, layout *[]layoutBlock) error {
+	if !sb.validBlock(blockPtr) {
+		return nil
+	}
+	*layout = append(*layout, layoutBlock{Index: blockPtr, Pointer: true})
+
+	ptrBlock := &PointerBlock{}
+	if err := ptrBlock.Deserialize(dev, int64(sb.S_block_start)+int64(blockPtr)*int64(sb.S_block_size)); err != nil {
+		return fmt.Errorf("error leyendo bloque de punteros %d: %w", blockPtr, err)
+	}
+	for _, next := range ptrBlock.P_pointers {
+		if level == 1 {
+			if sb.validBlock(next) {
+				*layout = append(*layout, layoutBlock{Index: next})
+			}
+			continue
+		}
+		if err := sb.appendIndirectLayout(dev, level-1, next, layout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inodePaths recorre el árbol de carpetas desde la raíz y devuelve el path de cada inodo alcanzable
+func (sb *SuperBlock) inodePaths(dev BlockDevice) map[int32]string {
+	paths := map[int32]string{0: "/"}
+	pending := []int32{0}
+
+	for len(pending) > 0 {
+		dirIndex := pending[0]
+		pending = pending[1:]
+
+		dirInode := &Inode{}
+		if err := dirInode.Deserialize(dev, int64(sb.S_inode_start)+int64(dirIndex)*int64(sb.S_inode_size)); err != nil || dirInode.I_type[0] != '0' {
+			continue
+		}
+		layout, err := sb.inodeLayout(dev, dirInode)
+		if err != nil {
+			continue
+		}
+
+		for _, block := range layout {
+			if block.Pointer {
+				continue
+			}
+			folderBlock := &FolderBlock{}
+			if err := folderBlock.Deserialize(dev, int64(sb.S_block_start)+int64(block.Index)*int64(sb.S_block_size)); err != nil {
+				continue
+			}
+			for _, content := range folderBlock.B_content {
+				name := strings.TrimRight(string(content.B_name[:]), "\x00")
+				if content.B_inodo < 0 || content.B_inodo >= sb.S_inodes_count || name == "" || name == "." || name == ".." {
+					continue
+				}
+				if _, seen := paths[content.B_inodo]; seen {
+					continue
+				}
+				paths[content.B_inodo] = strings.TrimSuffix(paths[dirIndex], "/") + "/" + name
+				pending = append(pending, content.B_inodo)
+			}
+		}
+	}
+	return paths
+}
+
+// AnalyzeFragmentation calcula la fragmentación de cada inodo en uso de la partición
+func (sb *SuperBlock) AnalyzeFragmentation(dev BlockDevice) (*FragmentationReport, error) {
+	inodeBitmap := make([]byte, sb.S_inodes_count)
+	if _, err := dev.ReadAt(inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de inodos: %w", err)
+	}
+	blockBitmap := make([]byte, sb.S_blocks_count)
+	if _, err := dev.ReadAt(blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de bloques: %w", err)
+	}
+
+	paths := sb.inodePaths(dev)
+	report := &FragmentationReport{}
+	referenced := make(map[int32]bool)
+
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		if inodeBitmap[i] != '1' {
+			continue
+		}
+		inode := &Inode{}
+		if err := inode.Deserialize(dev, int64(sb.S_inode_start)+int64(i)*int64(sb.S_inode_size)); err != nil {
+			return nil, fmt.Errorf("error al leer inodo %d: %w", i, err)
+		}
+		layout, err := sb.inodeLayout(dev, inode)
+		if err != nil {
+			return nil, fmt.Errorf("error al recorrer los bloques del inodo %d: %w", i, err)
+		}
+
+		frag := InodeFragmentation{InodeIndex: i, Path: paths[i], Type: inode.I_type[0]}
+		for j, block := range layout {
+			frag.Blocks = append(frag.Blocks, block.Index)
+			referenced[block.Index] = true
+			if j == 0 || block.Index != layout[j-1].Index+1 {
+				frag.Runs++
+			}
+		}
+		report.Inodes = append(report.Inodes, frag)
+	}
+
+	for b := int32(0); b < sb.S_blocks_count; b++ {
+		if blockBitmap[b] == '1' && !referenced[b] {
+			report.OrphanBlocks = append(report.OrphanBlocks, b)
+		}
+	}
+	return report, nil
+}
+
+// DefragResult resume lo hecho por Defragment
+type DefragResult struct {
+	Before       *FragmentationReport
+	After        *FragmentationReport
+	MovedBlocks  int // Bloques que cambiaron de posición
+	MovedInodes  int // Inodos que cambiaron de posición
+	FreedOrphans int // Bloques huérfanos que quedaron libres
+}
+
+// Defragment reubica los bloques de todos los inodos para que los de cada uno queden contiguos y compactados
+// al inicio del área de bloques, y compacta también los inodos en uso conservando su orden (la raíz y users.txt
+// siguen siendo 0 y 1). Reescribe I_block, los bloques de punteros, las entradas de carpetas y ambos bitmaps,
+// y actualiza S_first_ino, S_first_blo y los contadores de libres en memoria: el llamador debe serializar el superbloque.
+func (sb *SuperBlock) Defragment(dev BlockDevice) (*DefragResult, error) {
+	before, err := sb.AnalyzeFragmentation(dev)
+	if err != nil {
+		return nil, err
+	}
+
+	inodeBitmap := make([]byte, sb.S_inodes_count)
+	if _, err := dev.ReadAt(inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de inodos: %w", err)
+	}
+	oldBlockBitmap := make([]byte, sb.S_blocks_count)
+	if _, err := dev.ReadAt(oldBlockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de bloques: %w", err)
+	}
+
+	blockOffset := func(index int32) int64 {
+		return int64(sb.S_block_start) + int64(index)*int64(sb.S_block_size)
+	}
+
+	inodeOffset := func(index int32) int64 {
+		return int64(sb.S_inode_start) + int64(index)*int64(sb.S_inode_size)
+	}
+
+	// Calcular la nueva posición de cada inodo y de cada bloque: los inodos en orden y sus bloques en orden lógico
+	type movedBlock struct {
+		layoutBlock
+		folder bool // Bloque de datos de una carpeta
+	}
+	mapping := make(map[int32]int32)
+	inodeMapping := make(map[int32]int32)
+	var order []movedBlock
+	var inodeOrder []int32
+	inodes := make(map[int32]*Inode)
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		if inodeBitmap[i] != '1' {
+			continue
+		}
+		inode := &Inode{}
+		if err := inode.Deserialize(dev, inodeOffset(i)); err != nil {
+			return nil, fmt.Errorf("error al leer inodo %d: %w", i, err)
+		}
+		inodes[i] = inode
+		inodeMapping[i] = int32(len(inodeOrder))
+		inodeOrder = append(inodeOrder, i)
+
+		layout, err := sb.inodeLayout(dev, inode)
+		if err != nil {
+			return nil, fmt.Errorf("error al recorrer los bloques del inodo %d: %w", i, err)
+		}
+		for _, block := range layout {
+			if _, exists := mapping[block.Index]; exists {
+				continue // Bloque compartido (no debería ocurrir), conserva su primera posición
+			}
+			mapping[block.Index] = int32(len(order))
+			order = append(order, movedBlock{layoutBlock: block, folder: !block.Pointer && inode.I_type[0] == '0'})
+		}
+	}
+
+	translate := func(ptr int32) int32 {
+		if newIndex, exists := mapping[ptr]; exists {
+			return newIndex
+		}
+		return -1
+	}
+
+	// Leer todo el contenido antes de mover nada, así no importa el orden de escritura
+	contents := make([][]byte, len(order))
+	for n, block := range order {
+		buffer := make([]byte, sb.S_block_size)
+		if _, err := dev.ReadAt(buffer, blockOffset(block.Index)); err != nil {
+			return nil, fmt.Errorf("error al leer bloque %d: %w", block.Index, err)
+		}
+
+		// Las carpetas deben apuntar a la nueva posición de sus inodos
+		if block.folder {
+			folderBlock := &FolderBlock{}
+			if err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, folderBlock); err != nil {
+				return nil, fmt.Errorf("error al decodificar bloque de carpeta %d: %w", block.Index, err)
+			}
+			for k, content := range folderBlock.B_content {
+				if newInode, exists := inodeMapping[content.B_inodo]; exists {
+					folderBlock.B_content[k].B_inodo = newInode
+				}
+			}
+			var encoded bytes.Buffer
+			if err := binary.Write(&encoded, binary.LittleEndian, folderBlock); err != nil {
+				return nil, err
+			}
+			buffer = encoded.Bytes()
+		}
+
+		// Los bloques de punteros deben apuntar a las nuevas posiciones
+		if block.Pointer {
+			ptrBlock := &PointerBlock{}
+			if err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, ptrBlock); err != nil {
+				return nil, fmt.Errorf("error al decodificar bloque de punteros %d: %w", block.Index, err)
+			}
+			for k, ptr := range ptrBlock.P_pointers {
+				if ptr != -1 {
+					ptrBlock.P_pointers[k] = translate(ptr)
+				}
+			}
+			var encoded bytes.Buffer
+			if err := binary.Write(&encoded, binary.LittleEndian, ptrBlock); err != nil {
+				return nil, err
+			}
+			buffer = encoded.Bytes()
+		}
+		contents[n] = buffer
+	}
+
+	result := &DefragResult{Before: before, FreedOrphans: len(before.OrphanBlocks)}
+
+	for newIndex, oldIndex := range inodeOrder {
+		if oldIndex != int32(newIndex) {
+			result.MovedInodes++
+		}
+	}
+
+	// Escribir los bloques en sus nuevas posiciones
+	for n, block := range order {
+		if block.Index != int32(n) {
+			result.MovedBlocks++
+		}
+		if _, err := dev.WriteAt(contents[n], blockOffset(int32(n))); err != nil {
+			return nil, fmt.Errorf("error al escribir bloque %d: %w", n, err)
+		}
+	}
+
+	// Limpiar los bloques que quedaron libres después del área compactada
+	empty := make([]byte, sb.S_block_size)
+	for b := int32(len(order)); b < sb.S_blocks_count; b++ {
+		if _, wasUsed := mapping[b]; !wasUsed && oldBlockBitmap[b] != '1' {
+			continue
+		}
+		if _, err := dev.WriteAt(empty, blockOffset(b)); err != nil {
+			return nil, fmt.Errorf("error al limpiar bloque %d: %w", b, err)
+		}
+	}
+
+	// Actualizar los punteros de cada inodo y escribirlo en su nueva posición
+	for newIndex, oldIndex := range inodeOrder {
+		inode := inodes[oldIndex]
+		for k, ptr := range inode.I_block {
+			if ptr != -1 {
+				inode.I_block[k] = translate(ptr)
+			}
+		}
+		if err := inode.Serialize(dev, inodeOffset(int32(newIndex))); err != nil {
+			return nil, fmt.Errorf("error al escribir inodo %d: %w", newIndex, err)
+		}
+	}
+
+	// Limpiar los inodos que quedaron libres después del área compactada
+	emptyInode := make([]byte, sb.S_inode_size)
+	for i := int32(len(inodeOrder)); i < sb.S_inodes_count; i++ {
+		if inodeBitmap[i] != '1' {
+			continue
+		}
+		if _, err := dev.WriteAt(emptyInode, inodeOffset(i)); err != nil {
+			return nil, fmt.Errorf("error al limpiar inodo %d: %w", i, err)
+		}
+	}
+
+	// Reescribir el bitmap de bloques: todo el área compactada ocupada y el resto libre
+	blockBitmap := bytes.Repeat([]byte{'0'}, int(sb.S_blocks_count))
+	for b := 0; b < len(order); b++ {
+		blockBitmap[b] = '1'
+	}
+	if _, err := dev.WriteAt(blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error al escribir bitmap de bloques: %w", err)
+	}
+
+	// Lo mismo para el bitmap de inodos
+	newInodeBitmap := bytes.Repeat([]byte{'0'}, int(sb.S_inodes_count))
+	for i := 0; i < len(inodeOrder); i++ {
+		newInodeBitmap[i] = '1'
+	}
+	if _, err := dev.WriteAt(newInodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error al escribir bitmap de inodos: %w", err)
+	}
+
+	// La asignación secuencial continúa justo después de las áreas compactadas
+	sb.S_first_ino = sb.S_inode_start + int32(len(inodeOrder))*sb.S_inode_size
+	sb.S_first_blo = sb.S_block_start + int32(len(order))*sb.S_block_size
+	sb.S_free_inodes_count = sb.S_inodes_count - int32(len(inodeOrder))
+	sb.S_free_blocks_count = sb.S_blocks_count - int32(len(order))
+
+	after, err := sb.AnalyzeFragmentation(dev)
+	if err != nil {
+		return nil, err
+	}
+	result.After = after
+	return result, nil
+}
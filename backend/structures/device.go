@@ -0,0 +1,206 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BlockDevice representa el medio donde vive un disco virtual.
+// Todas las estructuras (MBR, EBR, SuperBlock, Inode y bloques) se leen y escriben a través de esta interfaz,
+// de modo que el sistema de archivos puede trabajar igual sobre un archivo .mia o sobre memoria.
+type BlockDevice interface {
+	ReadAt(p []byte, off int64) (int, error)  // Lee len(p) bytes desde el offset indicado
+	WriteAt(p []byte, off int64) (int, error) // Escribe p en el offset indicado
+	Size() (int64, error)                     // Tamaño total del dispositivo en bytes
+	Sync() error                              // Asegura que lo escrito llegue al medio de almacenamiento
+}
+
+// Prefijo de los paths que identifican discos en memoria (ej: mem://Disco1.mia)
+const MemoryDevicePrefix = "mem://"
+
+// IsMemoryPath indica si un path corresponde a un disco en memoria
+func IsMemoryPath(path string) bool {
+	return strings.HasPrefix(path, MemoryDevicePrefix)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+// Dispositivo respaldado por un archivo del host
+
+// FileDevice implementa BlockDevice sobre un archivo binario del host
+type FileDevice struct {
+	file *os.File
+}
+
+// OpenFileDevice abre un archivo existente del host como dispositivo de bloques
+func OpenFileDevice(path string) (*FileDevice, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDevice{file: file}, nil
+}
+
+func (d *FileDevice) ReadAt(p []byte, off int64) (int, error) {
+	return d.file.ReadAt(p, off)
+}
+
+func (d *FileDevice) WriteAt(p []byte, off int64) (int, error) {
+	return d.file.WriteAt(p, off)
+}
+
+func (d *FileDevice) Size() (int64, error) {
+	info, err := d.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (d *FileDevice) Sync() error {
+	return d.file.Sync()
+}
+
+// Close cierra el archivo subyacente
+func (d *FileDevice) Close() error {
+	return d.file.Close()
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+// Dispositivo en memoria
+
+// MemoryDevice implementa BlockDevice sobre un slice de bytes de tamaño fijo
+type MemoryDevice struct {
+	mu   sync.RWMutex
+	data []byte
+}
+
+// NewMemoryDevice crea un dispositivo en memoria de size bytes inicializados en cero
+func NewMemoryDevice(size int64) *MemoryDevice {
+	return &MemoryDevice{data: make([]byte, size)}
+}
+
+func (d *MemoryDevice) ReadAt(p []byte, off int64) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if off < 0 {
+		return 0, fmt.Errorf("offset negativo: %d", off)
+	}
+	if off >= int64(len(d.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, d.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (d *MemoryDevice) WriteAt(p []byte, off int64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Un disco en memoria no crece: escribir fuera de sus límites es un error
+	if off < 0 || off+int64(len(p)) > int64(len(d.data)) {
+		return 0, fmt.Errorf("escritura fuera de los límites del disco en memoria (offset %d, %d bytes, tamaño %d)", off, len(p), len(d.data))
+	}
+	return copy(d.data[off:], p), nil
+}
+
+func (d *MemoryDevice) Size() (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return int64(len(d.data)), nil
+}
+
+func (d *MemoryDevice) Sync() error {
+	return nil // Nada que sincronizar, todo vive en memoria
+}
+
+// Registro de los discos en memoria creados con mkdisk -path=mem://...
+var (
+	memoryDevicesMu sync.Mutex
+	memoryDevices   = make(map[string]*MemoryDevice)
+)
+
+// CreateMemoryDevice crea y registra un disco en memoria con el path indicado
+func CreateMemoryDevice(path string, size int64) (*MemoryDevice, error) {
+	if !IsMemoryPath(path) {
+		return nil, fmt.Errorf("el path '%s' no corresponde a un disco en memoria", path)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("tamaño inválido para disco en memoria: %d", size)
+	}
+
+	memoryDevicesMu.Lock()
+	defer memoryDevicesMu.Unlock()
+
+	dev := NewMemoryDevice(size)
+	memoryDevices[path] = dev // Igual que os.Create, un disco existente se reemplaza
+	return dev, nil
+}
+
+// RemoveMemoryDevice elimina un disco en memoria del registro
+func RemoveMemoryDevice(path string) error {
+	memoryDevicesMu.Lock()
+	defer memoryDevicesMu.Unlock()
+
+	if _, exists := memoryDevices[path]; !exists {
+		return fmt.Errorf("no existe el disco en memoria %s", path)
+	}
+	delete(memoryDevices, path)
+	return nil
+}
+
+// MemoryDeviceExists indica si hay un disco en memoria registrado con ese path
+func MemoryDeviceExists(path string) bool {
+	memoryDevicesMu.Lock()
+	defer memoryDevicesMu.Unlock()
+
+	_, exists := memoryDevices[path]
+	return exists
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+// Apertura y cierre
+
+// OpenDevice devuelve el dispositivo asociado a un path: un disco en memoria si el path empieza con mem://,
+// o el handle cacheado del archivo del host en cualquier otro caso. El llamador debe liberarlo con CloseDevice.
+func OpenDevice(path string) (BlockDevice, error) {
+	if IsMemoryPath(path) {
+		memoryDevicesMu.Lock()
+		defer memoryDevicesMu.Unlock()
+
+		dev, exists := memoryDevices[path]
+		if !exists {
+			return nil, fmt.Errorf("no existe el disco en memoria %s", path)
+		}
+		return dev, nil
+	}
+
+	dev, err := acquireCachedDevice(path)
+	if err != nil {
+		return nil, err
+	}
+	return dev, nil
+}
+
+// CloseDevice libera un dispositivo obtenido con OpenDevice.
+// Los discos cacheados no se cierran: sus cambios se vuelcan con FlushDevices al terminar el comando que los usó.
+func CloseDevice(dev BlockDevice) error {
+	if dev == nil {
+		return errors.New("dispositivo nulo")
+	}
+	if cached, ok := dev.(*CachedDevice); ok {
+		releaseCachedDevice(cached)
+		return nil
+	}
+	if closer, ok := dev.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
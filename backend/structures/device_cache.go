@@ -0,0 +1,450 @@
+package structures
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Parámetros de la caché de disco
+const (
+	CachePageSize = 4096 // Tamaño de cada página de la caché en bytes
+	CacheMaxPages = 256  // Páginas que se mantienen por disco antes de desalojar (1 MB)
+)
+
+// ErrExternalModification se usa con errors.Is para reconocer discos modificados fuera del programa
+var ErrExternalModification = errors.New("disco modificado externamente")
+
+// ExternalModificationError indica que el archivo .mia cambió (o desapareció) desde la última vez
+// que el programa lo leyó o escribió, por lo que la caché ya no es confiable.
+type ExternalModificationError struct {
+	Path   string // Path del disco afectado
+	Reason string // Qué cambio se detectó
+}
+
+func (e *ExternalModificationError) Error() string {
+	return fmt.Sprintf("el disco '%s' fue modificado externamente: %s", e.Path, e.Reason)
+}
+
+func (e *ExternalModificationError) Unwrap() error {
+	return ErrExternalModification
+}
+
+// Página de la caché: un fragmento alineado del disco
+type cachePage struct {
+	index int64  // Número de página (offset / CachePageSize)
+	data  []byte // Contenido de la página
+	dirty bool   // true si tiene cambios que aún no se escriben al archivo
+}
+
+// CachedDevice implementa BlockDevice sobre un archivo del host manteniendo el archivo abierto
+// y una caché LRU de páginas. Como inodos y bloques se leen por offset, ambos quedan cubiertos por la misma caché.
+// Las escrituras se quedan en memoria hasta llamar Flush (write-back). Quien llama a Flush o Sync debe asegurarse de
+// que ningún comando tenga cambios a medias en el disco (ver FlushDevices).
+type CachedDevice struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	size  int64       // Tamaño lógico del disco (incluye escrituras aún no volcadas)
+	state os.FileInfo // Último estado conocido del archivo, para detectar cambios externos
+	pages map[int64]*list.Element
+	lru   *list.List // Frente = página usada más recientemente
+	refs  int        // Cantidad de OpenDevice sin su CloseDevice correspondiente
+}
+
+// openCachedDevice abre el archivo del host y prepara una caché vacía
+func openCachedDevice(path string) (*CachedDevice, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &CachedDevice{
+		path:  path,
+		file:  file,
+		size:  info.Size(),
+		state: info,
+		pages: make(map[int64]*list.Element),
+		lru:   list.New(),
+	}, nil
+}
+
+func (d *CachedDevice) ReadAt(p []byte, off int64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if off < 0 {
+		return 0, fmt.Errorf("offset negativo: %d", off)
+	}
+	if off >= d.size {
+		return 0, io.EOF
+	}
+
+	// No leer más allá del final del disco
+	end := off + int64(len(p))
+	if end > d.size {
+		end = d.size
+	}
+
+	n := 0
+	for pos := off; pos < end; {
+		page, err := d.getPage(pos / CachePageSize)
+		if err != nil {
+			return n, err
+		}
+		start := pos % CachePageSize
+		count := copy(p[n:end-off], page.data[start:])
+		n += count
+		pos += int64(count)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (d *CachedDevice) WriteAt(p []byte, off int64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if off < 0 {
+		return 0, fmt.Errorf("offset negativo: %d", off)
+	}
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		page, err := d.getPage(pos / CachePageSize)
+		if err != nil {
+			return n, err
+		}
+		start := pos % CachePageSize
+		count := copy(page.data[start:], p[n:])
+		page.dirty = true
+		n += count
+	}
+
+	// Igual que un archivo, escribir después del final hace crecer el disco
+	if off+int64(n) > d.size {
+		d.size = off + int64(n)
+	}
+	return n, nil
+}
+
+func (d *CachedDevice) Size() (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size, nil
+}
+
+// Sync vuelca las páginas modificadas y fuerza la escritura física del archivo
+func (d *CachedDevice) Sync() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.flushLocked(); err != nil {
+		return err
+	}
+	if err := d.file.Sync(); err != nil {
+		return fmt.Errorf("error al sincronizar el disco '%s': %w", d.path, err)
+	}
+	return nil
+}
+
+// Flush escribe en el archivo todas las páginas modificadas
+func (d *CachedDevice) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.flushLocked()
+}
+
+// DirtyPages devuelve la cantidad de páginas con cambios pendientes
+func (d *CachedDevice) DirtyPages() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	count := 0
+	for _, elem := range d.pages {
+		if elem.Value.(*cachePage).dirty {
+			count++
+		}
+	}
+	return count
+}
+
+// getPage devuelve la página pedida, cargándola del archivo si no está en caché
+func (d *CachedDevice) getPage(index int64) (*cachePage, error) {
+	if elem, exists := d.pages[index]; exists {
+		d.lru.MoveToFront(elem)
+		return elem.Value.(*cachePage), nil
+	}
+
+	page := &cachePage{index: index, data: make([]byte, CachePageSize)}
+
+	// Solo se lee del archivo la parte que ya existe en él; el resto queda en cero
+	if index*CachePageSize < d.state.Size() {
+		if _, err := d.file.ReadAt(page.data, index*CachePageSize); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error al leer el disco '%s': %w", d.path, err)
+		}
+	}
+
+	if d.lru.Len() >= CacheMaxPages {
+		if err := d.evictLocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	d.pages[index] = d.lru.PushFront(page)
+	return page, nil
+}
+
+// evictLocked desaloja la página sin cambios menos usada. Si todas tienen cambios pendientes, escribe y desaloja
+// la menos usada: solo pasa cuando un comando modifica más de CacheMaxPages páginas antes de volcarlas, y antes de
+// escribirla se verifica, como al volcar, que nadie más haya cambiado el archivo. Requiere d.mu tomado.
+func (d *CachedDevice) evictLocked() error {
+	for elem := d.lru.Back(); elem != nil; elem = elem.Prev() {
+		if page := elem.Value.(*cachePage); !page.dirty {
+			d.lru.Remove(elem)
+			delete(d.pages, page.index)
+			return nil
+		}
+	}
+
+	oldest := d.lru.Back()
+	victim := oldest.Value.(*cachePage)
+	if err := d.checkExternalLocked(); err != nil {
+		return err
+	}
+	if err := d.writePage(victim); err != nil {
+		return err
+	}
+	if err := d.refreshState(); err != nil {
+		return err
+	}
+	d.lru.Remove(oldest)
+	delete(d.pages, victim.index)
+	return nil
+}
+
+// writePage escribe una página en el archivo sin pasar del tamaño lógico del disco
+func (d *CachedDevice) writePage(page *cachePage) error {
+	start := page.index * CachePageSize
+	length := int64(CachePageSize)
+	if start+length > d.size {
+		length = d.size - start
+	}
+	if length > 0 {
+		if _, err := d.file.WriteAt(page.data[:length], start); err != nil {
+			return fmt.Errorf("error al escribir el disco '%s': %w", d.path, err)
+		}
+	}
+	page.dirty = false
+	return nil
+}
+
+// flushLocked vuelca las páginas modificadas en orden de offset. Requiere d.mu tomado.
+func (d *CachedDevice) flushLocked() error {
+	var dirty []*cachePage
+	for _, elem := range d.pages {
+		if page := elem.Value.(*cachePage); page.dirty {
+			dirty = append(dirty, page)
+		}
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	// No sobrescribir un archivo que alguien más cambió
+	if err := d.checkExternalLocked(); err != nil {
+		return err
+	}
+
+	sort.Slice(dirty, func(i, j int) bool { return dirty[i].index < dirty[j].index })
+	for _, page := range dirty {
+		if err := d.writePage(page); err != nil {
+			return err
+		}
+	}
+	return d.refreshState()
+}
+
+// refreshState guarda el estado actual del archivo como el último conocido
+func (d *CachedDevice) refreshState() error {
+	info, err := d.file.Stat()
+	if err != nil {
+		return fmt.Errorf("error al obtener el estado del disco '%s': %w", d.path, err)
+	}
+	d.state = info
+	return nil
+}
+
+// checkExternalLocked compara el archivo en el host con el último estado conocido. Requiere d.mu tomado.
+func (d *CachedDevice) checkExternalLocked() error {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ExternalModificationError{Path: d.path, Reason: "el archivo ya no existe"}
+		}
+		return fmt.Errorf("error al obtener el estado del disco '%s': %w", d.path, err)
+	}
+
+	switch {
+	case !os.SameFile(info, d.state):
+		return &ExternalModificationError{Path: d.path, Reason: "el archivo fue reemplazado"}
+	case info.Size() != d.state.Size():
+		return &ExternalModificationError{Path: d.path, Reason: fmt.Sprintf("el tamaño cambió de %d a %d bytes", d.state.Size(), info.Size())}
+	case !info.ModTime().Equal(d.state.ModTime()):
+		return &ExternalModificationError{Path: d.path, Reason: "la fecha de modificación cambió"}
+	}
+	return nil
+}
+
+// close cierra el archivo descartando la caché, sin escribir cambios pendientes
+func (d *CachedDevice) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pages = make(map[int64]*list.Element)
+	d.lru.Init()
+	return d.file.Close()
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+// Registro de discos abiertos
+
+// Un solo CachedDevice por archivo de disco, compartido por todos los comandos
+var (
+	cachedDevicesMu sync.Mutex
+	cachedDevices   = make(map[string]*CachedDevice)
+)
+
+// acquireCachedDevice devuelve el handle cacheado del disco, abriéndolo si hace falta.
+// Si el archivo cambió desde el último acceso, el handle se descarta y se devuelve un ExternalModificationError;
+// el siguiente intento abrirá el disco de nuevo con su contenido actual.
+func acquireCachedDevice(path string) (*CachedDevice, error) {
+	key := filepath.Clean(path)
+
+	cachedDevicesMu.Lock()
+	defer cachedDevicesMu.Unlock()
+
+	if dev, exists := cachedDevices[key]; exists {
+		dev.mu.Lock()
+		err := dev.checkExternalLocked()
+		if err == nil {
+			dev.refs++
+		}
+		dev.mu.Unlock()
+
+		if err == nil {
+			return dev, nil
+		}
+		delete(cachedDevices, key)
+		dev.close()
+		return nil, err
+	}
+
+	dev, err := openCachedDevice(key)
+	if err != nil {
+		return nil, err
+	}
+	dev.refs = 1
+	cachedDevices[key] = dev
+	return dev, nil
+}
+
+// releaseCachedDevice marca que un comando terminó de usar el handle. El archivo sigue abierto para el siguiente comando.
+func releaseCachedDevice(dev *CachedDevice) {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if dev.refs > 0 {
+		dev.refs--
+	}
+}
+
+// FlushDevices vuelca a sus archivos los cambios pendientes de los discos indicados (los que no están abiertos
+// se ignoran). Se llama al terminar cada comando con los discos que usó, con el lock de cada disco en escritura:
+// con el lock en lectura otro comando podría tener cambios a medias en él (ver analyzer.flushDisks).
+func FlushDevices(paths ...string) error {
+	cachedDevicesMu.Lock()
+	defer cachedDevicesMu.Unlock()
+
+	var errs []error
+	for _, path := range paths {
+		dev, exists := cachedDevices[filepath.Clean(path)]
+		if !exists {
+			continue
+		}
+		if err := dev.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HasDirtyPages indica si el disco está abierto y tiene cambios pendientes de volcar
+func HasDirtyPages(path string) bool {
+	cachedDevicesMu.Lock()
+	dev, exists := cachedDevices[filepath.Clean(path)]
+	cachedDevicesMu.Unlock()
+	return exists && dev.DirtyPages() > 0
+}
+
+// CachedDevicePaths devuelve, ordenados, los paths de los discos abiertos en caché
+func CachedDevicePaths() []string {
+	cachedDevicesMu.Lock()
+	defer cachedDevicesMu.Unlock()
+
+	paths := make([]string, 0, len(cachedDevices))
+	for path := range cachedDevices {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SyncDevices vuelca y sincroniza físicamente los discos indicados (los que no están abiertos se ignoran).
+// Igual que FlushDevices, requiere el lock de cada disco en escritura. Devuelve la cantidad de discos sincronizados.
+func SyncDevices(paths ...string) (int, error) {
+	cachedDevicesMu.Lock()
+	defer cachedDevicesMu.Unlock()
+
+	var errs []error
+	count := 0
+	for _, path := range paths {
+		dev, exists := cachedDevices[filepath.Clean(path)]
+		if !exists {
+			continue
+		}
+		if err := dev.Sync(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		count++
+	}
+	return count, errors.Join(errs...)
+}
+
+// DiscardDevice cierra el handle cacheado de un disco sin escribir sus cambios pendientes.
+// Se usa cuando el archivo va a ser recreado o eliminado (mkdisk, rmdisk).
+func DiscardDevice(path string) {
+	key := filepath.Clean(path)
+
+	cachedDevicesMu.Lock()
+	defer cachedDevicesMu.Unlock()
+
+	if dev, exists := cachedDevices[key]; exists {
+		delete(cachedDevices, key)
+		dev.close()
+	}
+}
@@ -0,0 +1,171 @@
+package structures
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestCachedDevice crea un archivo de disco en cero con las páginas indicadas y lo abre con la caché
+func newTestCachedDevice(t *testing.T, pages int) (*CachedDevice, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	if err := os.WriteFile(path, make([]byte, pages*CachePageSize), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	dev, err := OpenDevice(path)
+	if err != nil {
+		t.Fatalf("OpenDevice: %v", err)
+	}
+	t.Cleanup(func() {
+		CloseDevice(dev)
+		DiscardDevice(path)
+	})
+	return dev.(*CachedDevice), path
+}
+
+// fileBytes lee del archivo, sin pasar por la caché, los bytes en el offset indicado
+func fileBytes(t *testing.T, path string, off int64, length int) []byte {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return content[off : off+int64(length)]
+}
+
+func TestCachedDeviceWriteBack(t *testing.T) {
+	dev, path := newTestCachedDevice(t, 2)
+	data := []byte("superbloque")
+
+	// La escritura queda en la caché: se lee de ahí, pero el archivo no cambia hasta volcarla
+	const off = CachePageSize - 4 // Cruza el límite entre las dos páginas
+	if _, err := dev.WriteAt(data, off); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	read := make([]byte, len(data))
+	if _, err := dev.ReadAt(read, off); err != nil || !bytes.Equal(read, data) {
+		t.Errorf("ReadAt = (%q, %v), se esperaba %q", read, err, data)
+	}
+	if got := fileBytes(t, path, off, len(data)); !bytes.Equal(got, make([]byte, len(data))) {
+		t.Errorf("el archivo cambió antes de volcar la caché: %q", got)
+	}
+	if dirty := dev.DirtyPages(); dirty != 2 {
+		t.Errorf("DirtyPages = %d, se esperaba 2", dirty)
+	}
+	if !HasDirtyPages(path) {
+		t.Error("HasDirtyPages = false con cambios pendientes")
+	}
+
+	if err := FlushDevices(path); err != nil {
+		t.Fatalf("FlushDevices: %v", err)
+	}
+	if got := fileBytes(t, path, off, len(data)); !bytes.Equal(got, data) {
+		t.Errorf("después de volcar, el archivo tiene %q, se esperaba %q", got, data)
+	}
+	if dev.DirtyPages() != 0 || HasDirtyPages(path) {
+		t.Error("quedaron páginas con cambios después de volcar")
+	}
+}
+
+func TestCachedDeviceEvictsCleanPagesFirst(t *testing.T) {
+	dev, path := newTestCachedDevice(t, CacheMaxPages+2)
+	buffer := make([]byte, 1)
+
+	// La página 0 queda con cambios y es la menos usada cuando la caché se llena
+	if _, err := dev.WriteAt([]byte{7}, 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	for page := int64(1); page < CacheMaxPages; page++ {
+		if _, err := dev.ReadAt(buffer, page*CachePageSize); err != nil {
+			t.Fatalf("ReadAt de la página %d: %v", page, err)
+		}
+	}
+	if _, err := dev.ReadAt(buffer, CacheMaxPages*CachePageSize); err != nil {
+		t.Fatalf("ReadAt con la caché llena: %v", err)
+	}
+
+	if len(dev.pages) != CacheMaxPages {
+		t.Errorf("la caché tiene %d páginas, el límite es %d", len(dev.pages), CacheMaxPages)
+	}
+	if _, exists := dev.pages[0]; !exists {
+		t.Error("se desalojó la página con cambios habiendo páginas sin cambios")
+	}
+	if _, exists := dev.pages[1]; exists {
+		t.Error("no se desalojó la página sin cambios menos usada")
+	}
+	if got := fileBytes(t, path, 0, 1); got[0] != 0 {
+		t.Error("desalojar una página sin cambios escribió en el archivo")
+	}
+}
+
+func TestCachedDeviceEvictsDirtyPageWhenFull(t *testing.T) {
+	dev, path := newTestCachedDevice(t, CacheMaxPages+1)
+
+	// Todas las páginas con cambios: para cargar una más hay que escribir la menos usada
+	for page := int64(0); page < CacheMaxPages; page++ {
+		if _, err := dev.WriteAt([]byte{byte(page%250 + 1)}, page*CachePageSize); err != nil {
+			t.Fatalf("WriteAt de la página %d: %v", page, err)
+		}
+	}
+	if _, err := dev.ReadAt(make([]byte, 1), CacheMaxPages*CachePageSize); err != nil {
+		t.Fatalf("ReadAt con la caché llena: %v", err)
+	}
+
+	if _, exists := dev.pages[0]; exists {
+		t.Error("no se desalojó la página menos usada")
+	}
+	if got := fileBytes(t, path, 0, 1); got[0] != 1 {
+		t.Errorf("la página desalojada no se escribió en el archivo: %v", got)
+	}
+	if dirty := dev.DirtyPages(); dirty != CacheMaxPages-1 {
+		t.Errorf("DirtyPages = %d, se esperaba %d", dirty, CacheMaxPages-1)
+	}
+}
+
+func TestCachedDeviceDetectsExternalModification(t *testing.T) {
+	dev, path := newTestCachedDevice(t, 1)
+	if _, err := dev.WriteAt([]byte("cambio"), 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+
+	// Otro programa cambia el tamaño del archivo: volcar la caché lo sobrescribiría
+	if err := os.WriteFile(path, make([]byte, 2*CachePageSize), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	err := FlushDevices(path)
+	var modified *ExternalModificationError
+	if !errors.As(err, &modified) || !errors.Is(err, ErrExternalModification) {
+		t.Fatalf("FlushDevices = %v, se esperaba un ExternalModificationError", err)
+	}
+	if got := fileBytes(t, path, 0, 6); !bytes.Equal(got, make([]byte, 6)) {
+		t.Errorf("se escribió sobre el archivo modificado: %q", got)
+	}
+
+	// El siguiente acceso descarta la caché y el que le sigue abre el disco con su contenido actual
+	if _, err := OpenDevice(path); !errors.Is(err, ErrExternalModification) {
+		t.Errorf("OpenDevice del disco modificado = %v, se esperaba ErrExternalModification", err)
+	}
+	reopened, err := OpenDevice(path)
+	if err != nil {
+		t.Fatalf("OpenDevice después de descartar la caché: %v", err)
+	}
+	defer CloseDevice(reopened)
+	if size, _ := reopened.Size(); size != 2*CachePageSize {
+		t.Errorf("Size = %d, se esperaba el tamaño actual %d", size, 2*CachePageSize)
+	}
+
+	// Un archivo reemplazado también se detecta
+	replacement := path + ".nuevo"
+	if err := os.WriteFile(replacement, make([]byte, 2*CachePageSize), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if _, err := OpenDevice(path); !errors.Is(err, ErrExternalModification) {
+		t.Errorf("OpenDevice del disco reemplazado = %v, se esperaba ErrExternalModification", err)
+	}
+}
@@ -0,0 +1,224 @@
+package structures
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// Las estructuras se escriben y se leen de un disco en memoria y deben quedar iguales
+
+func newTestPartition(status, typ, fit byte, start, size int32, name string) Partition {
+	partition := Partition{Part_start: start, Part_size: size, Part_correlative: 1}
+	partition.Part_status[0] = status
+	partition.Part_type[0] = typ
+	partition.Part_fit[0] = fit
+	copy(partition.Part_name[:], name)
+	copy(partition.Part_id[:], "201A")
+	return partition
+}
+
+func TestMBRRoundTrip(t *testing.T) {
+	dev := NewMemoryDevice(4096)
+	written := MBR{Mbr_size: 4096, Mbr_creation_date: 1700000000, Mbr_disk_signature: 12345}
+	written.Mbr_disk_fit[0] = 'F'
+	written.Mbr_partitions[0] = newTestPartition('1', 'P', 'B', 200, 1024, "Particion1")
+	written.Mbr_partitions[1] = newTestPartition('0', 'E', 'W', 1224, 2048, "Extendida")
+	for i := 2; i < len(written.Mbr_partitions); i++ {
+		written.Mbr_partitions[i] = newTestPartition('N', 'N', 'N', -1, -1, "")
+	}
+
+	if err := written.Serialize(dev); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var read MBR
+	if err := read.Deserialize(dev); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if read != written {
+		t.Errorf("MBR leído distinto del escrito:\n got  %+v\n want %+v", read, written)
+	}
+}
+
+func TestSuperBlockRoundTrip(t *testing.T) {
+	dev := NewMemoryDevice(4096)
+	written := SuperBlock{
+		S_filesystem_type:   2,
+		S_inodes_count:      10,
+		S_blocks_count:      30,
+		S_free_inodes_count: 8,
+		S_free_blocks_count: 28,
+		S_mtime:             1700000000,
+		S_umtime:            1700000000,
+		S_mnt_count:         1,
+		S_magic:             0xEF53,
+		S_inode_size:        88,
+		S_block_size:        64,
+		S_first_ino:         1500,
+		S_first_blo:         2500,
+		S_bm_inode_start:    1068,
+		S_bm_block_start:    1078,
+		S_inode_start:       1108,
+		S_block_start:       1988,
+	}
+
+	const offset = 1000
+	if err := written.Serialize(dev, offset); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var read SuperBlock
+	if err := read.Deserialize(dev, offset); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if read != written {
+		t.Errorf("SuperBlock leído distinto del escrito:\n got  %+v\n want %+v", read, written)
+	}
+}
+
+func TestInodeRoundTrip(t *testing.T) {
+	dev := NewMemoryDevice(4096)
+	written := Inode{I_uid: 1, I_gid: 2, I_size: 130, I_atime: 1700000000, I_ctime: 1700000001, I_mtime: 1700000002}
+	for i := range written.I_block {
+		written.I_block[i] = -1
+	}
+	written.I_block[0], written.I_block[1], written.I_block[12] = 4, 5, 9
+	written.I_type[0] = '1'
+	copy(written.I_perm[:], "664")
+
+	// Dos inodos seguidos: escribir uno no debe pisar al otro
+	const offset = 512
+	inodeSize := int64(88)
+	neighbor := written
+	neighbor.I_uid = 7
+	if err := written.Serialize(dev, offset); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	if err := neighbor.Serialize(dev, offset+inodeSize); err != nil {
+		t.Fatalf("Serialize del vecino: %v", err)
+	}
+
+	var read Inode
+	if err := read.Deserialize(dev, offset); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if read != written {
+		t.Errorf("Inode leído distinto del escrito:\n got  %+v\n want %+v", read, written)
+	}
+	if err := read.Deserialize(dev, offset+inodeSize); err != nil {
+		t.Fatalf("Deserialize del vecino: %v", err)
+	}
+	if read != neighbor {
+		t.Errorf("Inode vecino leído distinto del escrito:\n got  %+v\n want %+v", read, neighbor)
+	}
+}
+
+func TestBlocksRoundTrip(t *testing.T) {
+	dev := NewMemoryDevice(4096)
+
+	folder := FolderBlock{}
+	for i, name := range []string{".", "..", "users.txt", "home"} {
+		copy(folder.B_content[i].B_name[:], name)
+		folder.B_content[i].B_inodo = int32(i)
+	}
+	if err := folder.Serialize(dev, 0); err != nil {
+		t.Fatalf("FolderBlock.Serialize: %v", err)
+	}
+	var readFolder FolderBlock
+	if err := readFolder.Deserialize(dev, 0); err != nil {
+		t.Fatalf("FolderBlock.Deserialize: %v", err)
+	}
+	if readFolder != folder {
+		t.Errorf("FolderBlock leído distinto del escrito:\n got  %+v\n want %+v", readFolder, folder)
+	}
+
+	file := FileBlock{}
+	copy(file.B_content[:], "1,G,root\n1,U,root,root,123\n")
+	if err := file.Serialize(dev, 64); err != nil {
+		t.Fatalf("FileBlock.Serialize: %v", err)
+	}
+	var readFile FileBlock
+	if err := readFile.Deserialize(dev, 64); err != nil {
+		t.Fatalf("FileBlock.Deserialize: %v", err)
+	}
+	if readFile != file {
+		t.Errorf("FileBlock leído distinto del escrito:\n got  %q\n want %q", readFile.B_content, file.B_content)
+	}
+
+	pointers := PointerBlock{}
+	for i := range pointers.P_pointers {
+		pointers.P_pointers[i] = -1
+	}
+	pointers.P_pointers[0], pointers.P_pointers[15] = 20, 35
+	if err := pointers.Serialize(dev, 128); err != nil {
+		t.Fatalf("PointerBlock.Serialize: %v", err)
+	}
+	var readPointers PointerBlock
+	if err := readPointers.Deserialize(dev, 128); err != nil {
+		t.Fatalf("PointerBlock.Deserialize: %v", err)
+	}
+	if readPointers != pointers {
+		t.Errorf("PointerBlock leído distinto del escrito:\n got  %v\n want %v", readPointers.P_pointers, pointers.P_pointers)
+	}
+
+	// Cada bloque ocupa sus 64 bytes: el primero no cambió al escribir los siguientes
+	if err := readFolder.Deserialize(dev, 0); err != nil {
+		t.Fatalf("FolderBlock.Deserialize: %v", err)
+	}
+	if readFolder != folder {
+		t.Errorf("FolderBlock modificado al escribir los bloques siguientes: %+v", readFolder)
+	}
+}
+
+func TestMemoryDeviceBounds(t *testing.T) {
+	dev := NewMemoryDevice(100)
+
+	if _, err := dev.WriteAt(make([]byte, 10), 95); err == nil {
+		t.Error("WriteAt más allá del final: se esperaba un error")
+	}
+	if _, err := dev.WriteAt([]byte{1}, -1); err == nil {
+		t.Error("WriteAt con offset negativo: se esperaba un error")
+	}
+
+	buffer := make([]byte, 10)
+	n, err := dev.ReadAt(buffer, 95)
+	if n != 5 || !errors.Is(err, io.EOF) {
+		t.Errorf("ReadAt al final = (%d, %v), se esperaba (5, EOF)", n, err)
+	}
+	if size, _ := dev.Size(); size != 100 {
+		t.Errorf("Size = %d, se esperaba 100", size)
+	}
+}
+
+func TestMemoryDeviceRegistry(t *testing.T) {
+	const path = MemoryDevicePrefix + "TestRegistro.mia"
+	if _, err := CreateMemoryDevice("/tmp/disco.mia", 100); err == nil {
+		t.Error("CreateMemoryDevice con un path de archivo: se esperaba un error")
+	}
+
+	created, err := CreateMemoryDevice(path, 1024)
+	if err != nil {
+		t.Fatalf("CreateMemoryDevice: %v", err)
+	}
+	defer RemoveMemoryDevice(path)
+
+	dev, err := OpenDevice(path)
+	if err != nil {
+		t.Fatalf("OpenDevice: %v", err)
+	}
+	if dev != BlockDevice(created) {
+		t.Error("OpenDevice no devolvió el disco registrado")
+	}
+	if err := CloseDevice(dev); err != nil {
+		t.Errorf("CloseDevice: %v", err)
+	}
+
+	if err := RemoveMemoryDevice(path); err != nil {
+		t.Fatalf("RemoveMemoryDevice: %v", err)
+	}
+	if MemoryDeviceExists(path) {
+		t.Error("el disco sigue registrado después de eliminarlo")
+	}
+	if _, err := OpenDevice(path); err == nil {
+		t.Error("OpenDevice de un disco eliminado: se esperaba un error")
+	}
+}
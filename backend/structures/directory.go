@@ -0,0 +1,126 @@
+package structures
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DirEntry es una entrada de una carpeta: el nombre y el inodo al que apunta
+type DirEntry struct {
+	Name  string
+	Inode int32
+}
+
+// DirectoryEntries devuelve las entradas de una carpeta, sin "." ni ".."
+func (sb *SuperBlock) DirectoryEntries(dev BlockDevice, inode *Inode) ([]DirEntry, error) {
+	if inode.I_type[0] != '0' {
+		return nil, fmt.Errorf("el inodo no es una carpeta")
+	}
+	blocks, err := sb.FileDataBlocks(dev, inode)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DirEntry
+	for _, blockIndex := range blocks {
+		folderBlock := &FolderBlock{}
+		if err := folderBlock.Deserialize(dev, int64(sb.S_block_start)+int64(blockIndex)*int64(sb.S_block_size)); err != nil {
+			return nil, fmt.Errorf("error leyendo bloque de carpeta %d: %w", blockIndex, err)
+		}
+		for _, content := range folderBlock.B_content {
+			name := strings.TrimRight(string(content.B_name[:]), "\x00")
+			if content.B_inodo < 0 || content.B_inodo >= sb.S_inodes_count || name == "" || name == "." || name == ".." {
+				continue
+			}
+			entries = append(entries, DirEntry{Name: name, Inode: content.B_inodo})
+		}
+	}
+	return entries, nil
+}
+
+// RemoveEntry quita la entrada con el nombre indicado de una carpeta, dejando libre su espacio
+func (sb *SuperBlock) RemoveEntry(dev BlockDevice, parentIndex int32, name string) error {
+	parentOffset := int64(sb.S_inode_start) + int64(parentIndex)*int64(sb.S_inode_size)
+	parentInode := &Inode{}
+	if err := parentInode.Deserialize(dev, parentOffset); err != nil {
+		return fmt.Errorf("error deserializando inodo padre %d: %w", parentIndex, err)
+	}
+	blocks, err := sb.FileDataBlocks(dev, parentInode)
+	if err != nil {
+		return err
+	}
+
+	for _, blockIndex := range blocks {
+		blockOffset := int64(sb.S_block_start) + int64(blockIndex)*int64(sb.S_block_size)
+		folderBlock := &FolderBlock{}
+		if err := folderBlock.Deserialize(dev, blockOffset); err != nil {
+			return fmt.Errorf("error leyendo bloque de carpeta %d: %w", blockIndex, err)
+		}
+		for i, content := range folderBlock.B_content {
+			if content.B_inodo == -1 || strings.TrimRight(string(content.B_name[:]), "\x00") != name {
+				continue
+			}
+			folderBlock.B_content[i] = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+			if err := folderBlock.Serialize(dev, blockOffset); err != nil {
+				return fmt.Errorf("error serializando bloque de carpeta %d: %w", blockIndex, err)
+			}
+			parentInode.I_mtime = float32(time.Now().Unix())
+			return parentInode.Serialize(dev, parentOffset)
+		}
+	}
+	return fmt.Errorf("no se encontró '%s' en la carpeta (inodo %d)", name, parentIndex)
+}
+
+// RemoveTree libera un inodo y, si es una carpeta, todo su contenido: marca como libres en los bitmaps los inodos
+// y los bloques (de datos, de carpeta y de punteros) y actualiza los contadores del superbloque en memoria.
+// No quita la entrada de la carpeta padre (ver RemoveEntry). Devuelve cuántos inodos y bloques liberó.
+func (sb *SuperBlock) RemoveTree(dev BlockDevice, inodeIndex int32) (int32, int32, error) {
+	var freedInodes, freedBlocks int32
+	visited := map[int32]bool{}
+
+	var remove func(index int32) error
+	remove = func(index int32) error {
+		if visited[index] || index <= 0 || index >= sb.S_inodes_count {
+			return nil // Nunca se libera la raíz
+		}
+		visited[index] = true
+
+		inode := &Inode{}
+		if err := inode.Deserialize(dev, int64(sb.S_inode_start)+int64(index)*int64(sb.S_inode_size)); err != nil {
+			return fmt.Errorf("error deserializando inodo %d: %w", index, err)
+		}
+		if inode.I_type[0] == '0' {
+			entries, err := sb.DirectoryEntries(dev, inode)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if err := remove(entry.Inode); err != nil {
+					return err
+				}
+			}
+		}
+
+		layout, err := sb.inodeLayout(dev, inode)
+		if err != nil {
+			return fmt.Errorf("error leyendo los bloques del inodo %d: %w", index, err)
+		}
+		for _, block := range layout {
+			if err := freeDataBlockIfValid(block.Index, sb, dev); err != nil {
+				return err
+			}
+			freedBlocks++
+		}
+
+		if _, err := dev.WriteAt([]byte{'0'}, int64(sb.S_bm_inode_start)+int64(index)); err != nil {
+			return fmt.Errorf("error escribiendo en bitmap para liberar inodo %d: %w", index, err)
+		}
+		sb.S_free_inodes_count++
+		freedInodes++
+		return nil
+	}
+
+	err := remove(inodeIndex)
+	return freedInodes, freedBlocks, err
+}
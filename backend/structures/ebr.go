@@ -1,5 +1,11 @@
 package structures
 
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
 type EBR struct {
 	Part_status  [1]byte  // Estado de la partición
 	Part_fit     [1]byte  // Tipo de ajuste
@@ -8,3 +14,46 @@ type EBR struct {
 	Part_next    int32    // Dirección del siguiente EBR (-1 si no hay otro)
 	Part_name    [16]byte // Nombre de la partición
 }
+
+// Serialize escribe la estructura EBR en el dispositivo en la posición especificada
+func (ebr *EBR) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura EBR en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, ebr)
+	if err != nil {
+		return err
+	}
+
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Deserialize lee la estructura EBR desde el dispositivo en la posición especificada
+func (ebr *EBR) Deserialize(dev BlockDevice, offset int64) error {
+	// Obtener el tamaño de la estructura EBR
+	ebrSize := binary.Size(ebr)
+	if ebrSize <= 0 {
+		return fmt.Errorf("invalid EBR size: %d", ebrSize)
+	}
+
+	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura EBR
+	buffer := make([]byte, ebrSize)
+	_, err := dev.ReadAt(buffer, offset)
+	if err != nil {
+		return err
+	}
+
+	// Deserializar los bytes leídos en la estructura EBR
+	reader := bytes.NewReader(buffer)
+	err = binary.Read(reader, binary.LittleEndian, ebr)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,15 +2,15 @@ package structures
 
 import (
 	utils "backend/utils"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 )
 
 
 // Crear users.txt en nuestro sistema de archivos
-func (sb *SuperBlock) CreateUsersFile(path string) error {
+func (sb *SuperBlock) CreateUsersFile(dev BlockDevice) error {
 
 	// Validar tamaños para evitar división por cero más adelante
 	if sb.S_inode_size <= 0 || sb.S_block_size <= 0 {
@@ -36,13 +36,13 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 	}
 
 	// Serializar el inodo raíz en la posición S_first_ino
-	err := rootInode.Serialize(path, int64(sb.S_first_ino))
+	err := rootInode.Serialize(dev, int64(sb.S_first_ino))
 	if err != nil {
 		return fmt.Errorf("error serializando inodo raíz: %w", err)
 	}
 
 	// Actualizar el bitmap de inodos en el índice calculado
-	err = sb.UpdateBitmapInode(path, rootInodeIndex)
+	err = sb.UpdateBitmapInode(dev, rootInodeIndex)
 	if err != nil {
 		return fmt.Errorf("error actualizando bitmap para inodo raíz (índice %d): %w", rootInodeIndex, err)
 	}
@@ -62,13 +62,13 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 	}
 
 	// Serializar el bloque raíz en la posición S_first_blo
-	err = rootBlock.Serialize(path, int64(sb.S_first_blo))
+	err = rootBlock.Serialize(dev, int64(sb.S_first_blo))
 	if err != nil {
 		return fmt.Errorf("error serializando bloque raíz: %w", err)
 	}
 
 	// Actualizar el bitmap de bloques en el índice calculado
-	err = sb.UpdateBitmapBlock(path, rootBlockIndex)
+	err = sb.UpdateBitmapBlock(dev, rootBlockIndex)
 	if err != nil {
 		return fmt.Errorf("error actualizando bitmap para bloque raíz (índice %d): %w", rootBlockIndex, err)
 	}
@@ -78,18 +78,33 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 	sb.S_first_blo += sb.S_block_size
 
 	// ----------- Creamos /users.txt ---------------------------------------------------------------------------------------------------------------
-	usersText := "1,G,root\n1,U,root,root,123\n"
+	// La contraseña de root se guarda hasheada, con la línea de versión del formato al inicio
+	rootPassword, err := utils.HashPassword("123")
+	if err != nil {
+		return err
+	}
+	usersText := utils.UsersFileVersionLine + "\n1,G,root\n1,U,root,root," + rootPassword + "\n"
+	usersChunks := utils.SplitStringIntoChunks(usersText)
+	if len(usersChunks) > 12 {
+		return fmt.Errorf("el contenido inicial de users.txt no cabe en bloques directos (%d bloques)", len(usersChunks))
+	}
 
 	// Calcular índices para users.txt ANTES de modificar S_first_*
 	usersInodeIndex := (sb.S_first_ino - sb.S_inode_start) / sb.S_inode_size
 	usersBlockIndex := (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
 
+	// Los bloques de users.txt son consecutivos a partir de usersBlockIndex
+	usersBlocks := [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
+	for i := range usersChunks {
+		usersBlocks[i] = usersBlockIndex + int32(i)
+	}
+
 	// Actualizar la entrada en el bloque raíz para que apunte a users.txt
-	if err := rootBlock.Deserialize(path, int64(sb.S_block_start)); err != nil { // Usa offset calculado o conocido
+	if err := rootBlock.Deserialize(dev, int64(sb.S_block_start)); err != nil { // Usa offset calculado o conocido
 		return fmt.Errorf("error re-deserializando bloque raíz para actualizar: %w", err)
 	}
 	rootBlock.B_content[2] = FolderContent{B_name: [12]byte{'u', 's', 'e', 'r', 's', '.', 't', 'x', 't'}, B_inodo: usersInodeIndex} // Apunta al índice calculado
-	if err := rootBlock.Serialize(path, int64(sb.S_block_start)); err != nil {
+	if err := rootBlock.Serialize(dev, int64(sb.S_block_start)); err != nil {
 		return fmt.Errorf("error re-serializando bloque raíz actualizado: %w", err)
 	}
 
@@ -98,18 +113,18 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 		I_uid: 1, I_gid: 1,
 		I_size:  int32(len(usersText)),
 		I_atime: float32(time.Now().Unix()), I_ctime: float32(time.Now().Unix()), I_mtime: float32(time.Now().Unix()),
-		I_block: [15]int32{usersBlockIndex, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, // Usa índice calculado
+		I_block: usersBlocks, // Usa los índices calculados
 		I_type:  [1]byte{'1'}, I_perm: [3]byte{'7', '7', '7'},
 	}
 
 	// Serializar inodo users.txt en S_first_ino
-	err = usersInode.Serialize(path, int64(sb.S_first_ino))
+	err = usersInode.Serialize(dev, int64(sb.S_first_ino))
 	if err != nil {
 		return fmt.Errorf("error serializando inodo users.txt: %w", err)
 	}
 
 	// Actualizar bitmap inodo en usersInodeIndex
-	err = sb.UpdateBitmapInode(path, usersInodeIndex)
+	err = sb.UpdateBitmapInode(dev, usersInodeIndex)
 	if err != nil {
 		return fmt.Errorf("error actualizando bitmap para inodo users.txt (índice %d): %w", usersInodeIndex, err)
 	}
@@ -118,31 +133,33 @@ func (sb *SuperBlock) CreateUsersFile(path string) error {
 	sb.S_free_inodes_count--
 	sb.S_first_ino += sb.S_inode_size
 
-	// Crear el bloque de users.txt
-	usersBlock := &FileBlock{B_content: [64]byte{}}
-	copy(usersBlock.B_content[:], usersText)
+	// Crear los bloques de users.txt
+	for i, chunk := range usersChunks {
+		usersBlock := &FileBlock{B_content: [64]byte{}}
+		copy(usersBlock.B_content[:], chunk)
 
-	// Serializar el bloque de users.txt en S_first_blo
-	err = usersBlock.Serialize(path, int64(sb.S_first_blo))
-	if err != nil {
-		return fmt.Errorf("error serializando bloque users.txt: %w", err)
-	}
+		// Serializar el bloque de users.txt en S_first_blo
+		err = usersBlock.Serialize(dev, int64(sb.S_first_blo))
+		if err != nil {
+			return fmt.Errorf("error serializando bloque users.txt: %w", err)
+		}
 
-	// Actualizar bitmap bloque en usersBlockIndex
-	err = sb.UpdateBitmapBlock(path, usersBlockIndex)
-	if err != nil {
-		return fmt.Errorf("error actualizando bitmap para bloque users.txt (índice %d): %w", usersBlockIndex, err)
-	}
+		// Actualizar bitmap bloque en usersBlocks[i]
+		err = sb.UpdateBitmapBlock(dev, usersBlocks[i])
+		if err != nil {
+			return fmt.Errorf("error actualizando bitmap para bloque users.txt (índice %d): %w", usersBlocks[i], err)
+		}
 
-	// Actualizar el superbloque (parte bloque)
-	sb.S_free_blocks_count--
-	sb.S_first_blo += sb.S_block_size
+		// Actualizar el superbloque (parte bloque)
+		sb.S_free_blocks_count--
+		sb.S_first_blo += sb.S_block_size
+	}
 
 	return nil
 }
 
 // CreateFolder crea una carpeta en el sistema de archivos
-func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parentsDir []string, destDir string) error {
+func (sb *SuperBlock) createFolderInInode(dev BlockDevice, inodeIndex int32, parentsDir []string, destDir string) error {
 	// Validar tamaños para evitar división por cero más adelante
 	if sb.S_inode_size <= 0 || sb.S_block_size <= 0 {
 		return fmt.Errorf("tamaño de inodo o bloque inválido en superbloque: inode=%d, block=%d", sb.S_inode_size, sb.S_block_size)
@@ -151,7 +168,7 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 	// Deserializar inodo padre
 	parentInode := &Inode{}
 	parentInodeOffset := int64(sb.S_inode_start + (inodeIndex * sb.S_inode_size))
-	err := parentInode.Deserialize(path, parentInodeOffset)
+	err := parentInode.Deserialize(dev, parentInodeOffset)
 	if err != nil {
 		return fmt.Errorf("error deserializando inodo padre %d: %w", inodeIndex, err)
 	}
@@ -171,9 +188,9 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 		// Deserializar el bloque del directorio padre
 		parentFolderBlock := &FolderBlock{}
 		parentFolderBlockOffset := int64(sb.S_block_start + (blockIndexInParent * sb.S_block_size))
-		err := parentFolderBlock.Deserialize(path, parentFolderBlockOffset)
+		err := parentFolderBlock.Deserialize(dev, parentFolderBlockOffset)
 		if err != nil {
-			fmt.Printf("Advertencia: error deserializando bloque de directorio %d del padre %d: %v\n", blockIndexInParent, inodeIndex, err)
+			logger.Warn("Error deserializando bloque de directorio del padre", "block", blockIndexInParent, "parent_inode", inodeIndex, "error", err)
 			continue // Intentar con el siguiente bloque del padre
 		}
 
@@ -187,7 +204,7 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 					contentName := strings.TrimRight(string(content.B_name[:]), "\x00 ")
 					if strings.EqualFold(contentName, targetSubDir) {
 						// Encontrado el siguiente subdirectorio, llamar recursivamente
-						err = sb.createFolderInInode(path, content.B_inodo, remainingPath, destDir)
+						err = sb.createFolderInInode(dev, content.B_inodo, remainingPath, destDir)
 						// Si la llamada recursiva tuvo éxito (o falló definitivamente), retornar
 						return err
 					}
@@ -219,7 +236,7 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 			parentFolderBlock.B_content[slotIndex].B_inodo = newFolderInodeIndex
 			copy(parentFolderBlock.B_content[slotIndex].B_name[:], destDir)
 			// Serializar el bloque del directorio padre MODIFICADO
-			err = parentFolderBlock.Serialize(path, parentFolderBlockOffset)
+			err = parentFolderBlock.Serialize(dev, parentFolderBlockOffset)
 			if err != nil {
 				return fmt.Errorf("error serializando bloque padre %d actualizado: %w", blockIndexInParent, err)
 			}
@@ -236,14 +253,14 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 				I_type:  [1]byte{'0'},                                                                           // Tipo Directorio
 				I_perm:  [3]byte{'7', '7', '5'},                                                                 // Permisos (ej: rwxrwxr-x) TODO: Usar umask o permisos del padre?
 			}
-			err = folderInode.Serialize(path, int64(sb.S_first_ino))
+			err = folderInode.Serialize(dev, int64(sb.S_first_ino))
 			if err != nil {
 				// Aquí podríamos intentar revertir el cambio en el bloque padre si la creación falla.
 				return fmt.Errorf("error serializando inodo de nueva carpeta '%s': %w", destDir, err)
 			}
 
 			// Actualizar bitmap de inodos y Superbloque (parte inodo)
-			err = sb.UpdateBitmapInode(path, newFolderInodeIndex)
+			err = sb.UpdateBitmapInode(dev, newFolderInodeIndex)
 			if err != nil {
 				return fmt.Errorf("error actualizando bitmap para inodo %d ('%s'): %w", newFolderInodeIndex, destDir, err)
 			}
@@ -259,14 +276,14 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 					{B_name: [12]byte{'-'}, B_inodo: -1},
 				},
 			}
-			err = folderBlock.Serialize(path, int64(sb.S_first_blo))
+			err = folderBlock.Serialize(dev, int64(sb.S_first_blo))
 			if err != nil {
 				// Revertir asignación de inodo sería complejo, mejor fallar.
 				return fmt.Errorf("error serializando bloque para nueva carpeta '%s': %w", destDir, err)
 			}
 
 			// Actualizar bitmap de bloques y Superbloque (parte bloque)
-			err = sb.UpdateBitmapBlock(path, newFolderBlockIndex)
+			err = sb.UpdateBitmapBlock(dev, newFolderBlockIndex)
 			if err != nil {
 				return fmt.Errorf("error actualizando bitmap para bloque %d ('%s'): %w", newFolderBlockIndex, destDir, err)
 			}
@@ -277,6 +294,17 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 		}
 	}
 
+	// Si el padre está lleno pero le quedan punteros directos libres, agregarle un bloque de carpeta y reintentar
+	if len(parentsDir) == 0 {
+		expanded, err := sb.addFolderBlock(dev, inodeIndex, parentInode)
+		if err != nil {
+			return err
+		}
+		if expanded {
+			return sb.createFolderInInode(dev, inodeIndex, parentsDir, destDir)
+		}
+	}
+
 	// Si se recorrieron todos los bloques del padre y no se encontró espacio O no se encontró el subdirectorio intermedio
 	if len(parentsDir) != 0 {
 		return fmt.Errorf("no se encontró el subdirectorio intermedio '%s' en la ruta", parentsDir[0])
@@ -285,14 +313,50 @@ func (sb *SuperBlock) createFolderInInode(path string, inodeIndex int32, parents
 	}
 }
 
+// addFolderBlock asigna un bloque de carpeta vacío en el primer puntero directo libre del inodo.
+// Devuelve false si el directorio ya usa sus 12 punteros directos.
+func (sb *SuperBlock) addFolderBlock(dev BlockDevice, inodeIndex int32, inode *Inode) (bool, error) {
+	for i := 0; i < 12; i++ {
+		if inode.I_block[i] != -1 {
+			continue
+		}
+		if sb.S_free_blocks_count <= 0 {
+			return false, errors.New("no hay bloques libres para ampliar el directorio")
+		}
+
+		newBlockIndex := (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
+		folderBlock := &FolderBlock{}
+		for j := range folderBlock.B_content {
+			folderBlock.B_content[j] = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+		}
+		if err := folderBlock.Serialize(dev, int64(sb.S_first_blo)); err != nil {
+			return false, fmt.Errorf("error serializando nuevo bloque de carpeta %d: %w", newBlockIndex, err)
+		}
+		if err := sb.UpdateBitmapBlock(dev, newBlockIndex); err != nil {
+			return false, fmt.Errorf("error actualizando bitmap para bloque %d: %w", newBlockIndex, err)
+		}
+		sb.S_free_blocks_count--
+		sb.S_first_blo += sb.S_block_size
+
+		inode.I_block[i] = newBlockIndex
+		inode.I_mtime = float32(time.Now().Unix())
+		if err := inode.Serialize(dev, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size))); err != nil {
+			return false, fmt.Errorf("error serializando inodo de carpeta %d: %w", inodeIndex, err)
+		}
+		logger.Debug("Directorio ampliado", "inode", inodeIndex, "block", newBlockIndex)
+		return true, nil
+	}
+	return false, nil
+}
+
 // COSITAS PARA LOS GRUPOS
 
 // Actualiza el bitmap de bloques y el contador de bloques libres
-func FreeInodeBlocks(inode *Inode, sb *SuperBlock, partitionPath string) error {
-	fmt.Printf("Liberando bloques para inodo con tamaño %d...\n", inode.I_size)
+func FreeInodeBlocks(inode *Inode, sb *SuperBlock, dev BlockDevice) error {
+	logger.Debug("Liberando bloques del inodo", "size", inode.I_size)
 	if inode.I_size == 0 { // Si el tamaño es 0
 		// Podemos verificar I_block por si acaso, pero es probable que estén en -1
-		fmt.Println("Tamaño de inodo es 0, no se liberan bloques.")
+		logger.Debug("Tamaño de inodo es 0, no se liberan bloques.")
 
 		for i := range inode.I_block {
 			inode.I_block[i] = -1
@@ -302,26 +366,26 @@ func FreeInodeBlocks(inode *Inode, sb *SuperBlock, partitionPath string) error {
 
 	// Liberar bloques directos
 	for i := 0; i < 12; i++ {
-		if err := freeDataBlockIfValid(inode.I_block[i], sb, partitionPath); err != nil {
-			fmt.Printf("Error liberando bloque directo %d: %v\n", inode.I_block[i], err)
+		if err := freeDataBlockIfValid(inode.I_block[i], sb, dev); err != nil {
+			logger.Debug("Error liberando bloque directo", "block", inode.I_block[i], "error", err)
 		}
 		inode.I_block[i] = -1 // Marcar como libre
 	}
 	// Liberar bloques simples
-	if err := freeIndirectBlocksRecursive(1, inode.I_block[12], sb, partitionPath); err != nil {
-		fmt.Printf("Error liberando indirección simple (nivel 1 desde %d): %v\n", inode.I_block[12], err)
+	if err := freeIndirectBlocksRecursive(1, inode.I_block[12], sb, dev); err != nil {
+		logger.Debug("Error liberando indirección simple", "block", inode.I_block[12], "error", err)
 	}
 	inode.I_block[12] = -1
 
 	// Liberar bloques dobles
-	if err := freeIndirectBlocksRecursive(2, inode.I_block[13], sb, partitionPath); err != nil {
-		fmt.Printf("Error liberando indirección doble (nivel 2 desde %d): %v\n", inode.I_block[13], err)
+	if err := freeIndirectBlocksRecursive(2, inode.I_block[13], sb, dev); err != nil {
+		logger.Debug("Error liberando indirección doble", "block", inode.I_block[13], "error", err)
 	}
 	inode.I_block[13] = -1
 
 	// Liberar bloques triples
-	if err := freeIndirectBlocksRecursive(3, inode.I_block[14], sb, partitionPath); err != nil {
-		fmt.Printf("Error liberando indirección triple (nivel 3 desde %d): %v\n", inode.I_block[14], err)
+	if err := freeIndirectBlocksRecursive(3, inode.I_block[14], sb, dev); err != nil {
+		logger.Debug("Error liberando indirección triple", "block", inode.I_block[14], "error", err)
 	}
 	inode.I_block[14] = -1
 
@@ -329,7 +393,7 @@ func FreeInodeBlocks(inode *Inode, sb *SuperBlock, partitionPath string) error {
 }
 
 // Libera los bloques de datos/punteros inferiores y LUEGO el bloque de punteros actual
-func freeIndirectBlocksRecursive(level int, blockPtr int32, sb *SuperBlock, partitionPath string) error {
+func freeIndirectBlocksRecursive(level int, blockPtr int32, sb *SuperBlock, dev BlockDevice) error {
 	if level < 1 || level > 3 || blockPtr == -1 || blockPtr >= sb.S_blocks_count {
 		return nil
 	}
@@ -337,48 +401,37 @@ func freeIndirectBlocksRecursive(level int, blockPtr int32, sb *SuperBlock, part
 	// Deserializar el bloque de punteros de este nivel
 	ptrBlock := &PointerBlock{}
 	ptrOffset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-	if err := ptrBlock.Deserialize(partitionPath, ptrOffset); err != nil {
-		fmt.Printf("Advertencia: no se pudo leer bloque de punteros Nivel %d (%d): %v. Intentando liberar bloque %d de todas formas.\n", level, blockPtr, err, blockPtr)
-		return freeDataBlockIfValid(blockPtr, sb, partitionPath) // Intentar liberar el ptrBlock mismo
+	if err := ptrBlock.Deserialize(dev, ptrOffset); err != nil {
+		logger.Warn("No se pudo leer el bloque de punteros; se libera de todas formas", "level", level, "block", blockPtr, "error", err)
+		return freeDataBlockIfValid(blockPtr, sb, dev) // Intentar liberar el ptrBlock mismo
 	}
 
 	for _, nextPtr := range ptrBlock.P_pointers {
 		var errRec error
 		if level == 1 { 
-			errRec = freeDataBlockIfValid(nextPtr, sb, partitionPath)
+			errRec = freeDataBlockIfValid(nextPtr, sb, dev)
 		} else { // Niveles superiores, nextPtr apunta a OTRO bloque de punteros
-			errRec = freeIndirectBlocksRecursive(level-1, nextPtr, sb, partitionPath)
+			errRec = freeIndirectBlocksRecursive(level-1, nextPtr, sb, dev)
 		}
 		if errRec != nil {
 			// Loguear pero continuar para intentar liberar el resto
-			fmt.Printf("Error durante liberación recursiva (nivel %d->%d, desde %d a %d): %v\n", level, level-1, blockPtr, nextPtr, errRec)
+			logger.Debug("Error durante la liberación recursiva", "level", level, "block", blockPtr, "next_block", nextPtr, "error", errRec)
 		}
 	}
 
 	// Después de liberar/procesar todos los punteros internos, liberar el bloque de punteros actual
-	fmt.Printf("Liberando bloque de punteros Nivel %d (índice %d)\n", level, blockPtr)
-	return freeDataBlockIfValid(blockPtr, sb, partitionPath)
+	logger.Debug("Liberando bloque de punteros", "level", level, "block", blockPtr)
+	return freeDataBlockIfValid(blockPtr, sb, dev)
 }
 
-func freeDataBlockIfValid(blockIndex int32, sb *SuperBlock, partitionPath string) error {
+func freeDataBlockIfValid(blockIndex int32, sb *SuperBlock, dev BlockDevice) error {
 	if blockIndex == -1 || blockIndex < 0 || blockIndex >= sb.S_blocks_count {
 		return nil // Índice inválido o no usado, nada que hacer
 	}
 
 	// Actualizar bitmap
 	bitmapOffset := int64(sb.S_bm_block_start) + int64(blockIndex)
-	file, err := os.OpenFile(partitionPath, os.O_WRONLY, 0644) // Solo escritura
-	if err != nil {
-		return fmt.Errorf("error abriendo disco para liberar bloque %d: %w", blockIndex, err)
-	}
-	defer file.Close()
-
-	_, err = file.Seek(bitmapOffset, 0)
-	if err != nil {
-		return fmt.Errorf("error buscando bitmap para liberar bloque %d: %w", blockIndex, err)
-	}
-
-	_, err = file.Write([]byte{'0'}) // Marcar como libre
+	_, err := dev.WriteAt([]byte{'0'}, bitmapOffset) // Marcar como libre
 	if err != nil {
 		return fmt.Errorf("error escribiendo en bitmap para liberar bloque %d: %w", blockIndex, err)
 	}
@@ -386,6 +439,6 @@ func freeDataBlockIfValid(blockIndex int32, sb *SuperBlock, partitionPath string
 	// Actualizar contador de libres en Superbloque (EN MEMORIA)
 	sb.S_free_blocks_count++
 
-	fmt.Printf("Bloque %d marcado como libre.\n", blockIndex)
+	logger.Debug("Bloque marcado como libre", "block", blockIndex)
 	return nil
 }
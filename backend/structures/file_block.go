@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
 )
 
 type FileBlock struct {
@@ -12,22 +11,17 @@ type FileBlock struct {
 	// Total: 64 bytes
 }
 
-// Serialize escribe la estructura FileBlock en un archivo binario en la posición especificada
-func (fb *FileBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+// Serialize escribe la estructura FileBlock en el dispositivo en la posición especificada
+func (fb *FileBlock) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura FileBlock en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, fb)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura FileBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, fb)
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
 	if err != nil {
 		return err
 	}
@@ -35,20 +29,8 @@ func (fb *FileBlock) Serialize(path string, offset int64) error {
 	return nil
 }
 
-// Deserialize lee la estructura FileBlock desde un archivo binario en la posición especificada
-func (fb *FileBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
+// Deserialize lee la estructura FileBlock desde el dispositivo en la posición especificada
+func (fb *FileBlock) Deserialize(dev BlockDevice, offset int64) error {
 	// Obtener el tamaño de la estructura FileBlock
 	fbSize := binary.Size(fb)
 	if fbSize <= 0 {
@@ -57,7 +39,7 @@ func (fb *FileBlock) Deserialize(path string, offset int64) error {
 
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura FileBlock
 	buffer := make([]byte, fbSize)
-	_, err = file.Read(buffer)
+	_, err := dev.ReadAt(buffer, offset)
 	if err != nil {
 		return err
 	}
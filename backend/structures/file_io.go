@@ -0,0 +1,48 @@
+package structures
+
+import (
+	"fmt"
+)
+
+// FileDataBlocks devuelve los bloques de datos asignados a un inodo en orden lógico, sin los bloques de punteros.
+// Incluye los bloques asignados más allá de I_size (archivos preasignados).
+func (sb *SuperBlock) FileDataBlocks(dev BlockDevice, inode *Inode) ([]int32, error) {
+	layout, err := sb.inodeLayout(dev, inode)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []int32
+	for _, block := range layout {
+		if !block.Pointer {
+			blocks = append(blocks, block.Index)
+		}
+	}
+	return blocks, nil
+}
+
+// WriteFileAt escribe data dentro del archivo a partir de offset, usando solo los bloques que ya tiene asignados.
+// No modifica I_size ni asigna bloques: el llamador actualiza el inodo.
+func (sb *SuperBlock) WriteFileAt(dev BlockDevice, inode *Inode, data []byte, offset int64) error {
+	blocks, err := sb.FileDataBlocks(dev, inode)
+	if err != nil {
+		return err
+	}
+	blockSize := int64(sb.S_block_size)
+	if capacity := int64(len(blocks)) * blockSize; offset < 0 || offset+int64(len(data)) > capacity {
+		return fmt.Errorf("escritura fuera del espacio asignado al archivo (%d bytes desde %d, capacidad %d)", len(data), offset, capacity)
+	}
+
+	for written := 0; written < len(data); {
+		pos := offset + int64(written)
+		block := blocks[pos/blockSize]
+		start := pos % blockSize
+		count := min(int64(len(data)-written), blockSize-start)
+
+		blockOffset := int64(sb.S_block_start) + int64(block)*blockSize + start
+		if _, err := dev.WriteAt(data[written:written+int(count)], blockOffset); err != nil {
+			return fmt.Errorf("error escribiendo bloque %d: %w", block, err)
+		}
+		written += int(count)
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
 )
 
 type FolderBlock struct {
@@ -18,22 +17,17 @@ type FolderContent struct {
 	// Total: 16 bytes
 }
 
-// Serialize escribe la estructura FolderBlock en un archivo binario en la posición especificada
-func (fb *FolderBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+// Serialize escribe la estructura FolderBlock en el dispositivo en la posición especificada
+func (fb *FolderBlock) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura FolderBlock en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, fb)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura FolderBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, fb)
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
 	if err != nil {
 		return err
 	}
@@ -41,20 +35,8 @@ func (fb *FolderBlock) Serialize(path string, offset int64) error {
 	return nil
 }
 
-// Deserialize lee la estructura FolderBlock desde un archivo binario en la posición especificada
-func (fb *FolderBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
+// Deserialize lee la estructura FolderBlock desde el dispositivo en la posición especificada
+func (fb *FolderBlock) Deserialize(dev BlockDevice, offset int64) error {
 	// Obtener el tamaño de la estructura FolderBlock
 	fbSize := binary.Size(fb)
 	if fbSize <= 0 {
@@ -63,7 +45,7 @@ func (fb *FolderBlock) Deserialize(path string, offset int64) error {
 
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura FolderBlock
 	buffer := make([]byte, fbSize)
-	bytesRead, err := file.Read(buffer)
+	bytesRead, err := dev.ReadAt(buffer, offset)
 	if err != nil {
 		return err
 	}
@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 )
@@ -23,22 +22,17 @@ type Inode struct {
 	// Total: 88 bytes
 }
 
-// Serialize escribe la estructura Inode en un archivo binario en la posición especificada
-func (inode *Inode) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+// Serialize escribe la estructura Inode en el dispositivo en la posición especificada
+func (inode *Inode) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura Inode en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, inode)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura Inode directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, inode)
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
 	if err != nil {
 		return err
 	}
@@ -46,20 +40,8 @@ func (inode *Inode) Serialize(path string, offset int64) error {
 	return nil
 }
 
-// Deserialize lee la estructura Inode desde un archivo binario en la posición especificada
-func (inode *Inode) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
+// Deserialize lee la estructura Inode desde el dispositivo en la posición especificada
+func (inode *Inode) Deserialize(dev BlockDevice, offset int64) error {
 	// Obtener el tamaño de la estructura Inode
 	inodeSize := binary.Size(inode)
 	if inodeSize <= 0 {
@@ -68,7 +50,7 @@ func (inode *Inode) Deserialize(path string, offset int64) error {
 
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura Inode
 	buffer := make([]byte, inodeSize)
-	_, err = file.Read(buffer)
+	_, err := dev.ReadAt(buffer, offset)
 	if err != nil {
 		return err
 	}
@@ -102,8 +84,8 @@ func (inode *Inode) Print() {
 
 // FUNCIÓN PARA BUSCAR UN ARCHIVO---------------------------------------------------------------------------------------
 // FUNCIÓN PARA BUSCAR UN ARCHIVO---------------------------------------------------------------------------------------
-func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inode, error) {
-	fmt.Printf("Buscando inodo para path: %s\n", path)
+func FindInodeByPath(sb *SuperBlock, dev BlockDevice, path string) (int32, *Inode, error) {
+	logger.Debug("Buscando inodo", "path", path)
 
 	components := strings.Split(path, "/")
 	var cleanComponents []string
@@ -113,12 +95,10 @@ func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inod
 		}
 	}
 
-	fmt.Printf("Componentes del path: %v\n", cleanComponents)
-
 	// Si es un path vacío o solo la raíz (/), devolver el inodo raíz
 	if len(cleanComponents) == 0 {
 		rootInode := &Inode{}
-		if err := rootInode.Deserialize(diskPath, int64(sb.S_inode_start)); err != nil {
+		if err := rootInode.Deserialize(dev, int64(sb.S_inode_start)); err != nil {
 			return -1, nil, fmt.Errorf("error al leer inodo raíz: %v", err)
 		}
 		return 0, rootInode, nil
@@ -128,16 +108,14 @@ func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inod
 
 	// Para cada componente del path, buscar en el directorio correspondiente
 	for i, component := range cleanComponents {
-		fmt.Printf("Buscando componente %d: %s (en inodo %d)\n", i, component, currentInodeNum)
+		logger.Debug("Buscando componente", "index", i, "component", component, "inode", currentInodeNum)
 
 		currentInode := &Inode{}
 		offset := int64(sb.S_inode_start + currentInodeNum*sb.S_inode_size)
-		if err := currentInode.Deserialize(diskPath, offset); err != nil {
+		if err := currentInode.Deserialize(dev, offset); err != nil {
 			return -1, nil, err
 		}
 
-		fmt.Printf("Tipo de inodo actual: %s\n", string(currentInode.I_type[:]))
-
 		// Verificar que el inodo actual es un directorio (excepto para el último componente)
 		if i < len(cleanComponents)-1 && currentInode.I_type[0] != '0' {
 			return -1, nil, fmt.Errorf("'%s' no es un directorio", component)
@@ -149,26 +127,15 @@ func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inod
 			if blockPtr == -1 {
 				continue
 			}
-			// Debugeando
-			fmt.Printf("Examinando bloque %d (puntero %d) del inodo %d\n",
-				blockIndex, blockPtr, currentInodeNum)
+			logger.Debug("Examinando bloque", "index", blockIndex, "block", blockPtr, "inode", currentInodeNum)
 
 			// Leer el bloque de carpeta
 			folderBlock := &FolderBlock{}
 			blockOffset := int64(sb.S_block_start + blockPtr*sb.S_block_size)
-			if err := folderBlock.Deserialize(diskPath, blockOffset); err != nil {
+			if err := folderBlock.Deserialize(dev, blockOffset); err != nil {
 				return -1, nil, fmt.Errorf("error al leer bloque %d: %v", blockPtr, err)
 			}
 
-			// Imprimir el contenido del bloque para depuración
-			fmt.Println("Contenido del bloque:")
-			for entryIndex, entry := range folderBlock.B_content {
-				if entry.B_inodo != -1 {
-					name := strings.TrimRight(string(entry.B_name[:]), "\x00")
-					fmt.Printf("  [%d] %q -> inodo %d\n", entryIndex, name, entry.B_inodo)
-				}
-			}
-
 			// Buscar el componente actual en el bloque de carpeta
 			for _, entry := range folderBlock.B_content {
 				if entry.B_inodo == -1 {
@@ -177,13 +144,11 @@ func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inod
 
 				// Convertir el nombre del archivo a una cadena y eliminar los caracteres nulos
 				name := strings.TrimRight(string(entry.B_name[:]), "\x00")
-				fmt.Printf("Comparando '%s' con '%s'\n", name, component)
-
 				// Si el nombre del archivo coincide con el componente actual, actualizar el inodo actual
 				if name == component {
 					currentInodeNum = entry.B_inodo
 					found = true
-					fmt.Printf("¡Encontrado! El inodo para '%s' es %d\n", component, currentInodeNum)
+					logger.Debug("Componente encontrado", "component", component, "inode", currentInodeNum)
 					break
 				}
 			}
@@ -202,23 +167,18 @@ func FindInodeByPath(sb *SuperBlock, diskPath string, path string) (int32, *Inod
 	// Leer el inodo final
 	targetInode := &Inode{}
 	offset := int64(sb.S_inode_start + currentInodeNum*sb.S_inode_size)
-	fmt.Printf("Obteniendo inodo final %d en offset %d\n", currentInodeNum, offset)
-
-	// Debugeando
-	if err := targetInode.Deserialize(diskPath, offset); err != nil {
+	if err := targetInode.Deserialize(dev, offset); err != nil {
 		return -1, nil, fmt.Errorf("error al leer inodo final %d: %v", currentInodeNum, err)
 	}
 
-	// Debugeando
-	fmt.Printf("Inodo encontrado - tipo: %s, tamaño: %d\n",
-		string(targetInode.I_type[:]), targetInode.I_size)
+	logger.Debug("Inodo encontrado", "inode", currentInodeNum, "type", string(targetInode.I_type[:]), "size", targetInode.I_size)
 
 	return currentInodeNum, targetInode, nil
 }
 
 
 // ReadFileContent lee el contenido completo de un archivo, manejando indirección.
-func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, error) {
+func ReadFileContent(sb *SuperBlock, dev BlockDevice, inode *Inode) (string, error) {
 	if inode.I_type[0] != '1' {
 		return "", fmt.Errorf("inodo %d no es un archivo", -1)
 	}
@@ -249,7 +209,7 @@ func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, err
 
 		fileBlock := &FileBlock{}
 		blockOffset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-		if err := fileBlock.Deserialize(diskPath, blockOffset); err != nil {
+		if err := fileBlock.Deserialize(dev, blockOffset); err != nil {
 			return fmt.Errorf("error leyendo bloque de datos %d: %w", blockPtr, err)
 		}
 
@@ -267,7 +227,7 @@ func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, err
 	}
 
 	// Bloques Directos (0-11)
-	fmt.Println("Leyendo bloques directos...")
+	logger.Debug("Leyendo bloques directos...")
 	for i := 0; i < 12; i++ {
 		if err := readBlock(inode.I_block[i]); err != nil {
 			return "", err
@@ -282,8 +242,8 @@ func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, err
 
 	//Indirecto Simple (12)
 	if inode.I_block[12] != -1 {
-		fmt.Println("Leyendo bloques desde Indirecto Simple...")
-		err := readIndirectBlocksRecursive(1, inode.I_block[12], sb, diskPath, &content, inode.I_size, readBlock)
+		logger.Debug("Leyendo bloques desde Indirecto Simple...")
+		err := readIndirectBlocksRecursive(1, inode.I_block[12], sb, dev, &content, inode.I_size, readBlock)
 		if err != nil {
 			return "", fmt.Errorf("error en indirección simple: %w", err)
 		}
@@ -294,8 +254,8 @@ func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, err
 
 	// Indirecto Doble (13)
 	if inode.I_block[13] != -1 {
-		fmt.Println("Leyendo bloques desde Indirecto Doble...")
-		err := readIndirectBlocksRecursive(2, inode.I_block[13], sb, diskPath, &content, inode.I_size, readBlock)
+		logger.Debug("Leyendo bloques desde Indirecto Doble...")
+		err := readIndirectBlocksRecursive(2, inode.I_block[13], sb, dev, &content, inode.I_size, readBlock)
 		if err != nil {
 			return "", fmt.Errorf("error en indirección doble: %w", err)
 		}
@@ -306,8 +266,8 @@ func ReadFileContent(sb *SuperBlock, diskPath string, inode *Inode) (string, err
 
 	// Indirecto Triple (14)
 	if inode.I_block[14] != -1 {
-		fmt.Println("Leyendo bloques desde Indirecto Triple...")
-		err := readIndirectBlocksRecursive(3, inode.I_block[14], sb, diskPath, &content, inode.I_size, readBlock)
+		logger.Debug("Leyendo bloques desde Indirecto Triple...")
+		err := readIndirectBlocksRecursive(3, inode.I_block[14], sb, dev, &content, inode.I_size, readBlock)
 		if err != nil {
 			return "", fmt.Errorf("error en indirección triple: %w", err)
 		}
@@ -327,7 +287,7 @@ func readIndirectBlocksRecursive(
 	level int, // Nivel de indirección actual (1, 2, 3)
 	blockPtr int32, // Puntero al bloque de punteros de este nivel
 	sb *SuperBlock,
-	diskPath string,
+	dev BlockDevice,
 	content *bytes.Buffer, // Usar buffer para eficiencia
 	sizeLimit int32,
 	readBlockFunc func(int32) error, // Función para leer un bloque de DATOS
@@ -345,9 +305,9 @@ func readIndirectBlocksRecursive(
 	// Deserializar el bloque de punteros de este nivel
 	ptrBlock := &PointerBlock{}
 	ptrOffset := int64(sb.S_block_start) + int64(blockPtr)*int64(sb.S_block_size)
-	if err := ptrBlock.Deserialize(diskPath, ptrOffset); err != nil {
+	if err := ptrBlock.Deserialize(dev, ptrOffset); err != nil {
 		// Loguear error pero intentar continuar si es posible? O retornar error?
-		fmt.Printf("Advertencia: error al leer bloque de punteros nivel %d (índice %d): %v\n", level, blockPtr, err)
+		logger.Warn("Error al leer bloque de punteros", "level", level, "block", blockPtr, "error", err)
 		return nil // Podría ser un error fatal, pero intentamos ser robustos
 	}
 
@@ -357,7 +317,7 @@ func readIndirectBlocksRecursive(
 			continue
 		}
 		if nextPtr < 0 || nextPtr >= sb.S_blocks_count {
-			fmt.Printf("Advertencia: puntero inválido %d encontrado en bloque de punteros nivel %d (índice %d)\n", nextPtr, level, blockPtr)
+			logger.Warn("Puntero inválido en bloque de punteros", "pointer", nextPtr, "level", level, "block", blockPtr)
 			continue
 		}
 
@@ -368,7 +328,7 @@ func readIndirectBlocksRecursive(
 			}
 		} else {
 			// Si no es el último nivel, llamar recursivamente para el siguiente nivel inferior
-			err := readIndirectBlocksRecursive(level-1, nextPtr, sb, diskPath, content, sizeLimit, readBlockFunc)
+			err := readIndirectBlocksRecursive(level-1, nextPtr, sb, dev, content, sizeLimit, readBlockFunc)
 			if err != nil {
 				return err // Propagar error de niveles inferiores
 			}
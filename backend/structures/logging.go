@@ -0,0 +1,76 @@
+package structures
+
+import (
+	logging "backend/logging"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var logger = logging.Logger(logging.Structures)
+
+// Las estructuras implementan slog.LogValuer para registrarlas como atributos, por ejemplo
+// logger.Debug("MBR creado", "mbr", mbr). Las líneas de este paquete no llevan request_id porque las funciones
+// de las estructuras no reciben el contexto de la petición.
+
+// LogValue devuelve los campos del MBR y sus particiones como un grupo
+func (mbr *MBR) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("size", int(mbr.Mbr_size)),
+		slog.String("creation_date", time.Unix(int64(mbr.Mbr_creation_date), 0).Format(time.RFC3339)),
+		slog.Int("signature", int(mbr.Mbr_disk_signature)),
+		slog.String("fit", string(mbr.Mbr_disk_fit[:])),
+	}
+	for i := range mbr.Mbr_partitions {
+		attrs = append(attrs, slog.Any("p"+string(rune('1'+i)), &mbr.Mbr_partitions[i]))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue devuelve los campos de la partición como un grupo
+func (p *Partition) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("status", string(p.Part_status[:])),
+		slog.String("type", string(p.Part_type[:])),
+		slog.String("fit", string(p.Part_fit[:])),
+		slog.Int("start", int(p.Part_start)),
+		slog.Int("size", int(p.Part_size)),
+		slog.String("name", strings.TrimRight(string(p.Part_name[:]), "\x00")),
+		slog.Int("correlative", int(p.Part_correlative)),
+		slog.String("id", strings.TrimRight(string(p.Part_id[:]), "\x00")),
+	)
+}
+
+// LogValue devuelve los campos del SuperBlock como un grupo
+func (sb *SuperBlock) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("filesystem_type", int(sb.S_filesystem_type)),
+		slog.Int("inodes_count", int(sb.S_inodes_count)),
+		slog.Int("blocks_count", int(sb.S_blocks_count)),
+		slog.Int("free_inodes_count", int(sb.S_free_inodes_count)),
+		slog.Int("free_blocks_count", int(sb.S_free_blocks_count)),
+		slog.String("mount_time", time.Unix(int64(sb.S_mtime), 0).Format(time.RFC3339)),
+		slog.Int("mount_count", int(sb.S_mnt_count)),
+		slog.Int("inode_size", int(sb.S_inode_size)),
+		slog.Int("block_size", int(sb.S_block_size)),
+		slog.Int("first_inode", int(sb.S_first_ino)),
+		slog.Int("first_block", int(sb.S_first_blo)),
+		slog.Int("bm_inode_start", int(sb.S_bm_inode_start)),
+		slog.Int("bm_block_start", int(sb.S_bm_block_start)),
+		slog.Int("inode_start", int(sb.S_inode_start)),
+		slog.Int("block_start", int(sb.S_block_start)),
+	)
+}
+
+// LogValue devuelve los campos del inodo como un grupo
+func (inode *Inode) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("uid", int(inode.I_uid)),
+		slog.Int("gid", int(inode.I_gid)),
+		slog.Int("size", int(inode.I_size)),
+		slog.String("mtime", time.Unix(int64(inode.I_mtime), 0).Format(time.RFC3339)),
+		slog.Any("block", inode.I_block),
+		slog.String("type", string(inode.I_type[:])),
+		slog.String("perm", string(inode.I_perm[:])),
+	)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/binary" // Paquete para codificación y decodificación de datos binarios
 	"errors"
 	"fmt" // Paquete para formateo de E/S
-	"os"  // Paquete para funciones del sistema operativo
 	"strings"
 	"time"
 )
@@ -18,16 +17,17 @@ type MBR struct {
 	Mbr_partitions     [4]Partition // Particiones del MBR
 }
 
-// SerializeMBR escribe la estructura MBR al inicio de un archivo binario
-func (mbr *MBR) Serialize(path string) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+// SerializeMBR escribe la estructura MBR al inicio del dispositivo
+func (mbr *MBR) Serialize(dev BlockDevice) error {
+	// Serializar la estructura MBR en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, mbr)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Serializar la estructura MBR directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, mbr)
+	// Escribir el buffer al inicio del dispositivo
+	_, err = dev.WriteAt(buffer.Bytes(), 0)
 	if err != nil {
 		return err
 	}
@@ -35,14 +35,8 @@ func (mbr *MBR) Serialize(path string) error {
 	return nil
 }
 
-// DeserializeMBR lee la estructura MBR desde el inicio de un archivo binario
-func (mbr *MBR) Deserialize(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
+// DeserializeMBR lee la estructura MBR desde el inicio del dispositivo
+func (mbr *MBR) Deserialize(dev BlockDevice) error {
 	// Obtener el tamaño de la estructura MBR
 	mbrSize := binary.Size(mbr)
 	if mbrSize <= 0 {
@@ -51,7 +45,7 @@ func (mbr *MBR) Deserialize(path string) error {
 
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura MBR
 	buffer := make([]byte, mbrSize)
-	_, err = file.Read(buffer)
+	_, err := dev.ReadAt(buffer, 0)
 	if err != nil {
 		return err
 	}
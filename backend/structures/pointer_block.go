@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
 )
 
 type PointerBlock struct {
@@ -14,19 +13,7 @@ type PointerBlock struct {
 
 
 
-func (pb *PointerBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
+func (pb *PointerBlock) Deserialize(dev BlockDevice, offset int64) error {
 	// Obtener el tamaño de la estructura PointerBlock
 	pbSize := binary.Size(pb)
 	if pbSize <= 0 {
@@ -35,7 +22,7 @@ func (pb *PointerBlock) Deserialize(path string, offset int64) error {
 	
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura PointerBlock
 	buffer := make([]byte, pbSize)
-	_, err = file.Read(buffer)
+	_, err := dev.ReadAt(buffer, offset)
 	if err != nil {
 		return err
 	}
@@ -50,22 +37,17 @@ func (pb *PointerBlock) Deserialize(path string, offset int64) error {
 	return nil
 }
 
-// Serialize escribe la estructura FileBlock en un archivo binario en la posición especificada
-func (pb *PointerBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
+// Serialize escribe la estructura FileBlock en el dispositivo en la posición especificada
+func (pb *PointerBlock) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura PointerBlock en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, pb)
 	if err != nil {
 		return err
 	}
 
-	// Serializar la estructura FileBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, pb)
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,159 @@
+package structures
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ResizeResult resume lo hecho por Resize
+type ResizeResult struct {
+	OldInodes    int32
+	NewInodes    int32
+	OldBlocks    int32
+	NewBlocks    int32
+	UsedInodes   int32
+	UsedBlocks   int32
+	Defragmented bool // true si hubo que compactar antes de reducir
+}
+
+// Resize cambia la cantidad de inodos y bloques del sistema de archivos a la del superbloque layout
+// (calculado igual que en mkfs para el nuevo tamaño) y mueve bitmaps, tabla de inodos y área de bloques
+// a sus nuevas posiciones. Si al reducir hay inodos o bloques en uso fuera del nuevo rango, primero se
+// compacta la partición con Defragment. Los índices de inodos y bloques son relativos al inicio de su área,
+// por lo que solo cambian si se compacta. Actualiza sb en memoria: el llamador debe serializarlo.
+func (sb *SuperBlock) Resize(dev BlockDevice, layout *SuperBlock) (*ResizeResult, error) {
+	result := &ResizeResult{
+		OldInodes: sb.S_inodes_count,
+		NewInodes: layout.S_inodes_count,
+		OldBlocks: sb.S_blocks_count,
+		NewBlocks: layout.S_blocks_count,
+	}
+
+	// Calcular el espacio realmente en uso
+	usage, err := sb.AnalyzeFragmentation(dev)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[int32]bool)
+	var lastInode, lastBlock int32 = -1, -1
+	for _, frag := range usage.Inodes {
+		result.UsedInodes++
+		lastInode = max(lastInode, frag.InodeIndex)
+		for _, block := range frag.Blocks {
+			referenced[block] = true
+		}
+	}
+	result.UsedBlocks = int32(len(referenced))
+	for _, block := range usage.OrphanBlocks {
+		lastBlock = max(lastBlock, block)
+	}
+	for block := range referenced {
+		lastBlock = max(lastBlock, block)
+	}
+
+	// Nunca reducir por debajo de lo que está en uso
+	if result.UsedInodes > layout.S_inodes_count {
+		return nil, fmt.Errorf("no se puede reducir: hay %d inodos en uso y el nuevo tamaño solo admite %d", result.UsedInodes, layout.S_inodes_count)
+	}
+	if result.UsedBlocks > layout.S_blocks_count {
+		return nil, fmt.Errorf("no se puede reducir: hay %d bloques en uso y el nuevo tamaño solo admite %d", result.UsedBlocks, layout.S_blocks_count)
+	}
+
+	// Si algo en uso queda fuera del nuevo rango, compactar primero
+	if lastInode >= layout.S_inodes_count || lastBlock >= layout.S_blocks_count {
+		logger.Debug("Resize: compactando la partición antes de reducir...")
+		if _, err := sb.Defragment(dev); err != nil {
+			return nil, fmt.Errorf("error al compactar antes de reducir: %w", err)
+		}
+		result.Defragmented = true
+	}
+
+	// Posición de asignación secuencial expresada como índice, para conservarla en el nuevo layout
+	nextInode := (sb.S_first_ino - sb.S_inode_start) / sb.S_inode_size
+	nextBlock := (sb.S_first_blo - sb.S_block_start) / sb.S_block_size
+
+	// Leer bitmaps e inodos/bloques en uso antes de escribir nada: las áreas nuevas se solapan con las viejas
+	inodeBitmap := make([]byte, sb.S_inodes_count)
+	if _, err := dev.ReadAt(inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de inodos: %w", err)
+	}
+	blockBitmap := make([]byte, sb.S_blocks_count)
+	if _, err := dev.ReadAt(blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error al leer bitmap de bloques: %w", err)
+	}
+
+	inodes := make(map[int32][]byte)
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		if inodeBitmap[i] != '1' {
+			continue
+		}
+		buffer := make([]byte, sb.S_inode_size)
+		if _, err := dev.ReadAt(buffer, int64(sb.S_inode_start)+int64(i)*int64(sb.S_inode_size)); err != nil {
+			return nil, fmt.Errorf("error al leer inodo %d: %w", i, err)
+		}
+		inodes[i] = buffer
+	}
+	blocks := make(map[int32][]byte)
+	for b := int32(0); b < sb.S_blocks_count; b++ {
+		if blockBitmap[b] != '1' {
+			continue
+		}
+		buffer := make([]byte, sb.S_block_size)
+		if _, err := dev.ReadAt(buffer, int64(sb.S_block_start)+int64(b)*int64(sb.S_block_size)); err != nil {
+			return nil, fmt.Errorf("error al leer bloque %d: %w", b, err)
+		}
+		blocks[b] = buffer
+	}
+
+	// Escribir los bitmaps en sus nuevas posiciones, completando con libres si el sistema crece
+	newInodeBitmap := bytes.Repeat([]byte{'0'}, int(layout.S_inodes_count))
+	copy(newInodeBitmap, inodeBitmap)
+	if _, err := dev.WriteAt(newInodeBitmap, int64(layout.S_bm_inode_start)); err != nil {
+		return nil, fmt.Errorf("error al escribir bitmap de inodos: %w", err)
+	}
+	newBlockBitmap := bytes.Repeat([]byte{'0'}, int(layout.S_blocks_count))
+	copy(newBlockBitmap, blockBitmap)
+	if _, err := dev.WriteAt(newBlockBitmap, int64(layout.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error al escribir bitmap de bloques: %w", err)
+	}
+
+	// Escribir inodos y bloques en uso en las nuevas tablas
+	for i, buffer := range inodes {
+		if _, err := dev.WriteAt(buffer, int64(layout.S_inode_start)+int64(i)*int64(layout.S_inode_size)); err != nil {
+			return nil, fmt.Errorf("error al escribir inodo %d: %w", i, err)
+		}
+	}
+	for b, buffer := range blocks {
+		if _, err := dev.WriteAt(buffer, int64(layout.S_block_start)+int64(b)*int64(layout.S_block_size)); err != nil {
+			return nil, fmt.Errorf("error al escribir bloque %d: %w", b, err)
+		}
+	}
+
+	// La asignación secuencial no puede quedar fuera del nuevo rango: continuar después del último en uso
+	if nextInode > layout.S_inodes_count {
+		nextInode = 0
+		for i := range inodes {
+			nextInode = max(nextInode, i+1)
+		}
+	}
+	if nextBlock > layout.S_blocks_count {
+		nextBlock = 0
+		for b := range blocks {
+			nextBlock = max(nextBlock, b+1)
+		}
+	}
+
+	// Actualizar el superbloque con el nuevo layout
+	sb.S_inodes_count = layout.S_inodes_count
+	sb.S_blocks_count = layout.S_blocks_count
+	sb.S_free_inodes_count = layout.S_inodes_count - int32(len(inodes))
+	sb.S_free_blocks_count = layout.S_blocks_count - int32(len(blocks))
+	sb.S_bm_inode_start = layout.S_bm_inode_start
+	sb.S_bm_block_start = layout.S_bm_block_start
+	sb.S_inode_start = layout.S_inode_start
+	sb.S_block_start = layout.S_block_start
+	sb.S_first_ino = sb.S_inode_start + nextInode*sb.S_inode_size
+	sb.S_first_blo = sb.S_block_start + nextBlock*sb.S_block_size
+
+	return result, nil
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
 	"time"
 )
 
@@ -29,22 +28,17 @@ type SuperBlock struct {
 	// Total: 68 bytes
 }
 
-// Serialize escribe la estructura SuperBlock en un archivo binario en la posición especificada
-func (sb *SuperBlock) Serialize(path string, offset int64) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+// Serialize escribe la estructura SuperBlock en el dispositivo en la posición especificada
+func (sb *SuperBlock) Serialize(dev BlockDevice, offset int64) error {
+	// Serializar la estructura SuperBlock en un buffer
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, sb)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
-	// Serializar la estructura SuperBlock directamente en el archivo
-	err = binary.Write(file, binary.LittleEndian, sb)
+	// Escribir el buffer en el dispositivo en la posición especificada
+	_, err = dev.WriteAt(buffer.Bytes(), offset)
 	if err != nil {
 		return err
 	}
@@ -52,20 +46,8 @@ func (sb *SuperBlock) Serialize(path string, offset int64) error {
 	return nil
 }
 
-// Deserialize lee la estructura SuperBlock desde un archivo binario en la posición especificada
-func (sb *SuperBlock) Deserialize(path string, offset int64) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Mover el puntero del archivo a la posición especificada
-	_, err = file.Seek(offset, 0)
-	if err != nil {
-		return err
-	}
-
+// Deserialize lee la estructura SuperBlock desde el dispositivo en la posición especificada
+func (sb *SuperBlock) Deserialize(dev BlockDevice, offset int64) error {
 	// Obtener el tamaño de la estructura SuperBlock
 	sbSize := binary.Size(sb)
 	if sbSize <= 0 {
@@ -74,7 +56,7 @@ func (sb *SuperBlock) Deserialize(path string, offset int64) error {
 
 	// Leer solo la cantidad de bytes que corresponden al tamaño de la estructura SuperBlock
 	buffer := make([]byte, sbSize)
-	_, err = file.Read(buffer)
+	_, err := dev.ReadAt(buffer, offset)
 	if err != nil {
 		return err
 	}
@@ -116,14 +98,14 @@ func (sb *SuperBlock) Print() {
 }
 
 // Imprimir inodos
-func (sb *SuperBlock) PrintInodes(path string) error {
+func (sb *SuperBlock) PrintInodes(dev BlockDevice) error {
 	// Imprimir inodos
 	fmt.Println("\nInodos\n----------------")
 	// Iterar sobre cada inodo
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		inode := &Inode{}
 		// Deserializar el inodo
-		err := inode.Deserialize(path, int64(sb.S_inode_start+(i*sb.S_inode_size)))
+		err := inode.Deserialize(dev, int64(sb.S_inode_start+(i*sb.S_inode_size)))
 		if err != nil {
 			return err
 		}
@@ -136,14 +118,14 @@ func (sb *SuperBlock) PrintInodes(path string) error {
 }
 
 // Imprimir bloques
-func (sb *SuperBlock) PrintBlocks(path string) error {
+func (sb *SuperBlock) PrintBlocks(dev BlockDevice) error {
 	// Imprimir bloques
 	fmt.Println("\nBloques\n----------------")
 	// Iterar sobre cada inodo
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		inode := &Inode{}
 		// Deserializar el inodo
-		err := inode.Deserialize(path, int64(sb.S_inode_start+(i*sb.S_inode_size)))
+		err := inode.Deserialize(dev, int64(sb.S_inode_start+(i*sb.S_inode_size)))
 		if err != nil {
 			return err
 		}
@@ -157,7 +139,7 @@ func (sb *SuperBlock) PrintBlocks(path string) error {
 			if inode.I_type[0] == '0' {
 				block := &FolderBlock{}
 				// Deserializar el bloque
-				err := block.Deserialize(path, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
+				err := block.Deserialize(dev, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
 				if err != nil {
 					return err
 				}
@@ -170,7 +152,7 @@ func (sb *SuperBlock) PrintBlocks(path string) error {
 			} else if inode.I_type[0] == '1' {
 				block := &FileBlock{}
 				// Deserializar el bloque
-				err := block.Deserialize(path, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
+				err := block.Deserialize(dev, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
 				if err != nil {
 					return err
 				}
@@ -185,26 +167,26 @@ func (sb *SuperBlock) PrintBlocks(path string) error {
 }
 
 // CreateFolder crea una carpeta en el sistema de archivos
-func (sb *SuperBlock) CreateFolder(path string, parentsDir []string, destDir string) error {
+func (sb *SuperBlock) CreateFolder(dev BlockDevice, parentsDir []string, destDir string) error {
 	// Si parentsDir está vacío, solo trabajar con el primer inodo que sería el raíz "/"
 	if len(parentsDir) == 0 {
-		return sb.createFolderInInode(path, 0, parentsDir, destDir)
+		return sb.createFolderInInode(dev, 0, parentsDir, destDir)
 	}
 
-	fmt.Printf("CreateFolder: Llamando a createFolderInInode desde la raíz (0) para path: %s (padres: %v, destino: %s)\n", path, parentsDir, destDir) // Log de depuración
-	return sb.createFolderInInode(path, 0, parentsDir, destDir)
+	logger.Debug("CreateFolder: creando desde la raíz", "parents", parentsDir, "dest", destDir)
+	return sb.createFolderInInode(dev, 0, parentsDir, destDir)
 }
 
 
 // PARA EL LOGIN --------------------------------------------------------------------------------------------------------------------
 
 // Get users.txt block
-func (sb *SuperBlock) GetUsersBlock(path string) (*FileBlock, error) {
+func (sb *SuperBlock) GetUsersBlock(dev BlockDevice) (*FileBlock, error) {
 	// Ir al inodo 1
 	inode := &Inode{}
 
 	// Deserializar el inodo
-	err := inode.Deserialize(path, int64(sb.S_inode_start+(1*sb.S_inode_size)))
+	err := inode.Deserialize(dev, int64(sb.S_inode_start+(1*sb.S_inode_size)))
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +201,7 @@ func (sb *SuperBlock) GetUsersBlock(path string) (*FileBlock, error) {
 		if inode.I_type[0] == '1' {
 			block := &FileBlock{}
 			// Deserializar el bloque
-			err := block.Deserialize(path, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
+			err := block.Deserialize(dev, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // 64 porque es el tamaño de un bloque
 			if err != nil {
 				return nil, err
 			}
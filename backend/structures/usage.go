@@ -0,0 +1,44 @@
+package structures
+
+import "fmt"
+
+// Usage es lo que ocupan los inodos de un dueño: cuántos inodos y cuántos bloques (de datos, de carpeta y de punteros)
+type Usage struct {
+	Inodes int32
+	Blocks int32
+}
+
+// UsageByOwner recorre los inodos en uso (según el bitmap de inodos) y suma lo que ocupan por UID y por GID
+func (sb *SuperBlock) UsageByOwner(dev BlockDevice) (map[int32]Usage, map[int32]Usage, error) {
+	inodeBitmap := make([]byte, sb.S_inodes_count)
+	if _, err := dev.ReadAt(inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return nil, nil, fmt.Errorf("error al leer bitmap de inodos: %w", err)
+	}
+
+	byUser := map[int32]Usage{}
+	byGroup := map[int32]Usage{}
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		if inodeBitmap[i] != '1' {
+			continue
+		}
+		inode := &Inode{}
+		if err := inode.Deserialize(dev, int64(sb.S_inode_start)+int64(i)*int64(sb.S_inode_size)); err != nil {
+			return nil, nil, fmt.Errorf("error deserializando inodo %d: %w", i, err)
+		}
+		layout, err := sb.inodeLayout(dev, inode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error leyendo los bloques del inodo %d: %w", i, err)
+		}
+
+		userUsage := byUser[inode.I_uid]
+		userUsage.Inodes++
+		userUsage.Blocks += int32(len(layout))
+		byUser[inode.I_uid] = userUsage
+
+		groupUsage := byGroup[inode.I_gid]
+		groupUsage.Inodes++
+		groupUsage.Blocks += int32(len(layout))
+		byGroup[inode.I_gid] = groupUsage
+	}
+	return byUser, byGroup, nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Archivo de cada partición donde se guarda el log de auditoría (una línea JSON por registro)
+const AuditLogPath = "/audit.log"
+
+// AuditEntry es un registro del log de auditoría de una partición. Cada registro se guarda como una línea JSON.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`              // Usuario que ejecutó el comando (el real, aunque haya usado sudo)
+	Session string    `json:"session,omitempty"` // Identificador corto de la sesión (nunca el token)
+	Event   string    `json:"event"`             // command, su, exit o sudo
+	Target  string    `json:"target,omitempty"`  // Usuario con cuyos privilegios se ejecuta
+	Command string    `json:"command,omitempty"` // Línea de comando, con las contraseñas ocultas
+	Result  string    `json:"result"`            // "ok" o el mensaje de error
+}
+
+// Parámetros secretos de cada comando: su valor nunca se escribe en el log de auditoría, el log del servidor,
+// los trabajos ni el historial de la terminal (ver RedactPasswords)
+var SecretParameters = map[string][]string{
+	"login":  {"pass"},
+	"mkusr":  {"pass"},
+	"passwd": {"pass", "old"},
+	"su":     {"pass"},
+	"sudo":   {"pass"},
+}
+
+// Expresión para encontrar los parámetros secretos en una línea de comando (-pass=..., con o sin comillas). Busca
+// los de todos los comandos, porque la línea puede traer otro comando detrás de sudo o un nombre mal escrito.
+var secretArgumentRegex = regexp.MustCompile(`(?i)(-(?:` + strings.Join(secretParameterNames(), "|") + `)=)("[^"]*"|\S+)`)
+
+// secretParameterNames devuelve los nombres de los parámetros secretos de todos los comandos, sin repetir
+func secretParameterNames() []string {
+	var names []string
+	for _, parameters := range SecretParameters {
+		for _, name := range parameters {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// RedactPasswords oculta el valor de los parámetros secretos (ver SecretParameters) de una línea de comando
+func RedactPasswords(commandLine string) string {
+	return secretArgumentRegex.ReplaceAllString(commandLine, "${1}***")
+}
+
+// FormatAuditEntry devuelve la línea (terminada en salto de línea) que representa el registro en el log
+func FormatAuditEntry(entry AuditEntry) (string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("error al serializar el registro de auditoría: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// ParseAuditLog convierte el contenido del log de auditoría en registros, ignorando líneas inválidas
+func ParseAuditLog(content string) []AuditEntry {
+	var entries []AuditEntry
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			logger.Warn("Registro de auditoría inválido", "line", line)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// AuditFilter selecciona registros del log de auditoría. Los campos vacíos no filtran.
+type AuditFilter struct {
+	User string    // Usuario (sin distinguir mayúsculas)
+	From time.Time // Desde (inclusive)
+	To   time.Time // Hasta (inclusive)
+}
+
+// Matches indica si el registro cumple el filtro
+func (f AuditFilter) Matches(entry AuditEntry) bool {
+	if f.User != "" && !strings.EqualFold(entry.User, f.User) {
+		return false
+	}
+	if !f.From.IsZero() && entry.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.Time.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Formatos aceptados para los límites de tiempo del filtro
+var auditTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseAuditTime interpreta un límite de tiempo del filtro (en hora local si no indica zona).
+// Si solo trae la fecha y es el límite superior, abarca el día completo.
+func ParseAuditTime(value string, upper bool) (time.Time, error) {
+	for _, layout := range auditTimeLayouts {
+		parsed, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if upper && layout == "2006-01-02" {
+			parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+		}
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("fecha inválida '%s': use AAAA-MM-DD, AAAA-MM-DDTHH:MM o RFC3339", value)
+}
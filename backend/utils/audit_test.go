@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestRedactPasswords(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"login -user=root -pass=123 -id=201A", "login -user=root -pass=*** -id=201A"},
+		{`mkusr -user=user1 -pass="mi clave" -grp=usuarios`, "mkusr -user=user1 -pass=*** -grp=usuarios"},
+		{"passwd -old=viejo -pass=nuevo", "passwd -old=*** -pass=***"},
+		{"PASSWD -OLD=viejo -Pass=nuevo", "PASSWD -OLD=*** -Pass=***"},
+		{"sudo -pass=123 passwd -user=user1 -pass=nuevo", "sudo -pass=*** passwd -user=user1 -pass=***"},
+		{"logn -pass=123", "logn -pass=***"}, // Comando mal escrito: la contraseña se oculta igual
+		{"mkdir -path=/home/old -p", "mkdir -path=/home/old -p"},
+		{"rep -id=201A -path=/tmp/passwd.txt -name=file", "rep -id=201A -path=/tmp/passwd.txt -name=file"},
+	}
+	for _, test := range tests {
+		if got := RedactPasswords(test.line); got != test.want {
+			t.Errorf("RedactPasswords(%q) = %q, se esperaba %q", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package utils
+
+import logging "backend/logging"
+
+// Las funciones de utils son parte de los comandos y registran con su subsistema
+var logger = logging.Logger(logging.Commands)
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Versión actual del formato de /users.txt. La versión se guarda en una línea "0,V,<versión>" al inicio del archivo;
+// los archivos sin esa línea son de la versión 1, con contraseñas en texto plano. La versión 3 agrega a las líneas
+// de usuario un sexto campo opcional con los grupos secundarios y la versión 4 la fecha de expiración y el
+// bloqueo de la cuenta (ver UserRecord).
+const UsersFileVersion = 4
+
+// Línea que marca la versión actual del formato de /users.txt
+var UsersFileVersionLine = fmt.Sprintf("0,V,%d", UsersFileVersion)
+
+// Parámetros del hash de contraseñas (PBKDF2 con HMAC-SHA256)
+const (
+	passwordHashPrefix     = "pbkdf2-sha256"
+	passwordHashIterations = 100000
+	passwordSaltSize       = 16
+	passwordKeySize        = 32
+)
+
+// HashPassword devuelve el hash con sal de una contraseña con el formato pbkdf2-sha256$iteraciones$sal$hash.
+// El resultado no contiene comas, por lo que puede guardarse directamente como campo de /users.txt.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error al generar la sal de la contraseña: %w", err)
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, passwordHashIterations, passwordKeySize)
+	return fmt.Sprintf("%s$%d$%s$%s", passwordHashPrefix, passwordHashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// IsPasswordHash indica si un campo de contraseña ya está hasheado (y no en texto plano)
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, passwordHashPrefix+"$")
+}
+
+// VerifyPassword compara una contraseña con el valor guardado en /users.txt.
+// Acepta tanto hashes como contraseñas en texto plano de archivos anteriores a la versión 2.
+func VerifyPassword(stored, password string) (bool, error) {
+	if !IsPasswordHash(stored) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1, nil
+	}
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 {
+		return false, errors.New("formato de hash de contraseña inválido")
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, errors.New("cantidad de iteraciones inválida en el hash de contraseña")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, fmt.Errorf("sal inválida en el hash de contraseña: %w", err)
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, fmt.Errorf("hash de contraseña inválido: %w", err)
+	}
+
+	key := pbkdf2SHA256([]byte(password), salt, iterations, len(expected))
+	return subtle.ConstantTimeCompare(key, expected) == 1, nil
+}
+
+// pbkdf2SHA256 implementa PBKDF2 (RFC 8018) con HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	key := make([]byte, 0, numBlocks*hashLen)
+	counter := make([]byte, 4)
+	for block := 1; block <= numBlocks; block++ {
+		// U1 = PRF(password, salt || INT(block))
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter, uint32(block))
+		prf.Write(counter)
+		u := prf.Sum(nil)
+
+		// T = U1 xor U2 xor ... xor Uc
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// UsersFileVersionOf devuelve la versión de formato de un contenido de /users.txt
+func UsersFileVersionOf(content string) int {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) == 3 && strings.TrimSpace(fields[1]) == "V" {
+			if version, err := strconv.Atoi(strings.TrimSpace(fields[2])); err == nil {
+				return version
+			}
+		}
+	}
+	return 1
+}
+
+// MigrateUsersContent lleva un contenido de /users.txt a la versión actual: agrega la línea de versión
+// y reemplaza las contraseñas en texto plano por su hash. Devuelve el nuevo contenido y si hubo cambios.
+func MigrateUsersContent(content string) (string, bool, error) {
+	changed := UsersFileVersionOf(content) != UsersFileVersion
+	lines := []string{UsersFileVersionLine}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			continue
+		}
+
+		fields := strings.Split(trimmedLine, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		// La línea de versión se reemplaza por la actual
+		if len(fields) == 3 && fields[1] == "V" {
+			continue
+		}
+
+		// Hashear las contraseñas que sigan en texto plano
+		if IsUserLine(fields) && !IsPasswordHash(fields[4]) {
+			hash, err := HashPassword(fields[4])
+			if err != nil {
+				return "", false, err
+			}
+			fields[4] = hash
+			trimmedLine = strings.Join(fields, ",")
+			changed = true
+		}
+		lines = append(lines, trimmedLine)
+	}
+
+	return strings.Join(lines, "\n") + "\n", changed, nil
+}
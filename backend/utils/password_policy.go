@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Largo máximo de una contraseña: los parámetros de los comandos admiten hasta 10 caracteres
+const MaxPasswordLength = 10
+
+// PasswordPolicy son las reglas que debe cumplir una contraseña nueva (mkusr y passwd).
+// Las contraseñas existentes no se validan al iniciar sesión.
+type PasswordPolicy struct {
+	MinLength         int  // Largo mínimo
+	RequireLetter     bool // Al menos una letra
+	RequireDigit      bool // Al menos un dígito
+	RequireSymbol     bool // Al menos un carácter que no sea letra ni dígito
+	AllowUsernameSame bool // Permite que la contraseña sea igual al nombre de usuario
+}
+
+// Política que se aplica a las contraseñas nuevas; el servidor la puede reemplazar al iniciar
+var CurrentPasswordPolicy = PasswordPolicy{MinLength: 4}
+
+// Validate verifica que la contraseña del usuario cumpla la política y describe todo lo que falta
+func (p PasswordPolicy) Validate(username, password string) error {
+	var problems []string
+	if len(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("tener al menos %d caracteres", p.MinLength))
+	}
+
+	var hasLetter, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if p.RequireLetter && !hasLetter {
+		problems = append(problems, "contener una letra")
+	}
+	if p.RequireDigit && !hasDigit {
+		problems = append(problems, "contener un dígito")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		problems = append(problems, "contener un símbolo")
+	}
+	if !p.AllowUsernameSame && strings.EqualFold(password, username) {
+		problems = append(problems, "ser distinta del nombre de usuario")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("la contraseña no cumple la política: debe %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// String describe la política en una línea (para mostrarla al iniciar el servidor)
+func (p PasswordPolicy) String() string {
+	rules := []string{fmt.Sprintf("mínimo %d caracteres", p.MinLength)}
+	if p.RequireLetter {
+		rules = append(rules, "letra")
+	}
+	if p.RequireDigit {
+		rules = append(rules, "dígito")
+	}
+	if p.RequireSymbol {
+		rules = append(rules, "símbolo")
+	}
+	if !p.AllowUsernameSame {
+		rules = append(rules, "distinta del usuario")
+	}
+	return strings.Join(rules, ", ")
+}
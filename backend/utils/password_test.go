@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPasswordVerify(t *testing.T) {
+	hash, err := HashPassword("123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if !IsPasswordHash(hash) {
+		t.Fatalf("IsPasswordHash(%q) = false", hash)
+	}
+	if strings.Contains(hash, ",") {
+		t.Errorf("el hash %q contiene comas y no puede guardarse en /users.txt", hash)
+	}
+
+	if ok, err := VerifyPassword(hash, "123"); err != nil || !ok {
+		t.Errorf("VerifyPassword con la contraseña correcta = (%v, %v)", ok, err)
+	}
+	if ok, _ := VerifyPassword(hash, "1234"); ok {
+		t.Error("VerifyPassword aceptó una contraseña incorrecta")
+	}
+
+	// La sal es aleatoria: la misma contraseña no produce el mismo hash
+	if other, _ := HashPassword("123"); other == hash {
+		t.Error("dos hashes de la misma contraseña son iguales")
+	}
+}
+
+func TestVerifyPasswordPlainText(t *testing.T) {
+	// Las contraseñas de archivos de la versión 1 se comparan en texto plano
+	if IsPasswordHash("123") {
+		t.Error("IsPasswordHash(\"123\") = true")
+	}
+	if ok, err := VerifyPassword("123", "123"); err != nil || !ok {
+		t.Errorf("VerifyPassword en texto plano = (%v, %v)", ok, err)
+	}
+	if ok, _ := VerifyPassword("123", "321"); ok {
+		t.Error("VerifyPassword en texto plano aceptó una contraseña incorrecta")
+	}
+}
+
+func TestMigrateUsersContentFromV1(t *testing.T) {
+	v1 := "1,G,root\n1,U,root,root,123\n2,G,usuarios\n2,U,usuarios,user1,abc\n0,U,usuarios,borrado,xyz\n"
+	if version := UsersFileVersionOf(v1); version != 1 {
+		t.Fatalf("UsersFileVersionOf(v1) = %d, se esperaba 1", version)
+	}
+
+	migrated, changed, err := MigrateUsersContent(v1)
+	if err != nil {
+		t.Fatalf("MigrateUsersContent: %v", err)
+	}
+	if !changed {
+		t.Error("la migración de un archivo v1 no reportó cambios")
+	}
+	if version := UsersFileVersionOf(migrated); version != UsersFileVersion {
+		t.Errorf("versión migrada = %d, se esperaba %d", version, UsersFileVersion)
+	}
+	if !strings.HasPrefix(migrated, UsersFileVersionLine+"\n") {
+		t.Errorf("el contenido migrado no empieza con la línea de versión:\n%s", migrated)
+	}
+
+	// Los grupos quedan igual y cada contraseña pasa a ser un hash que se verifica con la original
+	for _, group := range []string{"1,G,root\n", "2,G,usuarios\n"} {
+		if !strings.Contains(migrated, group) {
+			t.Errorf("falta la línea de grupo %q en:\n%s", group, migrated)
+		}
+	}
+	for user, password := range map[string]string{"root": "123", "user1": "abc", "borrado": "xyz"} {
+		fields := FindUser(migrated, user)
+		if fields == nil {
+			t.Errorf("falta el usuario %s en:\n%s", user, migrated)
+			continue
+		}
+		if !IsPasswordHash(fields[4]) {
+			t.Errorf("la contraseña de %s sigue en texto plano: %q", user, fields[4])
+		}
+		if ok, err := VerifyPassword(fields[4], password); err != nil || !ok {
+			t.Errorf("VerifyPassword de %s después de migrar = (%v, %v)", user, ok, err)
+		}
+	}
+
+	// Migrar de nuevo no cambia nada
+	again, changed, err := MigrateUsersContent(migrated)
+	if err != nil {
+		t.Fatalf("MigrateUsersContent del contenido migrado: %v", err)
+	}
+	if changed || again != migrated {
+		t.Errorf("migrar un archivo actual lo modificó (changed=%v):\n%s", changed, again)
+	}
+}
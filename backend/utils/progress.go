@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// Progress es el avance de una operación larga (por ejemplo los bytes que escribe mkdisk o los inodos que recorre
+// un reporte). Todos los métodos aceptan un *Progress nil, así los comandos lo usan sin revisar si alguien lo lee.
+type Progress struct {
+	mu    sync.Mutex
+	done  int64
+	total int64
+	unit  string
+}
+
+// ProgressSnapshot es el estado de un Progress en un momento dado
+type ProgressSnapshot struct {
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"` // 0 si no se conoce
+	Unit  string `json:"unit,omitempty"`
+}
+
+// Start reinicia el avance con el total y la unidad de la siguiente etapa
+func (p *Progress) Start(total int64, unit string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done, p.total, p.unit = 0, total, unit
+}
+
+// Add suma n al avance
+func (p *Progress) Add(n int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+}
+
+// Snapshot devuelve una copia del avance
+func (p *Progress) Snapshot() ProgressSnapshot {
+	if p == nil {
+		return ProgressSnapshot{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ProgressSnapshot{Done: p.done, Total: p.total, Unit: p.unit}
+}
+
+// Clave para guardar el Progress en el contexto de un comando
+type progressContextKey struct{}
+
+// WithProgress devuelve un contexto en el que los comandos informan su avance
+func WithProgress(ctx context.Context, progress *Progress) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, progress)
+}
+
+// ProgressFromContext devuelve el Progress del contexto, o nil si nadie sigue el avance
+func ProgressFromContext(ctx context.Context) *Progress {
+	progress, _ := ctx.Value(progressContextKey{}).(*Progress)
+	return progress
+}
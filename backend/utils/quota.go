@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Archivo de cada partición donde se guardan las cuotas. Una línea por cuota:
+// U,usuario,bloques,inodos o G,grupo,bloques,inodos. Un límite en 0 significa sin límite.
+const QuotaFilePath = "/quota.txt"
+
+// Quota son los límites de bloques e inodos de un usuario o grupo (0 = sin límite)
+type Quota struct {
+	Blocks int32
+	Inodes int32
+}
+
+// Unlimited indica si la cuota no limita nada
+func (q Quota) Unlimited() bool {
+	return q.Blocks <= 0 && q.Inodes <= 0
+}
+
+// QuotaTable son las cuotas de una partición, indexadas por nombre en minúsculas
+type QuotaTable struct {
+	Users  map[string]Quota
+	Groups map[string]Quota
+}
+
+// ParseQuotas convierte el contenido de /quota.txt en una tabla, ignorando líneas inválidas
+func ParseQuotas(content string) QuotaTable {
+	table := QuotaTable{Users: map[string]Quota{}, Groups: map[string]Quota{}}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) != 4 {
+			continue
+		}
+		blocks, errBlocks := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 32)
+		inodes, errInodes := strconv.ParseInt(strings.TrimSpace(fields[3]), 10, 32)
+		if errBlocks != nil || errInodes != nil {
+			logger.Warn("Línea de cuota inválida", "line", line)
+			continue
+		}
+		table.Set(strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]), Quota{Blocks: int32(blocks), Inodes: int32(inodes)})
+	}
+	return table
+}
+
+// Set guarda la cuota de un usuario (kind "U") o grupo (kind "G"); una cuota sin límites la elimina
+func (t QuotaTable) Set(kind, name string, quota Quota) {
+	target := t.Users
+	if kind == "G" {
+		target = t.Groups
+	} else if kind != "U" {
+		return
+	}
+	if quota.Unlimited() {
+		delete(target, strings.ToLower(name))
+		return
+	}
+	target[strings.ToLower(name)] = quota
+}
+
+// User devuelve la cuota de un usuario (sin límites si no tiene)
+func (t QuotaTable) User(name string) Quota {
+	return t.Users[strings.ToLower(name)]
+}
+
+// Group devuelve la cuota de un grupo (sin límites si no tiene)
+func (t QuotaTable) Group(name string) Quota {
+	return t.Groups[strings.ToLower(name)]
+}
+
+// Format devuelve el contenido de /quota.txt, con las líneas ordenadas
+func (t QuotaTable) Format() string {
+	var lines []string
+	for name, quota := range t.Users {
+		lines = append(lines, fmt.Sprintf("U,%s,%d,%d", name, quota.Blocks, quota.Inodes))
+	}
+	for name, quota := range t.Groups {
+		lines = append(lines, fmt.Sprintf("G,%s,%d,%d", name, quota.Blocks, quota.Inodes))
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// FormatQuotaLimit muestra un límite de cuota, o "-" si no hay límite
+func FormatQuotaLimit(limit int32) string {
+	if limit <= 0 {
+		return "-"
+	}
+	return strconv.Itoa(int(limit))
+}
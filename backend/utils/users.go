@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Separador de los grupos secundarios dentro del sexto campo de una línea U de /users.txt (ver UserRecord)
+const GroupListSeparator = ";"
+
+// IsUserLine indica si los campos (ya separados por coma) corresponden a una línea de usuario
+func IsUserLine(fields []string) bool {
+	return len(fields) >= 5 && fields[1] == "U"
+}
+
+// SupplementaryGroups devuelve los grupos secundarios de una línea de usuario (vacío si no tiene)
+func SupplementaryGroups(fields []string) []string {
+	if len(fields) < 6 {
+		return nil
+	}
+	var groups []string
+	for _, group := range strings.Split(fields[5], GroupListSeparator) {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// UserGroups devuelve todos los grupos de una línea de usuario: primero el principal y luego los secundarios
+func UserGroups(fields []string) []string {
+	if !IsUserLine(fields) {
+		return nil
+	}
+	return append([]string{fields[2]}, SupplementaryGroups(fields)...)
+}
+
+// HasGroup indica si el grupo está en la lista, sin distinguir mayúsculas (igual que el resto de /users.txt)
+func HasGroup(groups []string, group string) bool {
+	return slices.ContainsFunc(groups, func(g string) bool { return strings.EqualFold(g, group) })
+}
+
+// Valor del octavo campo de una línea U cuando root bloqueó la cuenta con lockusr
+const LockedAccountMark = "L"
+
+// Formato de la fecha de expiración de una cuenta (séptimo campo de una línea U)
+const ExpiryDateLayout = "2006-01-02"
+
+// UserRecord es una línea de usuario de /users.txt con sus campos opcionales:
+// UID,U,grupo,usuario,contraseña[,grupo2;grupo3[,expiración[,L]]]
+type UserRecord struct {
+	UID           string
+	Group         string // Grupo principal
+	Name          string
+	Password      string   // Hash (o texto plano en archivos de la versión 1)
+	Supplementary []string // Grupos secundarios
+	Expires       string   // Último día en que la cuenta es válida (AAAA-MM-DD); vacío si no expira
+	Locked        bool     // Bloqueada por root con lockusr
+}
+
+// ParseUserRecord convierte los campos de una línea de usuario (ver IsUserLine) en un UserRecord
+func ParseUserRecord(fields []string) UserRecord {
+	record := UserRecord{
+		UID:           fields[0],
+		Group:         fields[2],
+		Name:          fields[3],
+		Password:      fields[4],
+		Supplementary: SupplementaryGroups(fields),
+	}
+	if len(fields) > 6 {
+		record.Expires = fields[6]
+	}
+	if len(fields) > 7 {
+		record.Locked = strings.EqualFold(fields[7], LockedAccountMark)
+	}
+	return record
+}
+
+// Line arma la línea del usuario; los campos opcionales solo se escriben si hace falta
+func (u UserRecord) Line() string {
+	fields := []string{u.UID, "U", u.Group, u.Name, u.Password, strings.Join(u.Supplementary, GroupListSeparator), u.Expires, ""}
+	if u.Locked {
+		fields[7] = LockedAccountMark
+	}
+	for len(fields) > 5 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, ",")
+}
+
+// Expired indica si la cuenta ya expiró en el momento indicado. La cuenta es válida hasta el final del día de expiración.
+func (u UserRecord) Expired(now time.Time) bool {
+	if u.Expires == "" {
+		return false
+	}
+	expires, err := time.ParseInLocation(ExpiryDateLayout, u.Expires, time.Local)
+	if err != nil {
+		return false
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// ParseExpiryDate valida una fecha de expiración de cuenta (AAAA-MM-DD) y la devuelve normalizada
+func ParseExpiryDate(value string) (string, error) {
+	expires, err := time.ParseInLocation(ExpiryDateLayout, value, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("fecha de expiración inválida '%s': use AAAA-MM-DD", value)
+	}
+	return expires.Format(ExpiryDateLayout), nil
+}
+
+// Grupo cuyos miembros pueden ejecutar comandos de root con sudo, usando su propia contraseña
+const SudoGroup = "wheel"
+
+// FindUser busca la línea de un usuario en el contenido de /users.txt y devuelve sus campos (nil si no existe)
+func FindUser(content, username string) []string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if IsUserLine(fields) && strings.EqualFold(fields[3], username) {
+			return fields
+		}
+	}
+	return nil
+}
+
+// Carpeta donde mkusr -home crea los directorios personales, y plantilla que se copia en cada uno
+const (
+	HomeRoot = "/home"
+	SkelDir  = "/etc/skel"
+)
+
+// HomeDir devuelve el directorio personal de un usuario
+func HomeDir(username string) string {
+	return HomeRoot + "/" + username
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateUsersContentFromV3KeepsGroups(t *testing.T) {
+	hash, err := HashPassword("123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	v3 := "0,V,3\n1,G,root\n2,G,wheel\n3,G,dev\n1,U,root,root," + hash + "\n" +
+		"2,U,dev,user1,abc,wheel;root\n3,U,dev,user2," + hash + "\n"
+
+	migrated, changed, err := MigrateUsersContent(v3)
+	if err != nil {
+		t.Fatalf("MigrateUsersContent: %v", err)
+	}
+	if !changed {
+		t.Error("la migración de un archivo v3 no reportó cambios")
+	}
+	if version := UsersFileVersionOf(migrated); version != UsersFileVersion {
+		t.Errorf("versión migrada = %d, se esperaba %d", version, UsersFileVersion)
+	}
+	if strings.Contains(migrated, "0,V,3") {
+		t.Errorf("quedó la línea de versión anterior en:\n%s", migrated)
+	}
+
+	user1 := FindUser(migrated, "user1")
+	if user1 == nil {
+		t.Fatalf("falta user1 en:\n%s", migrated)
+	}
+	if groups := UserGroups(user1); !slices.Equal(groups, []string{"dev", "wheel", "root"}) {
+		t.Errorf("grupos de user1 = %v, se esperaba [dev wheel root]", groups)
+	}
+	if ok, _ := VerifyPassword(user1[4], "abc"); !ok {
+		t.Error("la contraseña de user1 no se verifica después de migrar")
+	}
+
+	// Las contraseñas que ya eran hash no se vuelven a hashear
+	if root := FindUser(migrated, "root"); root == nil || root[4] != hash {
+		t.Errorf("el hash de root cambió al migrar: %v", root)
+	}
+	if groups := UserGroups(FindUser(migrated, "user2")); !slices.Equal(groups, []string{"dev"}) {
+		t.Errorf("grupos de user2 = %v, se esperaba [dev]", groups)
+	}
+}
+
+func TestUserRecordLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		record UserRecord
+	}{
+		{"2,U,dev,user1,abc", UserRecord{UID: "2", Group: "dev", Name: "user1", Password: "abc"}},
+		{"2,U,dev,user1,abc,wheel;root", UserRecord{UID: "2", Group: "dev", Name: "user1", Password: "abc", Supplementary: []string{"wheel", "root"}}},
+		{"2,U,dev,user1,abc,,2030-01-31", UserRecord{UID: "2", Group: "dev", Name: "user1", Password: "abc", Expires: "2030-01-31"}},
+		{"2,U,dev,user1,abc,wheel,,L", UserRecord{UID: "2", Group: "dev", Name: "user1", Password: "abc", Supplementary: []string{"wheel"}, Locked: true}},
+	}
+
+	for _, test := range tests {
+		record := ParseUserRecord(strings.Split(test.line, ","))
+		if record.UID != test.record.UID || record.Group != test.record.Group || record.Name != test.record.Name ||
+			record.Password != test.record.Password || !slices.Equal(record.Supplementary, test.record.Supplementary) ||
+			record.Expires != test.record.Expires || record.Locked != test.record.Locked {
+			t.Errorf("ParseUserRecord(%q) = %+v, se esperaba %+v", test.line, record, test.record)
+		}
+		if line := test.record.Line(); line != test.line {
+			t.Errorf("Line() = %q, se esperaba %q", line, test.line)
+		}
+	}
+}
+
+func TestSupplementaryGroups(t *testing.T) {
+	fields := strings.Split("2,U,dev,user1,abc, wheel ;;root", ",")
+	if groups := SupplementaryGroups(fields); !slices.Equal(groups, []string{"wheel", "root"}) {
+		t.Errorf("SupplementaryGroups = %v, se esperaba [wheel root]", groups)
+	}
+	if !HasGroup(UserGroups(fields), "WHEEL") {
+		t.Error("HasGroup no encontró wheel sin distinguir mayúsculas")
+	}
+	if HasGroup(UserGroups(fields), "admin") {
+		t.Error("HasGroup encontró un grupo que el usuario no tiene")
+	}
+}
+
+func TestUserRecordExpired(t *testing.T) {
+	record := UserRecord{Expires: "2030-01-31"}
+	lastDay := time.Date(2030, 1, 31, 23, 59, 0, 0, time.Local)
+	if record.Expired(lastDay) {
+		t.Error("la cuenta expiró antes de terminar su último día")
+	}
+	if !record.Expired(lastDay.Add(time.Minute)) {
+		t.Error("la cuenta sigue válida después de su último día")
+	}
+	if (UserRecord{}).Expired(lastDay) {
+		t.Error("una cuenta sin fecha de expiración expiró")
+	}
+}
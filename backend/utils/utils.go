@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -35,6 +36,15 @@ var pathToPartitionCount = make(map[string]int)
 // Índice para la siguiente letra disponible en el abecedario
 var nextLetterIndex = 0
 
+// DiskLetters devuelve una copia de la letra asignada a cada disco. Requiere StateLock tomado (ver stores/locks.go).
+func DiskLetters() map[string]string {
+	letters := make(map[string]string, len(pathToLetter))
+	for path, letter := range pathToLetter {
+		letters[path] = letter
+	}
+	return letters
+}
+
 // GetLetter obtiene la letra asignada a un path y el siguiente índice de partición
 func GetLetterAndPartitionCorrelative(path string) (string, int, error) {
 	// Asignar una letra al path si no tiene una asignada
@@ -44,7 +54,6 @@ func GetLetterAndPartitionCorrelative(path string) (string, int, error) {
 			pathToPartitionCount[path] = 0 // Inicializar el contador de particiones
 			nextLetterIndex++
 		} else {
-			fmt.Println("Error: no hay más letras disponibles para asignar")
 			return "", 0, errors.New("no hay más letras disponibles para asignar")
 		}
 	}
@@ -77,12 +86,12 @@ func GetFileNames(path string) (string, string) {
 }
 
 // GetParentDirectories obtiene las carpetas padres y el directorio de destino
-func GetParentDirectories(path string) ([]string, string) {
+func GetParentDirectories(entryPath string) ([]string, string) {
 	// Normalizar el path
-	path = filepath.Clean(path)
+	entryPath = path.Clean(entryPath)
 
 	// Dividir el path en sus componentes
-	components := strings.Split(path, string(filepath.Separator))
+	components := strings.Split(entryPath, "/")
 
 	// Lista para almacenar las rutas de las carpetas padres
 	var parentDirs []string
@@ -98,6 +107,19 @@ func GetParentDirectories(path string) ([]string, string) {
 	return parentDirs, destDir
 }
 
+// ResolvePath convierte un path de la partición en absoluto y normalizado. Los paths relativos se resuelven
+// desde cwd (el directorio de trabajo de la sesión; si está vacío, desde la raíz), y "." y ".." se eliminan:
+// ".." en la raíz se queda en la raíz. Todos los comandos que reciben paths de la partición lo usan.
+func ResolvePath(cwd, entryPath string) string {
+	if !strings.HasPrefix(entryPath, "/") {
+		if cwd == "" {
+			cwd = "/"
+		}
+		entryPath = cwd + "/" + entryPath
+	}
+	return path.Clean(entryPath)
+}
+
 // First devuelve el primer elemento de un slice
 func First[T any](slice []T) (T, error) {
 	if len(slice) == 0 {
@@ -107,12 +129,15 @@ func First[T any](slice []T) (T, error) {
 	return slice[0], nil
 }
 
-// RemoveElement elimina un elemento de un slice en el índice dado
+// RemoveElement devuelve una copia del slice sin el elemento en el índice dado (el original no se modifica,
+// porque createFolderInInode lo vuelve a usar con cada bloque del directorio)
 func RemoveElement[T any](slice []T, index int) []T {
 	if index < 0 || index >= len(slice) {
 		return slice // Índice fuera de rango, devolver el slice original
 	}
-	return append(slice[:index], slice[index+1:]...)
+	result := make([]T, 0, len(slice)-1)
+	result = append(result, slice[:index]...)
+	return append(result, slice[index+1:]...)
 }
 
 // splitStringIntoChunks divide una cadena en partes de tamaño chunkSize y las almacena en una lista
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+// Los paths de la partición siempre usan "/", sin importar el sistema operativo: "\" es parte del nombre
+func TestResolvePath(t *testing.T) {
+	tests := []struct{ cwd, path, want string }{
+		{"", "a.txt", "/a.txt"},
+		{"/home/user1", "docs/../a.txt", "/home/user1/a.txt"},
+		{"/home", "../../..", "/"},
+		{"/home", "/tmp//x/./y", "/tmp/x/y"},
+		{"/", `a\b\..\c`, `/a\b\..\c`},
+	}
+	for _, tt := range tests {
+		if got := ResolvePath(tt.cwd, tt.path); got != tt.want {
+			t.Errorf("ResolvePath(%q, %q) = %q, se esperaba %q", tt.cwd, tt.path, got, tt.want)
+		}
+	}
+
+	parents, dest := GetParentDirectories(`/a\b/c/d`)
+	if len(parents) != 2 || parents[0] != `a\b` || parents[1] != "c" || dest != "d" {
+		t.Errorf(`GetParentDirectories("/a\b/c/d") = %q, %q`, parents, dest)
+	}
+}